@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS clicks (
+    id bigserial PRIMARY KEY,
+    short_url varchar NOT NULL REFERENCES urls(short_url) ON DELETE CASCADE,
+    clicked_at timestamp NOT NULL,
+    referrer varchar NOT NULL DEFAULT '',
+    user_agent varchar NOT NULL DEFAULT '',
+    ip_hash varchar NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS clicks_short_url_clicked_at_idx ON clicks (short_url, clicked_at);
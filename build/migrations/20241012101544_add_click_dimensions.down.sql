@@ -0,0 +1,5 @@
+ALTER TABLE clicks
+    DROP COLUMN referrer_host,
+    DROP COLUMN country,
+    DROP COLUMN browser,
+    DROP COLUMN os;
\ No newline at end of file
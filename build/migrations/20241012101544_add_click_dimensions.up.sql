@@ -0,0 +1,5 @@
+ALTER TABLE clicks
+    ADD COLUMN IF NOT EXISTS referrer_host varchar NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS country varchar NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS browser varchar NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS os varchar NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS max_link_ttl_days int NOT NULL DEFAULT 90;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS subscription_id int REFERENCES subscriptions(id);
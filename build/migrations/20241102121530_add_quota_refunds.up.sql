@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS refund_deleted_links boolean NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS orders;
+
+ALTER TABLE subscriptions
+    DROP COLUMN price;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS user_subscriptions;
+
+ALTER TABLE orders
+    DROP COLUMN days;
+
+ALTER TABLE subscriptions
+    DROP COLUMN period_days,
+    DROP COLUMN link_ttl_days,
+    DROP COLUMN min_alias_length;
@@ -0,0 +1,38 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS period_days int NOT NULL DEFAULT 30,
+    ADD COLUMN IF NOT EXISTS link_ttl_days int NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS min_alias_length int NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS days int NOT NULL DEFAULT 0;
+
+UPDATE orders o
+SET days = s.period_days
+FROM subscriptions s
+WHERE s.id = o.subscription_id;
+
+CREATE TABLE IF NOT EXISTS user_subscriptions (
+    id bigserial PRIMARY KEY,
+    user_email varchar NOT NULL REFERENCES users(email) ON DELETE CASCADE,
+    subscription_id int NOT NULL REFERENCES subscriptions(id),
+    status varchar NOT NULL,
+    starts_at timestamp NOT NULL,
+    ends_at timestamp NOT NULL,
+    next_reset_at timestamp NOT NULL
+);
+
+-- a user is on one plan at a time
+CREATE UNIQUE INDEX IF NOT EXISTS user_subscriptions_active_idx ON user_subscriptions (user_email) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS user_subscriptions_ends_at_idx ON user_subscriptions (ends_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS user_subscriptions_next_reset_at_idx ON user_subscriptions (next_reset_at) WHERE status = 'active';
+
+-- plans bought before are treated as bought now
+INSERT INTO user_subscriptions (user_email, subscription_id, status, starts_at, ends_at, next_reset_at)
+SELECT u.email,
+       s.id,
+       'active',
+       now() AT TIME ZONE 'utc',
+       now() AT TIME ZONE 'utc' + s.period_days * interval '1 day',
+       now() AT TIME ZONE 'utc' + interval '1 month'
+FROM users u
+         JOIN subscriptions s ON s.id = u.subscription_id;
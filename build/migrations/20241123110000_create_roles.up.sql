@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name varchar PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role varchar NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission varchar NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name)
+VALUES ('user'),
+       ('support'),
+       ('admin')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+VALUES ('support', 'users.view'),
+       ('admin', 'users.view'),
+       ('admin', 'users.manage_quota'),
+       ('admin', 'users.manage_roles')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role varchar NOT NULL DEFAULT 'user' REFERENCES roles(name);
@@ -0,0 +1,8 @@
+DELETE FROM role_permissions
+WHERE permission IN ('links.view', 'links.moderate', 'users.disable');
+
+ALTER TABLE urls
+    DROP COLUMN disabled;
+
+ALTER TABLE users
+    DROP COLUMN disabled;
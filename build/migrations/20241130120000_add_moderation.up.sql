@@ -0,0 +1,12 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS disabled boolean NOT NULL DEFAULT false;
+
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS disabled boolean NOT NULL DEFAULT false;
+
+INSERT INTO role_permissions (role, permission)
+VALUES ('support', 'links.view'),
+       ('admin', 'links.view'),
+       ('admin', 'links.moderate'),
+       ('admin', 'users.disable')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+-- numbers the sequence code generator turns into short links
+CREATE SEQUENCE IF NOT EXISTS link_code_seq;
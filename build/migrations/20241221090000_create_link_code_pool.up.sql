@@ -0,0 +1,5 @@
+-- short links generated ahead of time, creating a link claims one of them
+CREATE TABLE IF NOT EXISTS link_code_pool (
+    code varchar PRIMARY KEY,
+    created_at timestamp NOT NULL
+);
@@ -0,0 +1,3 @@
+-- links with a password_hash ask visitors for the password before redirecting them
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS password_hash varchar;
@@ -0,0 +1,2 @@
+ALTER TABLE urls
+    DROP COLUMN clicks_left;
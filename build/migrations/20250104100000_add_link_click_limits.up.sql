@@ -0,0 +1,3 @@
+-- links with clicks_left stop redirecting once it drops to 0, NULL is for the links without a limit
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS clicks_left integer CHECK (clicks_left >= 0);
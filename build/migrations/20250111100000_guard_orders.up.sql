@@ -0,0 +1,16 @@
+-- the user subscription an order was priced against, an order is failed if the user is on another one once paid
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS replaces_subscription_id bigint REFERENCES user_subscriptions(id);
+
+-- a user has one pending order at a time, the newest one is kept
+UPDATE orders o
+SET status = 'failed',
+    updated_at = now() AT TIME ZONE 'utc'
+WHERE o.status = 'pending'
+  AND EXISTS (SELECT 1
+              FROM orders n
+              WHERE n.user_email = o.user_email
+                AND n.status = 'pending'
+                AND (n.created_at, n.id) > (o.created_at, o.id));
+
+CREATE UNIQUE INDEX IF NOT EXISTS orders_pending_idx ON orders (user_email) WHERE status = 'pending';
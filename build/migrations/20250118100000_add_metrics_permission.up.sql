@@ -0,0 +1,3 @@
+INSERT INTO role_permissions (role, permission)
+VALUES ('admin', 'system.metrics')
+ON CONFLICT DO NOTHING;
@@ -3,57 +3,283 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/antonlindstrom/pgstore"
+	"github.com/gorilla/securecookie"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/labstack/echo/v4"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	"urleater/build/migrations"
+	"urleater/internal/clicks"
+	"urleater/internal/codegen"
 	"urleater/internal/config"
 	"urleater/internal/handlers"
+	"urleater/internal/jobs"
+	"urleater/internal/lifecycle"
+	"urleater/internal/migrator"
+	"urleater/internal/payments"
+	"urleater/internal/repository/cachedDB"
+	"urleater/internal/repository/memoryDB"
 	"urleater/internal/repository/postgresDB"
+	"urleater/internal/repository/sqliteDB"
 	"urleater/internal/service"
 	"urleater/internal/validator"
 )
 
-const port = ":8080"
+const clickFlushTimeout = 10 * time.Second
+
+// storage is what every backend provides: the service storage itself and a sink for batched clicks.
+type storage interface {
+	service.Storage
+	clicks.Sink
+	codegen.Counter
+	codegen.PoolStore
+}
+
+// newCodeGenerator makes the generator of short links the configuration asks for.
+func newCodeGenerator(cfg config.LinksConfig, counter codegen.Counter) (service.CodeGenerator, error) {
+	switch cfg.CodeGenerator {
+	case config.CodesSequence:
+		return codegen.NewSequence(counter, cfg.CodeLength)
+
+	case config.CodesWords:
+		return codegen.NewWords(cfg.CodeLength)
+
+	default:
+		return codegen.NewRandom(cfg.CodeLength)
+	}
+}
+
+// demoSubscriptions are served by the in-memory storage, which has no migrations to seed them.
+var demoSubscriptions = []postgresDB.Subscription{
+	{Id: 1, Name: "Bronze", TotalUrls: 10, MaxLinkTTLDays: 90, Price: 9900, PeriodDays: 30, LinkTTLDays: 90},
+	{Id: 2, Name: "Silver", TotalUrls: 50, MaxLinkTTLDays: 180, RefundDeletedLinks: true, Price: 39900, PeriodDays: 30, LinkTTLDays: 120, MinAliasLength: 6},
+	{Id: 3, Name: "Gold", TotalUrls: 200, MaxLinkTTLDays: 365, RefundDeletedLinks: true, Price: 129900, PeriodDays: 30, LinkTTLDays: 180, MinAliasLength: 4},
+}
 
 func main() {
+	appConfig, args, err := config.Load(os.Args[1:], os.LookupEnv)
+
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] [migrate ... | config ... | grant EMAIL ROLE]\n", os.Args[0])
+		config.Usage(os.Stderr)
+
+		return
+	}
+
+	if err != nil {
+		log.Fatalf("could not load configuration: %v", err)
+	}
+
 	serverCtx, serverCancel := context.WithCancel(context.Background())
-	postgresConfig := config.Config{
-		DB: config.DBConfig{
-			PostgresHost:     "localhost",
-			PostgresPort:     "5432",
-			PostgresUser:     "postgres",
-			PostgresPassword: "postgres",
-			PostgresDatabase: "postgres",
-			PostgresParams:   "sslmode=disable",
-		}}
-	postgresPool := providePool(serverCtx, postgresConfig.PostgresURL(), true)
-
-	// storage layer
-	postgresStorage := postgresDB.NewStorage(postgresPool)
 
-	// service layer
-	srv := service.New(postgresStorage)
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			if err := appConfig.RunCommand(args[1:], os.Stdout); err != nil {
+				log.Fatalf("config: %v", err)
+			}
+
+		case "migrate":
+			if err := migrate(serverCtx, appConfig, args[1:], os.Stdout); err != nil {
+				log.Fatalf("migrate: %v", err)
+			}
+
+		case "grant":
+			if err := grantRole(serverCtx, appConfig, args[1:], os.Stdout); err != nil {
+				log.Fatalf("grant: %v", err)
+			}
+
+		default:
+			log.Fatalf("unknown command %q", args[0])
+		}
+
+		return
+	}
+
+	if err := appConfig.Validate(); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	sessionSecret := []byte(appConfig.Session.Secret)
+
+	if len(sessionSecret) == 0 {
+		// only the memory driver gets here, its sessions are as short-lived as its data
+		sessionSecret = securecookie.GenerateRandomKey(32)
+	}
+
+	var (
+		appStorage   storage
+		sessionStore handlers.SessionStore
+		// linkChanges reaches the other instances, only Postgres can be shared by several of them
+		linkChanges cachedDB.Notifier
+	)
+
+	// components register their shutdown as they start, so they are stopped in reverse order
+	shutdown := lifecycle.New(log.Default())
 
-	store, err := pgstore.NewPGStore(postgresConfig.PostgresURL(), []byte("secret-key")) // TODO make env for secret key
+	switch appConfig.DB.Driver {
+	case config.DriverPostgres:
+		postgresPool := providePool(serverCtx, appConfig.PostgresURL(), true)
 
-	sessionStore := handlers.NewPostgresSessionStore(store)
+		shutdown.OnShutdown("postgres pool", 0, func(ctx context.Context) error {
+			postgresPool.Close()
+			return nil
+		})
+
+		postgresMigrator := provideMigrator(postgresPool)
+
+		if appConfig.DB.AutoMigrate {
+			if err := postgresMigrator.RunCommand(serverCtx, []string{"up"}, os.Stdout); err != nil {
+				log.Fatalf("could not migrate database: %v", err)
+			}
+		}
+
+		// refuses to start on a dirty or outdated schema, whoever migrates it
+		if err := postgresMigrator.Check(serverCtx); err != nil {
+			log.Fatalf("could not use database: %v, run \"migrate status\" for details", err)
+		}
+
+		// storage layer
+		postgresStorage := postgresDB.NewStorage(postgresPool)
+
+		appStorage = postgresStorage
+		linkChanges = postgresStorage
+
+		store, err := pgstore.NewPGStore(appConfig.PostgresURL(), sessionSecret)
+
+		if err != nil {
+			log.Fatalf(err.Error())
+		}
+
+		shutdown.OnShutdown("session store", 0, func(ctx context.Context) error {
+			store.Close()
+			return nil
+		})
+
+		cleanupQuit, cleanupDone := store.Cleanup(appConfig.Session.CleanupInterval)
+
+		shutdown.OnShutdown("session cleanup", 0, func(ctx context.Context) error {
+			store.StopCleanup(cleanupQuit, cleanupDone)
+			return nil
+		})
+
+		sessionStore = handlers.NewPostgresSessionStore(store)
+
+	case config.DriverSQLite:
+		db, err := sqliteDB.Open(serverCtx, appConfig.DB.SQLitePath)
+
+		if err != nil {
+			log.Fatalf(err.Error())
+		}
+
+		shutdown.OnShutdown("sqlite database", 0, func(ctx context.Context) error {
+			return db.Close()
+		})
+
+		appStorage = sqliteDB.NewStorage(db)
+		sessionStore = handlers.NewCookieSessionStore(sessionSecret)
+
+	case config.DriverMemory:
+		log.Println("Using in-memory storage, all data will be lost on exit")
+
+		appStorage = memoryDB.NewStorage(demoSubscriptions...)
+		sessionStore = handlers.NewCookieSessionStore(sessionSecret)
+
+	default:
+		log.Fatalf("unknown storage %q", appConfig.DB.Driver)
+	}
+
+	// clicks are written in batches off the redirect path
+	clickWriter := clicks.NewWriter(appStorage, clicks.DefaultConfig)
+	clickWriter.Start()
+
+	shutdown.OnShutdown("click writer", clickFlushTimeout, clickWriter.Close)
+
+	var fakePayments *payments.Fake
+
+	// validation lets the fake provider through in dev mode only, its checkout page pays any order
+	if appConfig.Payments.Provider == config.PaymentsFake {
+		paymentsSecret := []byte(appConfig.Payments.Secret)
+
+		if len(paymentsSecret) == 0 {
+			// the fake provider notifies this very process, so nobody else has to know the secret
+			paymentsSecret = securecookie.GenerateRandomKey(32)
+		}
+
+		fakePayments = payments.NewFake(paymentsSecret, appConfig.HTTP.PublicBaseURL)
+	}
+
+	codes, err := newCodeGenerator(appConfig.Links, appStorage)
 
 	if err != nil {
 		log.Fatalf(err.Error())
 	}
 
-	defer store.Close()
+	if appConfig.Links.CodePoolSize > 0 {
+		// links claim the codes generated ahead of time, the generator is only used while the pool is empty
+		pool := codegen.NewPool(appStorage, codes, appConfig.Links.CodePoolSize)
+		codes = pool
+
+		poolJob := jobs.NewPeriodic("code pool", appConfig.Links.CodePoolRefillInterval, pool.Refill)
+		poolJob.Start()
+
+		shutdown.OnShutdown("code pool", 0, poolJob.Close)
+	}
+
+	var serviceStorage service.Storage = appStorage
+
+	if appConfig.Cache.Size > 0 {
+		// the redirects resolve links from the cache, the changes to them are announced to the other instances
+		linkCache := cachedDB.NewStorage(appStorage, cachedDB.Config{
+			Size:    appConfig.Cache.Size,
+			TTL:     appConfig.Cache.TTL,
+			MissTTL: appConfig.Cache.MissTTL,
+		}, linkChanges)
+		linkCache.Start()
 
-	defer store.StopCleanup(store.Cleanup(time.Minute * 5))
+		shutdown.OnShutdown("link cache", 0, linkCache.Close)
+
+		serviceStorage = linkCache
+	}
+
+	// service layer
+	serviceOptions := []service.Option{
+		service.WithCodeGenerator(codes),
+		service.WithClickRecorder(clickWriter),
+		service.WithLinkTTL(appConfig.Links.TTL),
+		service.WithDefaultQuota(appConfig.Links.DefaultQuota),
+		// only the memory driver may leave it empty, a random one is made then
+		service.WithVisitorSecret([]byte(appConfig.Stats.Secret)),
+	}
+
+	if fakePayments != nil {
+		serviceOptions = append(serviceOptions, service.WithPaymentProvider(fakePayments))
+	}
+
+	srv := service.New(serviceStorage, serviceOptions...)
+
+	subscriptionJob := jobs.NewPeriodic("subscription job", appConfig.Subscriptions.CheckInterval, func(ctx context.Context) error {
+		return srv.MaintainSubscriptions(ctx, time.Now().UTC())
+	})
+	subscriptionJob.Start()
+
+	shutdown.OnShutdown("subscription job", 0, subscriptionJob.Close)
 
 	// handlers layer
-	e := handlers.GetRoutes(&handlers.Handlers{Service: srv, Store: sessionStore})
+	e := handlers.GetRoutes(&handlers.Handlers{
+		Service: srv,
+		Store:   sessionStore,
+		BaseURL: appConfig.HTTP.PublicBaseURL,
+		Unlocks: handlers.NewLinkUnlocks(sessionSecret),
+	})
 
 	httpValidator, err := validator.NewValidator()
 
@@ -63,21 +289,105 @@ func main() {
 
 	e.Validator = httpValidator
 
+	// validated with the rest of the config
+	trustedProxies, _ := appConfig.HTTP.TrustedProxyNets()
+	e.IPExtractor = handlers.IPExtractor(trustedProxies)
+
+	if fakePayments != nil {
+		e.Any(payments.CheckoutPath, echo.WrapHandler(fakePayments))
+	}
+
+	serverErr := make(chan error, 1)
+
 	go func() {
-		if err := e.Start(port); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatalf("could not start server: %v", err)
+		if err := e.Start(appConfig.HTTP.Addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
 		}
 	}()
 
+	// in-flight requests are drained before anything they use is stopped
+	shutdown.OnShutdown("http server", appConfig.HTTP.ShutdownTimeout, e.Shutdown)
+
 	quit := make(chan os.Signal, 1)
 
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
-	<-quit
+	exitCode := 0
+
+	select {
+	case sig := <-quit:
+		log.Printf("received %s, shutting down", sig)
+
+	case err := <-serverErr:
+		log.Printf("could not start server: %v", err)
+
+		exitCode = 1
+	}
+
+	// a second signal skips the graceful shutdown
+	signal.Reset(os.Interrupt, syscall.SIGTERM)
+
+	if err := shutdown.Shutdown(context.Background()); err != nil {
+		log.Printf("shutdown finished with errors: %v", err)
 
-	fmt.Println("Shutting down server...")
+		exitCode = 1
+	} else {
+		log.Println("shutdown complete")
+	}
 
 	serverCancel()
+	os.Exit(exitCode)
+}
+
+// migrate runs the "migrate" subcommand, only Postgres has migrations.
+func migrate(ctx context.Context, appConfig *config.Config, args []string, out io.Writer) error {
+	if appConfig.DB.Driver != config.DriverPostgres {
+		return fmt.Errorf("migrations only apply to the %s driver, not %s", config.DriverPostgres, appConfig.DB.Driver)
+	}
+
+	pool := providePool(ctx, appConfig.PostgresURL(), false)
+	defer pool.Close()
+
+	return provideMigrator(pool).RunCommand(ctx, args, out)
+}
+
+// grantRole gives a user a role, it's how the first admin is made.
+func grantRole(ctx context.Context, appConfig *config.Config, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return errors.New("expected EMAIL ROLE")
+	}
+
+	var roleStorage service.Storage
+
+	switch appConfig.DB.Driver {
+	case config.DriverPostgres:
+		pool := providePool(ctx, appConfig.PostgresURL(), false)
+		defer pool.Close()
+
+		roleStorage = postgresDB.NewStorage(pool)
+
+	case config.DriverSQLite:
+		db, err := sqliteDB.Open(ctx, appConfig.DB.SQLitePath)
+
+		if err != nil {
+			return err
+		}
+
+		defer db.Close()
+
+		roleStorage = sqliteDB.NewStorage(db)
+
+	default:
+		return fmt.Errorf("roles can't be granted with the %s driver, its users are gone once the server stops", appConfig.DB.Driver)
+	}
+
+	if err := service.New(roleStorage).GrantRole(ctx, args[0], args[1]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "granted role %s to %s\n", args[1], args[0])
+
+	return nil
 }
 
 func providePool(ctx context.Context, url string, lazy bool) *pgxpool.Pool {
@@ -91,8 +401,18 @@ func providePool(ctx context.Context, url string, lazy bool) *pgxpool.Pool {
 
 	pool, err := pgxpool.ConnectConfig(ctx, poolConfig)
 	if err != nil {
-		log.Fatal("Unable to establish connection to " + url + " because " + err.Error())
+		log.Fatal("Unable to establish connection to the database because " + err.Error())
 	}
 
 	return pool
 }
+
+func provideMigrator(pool *pgxpool.Pool) *migrator.Migrator {
+	m, err := migrator.New(pool, migrations.FS)
+
+	if err != nil {
+		log.Fatal("Unable to load migrations because " + err.Error())
+	}
+
+	return m
+}
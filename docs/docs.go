@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/": {
             "get": {
-                "summary": "Gets short link",
+                "summary": "Redirects to the long link, browsers get an HTML page for missing, expired and used up links",
                 "parameters": [
                     {
                         "type": "string",
@@ -28,48 +28,119 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "307": {
-                        "description": "Temporary Redirect",
+                    "302": {
+                        "description": "Found",
                         "schema": {
                             "$ref": "#/definitions/handlers.DeleteShortLinkRequest"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/create_link": {
+        "/admin": {
             "get": {
                 "produces": [
                     "text/html"
                 ],
-                "summary": "Gets create link page HTML",
+                "summary": "Gets admin console HTML, requires the users.view permission",
                 "responses": {
                     "200": {
                         "description": "OK"
                     },
-                    "307": {
-                        "description": "Temporary Redirect"
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    }
+                }
+            }
+        },
+        "/admin/links": {
+            "get": {
+                "summary": "Searches all short links by alias, destination or owner, requires the links.view permission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Part of the alias, destination or owner email, all links if empty",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Links to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum amount of links to show, 20 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SearchLinksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     },
                     "500": {
-                        "description": "Internal Server Error"
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/admin/links/disable": {
             "post": {
                 "consumes": [
                     "application/json"
                 ],
-                "summary": "Creates a link",
+                "summary": "Stops a short link from redirecting without deleting it or lets it redirect again, requires the links.moderate permission",
                 "parameters": [
                     {
-                        "description": "Short URL",
-                        "name": "short_url",
+                        "description": "Short link to change",
+                        "name": "short_link",
                         "in": "body",
                         "required": true,
                         "schema": {
@@ -77,12 +148,12 @@ const docTemplate = `{
                         }
                     },
                     {
-                        "description": "Long URL",
-                        "name": "long_url",
+                        "description": "Whether the link is disabled",
+                        "name": "disabled",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "type": "boolean"
                         }
                     }
                 ],
@@ -90,156 +161,203 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateShortLinkResponse"
+                            "$ref": "#/definitions/handlers.SetLinkDisabledResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/delete_link": {
-            "delete": {
-                "summary": "Tries to delete the short link",
-                "parameters": [
-                    {
-                        "description": "Short link to delete",
-                        "name": "ShortLink",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                ],
+        "/admin/stats": {
+            "get": {
+                "summary": "Counts users, links, clicks and sales of the whole service, requires the users.view permission",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.DeleteShortLinkRequest"
+                            "$ref": "#/definitions/handlers.GetSystemStatsResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/get_links": {
+        "/admin/users": {
             "get": {
-                "consumes": [
-                    "application/json"
-                ],
-                "summary": "Gets user's short links",
+                "summary": "Searches users by email, requires the users.view permission",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Limit of a number of user's short links",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "type": "string",
+                        "description": "Part of the email, all users if empty",
+                        "name": "query",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Maximum amount of links to show",
+                        "description": "Users to skip",
                         "name": "offset",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum amount of users to show, 20 by default",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.GetUserShortLinksResponse"
+                            "$ref": "#/definitions/handlers.SearchUsersResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/get_subscriptions": {
-            "get": {
-                "summary": "Gets all subscriptions",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+        "/admin/users/disable": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Disables a user account or enables it back, requires the users.disable permission",
+                "parameters": [
+                    {
+                        "description": "User to change",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.GetSubscriptionsResponse"
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Whether the account is disabled",
+                        "name": "disabled",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "boolean"
                         }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/login": {
-            "get": {
-                "produces": [
-                    "text/html"
-                ],
-                "summary": "Gets login page HTML",
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    },
-                    "307": {
-                        "description": "Temporary Redirect"
-                    },
-                    "500": {
-                        "description": "Internal Server Error"
-                    }
-                }
-            },
+        "/admin/users/quota": {
             "post": {
                 "consumes": [
                     "application/json"
                 ],
-                "summary": "Logins a user",
+                "summary": "Changes how many short links a user has left, requires the users.manage_quota permission",
                 "parameters": [
                     {
-                        "description": "Username",
-                        "name": "username",
+                        "description": "User to change",
+                        "name": "email",
                         "in": "body",
                         "required": true,
                         "schema": {
@@ -247,12 +365,12 @@ const docTemplate = `{
                         }
                     },
                     {
-                        "description": "Password",
-                        "name": "password",
+                        "description": "Links to add, negative to take them away",
+                        "name": "delta_links",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "type": "integer"
                         }
                     }
                 ],
@@ -260,70 +378,52 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.redirectResponse"
+                            "$ref": "#/definitions/handlers.UpdateUserShortLinksResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/logout": {
-            "get": {
-                "summary": "Logs out a user",
-                "responses": {
-                    "307": {
-                        "description": "Temporary Redirect",
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/handlers.redirectResponse"
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/register": {
-            "get": {
-                "produces": [
-                    "text/html"
-                ],
-                "summary": "Gets register page HTML",
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    },
-                    "307": {
-                        "description": "Temporary Redirect"
-                    },
-                    "500": {
-                        "description": "Internal Server Error"
-                    }
-                }
-            },
+        "/admin/users/role": {
             "post": {
                 "consumes": [
                     "application/json"
                 ],
-                "summary": "Registers a user",
+                "summary": "Gives a user another role, requires the users.manage_roles permission",
                 "parameters": [
                     {
-                        "description": "Username",
-                        "name": "username",
+                        "description": "User to change",
+                        "name": "email",
                         "in": "body",
                         "required": true,
                         "schema": {
@@ -331,8 +431,8 @@ const docTemplate = `{
                         }
                     },
                     {
-                        "description": "Password",
-                        "name": "password",
+                        "description": "Name of the new role",
+                        "name": "role",
                         "in": "body",
                         "required": true,
                         "schema": {
@@ -341,74 +441,1955 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.redirectResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/subscriptions": {
+        "/api/v1/links": {
             "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "produces": [
-                    "text/html"
+                    "application/json"
                 ],
-                "summary": "Gets subscription page HTML",
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    },
-                    "307": {
-                        "description": "Temporary Redirect"
+                "tags": [
+                    "links"
+                ],
+                "summary": "Lists user's short links, needs the links.read scope",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "How many links to skip",
+                        "name": "offset",
+                        "in": "query"
                     },
-                    "500": {
-                        "description": "Internal Server Error"
+                    {
+                        "type": "integer",
+                        "description": "How many links to return, 20 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
                     }
-                }
-            }
-        },
-        "/user": {
-            "get": {
-                "summary": "Gets user from session",
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.GetUserResponse"
+                            "$ref": "#/definitions/handlers.ListLinksResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": ""
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
-                    }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Creates a short link, needs the links.create scope",
+                "parameters": [
+                    {
+                        "description": "Link to create",
+                        "name": "link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LinkResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Address of the new link"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/{code}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Gets user's short link, expired and disabled ones included, needs the links.read scope",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short link code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LinkResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Deletes user's short link, needs the links.delete scope",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short link code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Changes the destination, lifetime, password or click limit of user's short link, needs the links.create scope",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short link code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Gets the user the request is made by, needs the links.read scope",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MeResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/subscriptions": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Lists the subscription plans, they are public",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ListSubscriptionsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/buy": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Creates an order for a subscription, the links are credited once it's paid",
+                "parameters": [
+                    {
+                        "description": "Subscription to buy",
+                        "name": "subscription_id",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BuySubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/create_link": {
+            "get": {
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Gets create link page HTML",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "307": {
+                        "description": "Temporary Redirect"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Creates a link, use POST /api/v1/links instead",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "description": "Short URL",
+                        "name": "short_url",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Long URL",
+                        "name": "long_url",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Password visitors have to enter",
+                        "name": "password",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Number of clicks the link works for",
+                        "name": "max_clicks",
+                        "in": "body",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateShortLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/create_token": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Creates an API token for scripts to call the API on behalf of the user",
+                "parameters": [
+                    {
+                        "description": "Name telling the token apart",
+                        "name": "name",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "What the token may do",
+                        "name": "scopes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    {
+                        "description": "How long the token lives, 0 for no expiry",
+                        "name": "days",
+                        "in": "body",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateApiTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/delete_link": {
+            "delete": {
+                "summary": "Tries to delete the short link, use DELETE /api/v1/links/{code} instead",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "description": "Short link to delete",
+                        "name": "ShortLink",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DeleteShortLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/extend_link": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Renews user's short link, expired ones included, use PATCH /api/v1/links/{code} instead",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "description": "Short link to renew",
+                        "name": "short_link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Days the link should live from now on, limited by the subscription",
+                        "name": "days",
+                        "in": "body",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ExtendShortLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/get_current_subscription": {
+            "get": {
+                "summary": "Gets user's current subscription and its plan",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetCurrentSubscriptionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/get_link_stats": {
+            "get": {
+                "description": "Unique clicks are visitor-days: visitors are told apart within a UTC day only, a visitor coming back on another day is counted again.",
+                "summary": "Gets click statistics of user's short link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short link",
+                        "name": "short_link",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Range start, RFC3339 or YYYY-MM-DD (default: a week before the end)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Range end, RFC3339 or YYYY-MM-DD (default: now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bucket size: hour, day or week (default: day)",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetLinkStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/get_link_versions": {
+            "get": {
+                "summary": "Gets the destination changes of user's short link, the latest first",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short link",
+                        "name": "short_link",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetLinkVersionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/get_links": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Gets user's short links, use GET /api/v1/links instead",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Limit of a number of user's short links",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum amount of links to show",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetUserShortLinksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/get_order": {
+            "get": {
+                "summary": "Gets user's order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order id",
+                        "name": "order_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetOrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/get_subscriptions": {
+            "get": {
+                "summary": "Gets all subscriptions, use GET /api/v1/subscriptions instead",
+                "deprecated": true,
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetSubscriptionsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/get_tokens": {
+            "get": {
+                "summary": "Lists user's API tokens, the newest first",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetApiTokensResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/links": {
+            "get": {
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Gets the page listing user's short links",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "307": {
+                        "description": "Temporary Redirect"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/login": {
+            "get": {
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Gets login page HTML",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "307": {
+                        "description": "Temporary Redirect"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Logins a user",
+                "parameters": [
+                    {
+                        "description": "Username",
+                        "name": "username",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RedirectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "get": {
+                "summary": "Logs out a user and sends them to the login form",
+                "responses": {
+                    "307": {
+                        "description": "Temporary Redirect"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Gets internal counters of the application, requires the system.metrics permission",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    }
+                }
+            }
+        },
+        "/payments/callback": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Receives payment notifications from the payment provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Signature of the body",
+                        "name": "X-Signature",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "get": {
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Gets register page HTML",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "307": {
+                        "description": "Temporary Redirect"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Registers a user",
+                "parameters": [
+                    {
+                        "description": "Username",
+                        "name": "username",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RedirectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/revoke_token": {
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Revokes user's API token, the requests made with it are refused from then on",
+                "parameters": [
+                    {
+                        "description": "Token to revoke",
+                        "name": "id",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RevokeApiTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rollback_link": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Undoes a destination change of user's short link and the ones made after it",
+                "parameters": [
+                    {
+                        "description": "Short link to roll back",
+                        "name": "short_link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "Change to undo",
+                        "name": "version_id",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RollbackShortLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Gets subscription page HTML",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "307": {
+                        "description": "Temporary Redirect"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/tokens": {
+            "get": {
+                "produces": [
+                    "text/html"
+                ],
+                "summary": "Gets the page managing user's API tokens",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "307": {
+                        "description": "Temporary Redirect"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/update_link": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "Points user's short link at another long link, use PATCH /api/v1/links/{code} instead",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "description": "Short link to change",
+                        "name": "short_link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "New destination",
+                        "name": "long_link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateShortLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "summary": "Gets user from session, use GET /api/v1/me instead",
+                "deprecated": true,
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GetUserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{short_link}": {
+            "post": {
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "summary": "Checks the password of a protected short link and lets the visitor through",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short link",
+                        "name": "short_link",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password of the link",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "See Other"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.BuySubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "$ref": "#/definitions/postgresDB.Order"
+                },
+                "payment_url": {
+                    "description": "PaymentURL is the provider's checkout page the user is sent to,\nempty when the unused part of the current subscription paid for the order",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateApiTokenResponse": {
+            "type": "object",
+            "properties": {
+                "api_token": {
+                    "$ref": "#/definitions/postgresDB.ApiToken"
+                },
+                "token": {
+                    "description": "Token is sent with the API requests as \"Authorization: Bearer \u003ctoken\u003e\", it's shown only once",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateLinkRequest": {
+            "type": "object",
+            "required": [
+                "long_url"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code is the alias of the link, a random one is made when it's empty",
+                    "type": "string"
+                },
+                "long_url": {
+                    "type": "string"
+                },
+                "max_clicks": {
+                    "description": "MaxClicks is how many times the link redirects before it's used up, 0 is for no limit",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "password": {
+                    "description": "Password protects the link, visitors have to enter it before they are redirected",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateShortLinkResponse": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "$ref": "#/definitions/postgresDB.Link"
+                }
+            }
+        },
+        "handlers.DeleteShortLinkRequest": {
+            "type": "object",
+            "properties": {
+                "short_link": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DeleteShortLinkResponse": {
+            "type": "object",
+            "properties": {
+                "short_link": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ErrorBody": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is stable and meant for programs, Message for people",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/handlers.ErrorBody"
+                },
+                "redirectTo": {
+                    "description": "RedirectTo is where pages should navigate to, e.g. the login form",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ExtendShortLinkResponse": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "$ref": "#/definitions/postgresDB.Link"
+                }
+            }
+        },
+        "handlers.GetApiTokensResponse": {
+            "type": "object",
+            "properties": {
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.ApiToken"
+                    }
+                }
+            }
+        },
+        "handlers.GetCurrentSubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "plan": {
+                    "$ref": "#/definitions/postgresDB.Subscription"
+                },
+                "subscription": {
+                    "description": "Subscription and Plan are null on the free tier",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/postgresDB.UserSubscription"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.GetLinkStatsResponse": {
+            "type": "object",
+            "properties": {
+                "stats": {
+                    "$ref": "#/definitions/postgresDB.LinkStats"
+                }
+            }
+        },
+        "handlers.GetLinkVersionsResponse": {
+            "type": "object",
+            "properties": {
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.LinkVersion"
+                    }
+                }
+            }
+        },
+        "handlers.GetOrderResponse": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "$ref": "#/definitions/postgresDB.Order"
+                }
+            }
+        },
+        "handlers.GetSubscriptionsResponse": {
+            "type": "object",
+            "properties": {
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.Subscription"
+                    }
+                }
+            }
+        },
+        "handlers.GetSystemStatsResponse": {
+            "type": "object",
+            "properties": {
+                "stats": {
+                    "$ref": "#/definitions/postgresDB.SystemStats"
+                }
+            }
+        },
+        "handlers.GetUserResponse": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/postgresDB.User"
+                }
+            }
+        },
+        "handlers.GetUserShortLinksResponse": {
+            "type": "object",
+            "properties": {
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.Link"
+                    }
+                },
+                "user": {
+                    "$ref": "#/definitions/postgresDB.User"
+                }
+            }
+        },
+        "handlers.LinkResource": {
+            "type": "object",
+            "properties": {
+                "clicks_left": {
+                    "description": "ClicksLeft is how many more times the link redirects, null for the links without a limit",
+                    "type": "integer"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "disabled": {
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "long_url": {
+                    "type": "string"
+                },
+                "protected": {
+                    "description": "Protected links ask visitors for a password",
+                    "type": "boolean"
+                },
+                "short_url": {
+                    "description": "ShortURL is the address the link is shared by",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LinkResponse": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "$ref": "#/definitions/handlers.LinkResource"
+                }
+            }
+        },
+        "handlers.ListLinksResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.LinkResource"
+                    }
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ListSubscriptionsResponse": {
+            "type": "object",
+            "properties": {
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.SubscriptionResource"
+                    }
+                }
+            }
+        },
+        "handlers.MeResponse": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/handlers.UserResource"
+                }
+            }
+        },
+        "handlers.RedirectResponse": {
+            "type": "object",
+            "properties": {
+                "redirectTo": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RevokeApiTokenResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.RollbackShortLinkResponse": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "$ref": "#/definitions/postgresDB.Link"
+                }
+            }
+        },
+        "handlers.SearchLinksResponse": {
+            "type": "object",
+            "properties": {
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.Link"
+                    }
+                }
+            }
+        },
+        "handlers.SearchUsersResponse": {
+            "type": "object",
+            "properties": {
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.UserSummary"
+                    }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.CreateShortLinkResponse": {
+        },
+        "handlers.SetLinkDisabledResponse": {
             "type": "object",
             "properties": {
                 "link": {
@@ -416,26 +2397,70 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.DeleteShortLinkRequest": {
+        "handlers.SubscriptionResource": {
             "type": "object",
             "properties": {
-                "short_link": {
+                "id": {
+                    "type": "integer"
+                },
+                "link_ttl_days": {
+                    "type": "integer"
+                },
+                "max_link_ttl_days": {
+                    "type": "integer"
+                },
+                "min_alias_length": {
+                    "type": "integer"
+                },
+                "name": {
                     "type": "string"
+                },
+                "period_days": {
+                    "type": "integer"
+                },
+                "price": {
+                    "description": "Price is in the smallest currency unit, 0 means the plan is not for sale",
+                    "type": "integer"
+                },
+                "refund_deleted_links": {
+                    "type": "boolean"
+                },
+                "total_urls": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.GetSubscriptionsResponse": {
+        "handlers.UpdateLinkRequest": {
             "type": "object",
             "properties": {
-                "subscriptions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/postgresDB.Subscription"
-                    }
+                "days": {
+                    "description": "Days renews the link for that many days from now, 0 means the default link TTL, cut to the plan maximum",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "long_url": {
+                    "type": "string"
+                },
+                "max_clicks": {
+                    "description": "MaxClicks lets the link redirect that many more times, 0 removes the limit",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "password": {
+                    "description": "Password protects the link, an empty one removes the protection",
+                    "type": "string"
                 }
             }
         },
-        "handlers.GetUserResponse": {
+        "handlers.UpdateShortLinkResponse": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "$ref": "#/definitions/postgresDB.Link"
+                }
+            }
+        },
+        "handlers.UpdateUserShortLinksResponse": {
             "type": "object",
             "properties": {
                 "user": {
@@ -443,34 +2468,216 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.GetUserShortLinksResponse": {
+        "handlers.UserResource": {
             "type": "object",
             "properties": {
-                "links": {
+                "email": {
+                    "type": "string"
+                },
+                "urls_left": {
+                    "description": "UrlsLeft is how many more links the user can create",
+                    "type": "integer"
+                }
+            }
+        },
+        "postgresDB.ApiToken": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt is nil for the tokens that never expire",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix is the start of the token, shown so that the user can tell their tokens apart",
+                    "type": "string"
+                },
+                "scopes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/postgresDB.Link"
+                        "type": "string"
                     }
                 },
-                "user": {
-                    "$ref": "#/definitions/postgresDB.User"
+                "userEmail": {
+                    "type": "string"
+                }
+            }
+        },
+        "postgresDB.ClickBucket": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "uniqueClicks": {
+                    "description": "UniqueClicks counts visitor-days: visitors are told apart within a UTC day only,\nso a week bucket counts a visitor once for every day they came back",
+                    "type": "integer"
                 }
             }
         },
-        "handlers.redirectResponse": {
-            "type": "object"
+        "postgresDB.ClickCount": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
         },
         "postgresDB.Link": {
             "type": "object",
             "properties": {
+                "clicksLeft": {
+                    "description": "ClicksLeft is how many more times the link redirects, nil for the links without a limit",
+                    "type": "integer"
+                },
+                "disabled": {
+                    "description": "Disabled links are kept but don't redirect anymore, only admins can enable them again",
+                    "type": "boolean"
+                },
                 "expiresAt": {
                     "type": "string"
                 },
                 "longUrl": {
                     "type": "string"
                 },
+                "protected": {
+                    "description": "Protected links ask visitors for a password before redirecting them",
+                    "type": "boolean"
+                },
+                "shortUrl": {
+                    "type": "string"
+                },
+                "userEmail": {
+                    "type": "string"
+                }
+            }
+        },
+        "postgresDB.LinkStats": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string"
+                },
+                "series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.ClickBucket"
+                    }
+                },
+                "shortUrl": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "topBrowsers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.ClickCount"
+                    }
+                },
+                "topCountries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.ClickCount"
+                    }
+                },
+                "topOSes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.ClickCount"
+                    }
+                },
+                "topReferrers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/postgresDB.ClickCount"
+                    }
+                },
+                "totalClicks": {
+                    "type": "integer"
+                },
+                "uniqueClicks": {
+                    "description": "UniqueClicks counts visitor-days, a visitor is counted once for every UTC day of the range they clicked on",
+                    "type": "integer"
+                }
+            }
+        },
+        "postgresDB.LinkVersion": {
+            "type": "object",
+            "properties": {
+                "changedAt": {
+                    "type": "string"
+                },
+                "changedBy": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "newLongUrl": {
+                    "type": "string"
+                },
+                "oldLongUrl": {
+                    "type": "string"
+                },
                 "shortUrl": {
                     "type": "string"
+                }
+            }
+        },
+        "postgresDB.Order": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "days": {
+                    "description": "Days is how long the subscription lasts once paid, unused days of the previous one included.\nAn order of the plan the user is on renews it, the days are added to its end, see RenewalEnd",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "paymentId": {
+                    "description": "PaymentId is the provider's id of the payment, known once the provider reports back",
+                    "type": "string"
+                },
+                "replacesSubscriptionId": {
+                    "description": "ReplacesSubscriptionId is the user subscription the order was priced against, nil on the free tier.\nThe order fails instead of being paid if the user is on another one by then, see OrderOutdated",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscriptionId": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
                 },
                 "userEmail": {
                     "type": "string"
@@ -483,17 +2690,77 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "linkTTLDays": {
+                    "description": "LinkTTLDays is the lifetime of new links, 0 stands for the service default",
+                    "type": "integer"
+                },
+                "maxLinkTTLDays": {
+                    "type": "integer"
+                },
+                "minAliasLength": {
+                    "description": "MinAliasLength is the shortest custom alias allowed, 0 stands for the service default",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
+                "periodDays": {
+                    "description": "PeriodDays is how long a bought subscription lasts, 0 stands for the service default.\nThe quota is refilled with TotalUrls every month of it",
+                    "type": "integer"
+                },
+                "price": {
+                    "description": "Price is in the smallest currency unit, 0 means the subscription is not for sale",
+                    "type": "integer"
+                },
+                "refundDeletedLinks": {
+                    "description": "RefundDeletedLinks gives the quota spent on a link back when it's deleted",
+                    "type": "boolean"
+                },
                 "totalUrls": {
                     "type": "integer"
                 }
             }
         },
+        "postgresDB.SystemStats": {
+            "type": "object",
+            "properties": {
+                "activeLinks": {
+                    "description": "ActiveLinks are the links that redirect, neither expired nor disabled",
+                    "type": "integer"
+                },
+                "activeSubscriptions": {
+                    "type": "integer"
+                },
+                "clicks": {
+                    "type": "integer"
+                },
+                "disabledLinks": {
+                    "type": "integer"
+                },
+                "disabledUsers": {
+                    "type": "integer"
+                },
+                "expiredLinks": {
+                    "type": "integer"
+                },
+                "links": {
+                    "type": "integer"
+                },
+                "paidOrders": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "integer"
+                }
+            }
+        },
         "postgresDB.User": {
             "type": "object",
             "properties": {
+                "disabled": {
+                    "description": "Disabled users can't log in",
+                    "type": "boolean"
+                },
                 "email": {
                     "type": "string"
                 },
@@ -504,18 +2771,77 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "postgresDB.UserSubscription": {
+            "type": "object",
+            "properties": {
+                "endsAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "nextResetAt": {
+                    "description": "NextResetAt is when the quota is refilled next",
+                    "type": "string"
+                },
+                "startsAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscriptionId": {
+                    "type": "integer"
+                },
+                "userEmail": {
+                    "type": "string"
+                }
+            }
+        },
+        "postgresDB.UserSummary": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "disabled": {
+                    "type": "boolean"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "links": {
+                    "description": "Links is how many short links the user has",
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "urlsLeft": {
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "An API token made on the tokens page, sent as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "",
-	Host:             "",
-	BasePath:         "",
+	Version:          "1.0",
+	Host:             "localhost:8080",
+	BasePath:         "/",
 	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
+	Title:            "URLEater Swagger API",
+	Description:      "Это описание API для работы с сайтом по сокращению ссылок",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
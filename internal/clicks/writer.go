@@ -0,0 +1,172 @@
+package clicks
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+// Sink persists a batch of clicks, postgresDB.Storage does it with a single COPY.
+type Sink interface {
+	CreateClicks(ctx context.Context, clicks []postgresDB.Click) (int64, error)
+}
+
+type Config struct {
+	// QueueSize is how many clicks may wait for a flush; when the queue is full new clicks are dropped
+	QueueSize int
+	// BatchSize triggers a flush as soon as that many clicks are buffered
+	BatchSize int
+	// FlushInterval triggers a flush of a partial batch
+	FlushInterval time.Duration
+	// FlushTimeout bounds a single write to the sink
+	FlushTimeout time.Duration
+}
+
+var DefaultConfig = Config{
+	QueueSize:     10000,
+	BatchSize:     500,
+	FlushInterval: time.Second,
+	FlushTimeout:  10 * time.Second,
+}
+
+var (
+	metrics = expvar.NewMap("click_writer")
+
+	enqueuedClicks = new(expvar.Int)
+	droppedClicks  = new(expvar.Int)
+	writtenClicks  = new(expvar.Int)
+	failedClicks   = new(expvar.Int)
+	flushes        = new(expvar.Int)
+)
+
+func init() {
+	metrics.Set("enqueued", enqueuedClicks)
+	metrics.Set("dropped", droppedClicks)
+	metrics.Set("written", writtenClicks)
+	metrics.Set("failed", failedClicks)
+	metrics.Set("flushes", flushes)
+}
+
+// Writer takes clicks off the redirect path: Enqueue never blocks, and a background
+// goroutine writes buffered clicks to the sink in batches.
+type Writer struct {
+	sink   Sink
+	config Config
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan postgresDB.Click
+	done   chan struct{}
+}
+
+func NewWriter(sink Sink, config Config) *Writer {
+	w := &Writer{
+		sink:   sink,
+		config: config,
+		queue:  make(chan postgresDB.Click, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+
+	metrics.Set("queue_depth", expvar.Func(func() any {
+		return len(w.queue)
+	}))
+
+	return w
+}
+
+// Start launches the flushing goroutine.
+func (w *Writer) Start() {
+	go w.run()
+}
+
+// Enqueue buffers the click and reports whether it was accepted. A full queue means
+// the sink can't keep up, in that case the click is dropped rather than slowing down the caller.
+func (w *Writer) Enqueue(click postgresDB.Click) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		droppedClicks.Add(1)
+		return false
+	}
+
+	select {
+	case w.queue <- click:
+		enqueuedClicks.Add(1)
+		return true
+	default:
+		droppedClicks.Add(1)
+		return false
+	}
+}
+
+// Close stops accepting clicks and waits until the buffered ones are flushed or ctx is done.
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]postgresDB.Click, 0, w.config.BatchSize)
+
+	for {
+		select {
+		case click, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+
+			batch = append(batch, click)
+
+			if len(batch) >= w.config.BatchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (w *Writer) flush(batch []postgresDB.Click) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.FlushTimeout)
+	defer cancel()
+
+	flushes.Add(1)
+
+	written, err := w.sink.CreateClicks(ctx, batch)
+
+	if err != nil {
+		failedClicks.Add(int64(len(batch)))
+		log.Printf("click writer: could not flush %d clicks: %v\n", len(batch), err)
+		return
+	}
+
+	writtenClicks.Add(written)
+}
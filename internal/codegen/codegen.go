@@ -0,0 +1,46 @@
+package codegen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// DefaultLength is how long the generated short links are unless configured otherwise
+	DefaultLength = 8
+	// MinLength keeps the random keyspaces from running out
+	MinLength = 4
+	// MaxLength is the longest alias a link may have
+	MaxLength = 20
+)
+
+// base62 are the characters links are made of, the same ones aliases may use
+const base62 = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+func checkLength(length int) error {
+	if length < MinLength || length > MaxLength {
+		return fmt.Errorf("code length must be %d to %d, got %d", MinLength, MaxLength, length)
+	}
+
+	return nil
+}
+
+// pick builds a string of length from crypto/rand, every character drawn uniformly from alphabets[i % len(alphabets)].
+func pick(length int, alphabets ...string) (string, error) {
+	res := make([]byte, length)
+
+	for i := range res {
+		alphabet := alphabets[i%len(alphabets)]
+
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+
+		if err != nil {
+			return "", fmt.Errorf("could not read random bytes: %w", err)
+		}
+
+		res[i] = alphabet[n.Int64()]
+	}
+
+	return string(res), nil
+}
@@ -0,0 +1,113 @@
+package codegen
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// refillBatch is how many codes Refill adds with one query
+const refillBatch = 500
+
+var (
+	metrics = expvar.NewMap("code_pool")
+
+	// poolDepth is the number of pooled codes as of the last refill minus the codes claimed since
+	poolDepth     = new(expvar.Int)
+	claimedCodes  = new(expvar.Int)
+	fallbackCodes = new(expvar.Int)
+	addedCodes    = new(expvar.Int)
+)
+
+func init() {
+	metrics.Set("depth", poolDepth)
+	metrics.Set("claimed", claimedCodes)
+	metrics.Set("fallbacks", fallbackCodes)
+	metrics.Set("added", addedCodes)
+}
+
+// Generator makes codes, it's what service.CodeGenerator is.
+type Generator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// PoolStore keeps the codes generated ahead of time, postgresDB.Storage claims them with SKIP LOCKED.
+type PoolStore interface {
+	AddPoolCodes(ctx context.Context, codes []string, now time.Time) (int, error)
+	ClaimPoolCode(ctx context.Context) (string, error)
+	CountPoolCodes(ctx context.Context) (int, error)
+}
+
+// Pool hands out the codes its source generated ahead of time, so that creating a link
+// needs a single claim instead of generating and checking codes. Refill has to run in the background
+// to keep it topped up; while it's empty the codes come from the source directly.
+type Pool struct {
+	store  PoolStore
+	source Generator
+	size   int
+}
+
+func NewPool(store PoolStore, source Generator, size int) *Pool {
+	return &Pool{store: store, source: source, size: size}
+}
+
+func (p *Pool) Generate(ctx context.Context) (string, error) {
+	code, err := p.store.ClaimPoolCode(ctx)
+
+	switch {
+	case err == nil:
+		claimedCodes.Add(1)
+		poolDepth.Add(-1)
+
+		return code, nil
+
+	case !errors.Is(err, pgx.ErrNoRows):
+		return "", fmt.Errorf("could not claim a pooled code: %w", err)
+	}
+
+	fallbackCodes.Add(1)
+
+	return p.source.Generate(ctx)
+}
+
+// Refill tops the pool up to its size. Codes of existing links are not pooled,
+// but a link may still take a pooled code as its alias, the caller retries on a taken code anyway.
+func (p *Pool) Refill(ctx context.Context) error {
+	depth, err := p.store.CountPoolCodes(ctx)
+
+	if err != nil {
+		return fmt.Errorf("could not count pooled codes: %w", err)
+	}
+
+	poolDepth.Set(int64(depth))
+
+	for depth < p.size {
+		codes := make([]string, min(p.size-depth, refillBatch))
+
+		for i := range codes {
+			if codes[i], err = p.source.Generate(ctx); err != nil {
+				return fmt.Errorf("could not generate codes: %w", err)
+			}
+		}
+
+		added, err := p.store.AddPoolCodes(ctx, codes, time.Now())
+
+		if err != nil {
+			return fmt.Errorf("could not add codes to the pool: %w", err)
+		}
+
+		if added == 0 {
+			return fmt.Errorf("none of %d generated codes were free, the keyspace is running out", len(codes))
+		}
+
+		depth += added
+		addedCodes.Add(int64(added))
+		poolDepth.Set(int64(depth))
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package codegen
+
+import "context"
+
+// Random makes codes of crypto-random base62 characters. They are unguessable but may collide,
+// so the caller retries on a taken code; with 8 characters that takes billions of links to matter.
+type Random struct {
+	length int
+}
+
+func NewRandom(length int) (*Random, error) {
+	if err := checkLength(length); err != nil {
+		return nil, err
+	}
+
+	return &Random{length: length}, nil
+}
+
+func (r *Random) Generate(ctx context.Context) (string, error) {
+	return pick(r.length, base62)
+}
@@ -0,0 +1,69 @@
+package codegen
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// shuffledBase62 is base62 in a fixed random order, changing it changes every code to come
+const shuffledBase62 = "cKaVPRug0job8tZSlEmMivsHLXGCh1DxWOe7ANIzJfTqr52dwUBn6yQ43Fp9Yk"
+
+// scrambler is a prime, multiplying by it permutes the numbers below any power of 62
+var scrambler = big.NewInt(1_580_030_173)
+
+// Counter hands out every number once, postgresDB.Storage does it with a sequence.
+type Counter interface {
+	NextLinkNumber(ctx context.Context) (int64, error)
+}
+
+// Sequence makes codes from the numbers of a Counter, so two codes never collide.
+// The numbers are scrambled within the keyspace of the length and written with a shuffled alphabet,
+// which keeps consecutive links from looking alike. This only hides the order, the codes are not secret.
+// Once the keyspace is used up the codes get longer.
+type Sequence struct {
+	counter Counter
+	length  int
+	// space is 62^length, the number of codes of the length
+	space *big.Int
+}
+
+func NewSequence(counter Counter, length int) (*Sequence, error) {
+	if err := checkLength(length); err != nil {
+		return nil, err
+	}
+
+	space := new(big.Int).Exp(big.NewInt(int64(len(shuffledBase62))), big.NewInt(int64(length)), nil)
+
+	return &Sequence{counter: counter, length: length, space: space}, nil
+}
+
+func (s *Sequence) Generate(ctx context.Context) (string, error) {
+	n, err := s.counter.NextLinkNumber(ctx)
+
+	if err != nil {
+		return "", fmt.Errorf("could not get the next link number: %w", err)
+	}
+
+	return s.encode(n), nil
+}
+
+func (s *Sequence) encode(n int64) string {
+	x := big.NewInt(n)
+
+	// the numbers past the keyspace are written as they are, they are longer than any scrambled one
+	if x.Cmp(s.space) < 0 {
+		x.Mul(x, scrambler).Mod(x, s.space)
+	}
+
+	base := big.NewInt(int64(len(shuffledBase62)))
+	digit := new(big.Int)
+	res := make([]byte, 0, s.length)
+
+	for x.Sign() > 0 || len(res) < s.length {
+		x.DivMod(x, base, digit)
+		res = append(res, shuffledBase62[digit.Int64()])
+	}
+
+	return string(res)
+}
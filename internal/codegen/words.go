@@ -0,0 +1,28 @@
+package codegen
+
+import "context"
+
+const (
+	// consonants leave out the letters that read ambiguously, like c, q, x and y
+	consonants = "bdfghjklmnprstvz"
+	vowels     = "aeiou"
+)
+
+// Words makes pronounceable codes of alternating consonants and vowels, like "bakotimu",
+// which are easy to read out and type. The keyspace is smaller than that of Random
+// of the same length, so they collide sooner and the caller retries on a taken code.
+type Words struct {
+	length int
+}
+
+func NewWords(length int) (*Words, error) {
+	if err := checkLength(length); err != nil {
+		return nil, err
+	}
+
+	return &Words{length: length}, nil
+}
+
+func (w *Words) Generate(ctx context.Context) (string, error) {
+	return pick(w.length, consonants, vowels)
+}
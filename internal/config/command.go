@@ -0,0 +1,33 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+const usage = "usage: config print | validate"
+
+// RunCommand implements the "config" subcommand of the server binary, args are the words after "config".
+func (c *Config) RunCommand(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "print":
+		return c.Print(out)
+
+	case "validate":
+		if err := c.Validate(); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(out, "configuration is valid")
+
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command %q, %s", args[0], usage)
+	}
+}
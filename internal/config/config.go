@@ -1,32 +1,293 @@
 package config
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"urleater/internal/codegen"
+)
+
+// Storage backends accepted in DBConfig.Driver
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Short link generators accepted in LinksConfig.CodeGenerator
+const (
+	CodesRandom   = "random"
+	CodesSequence = "sequence"
+	CodesWords    = "words"
+)
+
+// Payment providers accepted in PaymentsConfig.Provider
+const (
+	// PaymentsNone turns buying subscriptions off
+	PaymentsNone = "none"
+	// PaymentsFake lets anybody mark an order paid, it's accepted in dev mode only
+	PaymentsFake = "fake"
+)
 
 type DBConfig struct {
-	PostgresHost     string
-	PostgresPort     string
-	PostgresUser     string
-	PostgresPassword string
-	PostgresDatabase string
-	PostgresParams   string
+	Driver           string `yaml:"driver"`
+	PostgresHost     string `yaml:"postgres_host"`
+	PostgresPort     string `yaml:"postgres_port"`
+	PostgresUser     string `yaml:"postgres_user"`
+	PostgresPassword string `yaml:"postgres_password"`
+	PostgresDatabase string `yaml:"postgres_database"`
+	PostgresParams   string `yaml:"postgres_params"`
+	SQLitePath       string `yaml:"sqlite_path"`
+	AutoMigrate      bool   `yaml:"auto_migrate"`
+}
+
+type HTTPConfig struct {
+	Addr string `yaml:"addr"`
+	// PublicBaseURL is where users reach the service, short links are built from it
+	PublicBaseURL string `yaml:"public_base_url"`
+	// ShutdownTimeout is how long in-flight requests may take to finish on shutdown
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// TrustedProxies are the comma separated CIDRs of the reverse proxies whose X-Forwarded-For
+	// tells the client address, with none the address of the connection is used
+	TrustedProxies string `yaml:"trusted_proxies"`
+}
+
+type SessionConfig struct {
+	Secret          string        `yaml:"secret"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+}
+
+type LinksConfig struct {
+	// TTL is how long a new short link lives
+	TTL time.Duration `yaml:"ttl"`
+	// DefaultQuota is how many short links a new user may create
+	DefaultQuota int `yaml:"default_quota"`
+	// CodeGenerator makes the short links of the links created without an alias:
+	// random base62, sequence for collision-free codes from a database counter or pronounceable words
+	CodeGenerator string `yaml:"code_generator"`
+	// CodeLength is how many characters the generated short links have
+	CodeLength int `yaml:"code_length"`
+	// CodePoolSize is how many generated short links are kept ready for new links, 0 turns the pool off
+	CodePoolSize int `yaml:"code_pool_size"`
+	// CodePoolRefillInterval is how often the pool is topped up
+	CodePoolRefillInterval time.Duration `yaml:"code_pool_refill_interval"`
 }
+
+type CacheConfig struct {
+	// Size is how many short links are cached for the redirects, 0 turns the cache off
+	Size int `yaml:"size"`
+	// TTL bounds how long a link stays cached, changes made by other instances are announced
+	// with Postgres NOTIFY, so it matters only when a notification is lost
+	TTL time.Duration `yaml:"ttl"`
+	// MissTTL is how long the unknown short links are remembered as such
+	MissTTL time.Duration `yaml:"miss_ttl"`
+}
+
+type StatsConfig struct {
+	// Secret keys the hashes the visitor addresses are stored as, without it they can't be brute-forced back
+	Secret string `yaml:"secret"`
+}
+
+type SubscriptionsConfig struct {
+	// CheckInterval is how often lapsed subscriptions are expired and due quotas are reset
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+type PaymentsConfig struct {
+	// Provider takes the payments for subscriptions: none, or fake for a local checkout page in dev mode
+	Provider string `yaml:"provider"`
+	// Secret signs the provider's notifications, the fake provider makes one up when it's empty
+	Secret string `yaml:"secret"`
+}
+
 type Config struct {
-	DB DBConfig
+	// DevMode allows the settings that are unsafe in production, such as the fake payment provider
+	DevMode       bool                `yaml:"dev_mode"`
+	DB            DBConfig            `yaml:"db"`
+	HTTP          HTTPConfig          `yaml:"http"`
+	Session       SessionConfig       `yaml:"session"`
+	Links         LinksConfig         `yaml:"links"`
+	Cache         CacheConfig         `yaml:"cache"`
+	Stats         StatsConfig         `yaml:"stats"`
+	Subscriptions SubscriptionsConfig `yaml:"subscriptions"`
+	Payments      PaymentsConfig      `yaml:"payments"`
 }
 
-func (c *Config) PostgresURL() string {
-	pgURL := fmt.Sprintf(
-		"postgres://%v:%v@%v:%v/%v",
-		c.DB.PostgresUser,
-		c.DB.PostgresPassword,
-		c.DB.PostgresHost,
-		c.DB.PostgresPort,
-		c.DB.PostgresDatabase,
-	)
+// Default is the configuration for local development, secrets have no defaults.
+func Default() Config {
+	return Config{
+		DB: DBConfig{
+			Driver:           DriverPostgres,
+			PostgresHost:     "localhost",
+			PostgresPort:     "5432",
+			PostgresUser:     "postgres",
+			PostgresDatabase: "postgres",
+			PostgresParams:   "sslmode=disable",
+			SQLitePath:       "urleater.db",
+			AutoMigrate:      true,
+		},
+		HTTP: HTTPConfig{
+			Addr:            ":8080",
+			PublicBaseURL:   "http://localhost:8080",
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Session: SessionConfig{
+			CleanupInterval: 5 * time.Minute,
+		},
+		Links: LinksConfig{
+			TTL:                    90 * 24 * time.Hour,
+			DefaultQuota:           10,
+			CodeGenerator:          CodesRandom,
+			CodeLength:             codegen.DefaultLength,
+			CodePoolSize:           1000,
+			CodePoolRefillInterval: 10 * time.Second,
+		},
+		Cache: CacheConfig{
+			Size:    100000,
+			TTL:     10 * time.Minute,
+			MissTTL: 10 * time.Second,
+		},
+		Subscriptions: SubscriptionsConfig{
+			CheckInterval: 10 * time.Minute,
+		},
+		Payments: PaymentsConfig{
+			Provider: PaymentsNone,
+		},
+	}
+}
 
-	if c.DB.PostgresParams != "" {
-		pgURL = fmt.Sprintf("%v?%v", pgURL, c.DB.PostgresParams)
+// Validate reports every problem at once, so that a broken deployment can be fixed in one go.
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.DB.Driver {
+	case DriverPostgres:
+		if c.DB.PostgresHost == "" || c.DB.PostgresPort == "" || c.DB.PostgresUser == "" || c.DB.PostgresDatabase == "" {
+			errs = append(errs, errors.New("db: postgres host, port, user and database are required"))
+		}
+	case DriverSQLite:
+		if c.DB.SQLitePath == "" {
+			errs = append(errs, errors.New("db.sqlite_path is required for the sqlite driver"))
+		}
+	case DriverMemory:
+	default:
+		errs = append(errs, fmt.Errorf("db.driver must be one of %s, %s, %s, got %q", DriverPostgres, DriverSQLite, DriverMemory, c.DB.Driver))
+	}
+
+	if c.HTTP.Addr == "" {
+		errs = append(errs, errors.New("http.addr is required"))
+	}
+
+	if u, err := url.Parse(c.HTTP.PublicBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("http.public_base_url must be an absolute http(s) URL, got %q", c.HTTP.PublicBaseURL))
+	}
+
+	if c.HTTP.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("http.shutdown_timeout must be positive"))
+	}
+
+	if _, err := c.HTTP.TrustedProxyNets(); err != nil {
+		errs = append(errs, fmt.Errorf("http.trusted_proxies: %w", err))
+	}
+
+	// in-memory data does not survive a restart, so neither need sessions: an ephemeral secret is generated then
+	if c.Session.Secret == "" && c.DB.Driver != DriverMemory {
+		errs = append(errs, errors.New("session.secret is required"))
+	}
+
+	// a new secret counts every visitor anew, which loses nothing when the clicks are lost on restart anyway
+	if c.Stats.Secret == "" && c.DB.Driver != DriverMemory {
+		errs = append(errs, errors.New("stats.secret is required"))
+	}
+
+	if c.Session.CleanupInterval <= 0 {
+		errs = append(errs, errors.New("session.cleanup_interval must be positive"))
+	}
+
+	if c.Links.TTL < time.Hour {
+		errs = append(errs, fmt.Errorf("links.ttl must be at least 1h, got %s", c.Links.TTL))
+	}
+
+	if c.Links.DefaultQuota < 0 {
+		errs = append(errs, fmt.Errorf("links.default_quota can't be negative, got %d", c.Links.DefaultQuota))
+	}
+
+	switch c.Links.CodeGenerator {
+	case CodesRandom, CodesSequence, CodesWords:
+	default:
+		errs = append(errs, fmt.Errorf("links.code_generator must be one of %s, %s, %s, got %q", CodesRandom, CodesSequence, CodesWords, c.Links.CodeGenerator))
+	}
+
+	if c.Links.CodeLength < codegen.MinLength || c.Links.CodeLength > codegen.MaxLength {
+		errs = append(errs, fmt.Errorf("links.code_length must be %d to %d, got %d", codegen.MinLength, codegen.MaxLength, c.Links.CodeLength))
+	}
+
+	if c.Links.CodePoolSize < 0 {
+		errs = append(errs, fmt.Errorf("links.code_pool_size can't be negative, got %d", c.Links.CodePoolSize))
+	}
+
+	if c.Links.CodePoolSize > 0 && c.Links.CodePoolRefillInterval <= 0 {
+		errs = append(errs, errors.New("links.code_pool_refill_interval must be positive"))
+	}
+
+	if c.Cache.Size < 0 {
+		errs = append(errs, fmt.Errorf("cache.size can't be negative, got %d", c.Cache.Size))
+	}
+
+	if c.Cache.Size > 0 && (c.Cache.TTL <= 0 || c.Cache.MissTTL <= 0) {
+		errs = append(errs, errors.New("cache.ttl and cache.miss_ttl must be positive"))
+	}
+
+	if c.Subscriptions.CheckInterval <= 0 {
+		errs = append(errs, errors.New("subscriptions.check_interval must be positive"))
+	}
+
+	switch c.Payments.Provider {
+	case PaymentsNone:
+	case PaymentsFake:
+		// its checkout page pays any order, which is only fine on a developer's machine
+		if !c.DevMode {
+			errs = append(errs, fmt.Errorf("payments.provider %s is allowed in dev_mode only", PaymentsFake))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("payments.provider must be %s or %s, got %q", PaymentsNone, PaymentsFake, c.Payments.Provider))
+	}
+
+	return errors.Join(errs...)
+}
+
+// TrustedProxyNets parses TrustedProxies.
+func (c *HTTPConfig) TrustedProxyNets() ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, cidr := range strings.Split(c.TrustedProxies, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+func (c *Config) PostgresURL() string {
+	pgURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.DB.PostgresUser, c.DB.PostgresPassword),
+		Host:     fmt.Sprintf("%v:%v", c.DB.PostgresHost, c.DB.PostgresPort),
+		Path:     "/" + c.DB.PostgresDatabase,
+		RawQuery: c.DB.PostgresParams,
 	}
 
-	return pgURL
+	return pgURL.String()
 }
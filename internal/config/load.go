@@ -0,0 +1,232 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix  = "URLEATER_"
+	configEnv  = envPrefix + "CONFIG"
+	redacted   = "******"
+	configFlag = "config"
+)
+
+// setting binds one configuration value to its environment variable and command line flag.
+type setting struct {
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	// value points into a Config, it is *string, *int, *bool or *time.Duration
+	value any
+}
+
+func settings(c *Config) []setting {
+	return []setting{
+		{env: "DEV_MODE", flag: "dev-mode", usage: "allow the settings that are unsafe in production, such as the fake payment provider", value: &c.DevMode},
+		{env: "DB_DRIVER", flag: "storage", usage: "storage backend: postgres, sqlite or memory", value: &c.DB.Driver},
+		{env: "DB_POSTGRES_HOST", flag: "postgres-host", usage: "Postgres host", value: &c.DB.PostgresHost},
+		{env: "DB_POSTGRES_PORT", flag: "postgres-port", usage: "Postgres port", value: &c.DB.PostgresPort},
+		{env: "DB_POSTGRES_USER", flag: "postgres-user", usage: "Postgres user", value: &c.DB.PostgresUser},
+		{env: "DB_POSTGRES_PASSWORD", flag: "postgres-password", usage: "Postgres password", secret: true, value: &c.DB.PostgresPassword},
+		{env: "DB_POSTGRES_DATABASE", flag: "postgres-database", usage: "Postgres database", value: &c.DB.PostgresDatabase},
+		{env: "DB_POSTGRES_PARAMS", flag: "postgres-params", usage: "Postgres connection parameters, e.g. sslmode=disable", value: &c.DB.PostgresParams},
+		{env: "DB_SQLITE_PATH", flag: "sqlite-path", usage: "database file of the sqlite storage", value: &c.DB.SQLitePath},
+		{env: "DB_AUTO_MIGRATE", flag: "auto-migrate", usage: "apply pending Postgres migrations on startup", value: &c.DB.AutoMigrate},
+		{env: "HTTP_ADDR", flag: "http-addr", usage: "address the HTTP server listens on", value: &c.HTTP.Addr},
+		{env: "HTTP_PUBLIC_BASE_URL", flag: "public-base-url", usage: "URL users reach the service at", value: &c.HTTP.PublicBaseURL},
+		{env: "HTTP_SHUTDOWN_TIMEOUT", flag: "http-shutdown-timeout", usage: "how long in-flight requests may take to finish on shutdown", value: &c.HTTP.ShutdownTimeout},
+		{env: "HTTP_TRUSTED_PROXIES", flag: "trusted-proxies", usage: "comma separated CIDRs of the reverse proxies whose X-Forwarded-For is trusted", value: &c.HTTP.TrustedProxies},
+		{env: "SESSION_SECRET", flag: "session-secret", usage: "key the session cookies are signed with", secret: true, value: &c.Session.Secret},
+		{env: "SESSION_CLEANUP_INTERVAL", flag: "session-cleanup-interval", usage: "how often expired sessions are deleted", value: &c.Session.CleanupInterval},
+		{env: "LINKS_TTL", flag: "link-ttl", usage: "lifetime of a new short link", value: &c.Links.TTL},
+		{env: "LINKS_DEFAULT_QUOTA", flag: "default-quota", usage: "number of short links a new user may create", value: &c.Links.DefaultQuota},
+		{env: "LINKS_CODE_GENERATOR", flag: "code-generator", usage: "how short links are generated: random, sequence or words", value: &c.Links.CodeGenerator},
+		{env: "LINKS_CODE_LENGTH", flag: "code-length", usage: "number of characters of a generated short link", value: &c.Links.CodeLength},
+		{env: "LINKS_CODE_POOL_SIZE", flag: "code-pool-size", usage: "number of generated short links kept ready, 0 disables the pool", value: &c.Links.CodePoolSize},
+		{env: "LINKS_CODE_POOL_REFILL_INTERVAL", flag: "code-pool-refill-interval", usage: "how often the pool of short links is topped up", value: &c.Links.CodePoolRefillInterval},
+		{env: "CACHE_SIZE", flag: "cache-size", usage: "number of short links cached for the redirects, 0 disables the cache", value: &c.Cache.Size},
+		{env: "CACHE_TTL", flag: "cache-ttl", usage: "how long a short link stays cached", value: &c.Cache.TTL},
+		{env: "CACHE_MISS_TTL", flag: "cache-miss-ttl", usage: "how long an unknown short link is remembered as such", value: &c.Cache.MissTTL},
+		{env: "STATS_SECRET", flag: "stats-secret", usage: "key the visitor addresses of the clicks are hashed with", secret: true, value: &c.Stats.Secret},
+		{env: "SUBSCRIPTIONS_CHECK_INTERVAL", flag: "subscription-check-interval", usage: "how often lapsed subscriptions are expired and quotas are reset", value: &c.Subscriptions.CheckInterval},
+		{env: "PAYMENTS_PROVIDER", flag: "payments-provider", usage: "payment provider for subscriptions: none, or fake in dev mode", value: &c.Payments.Provider},
+		{env: "PAYMENTS_SECRET", flag: "payments-secret", usage: "key the payment notifications are signed with", secret: true, value: &c.Payments.Secret},
+	}
+}
+
+func (s setting) set(raw string) error {
+	switch v := s.value.(type) {
+	case *string:
+		*v = raw
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		*v = n
+	case *bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		*v = b
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		*v = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", s.value)
+	}
+
+	return nil
+}
+
+// Load builds the configuration from, in increasing order of precedence: defaults,
+// the YAML file given by -config or URLEATER_CONFIG, URLEATER_* environment variables and flags.
+// It returns the arguments left after the flags, which name a subcommand if any.
+// The result is not validated, call Validate.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, []string, error) {
+	cfg := Default()
+	fields := settings(&cfg)
+
+	fs := flag.NewFlagSet("urleater", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	path := fs.String(configFlag, "", "YAML configuration file")
+	flagValues := make(map[string]string)
+
+	for _, field := range fields {
+		name := field.flag
+		record := func(raw string) error {
+			flagValues[name] = raw
+			return nil
+		}
+
+		// a bare -flag turns a bool setting on, so that it doesn't take the next argument as its value
+		if _, ok := field.value.(*bool); ok {
+			fs.BoolFunc(name, field.usage, record)
+			continue
+		}
+
+		fs.Func(name, field.usage, record)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *path == "" {
+		*path, _ = lookupEnv(configEnv)
+	}
+
+	if *path != "" {
+		if err := loadFile(&cfg, *path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var errs []error
+
+	for _, field := range fields {
+		if raw, ok := lookupEnv(envPrefix + field.env); ok {
+			if err := field.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s%s: %w", envPrefix, field.env, err))
+			}
+		}
+	}
+
+	for _, field := range fields {
+		if raw, ok := flagValues[field.flag]; ok {
+			if err := field.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("invalid -%s: %w", field.flag, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	return &cfg, fs.Args(), nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open config file: %w", err)
+	}
+
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+
+	if err = decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Print writes the configuration as YAML, which can be used as a config file, with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	printed := *c
+
+	for _, field := range settings(&printed) {
+		if v, ok := field.value.(*string); ok && field.secret && *v != "" {
+			*v = redacted
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(printed); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// Usage describes every setting with its flag, environment variable and default.
+func Usage(w io.Writer) {
+	defaults := Default()
+
+	fmt.Fprintf(w, "  -%s\n\tYAML configuration file (env %s)\n", configFlag, configEnv)
+
+	for _, field := range settings(&defaults) {
+		fmt.Fprintf(w, "  -%s\n\t%s (env %s%s", field.flag, field.usage, envPrefix, field.env)
+
+		if !field.secret {
+			fmt.Fprintf(w, ", default %v", reflectValue(field.value))
+		}
+
+		fmt.Fprintln(w, ")")
+	}
+}
+
+func reflectValue(value any) any {
+	switch v := value.(type) {
+	case *string:
+		return strconv.Quote(*v)
+	case *int:
+		return *v
+	case *bool:
+		return *v
+	case *time.Duration:
+		return *v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// adminPageData lets the admin page show only what the principal may do.
+type adminPageData struct {
+	pageData
+	Principal *service.Principal
+}
+
+// queryInt parses an optional integer query parameter, a missing one is 0.
+func queryInt(c echo.Context, name string) (int, error) {
+	value := c.QueryParam(name)
+
+	if value == "" {
+		return 0, nil
+	}
+
+	res, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0, invalidInput(err)
+	}
+
+	return res, nil
+}
+
+// GetAdminPage godoc
+//
+// @Summary Gets admin console HTML, requires the users.view permission
+// @Produce	html
+// @Success 200
+// @Failure 401
+// @Failure 403
+// @Router /admin	[get]
+func (h *Handlers) GetAdminPage(c echo.Context) error {
+	principal, err := principalFrom(c)
+
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "admin_page.html", adminPageData{
+		pageData:  h.page(),
+		Principal: principal,
+	})
+}
+
+type SearchUsersResponse struct {
+	Users []postgresDB.UserSummary `json:"users"`
+}
+
+// SearchUsers godoc
+//
+//	@Summary		Searches users by email, requires the users.view permission
+//	@Param			query	query		string	false	"Part of the email, all users if empty"
+//	@Param			offset	query		int		false	"Users to skip"
+//	@Param			limit	query		int		false	"Maximum amount of users to show, 20 by default"
+//	@Success		200			{object}	SearchUsersResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/admin/users      [get]
+func (h *Handlers) SearchUsers(c echo.Context) error {
+	offset, err := queryInt(c, "offset")
+
+	if err != nil {
+		return err
+	}
+
+	limit, err := queryInt(c, "limit")
+
+	if err != nil {
+		return err
+	}
+
+	users, err := h.Service.SearchUsers(c.Request().Context(), c.QueryParam("query"), offset, limit)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SearchUsersResponse{
+		Users: users,
+	})
+}
+
+type SetUserDisabledRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Disabled bool   `json:"disabled"`
+}
+
+// SetUserDisabled godoc
+//
+//	@Summary		Disables a user account or enables it back, requires the users.disable permission
+//	@Accept			json
+//	@Param			email		body		string	true	"User to change"
+//	@Param			disabled	body		bool	true	"Whether the account is disabled"
+//	@Success		204
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/admin/users/disable      [post]
+func (h *Handlers) SetUserDisabled(c echo.Context) error {
+	principal, err := principalFrom(c)
+
+	if err != nil {
+		return err
+	}
+
+	requestData := new(SetUserDisabledRequest)
+
+	if err := c.Bind(requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	err = h.Service.SetUserDisabled(c.Request().Context(), requestData.Email, requestData.Disabled, principal.Email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type SetUserRoleRequest struct {
+	Email string `json:"email" validate:"required"`
+	Role  string `json:"role" validate:"required"`
+}
+
+// SetUserRole godoc
+//
+//	@Summary		Gives a user another role, requires the users.manage_roles permission
+//	@Accept			json
+//	@Param			email	body		string	true	"User to change"
+//	@Param			role	body		string	true	"Name of the new role"
+//	@Success		204
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/admin/users/role      [post]
+func (h *Handlers) SetUserRole(c echo.Context) error {
+	principal, err := principalFrom(c)
+
+	if err != nil {
+		return err
+	}
+
+	requestData := new(SetUserRoleRequest)
+
+	if err := c.Bind(requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	err = h.Service.SetUserRole(c.Request().Context(), requestData.Email, requestData.Role, principal.Email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type SearchLinksResponse struct {
+	Links []postgresDB.Link `json:"links"`
+}
+
+// SearchLinks godoc
+//
+//	@Summary		Searches all short links by alias, destination or owner, requires the links.view permission
+//	@Param			query	query		string	false	"Part of the alias, destination or owner email, all links if empty"
+//	@Param			offset	query		int		false	"Links to skip"
+//	@Param			limit	query		int		false	"Maximum amount of links to show, 20 by default"
+//	@Success		200			{object}	SearchLinksResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/admin/links      [get]
+func (h *Handlers) SearchLinks(c echo.Context) error {
+	offset, err := queryInt(c, "offset")
+
+	if err != nil {
+		return err
+	}
+
+	limit, err := queryInt(c, "limit")
+
+	if err != nil {
+		return err
+	}
+
+	links, err := h.Service.SearchLinks(c.Request().Context(), c.QueryParam("query"), offset, limit)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SearchLinksResponse{
+		Links: links,
+	})
+}
+
+type SetLinkDisabledRequest struct {
+	ShortLink string `json:"short_link" validate:"required"`
+	Disabled  bool   `json:"disabled"`
+}
+
+type SetLinkDisabledResponse struct {
+	Link postgresDB.Link `json:"link"`
+}
+
+// SetLinkDisabled godoc
+//
+//	@Summary		Stops a short link from redirecting without deleting it or lets it redirect again, requires the links.moderate permission
+//	@Accept			json
+//	@Param			short_link	body		string	true	"Short link to change"
+//	@Param			disabled	body		bool	true	"Whether the link is disabled"
+//	@Success		200			{object}	SetLinkDisabledResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/admin/links/disable      [post]
+func (h *Handlers) SetLinkDisabled(c echo.Context) error {
+	requestData := new(SetLinkDisabledRequest)
+
+	if err := c.Bind(requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	link, err := h.Service.SetLinkDisabled(c.Request().Context(), requestData.ShortLink, requestData.Disabled)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SetLinkDisabledResponse{
+		Link: *link,
+	})
+}
+
+type GetSystemStatsResponse struct {
+	Stats postgresDB.SystemStats `json:"stats"`
+}
+
+// GetSystemStats godoc
+//
+//	@Summary		Counts users, links, clicks and sales of the whole service, requires the users.view permission
+//	@Success		200			{object}	GetSystemStatsResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/admin/stats      [get]
+func (h *Handlers) GetSystemStats(c echo.Context) error {
+	stats, err := h.Service.GetSystemStats(c.Request().Context())
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, GetSystemStatsResponse{
+		Stats: *stats,
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetApiTokensPage godoc
+//
+// @Summary Gets the page managing user's API tokens
+// @Produce	html
+// @Success 200
+// @Failure 500
+// @Failure 307
+// @Router /tokens	[get]
+func (h *Handlers) GetApiTokensPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "tokens.html", h.page())
+}
+
+type GetApiTokensResponse struct {
+	Tokens []postgresDB.ApiToken `json:"tokens"`
+}
+
+// GetApiTokens godoc
+//
+//	@Summary		Lists user's API tokens, the newest first
+//	@Success		200			{object}	GetApiTokensResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/get_tokens      [get]
+func (h *Handlers) GetApiTokens(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	tokens, err := h.Service.GetApiTokens(c.Request().Context(), email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, GetApiTokensResponse{
+		Tokens: tokens,
+	})
+}
+
+type CreateApiTokenRequest struct {
+	Name string `json:"name" validate:"required"`
+	// Scopes are any of links.read, links.create, links.delete and stats.read
+	Scopes []string `json:"scopes" validate:"required"`
+	// Days is how long the token lives, 0 means it never expires
+	Days int `json:"days" validate:"gte=0"`
+}
+
+type CreateApiTokenResponse struct {
+	// Token is sent with the API requests as "Authorization: Bearer <token>", it's shown only once
+	Token    string              `json:"token"`
+	ApiToken postgresDB.ApiToken `json:"api_token"`
+}
+
+// CreateApiToken godoc
+//
+//	@Summary		Creates an API token for scripts to call the API on behalf of the user
+//	@Accept			json
+//	@Param			name	body		string		true	"Name telling the token apart"
+//	@Param			scopes	body		[]string	true	"What the token may do"
+//	@Param			days	body		int			false	"How long the token lives, 0 for no expiry"
+//	@Success		200			{object}	CreateApiTokenResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/create_token      [post]
+func (h *Handlers) CreateApiToken(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	requestData := new(CreateApiTokenRequest)
+
+	if err := c.Bind(requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	secret, token, err := h.Service.CreateApiToken(c.Request().Context(), email, requestData.Name, requestData.Scopes, requestData.Days)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, CreateApiTokenResponse{
+		Token:    secret,
+		ApiToken: *token,
+	})
+}
+
+type RevokeApiTokenRequest struct {
+	Id int `json:"id" validate:"required"`
+}
+
+type RevokeApiTokenResponse struct {
+	Id int `json:"id"`
+}
+
+// RevokeApiToken godoc
+//
+//	@Summary		Revokes user's API token, the requests made with it are refused from then on
+//	@Accept			json
+//	@Param			id	body		int	true	"Token to revoke"
+//	@Success		200			{object}	RevokeApiTokenResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/revoke_token      [delete]
+func (h *Handlers) RevokeApiToken(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	requestData := new(RevokeApiTokenRequest)
+
+	if err := c.Bind(requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	err = h.Service.RevokeApiToken(c.Request().Context(), email, requestData.Id)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, RevokeApiTokenResponse{
+		Id: requestData.Id,
+	})
+}
@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// apiPageSize is how many links GET /api/v1/links returns when no limit is given
+	apiPageSize    = 20
+	maxApiPageSize = 100
+)
+
+// Deprecated marks the responses of a route kept for old clients, pointing them to the /api/v1 route replacing it.
+func Deprecated(successor string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set("Deprecation", "true")
+			c.Response().Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+			return next(c)
+		}
+	}
+}
+
+// apiNotFound answers the unknown /api/v1 paths, which would be taken for pages otherwise.
+func apiNotFound(c echo.Context) error {
+	return echo.ErrNotFound
+}
+
+// LinkResource is a short link as /api/v1 shows it
+type LinkResource struct {
+	Code string `json:"code"`
+	// ShortURL is the address the link is shared by
+	ShortURL  string    `json:"short_url"`
+	LongURL   string    `json:"long_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Disabled  bool      `json:"disabled"`
+	// Protected links ask visitors for a password
+	Protected bool `json:"protected"`
+	// ClicksLeft is how many more times the link redirects, null for the links without a limit
+	ClicksLeft *int `json:"clicks_left"`
+}
+
+func (h *Handlers) linkResource(link *postgresDB.Link) LinkResource {
+	return LinkResource{
+		Code:       link.ShortUrl,
+		ShortURL:   h.BaseURL + "/" + link.ShortUrl,
+		LongURL:    link.LongUrl,
+		ExpiresAt:  link.ExpiresAt,
+		Disabled:   link.Disabled,
+		Protected:  link.Protected,
+		ClicksLeft: link.ClicksLeft,
+	}
+}
+
+// UserResource is the user as /api/v1 shows it
+type UserResource struct {
+	Email string `json:"email"`
+	// UrlsLeft is how many more links the user can create
+	UrlsLeft int `json:"urls_left"`
+}
+
+// SubscriptionResource is a plan as /api/v1 shows it
+type SubscriptionResource struct {
+	Id                 int    `json:"id"`
+	Name               string `json:"name"`
+	TotalUrls          int    `json:"total_urls"`
+	MaxLinkTTLDays     int    `json:"max_link_ttl_days"`
+	RefundDeletedLinks bool   `json:"refund_deleted_links"`
+	// Price is in the smallest currency unit, 0 means the plan is not for sale
+	Price          int `json:"price"`
+	PeriodDays     int `json:"period_days"`
+	LinkTTLDays    int `json:"link_ttl_days"`
+	MinAliasLength int `json:"min_alias_length"`
+}
+
+type LinkResponse struct {
+	Link LinkResource `json:"link"`
+}
+
+type ListLinksResponse struct {
+	Links  []LinkResource `json:"links"`
+	Offset int            `json:"offset"`
+	Limit  int            `json:"limit"`
+}
+
+type MeResponse struct {
+	User UserResource `json:"user"`
+}
+
+type ListSubscriptionsResponse struct {
+	Subscriptions []SubscriptionResource `json:"subscriptions"`
+}
+
+type CreateLinkRequest struct {
+	// Code is the alias of the link, a random one is made when it's empty
+	Code    string `json:"code"`
+	LongURL string `json:"long_url" validate:"required"`
+	// Password protects the link, visitors have to enter it before they are redirected
+	Password string `json:"password"`
+	// MaxClicks is how many times the link redirects before it's used up, 0 is for no limit
+	MaxClicks int `json:"max_clicks" validate:"gte=0"`
+}
+
+// UpdateLinkRequest changes the fields that are set, at least one of them has to be
+type UpdateLinkRequest struct {
+	LongURL *string `json:"long_url"`
+	// Days renews the link for that many days from now, 0 means the default link TTL, cut to the plan maximum
+	Days *int `json:"days" validate:"omitempty,gte=0"`
+	// Password protects the link, an empty one removes the protection
+	Password *string `json:"password"`
+	// MaxClicks lets the link redirect that many more times, 0 removes the limit
+	MaxClicks *int `json:"max_clicks" validate:"omitempty,gte=0"`
+}
+
+// CreateLinkV1 godoc
+//
+//	@Summary		Creates a short link, needs the links.create scope
+//	@Tags			links
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			link	body		CreateLinkRequest	true	"Link to create"
+//	@Success		201		{object}	LinkResponse
+//	@Header			201		{string}	Location	"Address of the new link"
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		409		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/links	[post]
+func (h *Handlers) CreateLinkV1(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	requestData := new(CreateLinkRequest)
+
+	if err := c.Bind(requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	link, err := h.Service.CreateShortLinkWithOptions(c.Request().Context(), requestData.Code, requestData.LongURL, email, service.LinkOptions{
+		Password:  requestData.Password,
+		MaxClicks: requestData.MaxClicks,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/api/v1/links/"+link.ShortUrl)
+
+	return c.JSON(http.StatusCreated, LinkResponse{
+		Link: h.linkResource(link),
+	})
+}
+
+// ListLinksV1 godoc
+//
+//	@Summary		Lists user's short links, needs the links.read scope
+//	@Tags			links
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			offset	query		int	false	"How many links to skip"
+//	@Param			limit	query		int	false	"How many links to return, 20 by default and 100 at most"
+//	@Success		200		{object}	ListLinksResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/links	[get]
+func (h *Handlers) ListLinksV1(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	offset, err := queryInt(c, "offset")
+
+	if err != nil {
+		return err
+	}
+
+	limit, err := queryInt(c, "limit")
+
+	if err != nil {
+		return err
+	}
+
+	if limit == 0 {
+		limit = apiPageSize
+	}
+
+	if limit > maxApiPageSize {
+		return invalidInput(fmt.Errorf("limit can't be over %d", maxApiPageSize))
+	}
+
+	links, _, err := h.Service.GetUserShortLinksWithOffsetAndLimit(c.Request().Context(), email, offset, limit)
+
+	if err != nil {
+		return err
+	}
+
+	resources := make([]LinkResource, 0, len(links))
+
+	for i := range links {
+		resources = append(resources, h.linkResource(&links[i]))
+	}
+
+	return c.JSON(http.StatusOK, ListLinksResponse{
+		Links:  resources,
+		Offset: offset,
+		Limit:  limit,
+	})
+}
+
+// GetLinkV1 godoc
+//
+//	@Summary		Gets user's short link, expired and disabled ones included, needs the links.read scope
+//	@Tags			links
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			code	path		string	true	"Short link code"
+//	@Success		200		{object}	LinkResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/links/{code}	[get]
+func (h *Handlers) GetLinkV1(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	link, err := h.Service.GetUserShortLink(c.Request().Context(), c.Param("code"), email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, LinkResponse{
+		Link: h.linkResource(link),
+	})
+}
+
+// UpdateLinkV1 godoc
+//
+//	@Summary		Changes the destination, lifetime, password or click limit of user's short link, needs the links.create scope
+//	@Tags			links
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			code	path		string				true	"Short link code"
+//	@Param			link	body		UpdateLinkRequest	true	"Fields to change"
+//	@Success		200		{object}	LinkResponse
+//	@Failure		400		{object}	ErrorResponse
+//	@Failure		401		{object}	ErrorResponse
+//	@Failure		403		{object}	ErrorResponse
+//	@Failure		404		{object}	ErrorResponse
+//	@Failure		500		{object}	ErrorResponse
+//	@Router			/api/v1/links/{code}	[patch]
+func (h *Handlers) UpdateLinkV1(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	requestData := new(UpdateLinkRequest)
+
+	if err := c.Bind(requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	if requestData.LongURL == nil && requestData.Days == nil && requestData.Password == nil && requestData.MaxClicks == nil {
+		return invalidInput(fmt.Errorf("nothing to change, expected long_url, days, password or max_clicks"))
+	}
+
+	link, err := h.Service.UpdateLink(c.Request().Context(), c.Param("code"), email, service.LinkUpdate{
+		LongUrl:   requestData.LongURL,
+		Days:      requestData.Days,
+		Password:  requestData.Password,
+		MaxClicks: requestData.MaxClicks,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, LinkResponse{
+		Link: h.linkResource(link),
+	})
+}
+
+// DeleteLinkV1 godoc
+//
+//	@Summary		Deletes user's short link, needs the links.delete scope
+//	@Tags			links
+//	@Security		BearerAuth
+//	@Param			code	path	string	true	"Short link code"
+//	@Success		204
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/api/v1/links/{code}	[delete]
+func (h *Handlers) DeleteLinkV1(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	if err := h.Service.DeleteShortLink(c.Request().Context(), c.Param("code"), email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetMeV1 godoc
+//
+//	@Summary		Gets the user the request is made by, needs the links.read scope
+//	@Tags			users
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	MeResponse
+//	@Failure		401	{object}	ErrorResponse
+//	@Failure		403	{object}	ErrorResponse
+//	@Failure		404	{object}	ErrorResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/api/v1/me	[get]
+func (h *Handlers) GetMeV1(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	user, err := h.Service.GetUser(c.Request().Context(), email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MeResponse{
+		User: UserResource{Email: user.Email, UrlsLeft: user.UrlsLeft},
+	})
+}
+
+// ListSubscriptionsV1 godoc
+//
+//	@Summary		Lists the subscription plans, they are public
+//	@Tags			subscriptions
+//	@Produce		json
+//	@Success		200	{object}	ListSubscriptionsResponse
+//	@Failure		500	{object}	ErrorResponse
+//	@Router			/api/v1/subscriptions	[get]
+func (h *Handlers) ListSubscriptionsV1(c echo.Context) error {
+	subscriptions, err := h.Service.GetSubscriptions(c.Request().Context())
+
+	if err != nil {
+		return err
+	}
+
+	resources := make([]SubscriptionResource, 0, len(subscriptions))
+
+	for _, sub := range subscriptions {
+		resources = append(resources, SubscriptionResource{
+			Id:                 sub.Id,
+			Name:               sub.Name,
+			TotalUrls:          sub.TotalUrls,
+			MaxLinkTTLDays:     sub.MaxLinkTTLDays,
+			RefundDeletedLinks: sub.RefundDeletedLinks,
+			Price:              sub.Price,
+			PeriodDays:         sub.PeriodDays,
+			LinkTTLDays:        sub.LinkTTLDays,
+			MinAliasLength:     sub.MinAliasLength,
+		})
+	}
+
+	return c.JSON(http.StatusOK, ListSubscriptionsResponse{
+		Subscriptions: resources,
+	})
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"urleater/internal/service"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+)
+
+// principalKey is where the principal is kept in the echo context
+const principalKey = "principal"
+
+// errAlreadyLoggedIn is returned by the endpoints meant for anonymous visitors only.
+var errAlreadyLoggedIn = &redirectError{
+	err:        &service.Error{Kind: service.ErrForbidden, Message: "already logged in"},
+	redirectTo: "/",
+}
+
+// RedirectResponse tells pages where to navigate to after the request.
+type RedirectResponse struct {
+	RedirectTo string `json:"redirectTo"`
+}
+
+// wantsPage tells browser navigations from the API requests pages make, which don't ask for text/html.
+func wantsPage(c echo.Context) bool {
+	method := c.Request().Method
+
+	return (method == http.MethodGet || method == http.MethodHead) &&
+		strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMETextHTML)
+}
+
+// loadPrincipal returns who the request is made by, it's loaded once per request.
+// Requests with an API token are made by its owner, the others by the user of the session.
+func (h *Handlers) loadPrincipal(c echo.Context) (*service.Principal, error) {
+	if principal, ok := c.Get(principalKey).(*service.Principal); ok {
+		return principal, nil
+	}
+
+	var (
+		principal *service.Principal
+		err       error
+	)
+
+	if secret, ok := bearerToken(c); ok {
+		principal, err = h.Service.AuthenticateApiToken(c.Request().Context(), secret)
+	} else {
+		principal, err = h.sessionPrincipal(c)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.Set(principalKey, principal)
+
+	return principal, nil
+}
+
+// sessionPrincipal is the user of the session, a session cookie that can't be decoded is expired
+// and its visitor is taken for an anonymous one.
+func (h *Handlers) sessionPrincipal(c echo.Context) (*service.Principal, error) {
+	email, err := h.Store.RetrieveEmailFromSession(c)
+
+	switch {
+	case badSession(err):
+		if err = h.expireSession(c); err != nil {
+			return nil, err
+		}
+
+		return &service.Principal{}, nil
+
+	case err != nil:
+		return nil, err
+	}
+
+	return h.Service.GetPrincipal(c.Request().Context(), email)
+}
+
+// badSession tells the errors of the session cookies that can't be decoded, like the ones signed
+// with a rotated secret or tampered with. Such a cookie is no session, it's not an error of the server.
+func badSession(err error) bool {
+	var cookieErr securecookie.Error
+
+	return errors.As(err, &cookieErr) && cookieErr.IsDecode()
+}
+
+// session is the session of the request, a bad session cookie gets a new session in its place.
+func (h *Handlers) session(c echo.Context) (*sessions.Session, error) {
+	session, err := h.Store.Get(c.Request(), "session_key")
+
+	if err != nil && !(badSession(err) && session != nil) {
+		return nil, fmt.Errorf("error getting session: %w", err)
+	}
+
+	return session, nil
+}
+
+// expireSession tells the browser to drop the session cookie.
+func (h *Handlers) expireSession(c echo.Context) error {
+	session, err := h.session(c)
+
+	if err != nil {
+		return err
+	}
+
+	session.Options.MaxAge = -1
+
+	if err = session.Save(c.Request(), c.Response()); err != nil {
+		return fmt.Errorf("error saving session: %w", err)
+	}
+
+	return nil
+}
+
+// bearerToken is the API token of the Authorization header, if there is one.
+func bearerToken(c echo.Context) (string, bool) {
+	scheme, secret, ok := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	return strings.TrimSpace(secret), true
+}
+
+// RequireAuth lets through logged-in users only and puts them into the context, see authEmail.
+// Browsers are sent to the login form, API requests get a 401 pointing there. API tokens are refused,
+// the endpoints they may call are guarded by RequireScope instead.
+func (h *Handlers) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return h.requireAuth(next, "")
+}
+
+// RequireScope is RequireAuth for the endpoints scripts may call, it lets through
+// the API tokens that were given the scope as well.
+func (h *Handlers) RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return h.requireAuth(next, scope)
+	}
+}
+
+func (h *Handlers) requireAuth(next echo.HandlerFunc, scope string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		principal, err := h.loadPrincipal(c)
+
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case principal.Anonymous() && wantsPage(c):
+			return c.Redirect(http.StatusTemporaryRedirect, errLoginRequired.redirectTo)
+
+		case principal.Anonymous():
+			return errLoginRequired
+
+		case principal.Disabled:
+			return &service.Error{Kind: service.ErrForbidden, Message: fmt.Sprintf("account %s is disabled", principal.Email)}
+
+		case principal.ViaApiToken() && scope == "":
+			return &service.Error{Kind: service.ErrForbidden, Message: "API tokens can't be used here"}
+
+		case !principal.Allows(scope):
+			return &service.Error{Kind: service.ErrForbidden, Message: fmt.Sprintf("API token has no %s scope", scope)}
+		}
+
+		return next(c)
+	}
+}
+
+// RequireAnonymous keeps logged-in users away from the login and registration, sending them to the main page.
+func (h *Handlers) RequireAnonymous(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		principal, err := h.loadPrincipal(c)
+
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case principal.Anonymous():
+			return next(c)
+
+		case wantsPage(c):
+			return c.Redirect(http.StatusTemporaryRedirect, errAlreadyLoggedIn.redirectTo)
+
+		default:
+			return errAlreadyLoggedIn
+		}
+	}
+}
+
+// RequirePermission lets through only the principals with the permission, it needs RequireAuth to run first.
+func (h *Handlers) RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, err := principalFrom(c)
+
+			if err != nil {
+				return err
+			}
+
+			if !principal.Can(permission) {
+				return &service.Error{Kind: service.ErrForbidden, Message: fmt.Sprintf("user %s is not allowed to do this", principal.Email)}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func principalFrom(c echo.Context) (*service.Principal, error) {
+	principal, ok := c.Get(principalKey).(*service.Principal)
+
+	if !ok {
+		return nil, errors.New("no principal in the context, RequireAuth must run first")
+	}
+
+	return principal, nil
+}
+
+// authEmail is the email of the user RequireAuth let through.
+func authEmail(c echo.Context) (string, error) {
+	principal, err := principalFrom(c)
+
+	if err != nil {
+		return "", err
+	}
+
+	if principal.Anonymous() {
+		return "", errors.New("anonymous principal in the context, RequireAuth must run first")
+	}
+
+	return principal.Email, nil
+}
@@ -1,5 +1,141 @@
 package handlers
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"urleater/internal/service"
 
-var ErrNoEmailFound = errors.New("email not found")
+	"github.com/labstack/echo/v4"
+)
+
+type ErrorBody struct {
+	// Code is stable and meant for programs, Message for people
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+	// RedirectTo is where pages should navigate to, e.g. the login form
+	RedirectTo string `json:"redirectTo,omitempty"`
+}
+
+type errorKind struct {
+	kind   error
+	status int
+	code   string
+}
+
+var errorKinds = []errorKind{
+	{service.ErrInvalidInput, http.StatusBadRequest, "invalid_input"},
+	{service.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{service.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{service.ErrNotFound, http.StatusNotFound, "not_found"},
+	{service.ErrAlreadyExists, http.StatusConflict, "already_exists"},
+	{service.ErrExpired, http.StatusGone, "expired"},
+	{service.ErrDisabled, http.StatusGone, "disabled"},
+	{service.ErrQuotaExceeded, http.StatusTooManyRequests, "quota_exceeded"},
+	{service.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+}
+
+// redirectError is an error after which the page should send the user elsewhere.
+type redirectError struct {
+	err        error
+	redirectTo string
+}
+
+func (e *redirectError) Error() string {
+	return e.err.Error()
+}
+
+func (e *redirectError) Unwrap() error {
+	return e.err
+}
+
+// errLoginRequired is returned by the endpoints that need a session.
+var errLoginRequired = &redirectError{
+	err:        &service.Error{Kind: service.ErrUnauthorized, Message: "login required"},
+	redirectTo: "/login",
+}
+
+// invalidInput reports a request that could not be bound or validated.
+func invalidInput(err error) error {
+	var httpErr *echo.HTTPError
+
+	if errors.As(err, &httpErr) {
+		return &service.Error{Kind: service.ErrInvalidInput, Message: fmt.Sprint(httpErr.Message)}
+	}
+
+	return &service.Error{Kind: service.ErrInvalidInput, Message: err.Error()}
+}
+
+// HTTPErrorHandler answers every failed request with an ErrorResponse. Errors of the service kinds
+// are shown to the client, all others are logged and hidden behind a generic 500.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, response := errorResponse(err)
+
+	if status == http.StatusInternalServerError {
+		log.Printf("%s %s: %v\n", c.Request().Method, c.Request().URL.Path, err)
+	}
+
+	var redirect *redirectError
+
+	if errors.As(err, &redirect) {
+		response.RedirectTo = redirect.redirectTo
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, response)
+	}
+
+	if err != nil {
+		log.Printf("could not write error response: %v\n", err)
+	}
+}
+
+func errorResponse(err error) (int, ErrorResponse) {
+	var serviceErr *service.Error
+
+	if errors.As(err, &serviceErr) {
+		for _, kind := range errorKinds {
+			if errors.Is(serviceErr, kind.kind) {
+				return kind.status, ErrorResponse{Error: ErrorBody{Code: kind.code, Message: serviceErr.Message}}
+			}
+		}
+	}
+
+	// raised by echo itself, e.g. for unknown routes or malformed bodies
+	var httpErr *echo.HTTPError
+
+	if errors.As(err, &httpErr) && httpErr.Code < http.StatusInternalServerError {
+		return httpErr.Code, ErrorResponse{Error: ErrorBody{
+			Code:    statusCode(httpErr.Code),
+			Message: fmt.Sprint(httpErr.Message),
+		}}
+	}
+
+	return http.StatusInternalServerError, ErrorResponse{Error: ErrorBody{
+		Code:    "internal",
+		Message: "internal server error",
+	}}
+}
+
+// statusCode derives a code from the status text, e.g. "method_not_allowed".
+func statusCode(status int) string {
+	for _, kind := range errorKinds {
+		if kind.status == status {
+			return kind.code
+		}
+	}
+
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -2,13 +2,16 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gorilla/sessions"
-	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	_ "urleater/docs"
 	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
 
 	"github.com/antonlindstrom/pgstore"
 	"github.com/labstack/echo/v4"
@@ -24,6 +27,35 @@ type Service interface {
 	GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error)
 	GetUser(ctx context.Context, email string) (*postgresDB.User, error)
 	DeleteShortLink(ctx context.Context, shortLink string, email string) error
+	ExtendShortLink(ctx context.Context, shortLink string, email string, days int) (*postgresDB.Link, error)
+	UpdateShortLink(ctx context.Context, shortLink string, longLink string, email string) (*postgresDB.Link, error)
+	GetLinkVersions(ctx context.Context, shortLink string, email string) ([]postgresDB.LinkVersion, error)
+	RollbackShortLink(ctx context.Context, shortLink string, versionId int, email string) (*postgresDB.Link, error)
+	RecordClick(ctx context.Context, shortLink string, referrer string, userAgent string, ip string, country string)
+	GetLinkStats(ctx context.Context, shortLink string, email string, from time.Time, to time.Time, interval string) (*postgresDB.LinkStats, error)
+	CreateOrder(ctx context.Context, email string, subscriptionId int) (*postgresDB.Order, string, error)
+	GetOrder(ctx context.Context, orderId string, email string) (*postgresDB.Order, error)
+	HandlePaymentCallback(ctx context.Context, payload []byte, signature string) error
+	GetCurrentSubscription(ctx context.Context, email string) (*postgresDB.UserSubscription, *postgresDB.Subscription, error)
+	GetPrincipal(ctx context.Context, email string) (*service.Principal, error)
+	SearchUsers(ctx context.Context, query string, offset int, limit int) ([]postgresDB.UserSummary, error)
+	SetUserDisabled(ctx context.Context, email string, disabled bool, changedBy string) error
+	SetUserRole(ctx context.Context, email string, role string, changedBy string) error
+	SearchLinks(ctx context.Context, query string, offset int, limit int) ([]postgresDB.Link, error)
+	SetLinkDisabled(ctx context.Context, shortLink string, disabled bool) (*postgresDB.Link, error)
+	GetSystemStats(ctx context.Context) (*postgresDB.SystemStats, error)
+	CreateApiToken(ctx context.Context, email string, name string, scopes []string, ttlDays int) (string, *postgresDB.ApiToken, error)
+	GetApiTokens(ctx context.Context, email string) ([]postgresDB.ApiToken, error)
+	RevokeApiToken(ctx context.Context, email string, id int) error
+	AuthenticateApiToken(ctx context.Context, secret string) (*service.Principal, error)
+	GetUserShortLink(ctx context.Context, shortLink string, email string) (*postgresDB.Link, error)
+	CreateShortLinkWithOptions(ctx context.Context, alias string, longLink string, userEmail string, options service.LinkOptions) (*postgresDB.Link, error)
+	UpdateLink(ctx context.Context, shortLink string, email string, update service.LinkUpdate) (*postgresDB.Link, error)
+	SetLinkPassword(ctx context.Context, shortLink string, email string, password string) (*postgresDB.Link, error)
+	UnlockShortLink(ctx context.Context, shortLink string, password string, client string) (*postgresDB.Link, string, error)
+	LinkPasswordVersion(ctx context.Context, shortLink string) (string, error)
+	SetLinkClicks(ctx context.Context, shortLink string, email string, clicks int) (*postgresDB.Link, error)
+	ConsumeLinkClick(ctx context.Context, link *postgresDB.Link) (*postgresDB.Link, error)
 }
 
 type SessionStore interface {
@@ -33,11 +65,40 @@ type SessionStore interface {
 }
 
 // TODO populate
-var domain string = "http://localhost:8080"
-
 type Handlers struct {
 	Service Service
 	Store   SessionStore
+	// BaseURL is the public address of the service, pages build their requests and short links from it
+	BaseURL string
+	// Unlocks lets the visitors who entered the password of a protected link through
+	Unlocks *LinkUnlocks
+}
+
+// pageData is passed to every page template.
+type pageData struct {
+	Domain string
+}
+
+func (h *Handlers) page() pageData {
+	return pageData{Domain: h.BaseURL}
+}
+
+// linkPageData is passed to the pages shown instead of a redirect.
+type linkPageData struct {
+	pageData
+	ShortLink string
+	ExpiresAt time.Time
+	// CanRenew is set for the owner of an expired link
+	CanRenew bool
+	// UsedUp is set for the click-limited links that have no clicks left, renewing doesn't bring them back
+	UsedUp bool
+}
+
+// prefersJSON tells API clients from browsers, which always ask for text/html.
+func prefersJSON(c echo.Context) bool {
+	accept := c.Request().Header.Get(echo.HeaderAccept)
+
+	return strings.Contains(accept, echo.MIMEApplicationJSON) && !strings.Contains(accept, echo.MIMETextHTML)
 }
 
 type PostgresSessionStore struct {
@@ -80,8 +141,35 @@ func (db *PostgresSessionStore) Save(c echo.Context, email string, session *sess
 	return nil
 }
 
-type redirectResponse struct {
-	redirectTo string
+// CookieSessionStore keeps the session in a signed cookie, it's used when there is no Postgres to put sessions into.
+type CookieSessionStore struct {
+	store *sessions.CookieStore
+}
+
+func NewCookieSessionStore(secret []byte) SessionStore {
+	return &CookieSessionStore{sessions.NewCookieStore(secret)}
+}
+
+func (cs *CookieSessionStore) RetrieveEmailFromSession(c echo.Context) (string, error) {
+	session, err := cs.store.Get(c.Request(), "session_key")
+
+	if err != nil {
+		return "", fmt.Errorf("error getting session: %w", err)
+	}
+
+	email, _ := session.Values["email"].(string)
+
+	return email, nil
+}
+
+func (cs *CookieSessionStore) Get(r *http.Request, key string) (*sessions.Session, error) {
+	return cs.store.Get(r, key)
+}
+
+func (cs *CookieSessionStore) Save(c echo.Context, email string, session *sessions.Session) error {
+	session.Values["email"] = email
+
+	return session.Save(c.Request(), c.Response())
 }
 
 // GetMainPage godoc
@@ -93,17 +181,7 @@ type redirectResponse struct {
 // @Failure 307 {} nil
 // @Router /	[get]
 func (h *Handlers) GetMainPage(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email == "" {
-		return c.Redirect(http.StatusTemporaryRedirect, "/login")
-	}
-
-	return c.Render(http.StatusOK, "main_page.html", nil)
+	return c.Render(http.StatusOK, "main_page.html", h.page())
 }
 
 type LoginRequest struct {
@@ -117,80 +195,58 @@ type LoginRequest struct {
 //	@Accept			json
 //	@Param			username	body		string	true	"Username"
 //	@Param			password	body		string	true	"Password"
-//	@Success		200			{object}	redirectResponse
-//	@Failure		400			{} nil
-//	@Failure		500			{} nil
+//	@Success		200			{object}	RedirectResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/login      [post]
 func (h *Handlers) PostLogin(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email != "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{
-			"redirectTo": "/",
-		})
-	}
-
 	ctx := c.Request().Context()
 
 	requestData := new(LoginRequest)
 
 	if err := c.Bind(&requestData); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return invalidInput(err)
 	}
 
 	if c.Echo().Validator != nil {
 		if err := c.Validate(requestData); err != nil {
-			return c.JSON(http.StatusBadRequest, err.Error())
+			return invalidInput(err)
 		}
 	}
 
-	err = h.Service.LoginUser(ctx, requestData.Email, requestData.Password)
+	err := h.Service.LoginUser(ctx, requestData.Email, requestData.Password)
 
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, err)
+		return err
 	}
 
-	session, err := h.Store.Get(c.Request(), "session_key")
+	session, err := h.session(c)
 
 	if err != nil {
-		log.Printf("Error getting session: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	if err = h.Store.Save(c, requestData.Email, session); err != nil {
-		log.Printf("Error saving session: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return fmt.Errorf("error saving session: %w", err)
 
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"redirectTo": "/",
+	return c.JSON(http.StatusOK, RedirectResponse{
+		RedirectTo: "/",
 	})
 }
 
 // GetLogout godoc
 //
-//	@Summary		Logs out a user
-//	@Success		307			{object}	redirectResponse
-//	@Failure		500			{} nil
+//	@Summary		Logs out a user and sends them to the login form
+//	@Success		307
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/logout      [get]
 func (h *Handlers) GetLogout(c echo.Context) error {
-	session, err := h.Store.Get(c.Request(), "session_key")
-
-	if err != nil {
-		log.Printf("Error getting session: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
-	}
-
-	session.Options.MaxAge = -1
-	if err = session.Save(c.Request(), c.Response()); err != nil {
-		log.Printf("Error saving session: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	if err := h.expireSession(c); err != nil {
+		return err
 	}
 
 	return c.Redirect(http.StatusTemporaryRedirect, "/login")
@@ -207,65 +263,55 @@ type RegisterRequest struct {
 //	@Accept			json
 //	@Param			username	body		string	true	"Username"
 //	@Param			password	body		string	true	"Password"
-//	@Success		200			{object}	redirectResponse
-//	@Failure		400			{} nil
-//	@Failure		500			{} nil
+//	@Success		200			{object}	RedirectResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		409			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/register      [post]
 func (h *Handlers) PostRegister(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email != "" {
-		return c.JSON(http.StatusOK, echo.Map{
-			"redirectTo": "/",
-		})
-	}
-
 	ctx := c.Request().Context()
 
 	requestData := new(RegisterRequest)
 
 	if err := c.Bind(&requestData); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return invalidInput(err)
 	}
 
 	if c.Echo().Validator != nil {
 		if err := c.Validate(requestData); err != nil {
-			return c.JSON(http.StatusBadRequest, err.Error())
+			return invalidInput(err)
 		}
 	}
 
-	err = h.Service.RegisterUser(ctx, requestData.Email, requestData.Password)
+	err := h.Service.RegisterUser(ctx, requestData.Email, requestData.Password)
 
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, err)
+		return err
 	}
 
-	session, err := h.Store.Get(c.Request(), "session_key")
+	session, err := h.session(c)
 
 	if err != nil {
-		log.Printf("Error getting session: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	if err = h.Store.Save(c, requestData.Email, session); err != nil {
-		log.Printf("Error saving session: %v\n", err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return fmt.Errorf("error saving session: %w", err)
 
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"redirectTo": "/",
+	return c.JSON(http.StatusOK, RedirectResponse{
+		RedirectTo: "/",
 	})
 }
 
 type CreateShortLinkRequest struct {
 	ShortURL string `json:"short_url"`
 	LongURL  string `json:"long_url" validate:"required"`
+	// Password protects the link, visitors have to enter it before they are redirected
+	Password string `json:"password"`
+	// MaxClicks is how many times the link redirects before it's used up, 0 is for no limit
+	MaxClicks int `json:"max_clicks"`
 }
 
 type CreateShortLinkResponse struct {
@@ -274,25 +320,24 @@ type CreateShortLinkResponse struct {
 
 // CreateShortLink godoc
 //
-//	@Summary		Creates a link
+//	@Summary		Creates a link, use POST /api/v1/links instead
+//	@Deprecated
 //	@Accept			json
 //	@Param			short_url	body		string	true	"Short URL"
 //	@Param			long_url	body		string	true	"Long URL"
+//	@Param			password	body		string	false	"Password visitors have to enter"
+//	@Param			max_clicks	body		int		false	"Number of clicks the link works for"
 //	@Success		200			{object}	CreateShortLinkResponse
-//	@Failure		400			{} nil
-//	@Failure		500			{} nil
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		409			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/create_link      [post]
 func (h *Handlers) CreateShortLink(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
+	email, err := authEmail(c)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{
-			"redirectTo": "/login",
-		})
+		return err
 	}
 
 	ctx := c.Request().Context()
@@ -300,19 +345,22 @@ func (h *Handlers) CreateShortLink(c echo.Context) error {
 	requestData := new(CreateShortLinkRequest)
 
 	if err := c.Bind(&requestData); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return invalidInput(err)
 	}
 
 	if c.Echo().Validator != nil {
 		if err := c.Validate(requestData); err != nil {
-			return c.JSON(http.StatusBadRequest, err.Error())
+			return invalidInput(err)
 		}
 	}
 
-	link, err := h.Service.CreateShortLink(ctx, requestData.ShortURL, requestData.LongURL, email)
+	link, err := h.Service.CreateShortLinkWithOptions(ctx, requestData.ShortURL, requestData.LongURL, email, service.LinkOptions{
+		Password:  requestData.Password,
+		MaxClicks: requestData.MaxClicks,
+	})
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, CreateShortLinkResponse{
@@ -327,25 +375,22 @@ type GetUserShortLinksResponse struct {
 
 // GetUserShortLinks godoc
 //
-//	@Summary		Gets user's short links
+//	@Summary		Gets user's short links, use GET /api/v1/links instead
+//	@Deprecated
 //	@Accept			json
 //	@Param			limit	query		int	true	"Limit of a number of user's short links"
 //	@Param			offset	query		int	true	"Maximum amount of links to show"
 //	@Success		200			{object}	GetUserShortLinksResponse
-//	@Failure		400			{} nil
-//	@Failure		500			{} nil
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/get_links      [get]
 func (h *Handlers) GetUserShortLinks(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
+	email, err := authEmail(c)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{
-			"redirectTo": "/login",
-		})
+		return err
 	}
 
 	limitParam, offsetParam := c.QueryParam("limit"), c.QueryParam("offset")
@@ -353,13 +398,13 @@ func (h *Handlers) GetUserShortLinks(c echo.Context) error {
 	limit, err := strconv.Atoi(limitParam)
 
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return invalidInput(err)
 	}
 
 	offset, err := strconv.Atoi(offsetParam)
 
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return invalidInput(err)
 	}
 
 	ctx := c.Request().Context()
@@ -367,7 +412,7 @@ func (h *Handlers) GetUserShortLinks(c echo.Context) error {
 	links, user, err := h.Service.GetUserShortLinksWithOffsetAndLimit(ctx, email, offset, limit)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, GetUserShortLinksResponse{
@@ -385,17 +430,7 @@ func (h *Handlers) GetUserShortLinks(c echo.Context) error {
 // @Failure 307
 // @Router /login	[get]
 func (h *Handlers) GetLoginPage(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email != "" {
-		return c.Redirect(http.StatusTemporaryRedirect, "/")
-	}
-
-	return c.Render(http.StatusOK, "login_page.html", nil)
+	return c.Render(http.StatusOK, "login_page.html", h.page())
 }
 
 // GetRegisterPage godoc
@@ -407,17 +442,7 @@ func (h *Handlers) GetLoginPage(c echo.Context) error {
 // @Failure 307
 // @Router /register	[get]
 func (h *Handlers) GetRegisterPage(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email != "" {
-		return c.Redirect(http.StatusTemporaryRedirect, "/")
-	}
-
-	return c.Render(http.StatusOK, "register_page.html", nil)
+	return c.Render(http.StatusOK, "register_page.html", h.page())
 }
 
 type UpdateUserShortLinksRequest struct {
@@ -429,35 +454,38 @@ type UpdateUserShortLinksResponse struct {
 	User postgresDB.User `json:"user"`
 }
 
+// UpdateUserShortLinks godoc
+//
+//	@Summary		Changes how many short links a user has left, requires the users.manage_quota permission
+//	@Accept			json
+//	@Param			email		body		string	true	"User to change"
+//	@Param			delta_links	body		int		true	"Links to add, negative to take them away"
+//	@Success		200			{object}	UpdateUserShortLinksResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/admin/users/quota      [post]
 func (h *Handlers) UpdateUserShortLinks(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email != "admin@admin.com" {
-		return c.JSON(http.StatusInternalServerError, fmt.Errorf("user %s is not authorized to change links number", email))
-	}
-
 	ctx := c.Request().Context()
 
 	requestData := new(UpdateUserShortLinksRequest)
 
 	if err := c.Bind(&requestData); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return invalidInput(err)
 	}
 
 	if c.Echo().Validator != nil {
 		if err := c.Validate(requestData); err != nil {
-			return c.JSON(http.StatusBadRequest, err.Error())
+			return invalidInput(err)
 		}
 	}
 
 	user, err := h.Service.UpdateUserShortLinks(ctx, requestData.Email, requestData.DeltaLinks)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, UpdateUserShortLinksResponse{
@@ -475,25 +503,17 @@ func (h *Handlers) UpdateUserShortLinks(c echo.Context) error {
 // @Failure 307
 // @Router /create_link	[get]
 func (h *Handlers) GetCreateShortLink(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email == "" {
-		return c.Redirect(http.StatusTemporaryRedirect, "/login")
-	}
-
-	return c.Render(http.StatusOK, "create_link_page.html", nil)
+	return c.Render(http.StatusOK, "create_link_page.html", h.page())
 }
 
 // GetShortLink godoc
 //
-//	@Summary		Gets short link
+//	@Summary		Redirects to the long link, browsers get an HTML page for missing, expired and used up links
 //	@Param			ShortLink	path		string	true	"Short link to get"
-//	@Success		307			{object}	DeleteShortLinkRequest
-//	@Failure		400			{} nil
+//	@Success		302			{object}	DeleteShortLinkRequest
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		410			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/      [get]
 func (h *Handlers) GetShortLink(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -503,10 +523,76 @@ func (h *Handlers) GetShortLink(c echo.Context) error {
 	link, err := h.Service.GetShortLink(ctx, shortLink)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return h.linkError(c, shortLink, link, err)
 	}
 
-	return c.Redirect(http.StatusMovedPermanently, link.LongUrl)
+	if link.Protected {
+		version, err := h.Service.LinkPasswordVersion(ctx, link.ShortUrl)
+
+		if err != nil {
+			return h.linkError(c, shortLink, link, err)
+		}
+
+		switch {
+		case h.Unlocks.Unlocked(c, link.ShortUrl, version):
+
+		case prefersJSON(c):
+			return errLinkLocked(link.ShortUrl)
+
+		default:
+			return c.Render(http.StatusOK, "link_password.html", linkPasswordPageData{
+				pageData:  h.page(),
+				ShortLink: link.ShortUrl,
+			})
+		}
+	}
+
+	// the click is spent only now, visitors who are asked for the password don't use one up
+	if link, err = h.Service.ConsumeLinkClick(ctx, link); err != nil {
+		return h.linkError(c, shortLink, link, err)
+	}
+
+	h.Service.RecordClick(ctx, link.ShortUrl, c.Request().Referer(), c.Request().UserAgent(), c.RealIP(), clientCountry(c))
+
+	// 302 instead of 301: browsers cache permanent redirects, and cached clicks would never reach us
+	return c.Redirect(http.StatusFound, link.LongUrl)
+}
+
+// linkError shows browsers the page explaining why the short link doesn't redirect, API clients get the error.
+func (h *Handlers) linkError(c echo.Context, shortLink string, link *postgresDB.Link, err error) error {
+	switch {
+	case prefersJSON(c):
+		return err
+
+	case errors.Is(err, service.ErrNotFound):
+		return c.Render(http.StatusNotFound, "link_not_found.html", linkPageData{
+			pageData:  h.page(),
+			ShortLink: shortLink,
+		})
+
+	case errors.Is(err, service.ErrDisabled):
+		return c.Render(http.StatusGone, "link_disabled.html", linkPageData{
+			pageData:  h.page(),
+			ShortLink: shortLink,
+		})
+
+	case errors.Is(err, service.ErrExpired):
+		// a broken session only hides the renew button
+		email, _ := h.Store.RetrieveEmailFromSession(c)
+
+		usedUp := link.ClicksLeft != nil && *link.ClicksLeft <= 0
+
+		return c.Render(http.StatusGone, "link_expired.html", linkPageData{
+			pageData:  h.page(),
+			ShortLink: link.ShortUrl,
+			ExpiresAt: link.ExpiresAt,
+			CanRenew:  !usedUp && email != "" && email == link.UserEmail,
+			UsedUp:    usedUp,
+		})
+
+	default:
+		return err
+	}
 }
 
 type GetSubscriptionsResponse struct {
@@ -515,34 +601,57 @@ type GetSubscriptionsResponse struct {
 
 // GetSubscriptions godoc
 //
-//	@Summary		Gets all subscriptions
+//	@Summary		Gets all subscriptions, use GET /api/v1/subscriptions instead
+//	@Deprecated
 //	@Success		200			{object}	GetSubscriptionsResponse
-//	@Failure		400			{} nil
-//	@Failure		500			{} nil
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/get_subscriptions      [get]
 func (h *Handlers) GetSubscriptions(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
+	ctx := c.Request().Context()
+
+	subscriptions, err := h.Service.GetSubscriptions(ctx)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
+		return err
 	}
 
-	if email == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{
-			"redirectTo": "/login",
-		})
+	return c.JSON(http.StatusOK, GetSubscriptionsResponse{
+		Subscriptions: subscriptions,
+	})
+}
+
+type GetCurrentSubscriptionResponse struct {
+	// Subscription and Plan are null on the free tier
+	Subscription *postgresDB.UserSubscription `json:"subscription"`
+	Plan         *postgresDB.Subscription     `json:"plan"`
+}
+
+// GetCurrentSubscription godoc
+//
+//	@Summary		Gets user's current subscription and its plan
+//	@Success		200			{object}	GetCurrentSubscriptionResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/get_current_subscription      [get]
+func (h *Handlers) GetCurrentSubscription(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
 	}
 
 	ctx := c.Request().Context()
 
-	subscriptions, err := h.Service.GetSubscriptions(ctx)
+	subscription, plan, err := h.Service.GetCurrentSubscription(ctx, email)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
-		"subscriptions": subscriptions,
+	return c.JSON(http.StatusOK, GetCurrentSubscriptionResponse{
+		Subscription: subscription,
+		Plan:         plan,
 	})
 }
 
@@ -555,18 +664,20 @@ func (h *Handlers) GetSubscriptions(c echo.Context) error {
 // @Failure 307
 // @Router /subscriptions	[get]
 func (h *Handlers) GetSubscriptionsPage(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email == "" {
-		return c.Redirect(http.StatusTemporaryRedirect, "/login")
-	}
+	return c.Render(http.StatusOK, "subscriptions.html", h.page())
 
-	return c.Render(http.StatusOK, "subscriptions.html", nil)
+}
 
+// GetLinksPage godoc
+//
+// @Summary Gets the page listing user's short links
+// @Produce	html
+// @Success 200
+// @Failure 500
+// @Failure 307
+// @Router /links	[get]
+func (h *Handlers) GetLinksPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "links_list.html", h.page())
 }
 
 type GetUserResponse struct {
@@ -575,22 +686,18 @@ type GetUserResponse struct {
 
 // GetUser godoc
 //
-//	@Summary		Gets user from session
+//	@Summary		Gets user from session, use GET /api/v1/me instead
+//	@Deprecated
 //	@Success		200			{object}	GetUserResponse
-//	@Failure		400			{} nil
-//	@Failure		500			{} nil
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/user      [get]
 func (h *Handlers) GetUser(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
+	email, err := authEmail(c)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{
-			"redirectTo": "/login",
-		})
+		return err
 	}
 
 	ctx := c.Request().Context()
@@ -598,7 +705,7 @@ func (h *Handlers) GetUser(c echo.Context) error {
 	user, err := h.Service.GetUser(ctx, email)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, GetUserResponse{
@@ -611,25 +718,27 @@ type DeleteShortLinkRequest struct {
 	ShortLink string `json:"short_link"`
 }
 
+type DeleteShortLinkResponse struct {
+	ShortLink string `json:"short_link"`
+}
+
 // DeleteShortLink godoc
 //
-//	@Summary		Tries to delete the short link
+//	@Summary		Tries to delete the short link, use DELETE /api/v1/links/{code} instead
+//	@Deprecated
 //	@Param			ShortLink	body		string	true	"Short link to delete"
-//	@Success		200			{object}	DeleteShortLinkRequest
-//	@Failure		400			{} nil
-//	@Failure		500			{} nil
+//	@Success		200			{object}	DeleteShortLinkResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
 //	@Router			/delete_link      [delete]
 func (h *Handlers) DeleteShortLink(c echo.Context) error {
-	email, err := h.Store.RetrieveEmailFromSession(c)
+	email, err := authEmail(c)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
-	}
-
-	if email == "" {
-		return c.JSON(http.StatusBadRequest, redirectResponse{
-			redirectTo: "/login",
-		})
+		return err
 	}
 
 	ctx := c.Request().Context()
@@ -637,20 +746,78 @@ func (h *Handlers) DeleteShortLink(c echo.Context) error {
 	requestData := new(DeleteShortLinkRequest)
 
 	if err := c.Bind(&requestData); err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return invalidInput(err)
 	}
 
 	if c.Echo().Validator != nil {
 		if err := c.Validate(requestData); err != nil {
-			return c.JSON(http.StatusBadRequest, err.Error())
+			return invalidInput(err)
 		}
 	}
 
 	err = h.Service.DeleteShortLink(ctx, requestData.ShortLink, email)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, nil)
+	return c.JSON(http.StatusOK, DeleteShortLinkResponse{
+		ShortLink: requestData.ShortLink,
+	})
+}
+
+type ExtendShortLinkRequest struct {
+	ShortLink string `json:"short_link" validate:"required"`
+	// Days is how long the link should live from now on, 0 means the default link TTL, cut to the plan maximum
+	Days int `json:"days" validate:"gte=0"`
+}
+
+type ExtendShortLinkResponse struct {
+	Link postgresDB.Link `json:"link"`
+}
+
+// ExtendShortLink godoc
+//
+//	@Summary		Renews user's short link, expired ones included, use PATCH /api/v1/links/{code} instead
+//	@Deprecated
+//	@Accept			json
+//	@Param			short_link	body		string	true	"Short link to renew"
+//	@Param			days		body		int		false	"Days the link should live from now on, limited by the subscription"
+//	@Success		200			{object}	ExtendShortLinkResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/extend_link      [post]
+func (h *Handlers) ExtendShortLink(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	requestData := new(ExtendShortLinkRequest)
+
+	if err := c.Bind(&requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	link, err := h.Service.ExtendShortLink(ctx, requestData.ShortLink, email, requestData.Days)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ExtendShortLinkResponse{
+		Link: *link,
+	})
 }
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"urleater/internal/service"
+
+	"github.com/gorilla/securecookie"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// unlockCookiePrefix is followed by the short link in the name of its cookie
+	unlockCookiePrefix = "unlocked_"
+	// unlockTTL is how long a visitor who entered the password of a link isn't asked for it again
+	unlockTTL = time.Hour
+)
+
+// LinkUnlocks remembers the protected links a visitor entered the password of, in a signed cookie per link.
+// The cookie holds the version of the password, so that it stops working once the password changes
+// or the link is deleted and created again.
+type LinkUnlocks struct {
+	codec *securecookie.SecureCookie
+}
+
+func NewLinkUnlocks(secret []byte) *LinkUnlocks {
+	codec := securecookie.New(secret, nil)
+	codec.MaxAge(int(unlockTTL.Seconds()))
+
+	return &LinkUnlocks{codec: codec}
+}
+
+// unlockValue is what the cookie of the short link holds.
+func unlockValue(shortLink string, version string) string {
+	return shortLink + " " + version
+}
+
+// Unlock sets the cookie for the version of the password, the cookie is sent back for the short link only.
+func (u *LinkUnlocks) Unlock(c echo.Context, shortLink string, version string) error {
+	name := unlockCookiePrefix + shortLink
+
+	value, err := u.codec.Encode(name, unlockValue(shortLink, version))
+
+	if err != nil {
+		return fmt.Errorf("could not sign the cookie of short link %s: %w", shortLink, err)
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/" + shortLink,
+		MaxAge:   int(unlockTTL.Seconds()),
+		Secure:   c.Scheme() == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// Unlocked tells whether the request carries a valid cookie of the short link set for the current version of its password.
+func (u *LinkUnlocks) Unlocked(c echo.Context, shortLink string, version string) bool {
+	name := unlockCookiePrefix + shortLink
+
+	cookie, err := c.Cookie(name)
+
+	if err != nil {
+		return false
+	}
+
+	var unlocked string
+
+	// the codec checks the signature and the age of the cookie
+	if err := u.codec.Decode(name, cookie.Value, &unlocked); err != nil {
+		return false
+	}
+
+	return unlocked == unlockValue(shortLink, version)
+}
+
+// linkPasswordPageData is passed to the page asking for the password of a protected link.
+type linkPasswordPageData struct {
+	pageData
+	ShortLink string
+	// Error tells why the entered password didn't work
+	Error string
+}
+
+// errLinkLocked is returned to the API clients following a protected link.
+func errLinkLocked(shortLink string) error {
+	return &service.Error{Kind: service.ErrUnauthorized, Message: fmt.Sprintf("short link %s is password protected", shortLink)}
+}
+
+// UnlockShortLink godoc
+//
+//	@Summary		Checks the password of a protected short link and lets the visitor through
+//	@Accept			x-www-form-urlencoded
+//	@Param			short_link	path		string	true	"Short link"
+//	@Param			password	formData	string	true	"Password of the link"
+//	@Success		303
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		410			{object}	ErrorResponse
+//	@Failure		429			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/{short_link}	[post]
+func (h *Handlers) UnlockShortLink(c echo.Context) error {
+	shortLink := c.Param("short_link")
+
+	link, version, err := h.Service.UnlockShortLink(c.Request().Context(), shortLink, c.FormValue("password"), c.RealIP())
+
+	switch {
+	case err == nil:
+
+	case prefersJSON(c):
+		return err
+
+	case errors.Is(err, service.ErrUnauthorized), errors.Is(err, service.ErrRateLimited):
+		status, _ := errorResponse(err)
+
+		return c.Render(status, "link_password.html", linkPasswordPageData{
+			pageData:  h.page(),
+			ShortLink: shortLink,
+			Error:     err.Error(),
+		})
+
+	default:
+		return err
+	}
+
+	if err := h.Unlocks.Unlock(c, link.ShortUrl, version); err != nil {
+		return err
+	}
+
+	// the redirect goes through GetShortLink again, so that the click is counted like any other
+	return c.Redirect(http.StatusSeeOther, "/"+link.ShortUrl)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// hiddenMetrics are published by the expvar package itself and would leak process internals.
+var hiddenMetrics = map[string]bool{
+	"cmdline":  true,
+	"memstats": true,
+}
+
+// GetMetrics godoc
+//
+//	@Summary		Gets internal counters of the application, requires the system.metrics permission
+//	@Produce		json
+//	@Success		200
+//	@Failure		401
+//	@Failure		403
+//	@Router			/metrics      [get]
+func GetMetrics(c echo.Context) error {
+	metrics := echo.Map{}
+
+	expvar.Do(func(kv expvar.KeyValue) {
+		if hiddenMetrics[kv.Key] {
+			return
+		}
+
+		metrics[kv.Key] = json.RawMessage(kv.Value.String())
+	})
+
+	return c.JSON(http.StatusOK, metrics)
+}
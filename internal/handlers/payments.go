@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"urleater/internal/repository/postgresDB"
+)
+
+// signatureHeader carries the provider's signature of the callback body
+const signatureHeader = "X-Signature"
+
+type BuySubscriptionRequest struct {
+	SubscriptionId int `json:"subscription_id" validate:"required"`
+}
+
+type BuySubscriptionResponse struct {
+	Order postgresDB.Order `json:"order"`
+	// PaymentURL is the provider's checkout page the user is sent to,
+	// empty when the unused part of the current subscription paid for the order
+	PaymentURL string `json:"payment_url"`
+}
+
+// BuySubscription godoc
+//
+//	@Summary		Creates an order for a subscription, the links are credited once it's paid
+//	@Accept			json
+//	@Param			subscription_id	body		int	true	"Subscription to buy"
+//	@Success		200				{object}	BuySubscriptionResponse
+//	@Failure		400				{object}	ErrorResponse
+//	@Failure		401				{object}	ErrorResponse
+//	@Failure		403				{object}	ErrorResponse
+//	@Failure		404				{object}	ErrorResponse
+//	@Failure		500				{object}	ErrorResponse
+//	@Router			/buy      [post]
+func (h *Handlers) BuySubscription(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	requestData := new(BuySubscriptionRequest)
+
+	if err := c.Bind(&requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	order, paymentURL, err := h.Service.CreateOrder(ctx, email, requestData.SubscriptionId)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, BuySubscriptionResponse{
+		Order:      *order,
+		PaymentURL: paymentURL,
+	})
+}
+
+type GetOrderResponse struct {
+	Order postgresDB.Order `json:"order"`
+}
+
+// GetOrder godoc
+//
+//	@Summary		Gets user's order
+//	@Param			order_id	query		string	true	"Order id"
+//	@Success		200			{object}	GetOrderResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/get_order      [get]
+func (h *Handlers) GetOrder(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	orderId := c.QueryParam("order_id")
+
+	if orderId == "" {
+		return invalidInput(errors.New("order_id is required"))
+	}
+
+	order, err := h.Service.GetOrder(ctx, orderId, email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, GetOrderResponse{
+		Order: *order,
+	})
+}
+
+// PaymentCallback godoc
+//
+//	@Summary		Receives payment notifications from the payment provider
+//	@Accept			json
+//	@Param			X-Signature	header		string	true	"Signature of the body"
+//	@Success		204
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/payments/callback      [post]
+func (h *Handlers) PaymentCallback(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	// the signature covers the exact bytes, so the body is not bound
+	payload, err := io.ReadAll(c.Request().Body)
+
+	if err != nil {
+		return invalidInput(err)
+	}
+
+	if err := h.Service.HandlePaymentCallback(ctx, payload, c.Request().Header.Get(signatureHeader)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"html/template"
 	"io"
+	"net"
 	_ "urleater/docs"
+	"urleater/internal/service"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -20,56 +22,166 @@ type ServerInterface interface {
 	GetRegisterPage(c echo.Context) error
 	GetLoginPage(c echo.Context) error
 	GetUserShortLinks(c echo.Context) error
+	GetLinksPage(c echo.Context) error
 	GetCreateShortLink(c echo.Context) error
 	GetShortLink(c echo.Context) error
+	UnlockShortLink(c echo.Context) error
 	GetSubscriptions(c echo.Context) error
 	GetSubscriptionsPage(c echo.Context) error
+	GetCurrentSubscription(c echo.Context) error
 	GetUser(c echo.Context) error
 	DeleteShortLink(c echo.Context) error
+	ExtendShortLink(c echo.Context) error
+	UpdateShortLink(c echo.Context) error
+	GetLinkVersions(c echo.Context) error
+	RollbackShortLink(c echo.Context) error
+	GetLinkStats(c echo.Context) error
+	BuySubscription(c echo.Context) error
+	GetOrder(c echo.Context) error
+	PaymentCallback(c echo.Context) error
+	GetAdminPage(c echo.Context) error
+	SearchUsers(c echo.Context) error
+	SetUserDisabled(c echo.Context) error
+	SetUserRole(c echo.Context) error
+	SearchLinks(c echo.Context) error
+	SetLinkDisabled(c echo.Context) error
+	GetSystemStats(c echo.Context) error
+	RequireAuth(next echo.HandlerFunc) echo.HandlerFunc
+	RequireAnonymous(next echo.HandlerFunc) echo.HandlerFunc
+	RequireScope(scope string) echo.MiddlewareFunc
+	GetApiTokensPage(c echo.Context) error
+	GetApiTokens(c echo.Context) error
+	CreateApiToken(c echo.Context) error
+	RevokeApiToken(c echo.Context) error
+	CreateLinkV1(c echo.Context) error
+	ListLinksV1(c echo.Context) error
+	GetLinkV1(c echo.Context) error
+	UpdateLinkV1(c echo.Context) error
+	DeleteLinkV1(c echo.Context) error
+	GetMeV1(c echo.Context) error
+	ListSubscriptionsV1(c echo.Context) error
+	RequirePermission(permission string) echo.MiddlewareFunc
 }
 
 type Template struct {
 	templates *template.Template
 }
 
+// NewTemplate parses the pages matching pattern and panics if they are broken.
+func NewTemplate(pattern string) *Template {
+	return &Template{
+		templates: template.Must(template.ParseGlob(pattern)),
+	}
+}
+
 func (t *Template) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
 	return t.templates.ExecuteTemplate(w, name, data)
 }
 
+// IPExtractor tells the client address from X-Forwarded-For when the request came through
+// one of the trusted proxies, and from the connection otherwise, so that clients can't make theirs up.
+func IPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+
+	for _, ipNet := range trustedProxies {
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
 // @title			URLEater Swagger API
 // @version		1.0
 // @description	Это описание API для работы с сайтом по сокращению ссылок
 // @host			localhost:8080
 // @BasePath		/
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				An API token made on the tokens page, sent as "Bearer <token>"
 func GetRoutes(si ServerInterface) *echo.Echo {
 	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	// no proxy is trusted until the server says so
+	e.IPExtractor = IPExtractor(nil)
 
 	e.Use(middleware.CORS())
 
 	e.Use(middleware.Static("/static"))
 
-	t := &Template{
-		templates: template.Must(template.ParseGlob("./templates/*.html")),
-	}
-
-	e.Renderer = t
+	e.Renderer = NewTemplate("./templates/*.html")
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
-	e.GET("/", si.GetMainPage)
-	e.GET("/login", si.GetLoginPage)
-	e.GET("/register", si.GetRegisterPage)
-	e.POST("/login", si.PostLogin)
-	e.POST("/register", si.PostRegister)
 	e.GET("/logout", si.GetLogout)
-	e.POST("/create_link", si.CreateShortLink)
-	e.GET("/create_link", si.GetCreateShortLink)
 	e.GET("/:short_link", si.GetShortLink)
-	e.GET("/subscriptions", si.GetSubscriptionsPage)
-	e.GET("/get_subscriptions", si.GetSubscriptions)
-	e.GET("/user", si.GetUser)
-	e.GET("/get_links", si.GetUserShortLinks)
-	e.DELETE("/delete_link", si.DeleteShortLink)
+	e.POST("/:short_link", si.UnlockShortLink)
+	e.POST("/payments/callback", si.PaymentCallback)
+
+	// echo keeps one not-found route per group prefix, so the anonymous group goes first
+	// and the authenticated one answers for the unknown paths
+	anonymous := e.Group("", si.RequireAnonymous)
+
+	anonymous.GET("/login", si.GetLoginPage)
+	anonymous.GET("/register", si.GetRegisterPage)
+	anonymous.POST("/login", si.PostLogin)
+	anonymous.POST("/register", si.PostRegister)
+
+	auth := e.Group("", si.RequireAuth)
+
+	auth.GET("/", si.GetMainPage)
+	auth.GET("/create_link", si.GetCreateShortLink)
+	auth.GET("/subscriptions", si.GetSubscriptionsPage)
+	auth.GET("/get_subscriptions", si.GetSubscriptions, Deprecated("/api/v1/subscriptions"))
+	auth.GET("/get_current_subscription", si.GetCurrentSubscription)
+	auth.GET("/user", si.GetUser, Deprecated("/api/v1/me"))
+	auth.GET("/links", si.GetLinksPage)
+	auth.POST("/buy", si.BuySubscription)
+	auth.GET("/get_order", si.GetOrder)
+	auth.GET("/tokens", si.GetApiTokensPage)
+	auth.GET("/get_tokens", si.GetApiTokens)
+	auth.POST("/create_token", si.CreateApiToken)
+	auth.DELETE("/revoke_token", si.RevokeApiToken)
+	// the counters tell about the internals of the running process, so only admins see them
+	auth.GET("/metrics", GetMetrics, si.RequirePermission(service.PermissionViewMetrics))
+
+	// the endpoints scripts may call with an API token of the scope, they take sessions as well
+	e.GET("/get_links", si.GetUserShortLinks, Deprecated("/api/v1/links"), si.RequireScope(service.ScopeReadLinks))
+	e.GET("/get_link_versions", si.GetLinkVersions, si.RequireScope(service.ScopeReadLinks))
+	e.POST("/create_link", si.CreateShortLink, Deprecated("/api/v1/links"), si.RequireScope(service.ScopeCreateLinks))
+	e.POST("/extend_link", si.ExtendShortLink, Deprecated("/api/v1/links/{code}"), si.RequireScope(service.ScopeCreateLinks))
+	e.POST("/update_link", si.UpdateShortLink, Deprecated("/api/v1/links/{code}"), si.RequireScope(service.ScopeCreateLinks))
+	e.POST("/rollback_link", si.RollbackShortLink, si.RequireScope(service.ScopeCreateLinks))
+	e.DELETE("/delete_link", si.DeleteShortLink, Deprecated("/api/v1/links/{code}"), si.RequireScope(service.ScopeDeleteLinks))
+	e.GET("/get_link_stats", si.GetLinkStats, si.RequireScope(service.ScopeStats))
+
+	// the verb-style routes above stay for the pages and the scripts written against them
+	v1 := e.Group("/api/v1")
+
+	v1.POST("/links", si.CreateLinkV1, si.RequireScope(service.ScopeCreateLinks))
+	v1.GET("/links", si.ListLinksV1, si.RequireScope(service.ScopeReadLinks))
+	v1.GET("/links/:code", si.GetLinkV1, si.RequireScope(service.ScopeReadLinks))
+	v1.PATCH("/links/:code", si.UpdateLinkV1, si.RequireScope(service.ScopeCreateLinks))
+	v1.DELETE("/links/:code", si.DeleteLinkV1, si.RequireScope(service.ScopeDeleteLinks))
+	v1.GET("/me", si.GetMeV1, si.RequireScope(service.ScopeReadLinks))
+	v1.GET("/subscriptions", si.ListSubscriptionsV1)
+	v1.RouteNotFound("/*", apiNotFound)
+
+	admin := auth.Group("/admin")
+
+	admin.GET("", si.GetAdminPage, si.RequirePermission(service.PermissionViewUsers))
+	admin.GET("/stats", si.GetSystemStats, si.RequirePermission(service.PermissionViewUsers))
+	admin.GET("/users", si.SearchUsers, si.RequirePermission(service.PermissionViewUsers))
+	admin.POST("/users/quota", si.UpdateUserShortLinks, si.RequirePermission(service.PermissionManageQuota))
+	admin.POST("/users/disable", si.SetUserDisabled, si.RequirePermission(service.PermissionDisableUsers))
+	admin.POST("/users/role", si.SetUserRole, si.RequirePermission(service.PermissionManageRoles))
+	admin.GET("/links", si.SearchLinks, si.RequirePermission(service.PermissionViewLinks))
+	admin.POST("/links/disable", si.SetLinkDisabled, si.RequirePermission(service.PermissionModerateLinks))
 
 	return e
 
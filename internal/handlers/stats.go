@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	dateFormat           = "2006-01-02"
+	defaultStatsRange    = 7 * 24 * time.Hour
+	defaultStatsInterval = "day"
+)
+
+// countryHeaders are set by the CDN or reverse proxy in front of us; we do no GeoIP lookups ourselves.
+var countryHeaders = []string{
+	"CF-IPCountry",
+	"X-Country-Code",
+}
+
+func clientCountry(c echo.Context) string {
+	for _, header := range countryHeaders {
+		if country := c.Request().Header.Get(header); country != "" {
+			return country
+		}
+	}
+
+	return ""
+}
+
+// parseStatsTime accepts either RFC3339 timestamps or plain dates.
+func parseStatsTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(dateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s, expected RFC3339 or %s", value, dateFormat)
+	}
+
+	return t, nil
+}
+
+type GetLinkStatsResponse struct {
+	Stats postgresDB.LinkStats `json:"stats"`
+}
+
+// GetLinkStats godoc
+//
+//	@Summary		Gets click statistics of user's short link
+//	@Description	Unique clicks are visitor-days: visitors are told apart within a UTC day only, a visitor coming back on another day is counted again.
+//	@Param			short_link	query		string	true	"Short link"
+//	@Param			from		query		string	false	"Range start, RFC3339 or YYYY-MM-DD (default: a week before the end)"
+//	@Param			to			query		string	false	"Range end, RFC3339 or YYYY-MM-DD (default: now)"
+//	@Param			interval	query		string	false	"Bucket size: hour, day or week (default: day)"
+//	@Success		200			{object}	GetLinkStatsResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/get_link_stats      [get]
+func (h *Handlers) GetLinkStats(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	shortLink := c.QueryParam("short_link")
+
+	if shortLink == "" {
+		return invalidInput(errors.New("short_link is required"))
+	}
+
+	to, err := parseStatsTime(c.QueryParam("to"), time.Now().UTC())
+
+	if err != nil {
+		return invalidInput(err)
+	}
+
+	from, err := parseStatsTime(c.QueryParam("from"), to.Add(-defaultStatsRange))
+
+	if err != nil {
+		return invalidInput(err)
+	}
+
+	interval := c.QueryParam("interval")
+
+	if interval == "" {
+		interval = defaultStatsInterval
+	}
+
+	ctx := c.Request().Context()
+
+	stats, err := h.Service.GetLinkStats(ctx, shortLink, email, from, to, interval)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, GetLinkStatsResponse{
+		Stats: *stats,
+	})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"urleater/internal/repository/postgresDB"
+)
+
+type UpdateShortLinkRequest struct {
+	ShortLink string `json:"short_link" validate:"required"`
+	LongLink  string `json:"long_link" validate:"required"`
+}
+
+type UpdateShortLinkResponse struct {
+	Link postgresDB.Link `json:"link"`
+}
+
+// UpdateShortLink godoc
+//
+//	@Summary		Points user's short link at another long link, use PATCH /api/v1/links/{code} instead
+//	@Deprecated
+//	@Accept			json
+//	@Param			short_link	body		string	true	"Short link to change"
+//	@Param			long_link	body		string	true	"New destination"
+//	@Success		200			{object}	UpdateShortLinkResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/update_link      [post]
+func (h *Handlers) UpdateShortLink(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	requestData := new(UpdateShortLinkRequest)
+
+	if err := c.Bind(&requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	link, err := h.Service.UpdateShortLink(ctx, requestData.ShortLink, requestData.LongLink, email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, UpdateShortLinkResponse{
+		Link: *link,
+	})
+}
+
+type GetLinkVersionsResponse struct {
+	Versions []postgresDB.LinkVersion `json:"versions"`
+}
+
+// GetLinkVersions godoc
+//
+//	@Summary		Gets the destination changes of user's short link, the latest first
+//	@Param			short_link	query		string	true	"Short link"
+//	@Success		200			{object}	GetLinkVersionsResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/get_link_versions      [get]
+func (h *Handlers) GetLinkVersions(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	shortLink := c.QueryParam("short_link")
+
+	if shortLink == "" {
+		return invalidInput(errors.New("short_link is required"))
+	}
+
+	ctx := c.Request().Context()
+
+	versions, err := h.Service.GetLinkVersions(ctx, shortLink, email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, GetLinkVersionsResponse{
+		Versions: versions,
+	})
+}
+
+type RollbackShortLinkRequest struct {
+	ShortLink string `json:"short_link" validate:"required"`
+	VersionId int    `json:"version_id" validate:"required"`
+}
+
+type RollbackShortLinkResponse struct {
+	Link postgresDB.Link `json:"link"`
+}
+
+// RollbackShortLink godoc
+//
+//	@Summary		Undoes a destination change of user's short link and the ones made after it
+//	@Accept			json
+//	@Param			short_link	body		string	true	"Short link to roll back"
+//	@Param			version_id	body		int		true	"Change to undo"
+//	@Success		200			{object}	RollbackShortLinkResponse
+//	@Failure		400			{object}	ErrorResponse
+//	@Failure		401			{object}	ErrorResponse
+//	@Failure		403			{object}	ErrorResponse
+//	@Failure		404			{object}	ErrorResponse
+//	@Failure		500			{object}	ErrorResponse
+//	@Router			/rollback_link      [post]
+func (h *Handlers) RollbackShortLink(c echo.Context) error {
+	email, err := authEmail(c)
+
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	requestData := new(RollbackShortLinkRequest)
+
+	if err := c.Bind(&requestData); err != nil {
+		return invalidInput(err)
+	}
+
+	if c.Echo().Validator != nil {
+		if err := c.Validate(requestData); err != nil {
+			return invalidInput(err)
+		}
+	}
+
+	link, err := h.Service.RollbackShortLink(ctx, requestData.ShortLink, requestData.VersionId, email)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, RollbackShortLinkResponse{
+		Link: *link,
+	})
+}
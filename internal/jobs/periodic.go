@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Periodic runs a task in the background right away and then every interval,
+// a run that takes longer than the interval delays the next one instead of overlapping it.
+type Periodic struct {
+	name     string
+	interval time.Duration
+	task     func(ctx context.Context) error
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewPeriodic(name string, interval time.Duration, task func(ctx context.Context) error) *Periodic {
+	return &Periodic{
+		name:     name,
+		interval: interval,
+		task:     task,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start launches the background goroutine.
+func (p *Periodic) Start() {
+	go p.run()
+}
+
+// Close cancels the current run, if any, and waits until it returns or ctx is done.
+func (p *Periodic) Close(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Periodic) run() {
+	defer close(p.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.task(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("%s: %v\n", p.name, err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type hook struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+// Manager stops the components of the application in the reverse order of their registration,
+// like deferred calls: whatever was started last depends on the earlier ones and goes first.
+type Manager struct {
+	mu     sync.Mutex
+	hooks  []hook
+	done   bool
+	logger *log.Logger
+}
+
+func New(logger *log.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// OnShutdown registers stop to be called on Shutdown. A positive timeout bounds stop on its own,
+// otherwise it only gets the deadline of the Shutdown context.
+func (m *Manager) OnShutdown(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook{name: name, timeout: timeout, stop: stop})
+}
+
+// Shutdown runs every hook once, even if some of them fail, and returns all their errors.
+// Later calls do nothing.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+
+	if m.done {
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.done = true
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	var errs []error
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := m.run(ctx, hooks[i]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hooks[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) run(ctx context.Context, h hook) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	m.logger.Printf("stopping %s", h.name)
+
+	started := time.Now()
+
+	if err := h.stop(ctx); err != nil {
+		m.logger.Printf("could not stop %s: %v", h.name, err)
+		return err
+	}
+
+	m.logger.Printf("stopped %s in %s", h.name, time.Since(started).Round(time.Millisecond))
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const usage = "usage: migrate up | down [N] | status | force VERSION"
+
+// RunCommand implements the "migrate" subcommand of the server binary, args are the words after "migrate".
+func (m *Migrator) RunCommand(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %d_%s\n", migration.Version, migration.Name)
+		}
+
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+
+		return err
+
+	case "down":
+		steps := 1
+
+		if len(args) > 1 {
+			var err error
+
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+
+		reverted, err := m.Down(ctx, steps)
+
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %d_%s\n", migration.Version, migration.Name)
+		}
+
+		return err
+
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range status.Applied {
+			fmt.Fprintf(out, "applied  %d_%s\n", migration.Version, migration.Name)
+		}
+
+		for _, migration := range status.Pending {
+			fmt.Fprintf(out, "pending  %d_%s\n", migration.Version, migration.Name)
+		}
+
+		if status.Dirty {
+			fmt.Fprintf(out, "schema is DIRTY at version %d, fix it by hand and run \"migrate force VERSION\"\n", status.Version)
+		}
+
+		return nil
+
+	case "force":
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		return m.Force(ctx, version)
+
+	default:
+		return errors.New(usage)
+	}
+}
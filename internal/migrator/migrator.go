@@ -0,0 +1,342 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// lockID is the key of the Postgres advisory lock held while migrating, so that
+// replicas starting at the same time apply every migration exactly once.
+const lockID int64 = 7216358239
+
+// ErrDirty means a migration failed half way and the schema has to be fixed by hand,
+// then marked as clean with Force.
+var ErrDirty = errors.New("database schema is dirty")
+
+// ErrPending means the schema is older than the migrations shipped with the binary.
+var ErrPending = errors.New("database schema has pending migrations")
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	// Version is the last applied migration, 0 if there is none
+	Version int64
+	Dirty   bool
+	Applied []Migration
+	Pending []Migration
+}
+
+// Migrator applies migrations and records the schema version in schema_migrations,
+// the same table the golang-migrate CLI uses, so databases migrated with it keep working.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := parseMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		pool:       pool,
+		migrations: migrations,
+	}, nil
+}
+
+// Migrations are the migrations found in the files, oldest first.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+func parseMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, file := range files {
+		prefix, rest, ok := strings.Cut(file, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration name %s", file)
+		}
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", file, err)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version}
+			byVersion[version] = m
+		}
+
+		switch {
+		case strings.HasSuffix(rest, ".up.sql"):
+			m.Name = strings.TrimSuffix(rest, ".up.sql")
+			m.Up = string(body)
+		case strings.HasSuffix(rest, ".down.sql"):
+			m.Down = string(body)
+		default:
+			return nil, fmt.Errorf("migration %s is neither up nor down", file)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", m.Version)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return fmt.Errorf("%w at version %d", ErrDirty, version)
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+
+			if err = m.apply(ctx, conn, migration.Version, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("could not apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns the ones it reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return fmt.Errorf("%w at version %d", ErrDirty, version)
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+
+			if migration.Version > version {
+				continue
+			}
+
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			if err = m.apply(ctx, conn, migration.Version, migration.Down, previous); err != nil {
+				return fmt.Errorf("could not revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force marks the schema as clean at the given version without running anything.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		return writeVersion(ctx, conn, version, false)
+	})
+}
+
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	var status Status
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		var err error
+
+		status.Version, status.Dirty, err = readVersion(ctx, conn)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version <= status.Version {
+			status.Applied = append(status.Applied, migration)
+		} else {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+
+	return &status, nil
+}
+
+// Check tells whether the server may run on the schema: it must be clean and have every migration applied.
+// A newer schema passes, replicas of the previous release keep running while the next one rolls out.
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status.Dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, status.Version)
+	}
+
+	if len(status.Pending) > 0 {
+		latest := status.Pending[len(status.Pending)-1]
+
+		return fmt.Errorf("%w, it is at version %d and the latest one is %d", ErrPending, status.Version, latest.Version)
+	}
+
+	return nil
+}
+
+// apply runs the script in a transaction. The schema stays marked dirty at
+// dirtyVersion if the script fails, and moves to version once it succeeds.
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, dirtyVersion int64, script string, version int64) error {
+	if err := writeVersion(ctx, conn, dirtyVersion, true); err != nil {
+		return err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if strings.TrimSpace(script) != "" {
+		if _, err = tx.Exec(ctx, script); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return writeVersion(ctx, conn, version, false)
+}
+
+func (m *Migrator) withLock(ctx context.Context, f func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("could not acquire connection: %w", err)
+	}
+
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("could not take migration lock: %w", err)
+	}
+
+	defer func() {
+		// the lock must be released even if ctx is already cancelled, or the connection goes back to the pool locked
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+	}()
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint NOT NULL PRIMARY KEY,
+		dirty boolean NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("could not create schema_migrations: %w", err)
+	}
+
+	return f(conn)
+}
+
+func readVersion(ctx context.Context, conn *pgxpool.Conn) (int64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("could not read schema version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
+// writeVersion replaces the single row of schema_migrations, version 0 means nothing is applied.
+func writeVersion(ctx context.Context, conn *pgxpool.Conn, version int64, dirty bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("could not write schema version: %w", err)
+	}
+
+	if version > 0 || dirty {
+		if _, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", version, dirty); err != nil {
+			return fmt.Errorf("could not write schema version: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
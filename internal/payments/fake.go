@@ -0,0 +1,191 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+)
+
+const (
+	// CheckoutPath is where the fake checkout page has to be mounted
+	CheckoutPath = "/payments/fake/checkout"
+	// CallbackPath is where the fake provider sends its notifications
+	CallbackPath = "/payments/callback"
+	// SignatureHeader carries the hex HMAC-SHA256 of the notification body
+	SignatureHeader = "X-Signature"
+)
+
+var checkoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>Fake checkout</title>
+  <link href="https://cdn.jsdelivr.net/npm/bootstrap@5.3.0/dist/css/bootstrap.min.css" rel="stylesheet">
+</head>
+<body>
+<div class="container mt-5" style="width: 40%">
+  <h1 class="mb-4">Fake checkout</h1>
+  <p>Order <code>{{.OrderId}}</code>, amount {{.Amount}}. No money is involved.</p>
+  <form method="post" class="d-flex">
+    <input type="hidden" name="order_id" value="{{.OrderId}}">
+    <input type="hidden" name="amount" value="{{.Amount}}">
+    <button class="btn btn-primary me-2" name="status" value="succeeded">Pay</button>
+    <button class="btn btn-outline-danger" name="status" value="failed">Decline</button>
+  </form>
+</div>
+</body>
+</html>
+`))
+
+// Fake is a payment provider for development and tests: its checkout page lets the user pay or decline,
+// and it notifies the service the way a real provider would, with a signed callback.
+type Fake struct {
+	secret  []byte
+	baseURL string
+	client  *http.Client
+}
+
+// NewFake creates a provider that signs notifications with secret, baseURL is the public address of the service.
+func NewFake(secret []byte, baseURL string) *Fake {
+	return &Fake{
+		secret:  secret,
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// CreatePayment returns the fake checkout page of the order.
+func (f *Fake) CreatePayment(ctx context.Context, order postgresDB.Order) (string, error) {
+	query := url.Values{}
+	query.Set("order_id", order.Id)
+	query.Set("amount", strconv.Itoa(order.Amount))
+
+	return f.baseURL + CheckoutPath + "?" + query.Encode(), nil
+}
+
+// VerifyCallback checks that the notification was signed with the provider's secret.
+func (f *Fake) VerifyCallback(payload []byte, signature string) (*service.PaymentEvent, error) {
+	expected, err := hex.DecodeString(signature)
+
+	if err != nil || !hmac.Equal(expected, f.mac(payload)) {
+		return nil, errors.New("signature mismatch")
+	}
+
+	event := new(service.PaymentEvent)
+
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("malformed notification: %w", err)
+	}
+
+	return event, nil
+}
+
+// Sign returns the signature of the notification body.
+func (f *Fake) Sign(payload []byte) string {
+	return hex.EncodeToString(f.mac(payload))
+}
+
+// Callback builds a signed notification about a new payment for the order.
+func (f *Fake) Callback(orderId string, amount int, status string) ([]byte, string) {
+	payload, _ := json.Marshal(service.PaymentEvent{
+		OrderId:   orderId,
+		PaymentId: newPaymentId(),
+		Status:    status,
+		Amount:    amount,
+	})
+
+	return payload, f.Sign(payload)
+}
+
+// ServeHTTP shows the checkout page, the submitted form notifies the service and brings the user back.
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		amount, err := strconv.Atoi(r.URL.Query().Get("amount"))
+
+		if err != nil || r.URL.Query().Get("order_id") == "" {
+			http.Error(w, "order_id and amount are required", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		_ = checkoutPage.Execute(w, struct {
+			OrderId string
+			Amount  int
+		}{r.URL.Query().Get("order_id"), amount})
+
+	case http.MethodPost:
+		orderId := r.FormValue("order_id")
+		amount, err := strconv.Atoi(r.FormValue("amount"))
+
+		if err != nil || orderId == "" {
+			http.Error(w, "order_id and amount are required", http.StatusBadRequest)
+			return
+		}
+
+		if err := f.notify(r.Context(), orderId, amount, r.FormValue("status")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		http.Redirect(w, r, "/subscriptions?order="+url.QueryEscape(orderId), http.StatusSeeOther)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *Fake) notify(ctx context.Context, orderId string, amount int, status string) error {
+	payload, signature := f.Callback(orderId, amount, status)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.baseURL+CallbackPath, bytes.NewReader(payload))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, signature)
+
+	resp, err := f.client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("could not notify the service: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("the service rejected the notification with %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (f *Fake) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+func newPaymentId() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return "fake_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,266 @@
+package cachedDB
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"time"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	shards = 16
+	// listenRetryDelay is how long Start waits before listening again after the connection broke
+	listenRetryDelay = 5 * time.Second
+)
+
+var (
+	metrics = expvar.NewMap("link_cache")
+
+	hits          = new(expvar.Int)
+	misses        = new(expvar.Int)
+	invalidations = new(expvar.Int)
+	evictions     = new(expvar.Int)
+)
+
+func init() {
+	metrics.Set("hits", hits)
+	metrics.Set("misses", misses)
+	metrics.Set("invalidations", invalidations)
+	metrics.Set("evictions", evictions)
+}
+
+// Notifier tells the other instances which short links have changed, postgresDB.Storage does it with NOTIFY.
+type Notifier interface {
+	NotifyLinkChanged(ctx context.Context, shortLink string) error
+	// ListenLinkChanges calls listening once it gets the notifications, and then changed with each of them.
+	ListenLinkChanges(ctx context.Context, listening func(), changed func(shortLink string)) error
+}
+
+type Config struct {
+	// Size is how many short links are kept, the least recently used ones are evicted
+	Size int
+	// TTL bounds how long a link is kept
+	TTL time.Duration
+	// MissTTL is how long the short links that don't exist are kept as such
+	MissTTL time.Duration
+}
+
+// Storage caches the short links GetShortLink returns, missing ones included, in front of another storage.
+// The links changed through it are dropped from the cache of every instance: directly from its own,
+// and by the Notifier from the other ones, which need Start to be listening.
+type Storage struct {
+	service.Storage
+
+	config   Config
+	cache    *lru
+	notifier Notifier
+
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// NewStorage wraps the storage, notifier may be nil when there is a single instance.
+func NewStorage(storage service.Storage, config Config, notifier Notifier) *Storage {
+	s := &Storage{
+		Storage:  storage,
+		config:   config,
+		cache:    newLRU(shards, config.Size),
+		notifier: notifier,
+	}
+
+	metrics.Set("size", expvar.Func(func() any {
+		return s.cache.len()
+	}))
+
+	return s
+}
+
+// Start listens for the links changed by the other instances in the background. Notifications
+// sent while it's not listening are lost, so the whole cache is dropped every time it starts listening.
+func (s *Storage) Start() {
+	if s.notifier == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.stop = cancel
+	s.done = make(chan struct{})
+
+	go s.listen(ctx)
+}
+
+// Close stops listening and waits until it's done or ctx is.
+func (s *Storage) Close(ctx context.Context) error {
+	if s.stop == nil {
+		return nil
+	}
+
+	s.stop()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Storage) listen(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		err := s.notifier.ListenLinkChanges(ctx, s.cache.clear, func(shortLink string) {
+			invalidations.Add(1)
+			s.cache.remove(shortLink)
+		})
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("link cache: %v, listening again in %s\n", err, listenRetryDelay)
+
+		select {
+		case <-time.After(listenRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Storage) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	now := time.Now()
+
+	link, found, generation := s.cache.get(shortLink, now)
+
+	switch {
+	case found && link == nil:
+		hits.Add(1)
+		return nil, pgx.ErrNoRows
+
+	case found:
+		hits.Add(1)
+		return copyLink(link), nil
+	}
+
+	misses.Add(1)
+
+	link, err := s.Storage.GetShortLink(ctx, shortLink)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		s.set(shortLink, nil, now.Add(s.config.MissTTL), generation)
+
+	case err == nil:
+		s.set(shortLink, copyLink(link), now.Add(s.config.TTL), generation)
+	}
+
+	return link, err
+}
+
+func (s *Storage) set(shortLink string, link *postgresDB.Link, expiresAt time.Time, generation uint64) {
+	if s.cache.set(shortLink, link, expiresAt, generation) {
+		evictions.Add(1)
+	}
+}
+
+// changed drops the link from the caches after it was written, even when the write failed,
+// as the error doesn't tell whether it was applied.
+func (s *Storage) changed(ctx context.Context, shortLink string) {
+	invalidations.Add(1)
+	s.cache.remove(shortLink)
+
+	if s.notifier == nil {
+		return
+	}
+
+	if err := s.notifier.NotifyLinkChanged(ctx, shortLink); err != nil {
+		log.Printf("link cache: could not notify about %s: %v\n", shortLink, err)
+	}
+}
+
+// the short links that were missing are cached as such, so they are dropped once created
+
+func (s *Storage) CreateShortLink(ctx context.Context, shortLink string, longLink string, userID string, expiresAt time.Time) (*postgresDB.Link, error) {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.CreateShortLink(ctx, shortLink, longLink, userID, expiresAt)
+}
+
+func (s *Storage) CreateShortLinkWithQuota(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time, options postgresDB.LinkOptions) (*postgresDB.Link, error) {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.CreateShortLinkWithQuota(ctx, shortLink, longLink, userEmail, expiresAt, options)
+}
+
+func (s *Storage) DeleteShortLink(ctx context.Context, shortLink string, email string, quotaRefund int) error {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.DeleteShortLink(ctx, shortLink, email, quotaRefund)
+}
+
+func (s *Storage) ExtendShortLink(ctx context.Context, shortLink string, expiresAt time.Time) (*postgresDB.Link, error) {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.ExtendShortLink(ctx, shortLink, expiresAt)
+}
+
+func (s *Storage) UpdateShortLink(ctx context.Context, shortLink string, longLink string, changedBy string) (*postgresDB.Link, error) {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.UpdateShortLink(ctx, shortLink, longLink, changedBy)
+}
+
+func (s *Storage) UpdateLink(ctx context.Context, shortLink string, changes postgresDB.LinkChanges, changedBy string) (*postgresDB.Link, error) {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.UpdateLink(ctx, shortLink, changes, changedBy)
+}
+
+func (s *Storage) SetLinkDisabled(ctx context.Context, shortLink string, disabled bool) (*postgresDB.Link, error) {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.SetLinkDisabled(ctx, shortLink, disabled)
+}
+
+func (s *Storage) SetLinkPassword(ctx context.Context, shortLink string, password string) (*postgresDB.Link, error) {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.SetLinkPassword(ctx, shortLink, password)
+}
+
+func (s *Storage) SetLinkClicks(ctx context.Context, shortLink string, clicks *int) (*postgresDB.Link, error) {
+	defer s.changed(ctx, shortLink)
+
+	return s.Storage.SetLinkClicks(ctx, shortLink, clicks)
+}
+
+// ConsumeLinkClick leaves the cached link alone while it has clicks left: the storage keeps the count,
+// the cache only has to learn that the link is used up.
+func (s *Storage) ConsumeLinkClick(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	link, err := s.Storage.ConsumeLinkClick(ctx, shortLink)
+
+	if err != nil || *link.ClicksLeft == 0 {
+		s.changed(ctx, shortLink)
+	}
+
+	return link, err
+}
+
+// copyLink keeps the callers from changing the cached links.
+func copyLink(link *postgresDB.Link) *postgresDB.Link {
+	c := *link
+
+	if link.ClicksLeft != nil {
+		left := *link.ClicksLeft
+		c.ClicksLeft = &left
+	}
+
+	return &c
+}
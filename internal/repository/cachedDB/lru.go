@@ -0,0 +1,146 @@
+package cachedDB
+
+import (
+	"container/list"
+	"hash/maphash"
+	"sync"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+// lru is split into shards with a lock each, so that concurrent redirects rarely wait for each other.
+type lru struct {
+	seed   maphash.Seed
+	shards []*shard
+}
+
+type shard struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	// order has the most recently used entry in front
+	order *list.List
+	// generation changes with every invalidation, see lru.set
+	generation uint64
+}
+
+type entry struct {
+	shortLink string
+	// link is nil for the short links that don't exist
+	link      *postgresDB.Link
+	expiresAt time.Time
+}
+
+func newLRU(shards int, capacity int) *lru {
+	c := &lru{seed: maphash.MakeSeed(), shards: make([]*shard, shards)}
+
+	for i := range c.shards {
+		c.shards[i] = &shard{
+			capacity: max(1, capacity/shards),
+			entries:  make(map[string]*list.Element),
+			order:    list.New(),
+		}
+	}
+
+	return c
+}
+
+func (c *lru) shard(shortLink string) *shard {
+	return c.shards[maphash.String(c.seed, shortLink)%uint64(len(c.shards))]
+}
+
+// get returns the cached link, nil when it's cached as missing. On a miss it returns the generation
+// of the shard, which the link read from the storage has to be set with.
+func (c *lru) get(shortLink string, now time.Time) (link *postgresDB.Link, found bool, generation uint64) {
+	sh := c.shard(shortLink)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	el, ok := sh.entries[shortLink]
+
+	if !ok {
+		return nil, false, sh.generation
+	}
+
+	e := el.Value.(*entry)
+
+	if !now.Before(e.expiresAt) {
+		sh.order.Remove(el)
+		delete(sh.entries, shortLink)
+
+		return nil, false, sh.generation
+	}
+
+	sh.order.MoveToFront(el)
+
+	return e.link, true, 0
+}
+
+// set caches the link read at the generation get returned. If anything was invalidated since,
+// the link may be older than the invalidation and is not cached. It reports whether an entry was evicted.
+func (c *lru) set(shortLink string, link *postgresDB.Link, expiresAt time.Time, generation uint64) (evicted bool) {
+	sh := c.shard(shortLink)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if sh.generation != generation {
+		return false
+	}
+
+	if el, ok := sh.entries[shortLink]; ok {
+		el.Value = &entry{shortLink: shortLink, link: link, expiresAt: expiresAt}
+		sh.order.MoveToFront(el)
+
+		return false
+	}
+
+	sh.entries[shortLink] = sh.order.PushFront(&entry{shortLink: shortLink, link: link, expiresAt: expiresAt})
+
+	if sh.order.Len() <= sh.capacity {
+		return false
+	}
+
+	oldest := sh.order.Back()
+	sh.order.Remove(oldest)
+	delete(sh.entries, oldest.Value.(*entry).shortLink)
+
+	return true
+}
+
+func (c *lru) remove(shortLink string) {
+	sh := c.shard(shortLink)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sh.generation++
+
+	if el, ok := sh.entries[shortLink]; ok {
+		sh.order.Remove(el)
+		delete(sh.entries, shortLink)
+	}
+}
+
+func (c *lru) clear() {
+	for _, sh := range c.shards {
+		sh.mu.Lock()
+		sh.generation++
+		sh.entries = make(map[string]*list.Element)
+		sh.order.Init()
+		sh.mu.Unlock()
+	}
+}
+
+func (c *lru) len() int {
+	n := 0
+
+	for _, sh := range c.shards {
+		sh.mu.Lock()
+		n += sh.order.Len()
+		sh.mu.Unlock()
+	}
+
+	return n
+}
@@ -0,0 +1,167 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// contains is a case-insensitive substring match like the ILIKE the databases use.
+func contains(value string, query string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(query))
+}
+
+func (s *Storage) SearchUsers(ctx context.Context, query string, offset int, limit int) ([]postgresDB.UserSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*user
+
+	for _, u := range s.users {
+		if contains(u.Email, query) {
+			found = append(found, u)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].createdAt.Equal(found[j].createdAt) {
+			return found[i].Email < found[j].Email
+		}
+
+		return found[i].createdAt.After(found[j].createdAt)
+	})
+
+	var users []postgresDB.UserSummary
+
+	for i := offset; i < len(found) && len(users) < limit; i++ {
+		u := found[i]
+
+		summary := postgresDB.UserSummary{
+			Email:     u.Email,
+			UrlsLeft:  u.UrlsLeft,
+			Role:      u.role,
+			Disabled:  u.Disabled,
+			CreatedAt: u.createdAt,
+		}
+
+		for _, l := range s.links {
+			if l.UserEmail == u.Email {
+				summary.Links++
+			}
+		}
+
+		users = append(users, summary)
+	}
+
+	return users, nil
+}
+
+func (s *Storage) SetUserDisabled(ctx context.Context, email string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		return fmt.Errorf("SetUserDisabled: %w", pgx.ErrNoRows)
+	}
+
+	u.Disabled = disabled
+
+	return nil
+}
+
+func (s *Storage) SearchLinks(ctx context.Context, query string, offset int, limit int) ([]postgresDB.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*link
+
+	for _, l := range s.links {
+		if contains(l.ShortUrl, query) || contains(l.LongUrl, query) || contains(l.UserEmail, query) {
+			found = append(found, l)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].createdAt.Equal(found[j].createdAt) {
+			return found[i].ShortUrl < found[j].ShortUrl
+		}
+
+		return found[i].createdAt.After(found[j].createdAt)
+	})
+
+	var links []postgresDB.Link
+
+	for i := offset; i < len(found) && len(links) < limit; i++ {
+		links = append(links, found[i].Link)
+	}
+
+	return links, nil
+}
+
+func (s *Storage) SetLinkDisabled(ctx context.Context, shortLink string, disabled bool) (*postgresDB.Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.links[shortLink]
+	if !ok {
+		return nil, fmt.Errorf("SetLinkDisabled: %w", pgx.ErrNoRows)
+	}
+
+	l.Disabled = disabled
+
+	res := l.Link
+
+	return &res, nil
+}
+
+func (s *Storage) GetSystemStats(ctx context.Context, now time.Time) (*postgresDB.SystemStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := postgresDB.SystemStats{
+		Users:  len(s.users),
+		Links:  len(s.links),
+		Clicks: len(s.clicks),
+	}
+
+	for _, u := range s.users {
+		if u.Disabled {
+			stats.DisabledUsers++
+		}
+	}
+
+	for _, l := range s.links {
+		expired := !l.ExpiresAt.After(now)
+
+		switch {
+		case l.Disabled:
+			stats.DisabledLinks++
+		case !expired:
+			stats.ActiveLinks++
+		}
+
+		if expired {
+			stats.ExpiredLinks++
+		}
+	}
+
+	for _, us := range s.userSubscriptions {
+		if us.Status == postgresDB.UserSubscriptionActive {
+			stats.ActiveSubscriptions++
+		}
+	}
+
+	for _, order := range s.orders {
+		if order.Status == postgresDB.OrderPaid {
+			stats.PaidOrders++
+		}
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,118 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// copyApiToken keeps the callers from changing the stored token.
+func copyApiToken(t *apiToken) *postgresDB.ApiToken {
+	res := t.ApiToken
+	res.Scopes = append([]string{}, t.Scopes...)
+
+	if t.ExpiresAt != nil {
+		expiresAt := *t.ExpiresAt
+		res.ExpiresAt = &expiresAt
+	}
+
+	if t.LastUsedAt != nil {
+		lastUsedAt := *t.LastUsedAt
+		res.LastUsedAt = &lastUsedAt
+	}
+
+	return &res
+}
+
+func (s *Storage) CreateApiToken(ctx context.Context, token postgresDB.ApiToken, tokenHash string) (*postgresDB.ApiToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[token.UserEmail]; !ok {
+		return nil, fmt.Errorf("CreateApiToken: user %s does not exist", token.UserEmail)
+	}
+
+	for _, t := range s.apiTokens {
+		if t.hash == tokenHash {
+			return nil, fmt.Errorf("CreateApiToken: %w", postgresDB.ErrAlreadyExists)
+		}
+	}
+
+	s.apiTokenSeq++
+
+	token.Id = s.apiTokenSeq
+	token.CreatedAt = token.CreatedAt.UTC().Truncate(time.Second)
+	token.LastUsedAt = nil
+
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.UTC().Truncate(time.Second)
+		token.ExpiresAt = &expiresAt
+	}
+
+	t := &apiToken{ApiToken: token, hash: tokenHash}
+	t.Scopes = append([]string{}, token.Scopes...)
+
+	s.apiTokens = append(s.apiTokens, t)
+
+	return copyApiToken(t), nil
+}
+
+func (s *Storage) GetApiTokens(ctx context.Context, email string) ([]postgresDB.ApiToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := []postgresDB.ApiToken{}
+
+	for i := len(s.apiTokens) - 1; i >= 0; i-- {
+		if s.apiTokens[i].UserEmail == email {
+			res = append(res, *copyApiToken(s.apiTokens[i]))
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Storage) GetApiTokenByHash(ctx context.Context, tokenHash string) (*postgresDB.ApiToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.apiTokens {
+		if t.hash == tokenHash {
+			return copyApiToken(t), nil
+		}
+	}
+
+	return nil, fmt.Errorf("GetApiTokenByHash: %w", pgx.ErrNoRows)
+}
+
+func (s *Storage) DeleteApiToken(ctx context.Context, id int, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.apiTokens {
+		if t.Id == id && t.UserEmail == email {
+			s.apiTokens = append(s.apiTokens[:i], s.apiTokens[i+1:]...)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("DeleteApiToken: token %d of user %s: %w", id, email, pgx.ErrNoRows)
+}
+
+func (s *Storage) TouchApiToken(ctx context.Context, id int, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.apiTokens {
+		if t.Id == id {
+			lastUsedAt := usedAt.UTC().Truncate(time.Second)
+			t.LastUsedAt = &lastUsedAt
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// SetLinkClicks lets the link redirect that many more times, nil removes the limit.
+func (s *Storage) SetLinkClicks(ctx context.Context, shortLink string, clicks *int) (*postgresDB.Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.links[shortLink]
+	if !ok {
+		return nil, fmt.Errorf("SetLinkClicks: %w", pgx.ErrNoRows)
+	}
+
+	l.ClicksLeft = nil
+
+	if clicks != nil {
+		// the links handed out share the counter, so it is replaced rather than changed in place
+		left := *clicks
+		l.ClicksLeft = &left
+	}
+
+	res := l.Link
+
+	return &res, nil
+}
+
+// ConsumeLinkClick takes one click off a click-limited link. Links that are used up, have no limit
+// or don't exist give pgx.ErrNoRows.
+func (s *Storage) ConsumeLinkClick(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.links[shortLink]
+	if !ok || l.ClicksLeft == nil || *l.ClicksLeft <= 0 {
+		return nil, fmt.Errorf("ConsumeLinkClick: %w", pgx.ErrNoRows)
+	}
+
+	left := *l.ClicksLeft - 1
+	l.ClicksLeft = &left
+
+	res := l.Link
+
+	return &res, nil
+}
@@ -0,0 +1,166 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+func (s *Storage) CreateClick(ctx context.Context, click postgresDB.Click) error {
+	_, err := s.CreateClicks(ctx, []postgresDB.Click{click})
+
+	return err
+}
+
+func (s *Storage) CreateClicks(ctx context.Context, clicks []postgresDB.Click) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, click := range clicks {
+		if _, ok := s.links[click.ShortUrl]; !ok {
+			return 0, fmt.Errorf("CreateClicks: short link %s does not exist", click.ShortUrl)
+		}
+	}
+
+	for _, click := range clicks {
+		click.ClickedAt = click.ClickedAt.UTC()
+		s.clicks = append(s.clicks, click)
+	}
+
+	return int64(len(clicks)), nil
+}
+
+// linkClicks returns clicks on the short link in [from, to), the caller must hold the lock.
+func (s *Storage) linkClicks(shortLink string, from time.Time, to time.Time) []postgresDB.Click {
+	var res []postgresDB.Click
+
+	for _, click := range s.clicks {
+		if click.ShortUrl == shortLink && !click.ClickedAt.Before(from) && click.ClickedAt.Before(to) {
+			res = append(res, click)
+		}
+	}
+
+	return res
+}
+
+func (s *Storage) GetClickTotals(ctx context.Context, shortLink string, from time.Time, to time.Time) (int, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clicks := s.linkClicks(shortLink, from, to)
+	unique := make(map[string]struct{})
+
+	for _, click := range clicks {
+		unique[click.IPHash] = struct{}{}
+	}
+
+	return len(clicks), len(unique), nil
+}
+
+func (s *Storage) GetClickSeries(ctx context.Context, shortLink string, from time.Time, to time.Time, interval string) ([]postgresDB.ClickBucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type bucket struct {
+		clicks int
+		unique map[string]struct{}
+	}
+
+	byStart := make(map[time.Time]*bucket)
+
+	for _, click := range s.linkClicks(shortLink, from, to) {
+		start, err := truncateTime(click.ClickedAt, interval)
+		if err != nil {
+			return nil, fmt.Errorf("GetClickSeries: %w", err)
+		}
+
+		b, ok := byStart[start]
+		if !ok {
+			b = &bucket{unique: make(map[string]struct{})}
+			byStart[start] = b
+		}
+
+		b.clicks++
+		b.unique[click.IPHash] = struct{}{}
+	}
+
+	var buckets []postgresDB.ClickBucket
+
+	for start, b := range byStart {
+		buckets = append(buckets, postgresDB.ClickBucket{
+			Start:        start,
+			Clicks:       b.clicks,
+			UniqueClicks: len(b.unique),
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start.Before(buckets[j].Start)
+	})
+
+	return buckets, nil
+}
+
+func (s *Storage) GetTopClickValues(ctx context.Context, shortLink string, dimension string, from time.Time, to time.Time, limit int) ([]postgresDB.ClickCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var value func(click postgresDB.Click) string
+
+	switch dimension {
+	case postgresDB.ClickDimensionReferrer:
+		value = func(click postgresDB.Click) string { return click.ReferrerHost }
+	case postgresDB.ClickDimensionCountry:
+		value = func(click postgresDB.Click) string { return click.Country }
+	case postgresDB.ClickDimensionBrowser:
+		value = func(click postgresDB.Click) string { return click.Browser }
+	case postgresDB.ClickDimensionOS:
+		value = func(click postgresDB.Click) string { return click.OS }
+	default:
+		return nil, fmt.Errorf("GetTopClickValues: unknown dimension %s", dimension)
+	}
+
+	byValue := make(map[string]int)
+
+	for _, click := range s.linkClicks(shortLink, from, to) {
+		byValue[value(click)]++
+	}
+
+	var counts []postgresDB.ClickCount
+
+	for val, clicks := range byValue {
+		counts = append(counts, postgresDB.ClickCount{Value: val, Clicks: clicks})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Clicks == counts[j].Clicks {
+			return counts[i].Value < counts[j].Value
+		}
+
+		return counts[i].Clicks > counts[j].Clicks
+	})
+
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+
+	return counts, nil
+}
+
+// truncateTime mirrors Postgres date_trunc for the intervals the service asks for; weeks start on Monday.
+func truncateTime(t time.Time, interval string) (time.Time, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch interval {
+	case "hour":
+		return t.UTC().Truncate(time.Hour), nil
+	case "day":
+		return day, nil
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported interval %s", interval)
+	}
+}
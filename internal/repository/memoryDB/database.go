@@ -0,0 +1,383 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Storage keeps everything in process memory. It behaves like postgresDB.Storage,
+// including returning pgx.ErrNoRows for missing rows, so the service can't tell them apart.
+type Storage struct {
+	mu sync.RWMutex
+
+	users         map[string]*user
+	links         map[string]*link
+	subscriptions []postgresDB.Subscription
+	clicks        []postgresDB.Click
+	versions      []postgresDB.LinkVersion
+	orders        map[string]*postgresDB.Order
+	// userSubscriptions are kept in the order they started
+	userSubscriptions []*postgresDB.UserSubscription
+	// versionSeq is the id of the last link version, ids are never reused like with a sequence
+	versionSeq int
+	// userSubscriptionSeq is the id of the last user subscription
+	userSubscriptionSeq int
+	apiTokens           []*apiToken
+	// apiTokenSeq is the id of the last API token
+	apiTokenSeq int
+	// linkCodeSeq is the last number handed out by NextLinkNumber
+	linkCodeSeq int64
+	codePool    map[string]struct{}
+}
+
+type user struct {
+	postgresDB.User
+	createdAt time.Time
+	// subscriptionId is 0 while the user is on no plan
+	subscriptionId int
+	role           string
+}
+
+type apiToken struct {
+	postgresDB.ApiToken
+	hash string
+}
+
+type link struct {
+	postgresDB.Link
+	createdAt time.Time
+	// passwordHash is empty for the links that are not protected
+	passwordHash string
+}
+
+func NewStorage(subscriptions ...postgresDB.Subscription) *Storage {
+	return &Storage{
+		users:         make(map[string]*user),
+		links:         make(map[string]*link),
+		subscriptions: subscriptions,
+		orders:        make(map[string]*postgresDB.Order),
+		codePool:      make(map[string]struct{}),
+	}
+}
+
+func (s *Storage) CreateUser(ctx context.Context, email string, password string, urlsLeft int) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[email]; ok {
+		return fmt.Errorf("CreateUser: user %s: %w", email, postgresDB.ErrAlreadyExists)
+	}
+
+	s.users[email] = &user{
+		User: postgresDB.User{
+			Email:        email,
+			PasswordHash: string(passwordHash),
+			UrlsLeft:     urlsLeft,
+		},
+		createdAt: time.Now().UTC(),
+		role:      defaultRole,
+	}
+
+	return nil
+}
+
+func (s *Storage) ChangePassword(ctx context.Context, email string, password string) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		return fmt.Errorf("ChangePassword: %w", pgx.ErrNoRows)
+	}
+
+	u.PasswordHash = string(passwordHash)
+
+	return nil
+}
+
+func (s *Storage) GetUser(ctx context.Context, email string) (*postgresDB.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		return nil, fmt.Errorf("GetUser: %w", pgx.ErrNoRows)
+	}
+
+	res := u.User
+
+	return &res, nil
+}
+
+func (s *Storage) VerifyUserPassword(ctx context.Context, email string, password string) error {
+	u, err := s.GetUser(ctx, email)
+
+	if err != nil {
+		return fmt.Errorf("VerifyUserPassword: %w", err)
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password))
+	if err != nil {
+		return fmt.Errorf("VerifyUserPassword: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateUserLinks(ctx context.Context, email string, urlsDelta int) (*postgresDB.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		return nil, fmt.Errorf("UpdateUserLinks: %w", pgx.ErrNoRows)
+	}
+
+	u.UrlsLeft = max(u.UrlsLeft+urlsDelta, 0)
+
+	res := u.User
+
+	return &res, nil
+}
+
+func (s *Storage) CreateShortLink(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time) (*postgresDB.Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userEmail]; !ok {
+		return nil, fmt.Errorf("CreateShortLink: user %s does not exist", userEmail)
+	}
+
+	return s.createShortLink(shortLink, longLink, userEmail, expiresAt, postgresDB.LinkOptions{}, nil)
+}
+
+func (s *Storage) CreateShortLinkWithQuota(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time, options postgresDB.LinkOptions) (*postgresDB.Link, error) {
+	// hashing is slow, it's done before taking the lock
+	passwordHash, err := postgresDB.HashLinkPassword(options.Password)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userEmail]
+	if !ok {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota: %w", pgx.ErrNoRows)
+	}
+
+	if u.UrlsLeft <= 0 {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota: user %s: %w", userEmail, postgresDB.ErrQuotaExceeded)
+	}
+
+	link, err := s.createShortLink(shortLink, longLink, userEmail, expiresAt, options, passwordHash)
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota: %w", err)
+	}
+
+	u.UrlsLeft--
+
+	return link, nil
+}
+
+// createShortLink expects s.mu to be locked, passwordHash is the hash of options.Password.
+func (s *Storage) createShortLink(shortLink string, longLink string, userEmail string, expiresAt time.Time, options postgresDB.LinkOptions, passwordHash *string) (*postgresDB.Link, error) {
+	if _, ok := s.links[shortLink]; ok {
+		return nil, fmt.Errorf("CreateShortLink: short link %s: %w", shortLink, postgresDB.ErrAlreadyExists)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	l := &link{
+		Link: postgresDB.Link{
+			ShortUrl:  shortLink,
+			LongUrl:   longLink,
+			UserEmail: userEmail,
+			ExpiresAt: expiresAt.UTC().Truncate(time.Second),
+		},
+		createdAt: now,
+	}
+
+	if passwordHash != nil {
+		l.passwordHash = *passwordHash
+		l.Protected = true
+	}
+
+	if options.ClicksLeft != nil {
+		clicksLeft := *options.ClicksLeft
+		l.ClicksLeft = &clicksLeft
+	}
+
+	s.links[shortLink] = l
+
+	res := l.Link
+
+	return &res, nil
+}
+
+func (s *Storage) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.links[shortLink]
+	if !ok {
+		return nil, fmt.Errorf("GetShortLink: %w", pgx.ErrNoRows)
+	}
+
+	res := l.Link
+
+	return &res, nil
+}
+
+func (s *Storage) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email string, offset int, limit int) ([]postgresDB.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var userLinks []*link
+
+	for _, l := range s.links {
+		if l.UserEmail == email {
+			userLinks = append(userLinks, l)
+		}
+	}
+
+	sort.Slice(userLinks, func(i, j int) bool {
+		if userLinks[i].createdAt.Equal(userLinks[j].createdAt) {
+			return userLinks[i].ShortUrl < userLinks[j].ShortUrl
+		}
+
+		return userLinks[i].createdAt.Before(userLinks[j].createdAt)
+	})
+
+	var links []postgresDB.Link
+
+	for i := offset; i < len(userLinks) && len(links) < limit; i++ {
+		links = append(links, userLinks[i].Link)
+	}
+
+	return links, nil
+}
+
+func (s *Storage) DeleteShortLink(ctx context.Context, shortLink string, email string, quotaRefund int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.links[shortLink]
+	if !ok || l.UserEmail != email {
+		return nil
+	}
+
+	delete(s.links, shortLink)
+
+	if u, ok := s.users[email]; ok {
+		u.UrlsLeft += max(quotaRefund, 0)
+	}
+
+	clicks := s.clicks[:0]
+
+	for _, click := range s.clicks {
+		if click.ShortUrl != shortLink {
+			clicks = append(clicks, click)
+		}
+	}
+
+	s.clicks = clicks
+
+	versions := s.versions[:0]
+
+	for _, version := range s.versions {
+		if version.ShortUrl != shortLink {
+			versions = append(versions, version)
+		}
+	}
+
+	s.versions = versions
+
+	return nil
+}
+
+func (s *Storage) ExtendShortLink(ctx context.Context, shortLink string, expiresAt time.Time) (*postgresDB.Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.links[shortLink]
+	if !ok {
+		return nil, fmt.Errorf("ExtendShortLink: %w", pgx.ErrNoRows)
+	}
+
+	l.ExpiresAt = expiresAt.UTC().Truncate(time.Second)
+
+	res := l.Link
+
+	return &res, nil
+}
+
+func (s *Storage) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]postgresDB.Subscription(nil), s.subscriptions...), nil
+}
+
+func (s *Storage) GetUserSubscription(ctx context.Context, email string) (*postgresDB.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		return nil, fmt.Errorf("GetUserSubscription: %w", pgx.ErrNoRows)
+	}
+
+	sub, ok := s.subscription(u.subscriptionId)
+	if !ok {
+		return nil, fmt.Errorf("GetUserSubscription: %w", pgx.ErrNoRows)
+	}
+
+	return &sub, nil
+}
+
+func (s *Storage) SetUserSubscription(ctx context.Context, email string, subscriptionId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		return fmt.Errorf("SetUserSubscription: %w", pgx.ErrNoRows)
+	}
+
+	if _, ok = s.subscription(subscriptionId); !ok {
+		return fmt.Errorf("SetUserSubscription: subscription %d: %w", subscriptionId, pgx.ErrNoRows)
+	}
+
+	u.subscriptionId = subscriptionId
+
+	return nil
+}
+
+func (s *Storage) subscription(id int) (postgresDB.Subscription, bool) {
+	for _, sub := range s.subscriptions {
+		if sub.Id == id {
+			return sub, true
+		}
+	}
+
+	return postgresDB.Subscription{}, false
+}
@@ -0,0 +1,64 @@
+package memoryDB
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// NextLinkNumber hands out the next number of the counter, every number once, starting with 1.
+func (s *Storage) NextLinkNumber(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.linkCodeSeq++
+
+	return s.linkCodeSeq, nil
+}
+
+// AddPoolCodes puts the codes into the pool, skipping the ones pooled or used by a link already.
+// It returns how many were added.
+func (s *Storage) AddPoolCodes(ctx context.Context, codes []string, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+
+	for _, code := range codes {
+		if _, ok := s.links[code]; ok {
+			continue
+		}
+
+		if _, ok := s.codePool[code]; ok {
+			continue
+		}
+
+		s.codePool[code] = struct{}{}
+		added++
+	}
+
+	return added, nil
+}
+
+// ClaimPoolCode takes a code out of the pool, pgx.ErrNoRows if it's empty.
+func (s *Storage) ClaimPoolCode(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for code := range s.codePool {
+		delete(s.codePool, code)
+
+		return code, nil
+	}
+
+	return "", pgx.ErrNoRows
+}
+
+// CountPoolCodes is how many codes are left in the pool.
+func (s *Storage) CountPoolCodes(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.codePool), nil
+}
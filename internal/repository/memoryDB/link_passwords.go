@@ -0,0 +1,67 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SetLinkPassword protects the link with the password, stored as a bcrypt hash. An empty password removes the protection.
+func (s *Storage) SetLinkPassword(ctx context.Context, shortLink string, password string) (*postgresDB.Link, error) {
+	var passwordHash []byte
+
+	if password != "" {
+		var err error
+
+		if passwordHash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost); err != nil {
+			return nil, fmt.Errorf("SetLinkPassword: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.links[shortLink]
+	if !ok {
+		return nil, fmt.Errorf("SetLinkPassword: %w", pgx.ErrNoRows)
+	}
+
+	l.passwordHash = string(passwordHash)
+	l.Protected = password != ""
+
+	res := l.Link
+
+	return &res, nil
+}
+
+// GetLinkPasswordHash returns the bcrypt hash of the link password, empty for the links without one.
+func (s *Storage) GetLinkPasswordHash(ctx context.Context, shortLink string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.links[shortLink]
+	if !ok {
+		return "", fmt.Errorf("GetLinkPasswordHash: %w", pgx.ErrNoRows)
+	}
+
+	return l.passwordHash, nil
+}
+
+// VerifyLinkPassword checks the password of a protected link, the links without one take any password.
+// It returns the hash the password was checked against, empty for the links without one.
+func (s *Storage) VerifyLinkPassword(ctx context.Context, shortLink string, password string) (string, error) {
+	passwordHash, err := s.GetLinkPasswordHash(ctx, shortLink)
+
+	if err != nil || passwordHash == "" {
+		return "", err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		return "", fmt.Errorf("VerifyLinkPassword: %w", err)
+	}
+
+	return passwordHash, nil
+}
@@ -0,0 +1,120 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func (s *Storage) CreateOrder(ctx context.Context, order postgresDB.Order) (*postgresDB.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[order.UserEmail]; !ok {
+		return nil, fmt.Errorf("CreateOrder: user %s does not exist", order.UserEmail)
+	}
+
+	if _, ok := s.subscription(order.SubscriptionId); !ok {
+		return nil, fmt.Errorf("CreateOrder: subscription %d does not exist", order.SubscriptionId)
+	}
+
+	if _, ok := s.orders[order.Id]; ok {
+		return nil, fmt.Errorf("CreateOrder: order %s: %w", order.Id, postgresDB.ErrAlreadyExists)
+	}
+
+	// a user has one pending order at a time
+	for _, o := range s.orders {
+		if o.UserEmail == order.UserEmail && o.Status == postgresDB.OrderPending {
+			o.Status = postgresDB.OrderFailed
+			o.UpdatedAt = order.CreatedAt.UTC().Truncate(time.Second)
+		}
+	}
+
+	order.CreatedAt = order.CreatedAt.UTC().Truncate(time.Second)
+	order.UpdatedAt = order.UpdatedAt.UTC().Truncate(time.Second)
+
+	s.orders[order.Id] = &order
+
+	res := order
+
+	return &res, nil
+}
+
+func (s *Storage) GetOrder(ctx context.Context, orderId string) (*postgresDB.Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	o, ok := s.orders[orderId]
+	if !ok {
+		return nil, fmt.Errorf("GetOrder: %w", pgx.ErrNoRows)
+	}
+
+	res := *o
+
+	return &res, nil
+}
+
+func (s *Storage) PayOrder(ctx context.Context, orderId string, paymentId string) (*postgresDB.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, closed, err := s.closeOrder(orderId, paymentId, postgresDB.OrderPaid)
+	if err != nil || !closed {
+		return order, err
+	}
+
+	var current *int
+
+	if sub := s.activeUserSubscription(order.UserEmail); sub != nil {
+		current = &sub.Id
+	}
+
+	if postgresDB.OrderOutdated(order, current) {
+		s.orders[orderId].Status = postgresDB.OrderFailed
+		order.Status = postgresDB.OrderFailed
+
+		return order, nil
+	}
+
+	if sub := s.activeUserSubscription(order.UserEmail); sub != nil && sub.SubscriptionId == order.SubscriptionId {
+		sub.EndsAt = postgresDB.RenewalEnd(sub.EndsAt, order.UpdatedAt, order.Days)
+
+		return order, nil
+	}
+
+	s.startUserSubscription(order.UserEmail, order.SubscriptionId, order.UpdatedAt, order.Days)
+
+	return order, nil
+}
+
+func (s *Storage) FailOrder(ctx context.Context, orderId string, paymentId string) (*postgresDB.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, _, err := s.closeOrder(orderId, paymentId, postgresDB.OrderFailed)
+
+	return order, err
+}
+
+// closeOrder expects s.mu to be locked.
+func (s *Storage) closeOrder(orderId string, paymentId string, status string) (*postgresDB.Order, bool, error) {
+	o, ok := s.orders[orderId]
+	if !ok {
+		return nil, false, fmt.Errorf("closeOrder: %w", pgx.ErrNoRows)
+	}
+
+	closed := o.Status == postgresDB.OrderPending
+
+	if closed {
+		o.Status = status
+		o.PaymentId = paymentId
+		o.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	}
+
+	res := *o
+
+	return &res, closed, nil
+}
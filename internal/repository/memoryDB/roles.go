@@ -0,0 +1,79 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// defaultRole is what new users get, like the column default of users.role
+const defaultRole = "user"
+
+// roles mirror the ones the migrations seed
+var roles = []postgresDB.Role{
+	{Name: "admin", Permissions: []string{"links.moderate", "links.view", "system.metrics", "users.disable", "users.manage_quota", "users.manage_roles", "users.view"}},
+	{Name: "support", Permissions: []string{"links.view", "users.view"}},
+	{Name: "user", Permissions: []string{}},
+}
+
+func findRole(name string) (postgresDB.Role, bool) {
+	for _, role := range roles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+
+	return postgresDB.Role{}, false
+}
+
+func copyRole(role postgresDB.Role) *postgresDB.Role {
+	role.Permissions = append([]string{}, role.Permissions...)
+
+	return &role
+}
+
+func (s *Storage) GetRoles(ctx context.Context) ([]postgresDB.Role, error) {
+	res := make([]postgresDB.Role, 0, len(roles))
+
+	for _, role := range roles {
+		res = append(res, *copyRole(role))
+	}
+
+	return res, nil
+}
+
+func (s *Storage) GetUserRole(ctx context.Context, email string) (*postgresDB.Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[email]
+
+	if !ok {
+		return nil, fmt.Errorf("GetUserRole: user %s: %w", email, pgx.ErrNoRows)
+	}
+
+	role, _ := findRole(u.role)
+
+	return copyRole(role), nil
+}
+
+func (s *Storage) SetUserRole(ctx context.Context, email string, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[email]
+
+	if !ok {
+		return fmt.Errorf("SetUserRole: user %s: %w", email, pgx.ErrNoRows)
+	}
+
+	if _, ok := findRole(role); !ok {
+		return fmt.Errorf("SetUserRole: role %s: %w", role, pgx.ErrNoRows)
+	}
+
+	u.role = role
+
+	return nil
+}
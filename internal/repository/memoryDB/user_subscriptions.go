@@ -0,0 +1,113 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func (s *Storage) GetActiveUserSubscription(ctx context.Context, email string) (*postgresDB.UserSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub := s.activeUserSubscription(email)
+	if sub == nil {
+		return nil, fmt.Errorf("GetActiveUserSubscription: %w", pgx.ErrNoRows)
+	}
+
+	res := *sub
+
+	return &res, nil
+}
+
+// activeUserSubscription expects s.mu to be locked.
+func (s *Storage) activeUserSubscription(email string) *postgresDB.UserSubscription {
+	for _, sub := range s.userSubscriptions {
+		if sub.UserEmail == email && sub.Status == postgresDB.UserSubscriptionActive {
+			return sub
+		}
+	}
+
+	return nil
+}
+
+// startUserSubscription expects s.mu to be locked.
+func (s *Storage) startUserSubscription(email string, subscriptionId int, startsAt time.Time, days int) {
+	startsAt = startsAt.UTC().Truncate(time.Second)
+
+	if current := s.activeUserSubscription(email); current != nil {
+		current.Status = postgresDB.UserSubscriptionReplaced
+		current.EndsAt = startsAt
+	}
+
+	s.userSubscriptionSeq++
+
+	s.userSubscriptions = append(s.userSubscriptions, &postgresDB.UserSubscription{
+		Id:             s.userSubscriptionSeq,
+		UserEmail:      email,
+		SubscriptionId: subscriptionId,
+		Status:         postgresDB.UserSubscriptionActive,
+		StartsAt:       startsAt,
+		EndsAt:         startsAt.AddDate(0, 0, days),
+		NextResetAt:    postgresDB.NextQuotaReset(startsAt, startsAt),
+	})
+
+	sub, _ := s.subscription(subscriptionId)
+
+	if u, ok := s.users[email]; ok {
+		u.UrlsLeft = sub.TotalUrls
+		u.subscriptionId = subscriptionId
+	}
+}
+
+func (s *Storage) ResetQuotas(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reset := 0
+
+	for _, us := range s.userSubscriptions {
+		if us.Status != postgresDB.UserSubscriptionActive || us.NextResetAt.After(now) || !us.EndsAt.After(now) {
+			continue
+		}
+
+		us.NextResetAt = postgresDB.NextQuotaReset(us.StartsAt, now)
+
+		sub, _ := s.subscription(us.SubscriptionId)
+
+		if u, ok := s.users[us.UserEmail]; ok {
+			u.UrlsLeft = sub.TotalUrls
+		}
+
+		reset++
+	}
+
+	return reset, nil
+}
+
+func (s *Storage) ExpireUserSubscriptions(ctx context.Context, now time.Time, freeQuota int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := 0
+
+	for _, us := range s.userSubscriptions {
+		if us.Status != postgresDB.UserSubscriptionActive || us.EndsAt.After(now) {
+			continue
+		}
+
+		us.Status = postgresDB.UserSubscriptionExpired
+
+		if u, ok := s.users[us.UserEmail]; ok {
+			u.subscriptionId = 0
+			u.UrlsLeft = min(u.UrlsLeft, freeQuota)
+		}
+
+		expired++
+	}
+
+	return expired, nil
+}
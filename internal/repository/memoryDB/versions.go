@@ -0,0 +1,94 @@
+package memoryDB
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func (s *Storage) UpdateShortLink(ctx context.Context, shortLink string, longLink string, changedBy string) (*postgresDB.Link, error) {
+	return s.UpdateLink(ctx, shortLink, postgresDB.LinkChanges{LongUrl: &longLink}, changedBy)
+}
+
+// UpdateLink applies all the changes to the link at once, a new destination is recorded as a link version.
+func (s *Storage) UpdateLink(ctx context.Context, shortLink string, changes postgresDB.LinkChanges, changedBy string) (*postgresDB.Link, error) {
+	var passwordHash *string
+
+	if changes.Password != nil {
+		var err error
+
+		// hashing is slow, it's done before taking the lock
+		if passwordHash, err = postgresDB.HashLinkPassword(*changes.Password); err != nil {
+			return nil, fmt.Errorf("UpdateLink: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.links[shortLink]
+	if !ok {
+		return nil, fmt.Errorf("UpdateLink: %w", pgx.ErrNoRows)
+	}
+
+	if changes.LongUrl != nil {
+		s.versionSeq++
+
+		s.versions = append(s.versions, postgresDB.LinkVersion{
+			Id:         s.versionSeq,
+			ShortUrl:   shortLink,
+			OldLongUrl: l.LongUrl,
+			NewLongUrl: *changes.LongUrl,
+			ChangedBy:  changedBy,
+			ChangedAt:  time.Now().UTC().Truncate(time.Second),
+		})
+
+		l.LongUrl = *changes.LongUrl
+	}
+
+	if changes.ExpiresAt != nil {
+		l.ExpiresAt = changes.ExpiresAt.UTC().Truncate(time.Second)
+	}
+
+	if changes.Password != nil {
+		l.passwordHash = ""
+
+		if passwordHash != nil {
+			l.passwordHash = *passwordHash
+		}
+
+		l.Protected = passwordHash != nil
+	}
+
+	if changes.ClicksLeft != nil {
+		// the links handed out share the counter, so it is replaced rather than changed in place
+		l.ClicksLeft = nil
+
+		if *changes.ClicksLeft > 0 {
+			left := *changes.ClicksLeft
+			l.ClicksLeft = &left
+		}
+	}
+
+	res := l.Link
+
+	return &res, nil
+}
+
+func (s *Storage) GetLinkVersions(ctx context.Context, shortLink string) ([]postgresDB.LinkVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var versions []postgresDB.LinkVersion
+
+	for i := len(s.versions) - 1; i >= 0; i-- {
+		if s.versions[i].ShortUrl == shortLink {
+			versions = append(versions, s.versions[i])
+		}
+	}
+
+	return versions, nil
+}
@@ -4,13 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 	"time"
 )
 
-const linkExpireIn = 90 * 24 * time.Hour
-
 type Storage struct {
 	pgxPool      *pgxpool.Pool
 	queryBuilder squirrel.StatementBuilderType
@@ -23,14 +22,14 @@ func NewStorage(pgxPool *pgxpool.Pool) *Storage {
 	}
 }
 
-func (s *Storage) CreateUser(ctx context.Context, email string, password string) error {
+func (s *Storage) CreateUser(ctx context.Context, email string, password string, urlsLeft int) error {
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 	query, args, err := s.queryBuilder.Insert("users").
-		Columns("email", "password_hash", "created_at").
-		Values(email, passwordHash, time.Now().UTC().Format(time.RFC3339)).
+		Columns("email", "password_hash", "created_at", "urls_left").
+		Values(email, passwordHash, time.Now().UTC().Format(time.RFC3339), urlsLeft).
 		ToSql()
 
 	if err != nil {
@@ -39,7 +38,7 @@ func (s *Storage) CreateUser(ctx context.Context, email string, password string)
 
 	_, err = s.pgxPool.Exec(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("CreateUser query error | %w", err)
+		return fmt.Errorf("CreateUser query error | %w", uniqueViolation(err))
 	}
 
 	return nil
@@ -79,6 +78,7 @@ func (s *Storage) GetUser(ctx context.Context, email string) (*User, error) {
 			"email",
 			"password_hash",
 			"urls_left",
+			"disabled",
 		).
 		From("users").
 		Where(squirrel.Eq{"email": email}).
@@ -88,7 +88,7 @@ func (s *Storage) GetUser(ctx context.Context, email string) (*User, error) {
 		return nil, fmt.Errorf("GetUser query error | %w", err)
 	}
 
-	err = s.pgxPool.QueryRow(ctx, query, args...).Scan(&user.Email, &user.PasswordHash, &user.UrlsLeft)
+	err = s.pgxPool.QueryRow(ctx, query, args...).Scan(&user.Email, &user.PasswordHash, &user.UrlsLeft, &user.Disabled)
 	if err != nil {
 		return &User{}, fmt.Errorf("GetUser query error | %w", err)
 	}
@@ -115,8 +115,9 @@ func (s *Storage) UpdateUserLinks(ctx context.Context, email string, urlsDelta i
 
 	query, args, err := s.queryBuilder.
 		Update("users").
-		Set("urls_left", squirrel.Expr("IF(urls_left + ? >= 0, urls_left + ?, 0)", urlsDelta, urlsDelta)).
+		Set("urls_left", squirrel.Expr("GREATEST(urls_left + ?, 0)", urlsDelta)).
 		Where(squirrel.Eq{"email": email}).
+		Suffix("RETURNING email, password_hash, urls_left, disabled").
 		ToSql()
 
 	if err != nil {
@@ -127,39 +128,119 @@ func (s *Storage) UpdateUserLinks(ctx context.Context, email string, urlsDelta i
 		&user.Email,
 		&user.PasswordHash,
 		&user.UrlsLeft,
+		&user.Disabled,
 	)
 
 	if err != nil {
-		return &User{}, fmt.Errorf("UpdateUserLinks query error | %w", err)
+		return nil, fmt.Errorf("UpdateUserLinks query error | %w", err)
 	}
 
 	return &user, nil
 }
 
-func (s *Storage) CreateShortLink(ctx context.Context, shortLink string, longLink string, userEmail string) (*Link, error) {
+func (s *Storage) CreateShortLink(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time) (*Link, error) {
+	return createShortLink(ctx, s.pgxPool, s.queryBuilder, shortLink, longLink, userEmail, expiresAt, LinkOptions{})
+}
+
+// querier is implemented by both the pool and its transactions.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+func createShortLink(ctx context.Context, q querier, queryBuilder squirrel.StatementBuilderType, shortLink string, longLink string, userEmail string, expiresAt time.Time, options LinkOptions) (*Link, error) {
 	var link Link
-	expiresAt := time.Now().UTC().Add(linkExpireIn)
-	query, args, err := s.queryBuilder.Insert("urls").
-		Columns("short_url", "long_url", "created_at", "user_email", "expires_at").
-		Values(shortLink, longLink, time.Now().UTC().Format(time.RFC3339), userEmail, expiresAt.Format(time.RFC3339)).
-		Suffix("RETURNING short_url, long_url, expires_at").
+
+	passwordHash, err := HashLinkPassword(options.Password)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLink hash error | %w", err)
+	}
+
+	query, args, err := queryBuilder.Insert("urls").
+		Columns("short_url", "long_url", "created_at", "user_email", "expires_at", "password_hash", "clicks_left").
+		Values(shortLink, longLink, time.Now().UTC().Format(time.RFC3339), userEmail, expiresAt.UTC().Format(time.RFC3339), passwordHash, options.ClicksLeft).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left").
 		ToSql()
 
 	if err != nil {
 		return nil, fmt.Errorf("CreateShortLink query error | %w", err)
 	}
 
-	err = s.pgxPool.QueryRow(ctx, query, args...).Scan(
+	err = q.QueryRow(ctx, query, args...).Scan(
 		&link.ShortUrl,
 		&link.LongUrl,
-		&link.ExpiresAt)
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
 	if err != nil {
-		return nil, fmt.Errorf("CreateShortLink query error | %w", err)
+		return nil, fmt.Errorf("CreateShortLink query error | %w", uniqueViolation(err))
 	}
 
 	return &link, nil
 }
 
+// CreateShortLinkWithQuota spends one of the user's links on a new short link with the options.
+// Both happen in one transaction, the user's row lock serializes concurrent creations,
+// so the quota can't be overspent. Returns ErrQuotaExceeded when no links are left.
+func (s *Storage) CreateShortLinkWithQuota(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time, options LinkOptions) (*Link, error) {
+	var urlsLeft int
+
+	tx, err := s.pgxPool.Begin(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota begin error | %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	query, args, err := s.queryBuilder.
+		Select("urls_left").
+		From("users").
+		Where(squirrel.Eq{"email": userEmail}).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota query error | %w", err)
+	}
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&urlsLeft); err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota query error | %w", err)
+	}
+
+	if urlsLeft <= 0 {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota: user %s: %w", userEmail, ErrQuotaExceeded)
+	}
+
+	query, args, err = s.queryBuilder.
+		Update("users").
+		Set("urls_left", squirrel.Expr("urls_left - 1")).
+		Where(squirrel.Eq{"email": userEmail}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota query error | %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota query error | %w", err)
+	}
+
+	link, err := createShortLink(ctx, tx, s.queryBuilder, shortLink, longLink, userEmail, expiresAt, options)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota commit error | %w", err)
+	}
+
+	return link, nil
+}
+
 func (s *Storage) GetShortLink(ctx context.Context, shortLink string) (*Link, error) {
 	var link Link
 
@@ -167,7 +248,11 @@ func (s *Storage) GetShortLink(ctx context.Context, shortLink string) (*Link, er
 		Select(
 			"short_url",
 			"long_url",
+			"user_email",
 			"expires_at",
+			"disabled",
+			"password_hash IS NOT NULL",
+			"clicks_left",
 		).
 		From("urls").
 		Where(squirrel.Eq{"short_url": shortLink}).
@@ -179,7 +264,11 @@ func (s *Storage) GetShortLink(ctx context.Context, shortLink string) (*Link, er
 
 	err = s.pgxPool.QueryRow(ctx, query, args...).Scan(&link.ShortUrl,
 		&link.LongUrl,
-		&link.ExpiresAt)
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
 
 	if err != nil {
 		return nil, fmt.Errorf("GetShortLink query error | %w", err)
@@ -192,14 +281,17 @@ func (s *Storage) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email
 
 	query, args, err := s.queryBuilder.
 		Select(
-			"l.short_url",
-			"l.long_url",
-			"l.user_email",
-			"l.expires_at",
+			"short_url",
+			"long_url",
+			"user_email",
+			"expires_at",
+			"disabled",
+			"password_hash IS NOT NULL",
+			"clicks_left",
 		).
-		From("urls l").
-		Join("users u ON urls.user_email = users.email").
-		Where(squirrel.Eq{"urls.user_email": email}).
+		From("urls").
+		Where(squirrel.Eq{"user_email": email}).
+		OrderBy("created_at", "short_url").
 		Offset(uint64(offset)).
 		Limit(uint64(limit)).
 		ToSql()
@@ -224,6 +316,9 @@ func (s *Storage) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email
 			&link.LongUrl,
 			&link.UserEmail,
 			&link.ExpiresAt,
+			&link.Disabled,
+			&link.Protected,
+			&link.ClicksLeft,
 		)
 
 		if err != nil {
@@ -236,23 +331,53 @@ func (s *Storage) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email
 	return links, nil
 }
 
-func (s *Storage) DeleteShortLink(ctx context.Context, shortLink string, email string) error {
+// DeleteShortLink deletes the user's short link and gives quotaRefund links back to them, if there was such a link.
+func (s *Storage) DeleteShortLink(ctx context.Context, shortLink string, email string, quotaRefund int) error {
+	tx, err := s.pgxPool.Begin(ctx)
+
+	if err != nil {
+		return fmt.Errorf("DeleteShortLink begin error | %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
 	query, args, err := s.queryBuilder.
 		Delete("urls").
 		Where(squirrel.Eq{"short_url": shortLink}).
-		Where(squirrel.Eq{"email": email}).
+		Where(squirrel.Eq{"user_email": email}).
 		ToSql()
 
 	if err != nil {
 		return fmt.Errorf("DeleteShortLink query error | %w", err)
 	}
 
-	_, err = s.pgxPool.Exec(ctx, query, args...)
+	tag, err := tx.Exec(ctx, query, args...)
 
 	if err != nil {
 		return fmt.Errorf("DeleteShortLink query error | %w", err)
 	}
 
+	// a concurrent delete of the same link must not refund it twice
+	if tag.RowsAffected() > 0 && quotaRefund > 0 {
+		query, args, err = s.queryBuilder.
+			Update("users").
+			Set("urls_left", squirrel.Expr("urls_left + ?", quotaRefund)).
+			Where(squirrel.Eq{"email": email}).
+			ToSql()
+
+		if err != nil {
+			return fmt.Errorf("DeleteShortLink query error | %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("DeleteShortLink query error | %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("DeleteShortLink commit error | %w", err)
+	}
+
 	return nil
 }
 
@@ -261,9 +386,9 @@ func (s *Storage) ExtendShortLink(ctx context.Context, shortLink string, expires
 
 	query, args, err := s.queryBuilder.
 		Update("urls").
-		Set("expires_at", expiresAt.Add(linkExpireIn).UTC().Format(time.RFC3339)).
+		Set("expires_at", expiresAt.UTC().Format(time.RFC3339)).
 		Where(squirrel.Eq{"short_url": shortLink}).
-		Suffix("RETURNING short_url, long_url, expires_at").
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left").
 		ToSql()
 
 	if err != nil {
@@ -272,7 +397,11 @@ func (s *Storage) ExtendShortLink(ctx context.Context, shortLink string, expires
 
 	err = s.pgxPool.QueryRow(ctx, query, args...).Scan(&link.ShortUrl,
 		&link.LongUrl,
-		&link.ExpiresAt)
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
 
 	if err != nil {
 		return nil, fmt.Errorf("ExtendShortLink query error | %w", err)
@@ -281,16 +410,214 @@ func (s *Storage) ExtendShortLink(ctx context.Context, shortLink string, expires
 	return &link, nil
 }
 
-func (s *Storage) GetSubscriptions(ctx context.Context) ([]Subscription, error) {
-	var subscriptions []Subscription
+// UpdateShortLink points the short link at longLink and records the change in its history.
+func (s *Storage) UpdateShortLink(ctx context.Context, shortLink string, longLink string, changedBy string) (*Link, error) {
+	return s.UpdateLink(ctx, shortLink, LinkChanges{LongUrl: &longLink}, changedBy)
+}
+
+// UpdateLink applies all the changes to the link in one transaction, a new destination is recorded
+// as a link version. At least one change is expected.
+func (s *Storage) UpdateLink(ctx context.Context, shortLink string, changes LinkChanges, changedBy string) (*Link, error) {
+	var link Link
+
+	update := s.queryBuilder.
+		Update("urls").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left")
+
+	if changes.LongUrl != nil {
+		update = update.Set("long_url", *changes.LongUrl)
+	}
+
+	if changes.ExpiresAt != nil {
+		update = update.Set("expires_at", changes.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	if changes.Password != nil {
+		passwordHash, err := HashLinkPassword(*changes.Password)
+
+		if err != nil {
+			return nil, fmt.Errorf("UpdateLink hash error | %w", err)
+		}
+
+		update = update.Set("password_hash", passwordHash)
+	}
+
+	if changes.ClicksLeft != nil {
+		var clicksLeft *int
+
+		if *changes.ClicksLeft > 0 {
+			clicksLeft = changes.ClicksLeft
+		}
+
+		update = update.Set("clicks_left", clicksLeft)
+	}
+
+	tx, err := s.pgxPool.Begin(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateLink begin error | %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	if changes.LongUrl != nil {
+		// the row lock keeps concurrent changes from recording the same old destination
+		query, args, err := s.queryBuilder.
+			Insert("link_versions").
+			Columns("short_url", "old_long_url", "new_long_url", "changed_by", "changed_at").
+			Select(squirrel.
+				Select("short_url", "long_url").
+				Column("?::varchar", *changes.LongUrl).
+				Column("?::varchar", changedBy).
+				Column("?::timestamp", time.Now().UTC().Format(time.RFC3339)).
+				From("urls").
+				Where(squirrel.Eq{"short_url": shortLink}).
+				Suffix("FOR UPDATE")).
+			ToSql()
+
+		if err != nil {
+			return nil, fmt.Errorf("UpdateLink query error | %w", err)
+		}
+
+		tag, err := tx.Exec(ctx, query, args...)
+
+		if err != nil {
+			return nil, fmt.Errorf("UpdateLink query error | %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return nil, fmt.Errorf("UpdateLink query error | %w", pgx.ErrNoRows)
+		}
+	}
+
+	query, args, err := update.ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateLink query error | %w", err)
+	}
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateLink query error | %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("UpdateLink commit error | %w", err)
+	}
+
+	return &link, nil
+}
+
+// GetLinkVersions returns the destination changes of the short link, the latest first.
+func (s *Storage) GetLinkVersions(ctx context.Context, shortLink string) ([]LinkVersion, error) {
+	var versions []LinkVersion
 
 	query, args, err := s.queryBuilder.
 		Select(
 			"id",
-			"name",
-			"total_urls",
+			"short_url",
+			"old_long_url",
+			"new_long_url",
+			"changed_by",
+			"changed_at",
 		).
+		From("link_versions").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		OrderBy("id DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetLinkVersions query error | %w", err)
+	}
+
+	rows, err := s.pgxPool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetLinkVersions query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var version LinkVersion
+
+		err = rows.Scan(
+			&version.Id,
+			&version.ShortUrl,
+			&version.OldLongUrl,
+			&version.NewLongUrl,
+			&version.ChangedBy,
+			&version.ChangedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetLinkVersions scan error | %w", err)
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+// subscriptionColumns are the columns scanSubscription expects, prefixed with the table alias.
+func subscriptionColumns(prefix string) []string {
+	columns := []string{
+		"id",
+		"name",
+		"total_urls",
+		"max_link_ttl_days",
+		"refund_deleted_links",
+		"price",
+		"period_days",
+		"link_ttl_days",
+		"min_alias_length",
+	}
+
+	for i := range columns {
+		columns[i] = prefix + columns[i]
+	}
+
+	return columns
+}
+
+func scanSubscription(row pgx.Row) (*Subscription, error) {
+	var sub Subscription
+
+	err := row.Scan(
+		&sub.Id,
+		&sub.Name,
+		&sub.TotalUrls,
+		&sub.MaxLinkTTLDays,
+		&sub.RefundDeletedLinks,
+		&sub.Price,
+		&sub.PeriodDays,
+		&sub.LinkTTLDays,
+		&sub.MinAliasLength,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+func (s *Storage) GetSubscriptions(ctx context.Context) ([]Subscription, error) {
+	var subscriptions []Subscription
+
+	query, args, err := s.queryBuilder.
+		Select(subscriptionColumns("")...).
 		From("subscriptions").
+		OrderBy("id").
 		ToSql()
 
 	if err != nil {
@@ -306,21 +633,249 @@ func (s *Storage) GetSubscriptions(ctx context.Context) ([]Subscription, error)
 	defer rows.Close()
 
 	for rows.Next() {
-		var sub Subscription
-		err = rows.Scan(
-			&sub.Id,
-			&sub.Name,
-			&sub.TotalUrls,
-		)
+		sub, err := scanSubscription(rows)
 
 		if err != nil {
 			return nil, fmt.Errorf("GetSubscriptions scan error | %w", err)
 		}
 
-		subscriptions = append(subscriptions, sub)
+		subscriptions = append(subscriptions, *sub)
 
 	}
 
 	return subscriptions, nil
 
 }
+
+// GetUserSubscription returns the plan the user is on, pgx.ErrNoRows if there is no such user or they have none.
+func (s *Storage) GetUserSubscription(ctx context.Context, email string) (*Subscription, error) {
+	query, args, err := s.queryBuilder.
+		Select(subscriptionColumns("s.")...).
+		From("users u").
+		Join("subscriptions s ON s.id = u.subscription_id").
+		Where(squirrel.Eq{"u.email": email}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUserSubscription query error | %w", err)
+	}
+
+	sub, err := scanSubscription(s.pgxPool.QueryRow(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUserSubscription query error | %w", err)
+	}
+
+	return sub, nil
+}
+
+// SetUserSubscription puts the user on a plan, pgx.ErrNoRows if either of them doesn't exist.
+func (s *Storage) SetUserSubscription(ctx context.Context, email string, subscriptionId int) error {
+	query, args, err := s.queryBuilder.
+		Update("users").
+		Set("subscription_id", subscriptionId).
+		Where(squirrel.Eq{"email": email}).
+		Where(squirrel.Expr("EXISTS (SELECT 1 FROM subscriptions WHERE id = ?)", subscriptionId)).
+		Suffix("RETURNING email").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("SetUserSubscription query error | %w", err)
+	}
+
+	err = s.pgxPool.QueryRow(ctx, query, args...).Scan(&email)
+
+	if err != nil {
+		return fmt.Errorf("SetUserSubscription query error | %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) CreateClick(ctx context.Context, click Click) error {
+	query, args, err := s.queryBuilder.
+		Insert("clicks").
+		Columns("short_url", "clicked_at", "referrer", "referrer_host", "user_agent", "ip_hash", "country", "browser", "os").
+		Values(
+			click.ShortUrl,
+			click.ClickedAt.UTC().Format(time.RFC3339),
+			click.Referrer,
+			click.ReferrerHost,
+			click.UserAgent,
+			click.IPHash,
+			click.Country,
+			click.Browser,
+			click.OS,
+		).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("CreateClick query error | %w", err)
+	}
+
+	_, err = s.pgxPool.Exec(ctx, query, args...)
+
+	if err != nil {
+		return fmt.Errorf("CreateClick query error | %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetClickTotals(ctx context.Context, shortLink string, from time.Time, to time.Time) (int, int, error) {
+	var total, unique int
+
+	query, args, err := s.queryBuilder.
+		Select(
+			"count(*)",
+			"count(DISTINCT ip_hash)",
+		).
+		From("clicks").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Where(squirrel.GtOrEq{"clicked_at": from.UTC().Format(time.RFC3339)}).
+		Where(squirrel.Lt{"clicked_at": to.UTC().Format(time.RFC3339)}).
+		ToSql()
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("GetClickTotals query error | %w", err)
+	}
+
+	err = s.pgxPool.QueryRow(ctx, query, args...).Scan(&total, &unique)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("GetClickTotals query error | %w", err)
+	}
+
+	return total, unique, nil
+}
+
+// GetClickSeries groups clicks into buckets truncated by interval ("hour", "day" or "week").
+// Buckets without clicks are not returned.
+func (s *Storage) GetClickSeries(ctx context.Context, shortLink string, from time.Time, to time.Time, interval string) ([]ClickBucket, error) {
+	var buckets []ClickBucket
+
+	query, args, err := s.queryBuilder.
+		Select().
+		Column(squirrel.Expr("date_trunc(?, clicked_at) AS bucket", interval)).
+		Columns(
+			"count(*)",
+			"count(DISTINCT ip_hash)",
+		).
+		From("clicks").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Where(squirrel.GtOrEq{"clicked_at": from.UTC().Format(time.RFC3339)}).
+		Where(squirrel.Lt{"clicked_at": to.UTC().Format(time.RFC3339)}).
+		GroupBy("bucket").
+		OrderBy("bucket").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetClickSeries query error | %w", err)
+	}
+
+	rows, err := s.pgxPool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetClickSeries query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket ClickBucket
+
+		err = rows.Scan(
+			&bucket.Start,
+			&bucket.Clicks,
+			&bucket.UniqueClicks,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetClickSeries scan error | %w", err)
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, nil
+}
+
+// GetTopClickValues returns the most frequent values of one of the ClickDimension* columns.
+func (s *Storage) GetTopClickValues(ctx context.Context, shortLink string, dimension string, from time.Time, to time.Time, limit int) ([]ClickCount, error) {
+	var counts []ClickCount
+
+	switch dimension {
+	case ClickDimensionReferrer, ClickDimensionCountry, ClickDimensionBrowser, ClickDimensionOS:
+	default:
+		return nil, fmt.Errorf("GetTopClickValues: unknown dimension %s", dimension)
+	}
+
+	query, args, err := s.queryBuilder.
+		Select(
+			dimension,
+			"count(*) AS clicks",
+		).
+		From("clicks").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Where(squirrel.GtOrEq{"clicked_at": from.UTC().Format(time.RFC3339)}).
+		Where(squirrel.Lt{"clicked_at": to.UTC().Format(time.RFC3339)}).
+		GroupBy(dimension).
+		OrderBy("clicks DESC", dimension).
+		Limit(uint64(limit)).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetTopClickValues query error | %w", err)
+	}
+
+	rows, err := s.pgxPool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetTopClickValues query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var count ClickCount
+
+		err = rows.Scan(
+			&count.Value,
+			&count.Clicks,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetTopClickValues scan error | %w", err)
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+func (s *Storage) CreateClicks(ctx context.Context, clicks []Click) (int64, error) {
+	columns := []string{"short_url", "clicked_at", "referrer", "referrer_host", "user_agent", "ip_hash", "country", "browser", "os"}
+
+	written, err := s.pgxPool.CopyFrom(ctx, pgx.Identifier{"clicks"}, columns, pgx.CopyFromSlice(len(clicks), func(i int) ([]interface{}, error) {
+		click := clicks[i]
+
+		return []interface{}{
+			click.ShortUrl,
+			click.ClickedAt.UTC(),
+			click.Referrer,
+			click.ReferrerHost,
+			click.UserAgent,
+			click.IPHash,
+			click.Country,
+			click.Browser,
+			click.OS,
+		}, nil
+	}))
+
+	if err != nil {
+		return 0, fmt.Errorf("CreateClicks copy error | %w", err)
+	}
+
+	return written, nil
+}
@@ -6,6 +6,39 @@ type User struct {
 	Email        string
 	PasswordHash string
 	UrlsLeft     int
+	// Disabled users can't log in
+	Disabled bool
+}
+
+// UserSummary is what the admin console shows of a user
+type UserSummary struct {
+	Email     string
+	UrlsLeft  int
+	Role      string
+	Disabled  bool
+	CreatedAt time.Time
+	// Links is how many short links the user has
+	Links int
+}
+
+// Role is a named set of permissions, every user has exactly one
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+// ApiToken lets scripts call the API on behalf of the user, only a hash of the token itself is stored
+type ApiToken struct {
+	Id        int
+	UserEmail string
+	Name      string
+	// Prefix is the start of the token, shown so that the user can tell their tokens apart
+	Prefix    string
+	Scopes    []string
+	CreatedAt time.Time
+	// ExpiresAt is nil for the tokens that never expire
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
 }
 
 type Link struct {
@@ -13,10 +46,165 @@ type Link struct {
 	LongUrl   string
 	UserEmail string
 	ExpiresAt time.Time
+	// Disabled links are kept but don't redirect anymore, only admins can enable them again
+	Disabled bool
+	// Protected links ask visitors for a password before redirecting them
+	Protected bool
+	// ClicksLeft is how many more times the link redirects, nil for the links without a limit
+	ClicksLeft *int
+}
+
+// LinkOptions are set on a short link in the insert that creates it, so that it never exists without them.
+// The zero value makes a plain link.
+type LinkOptions struct {
+	// Password protects the link, it's stored as a bcrypt hash
+	Password string
+	// ClicksLeft limits how many times the link redirects, nil for no limit
+	ClicksLeft *int
+}
+
+// LinkChanges are applied to a short link together, the nil fields are left as they are.
+type LinkChanges struct {
+	LongUrl   *string
+	ExpiresAt *time.Time
+	// Password replaces the password of the link, an empty one removes the protection
+	Password *string
+	// ClicksLeft replaces the click limit of the link, 0 removes it
+	ClicksLeft *int
+}
+
+// SystemStats are the counts shown on the admin console
+type SystemStats struct {
+	Users         int
+	DisabledUsers int
+	Links         int
+	// ActiveLinks are the links that redirect, neither expired nor disabled
+	ActiveLinks         int
+	ExpiredLinks        int
+	DisabledLinks       int
+	Clicks              int
+	ActiveSubscriptions int
+	PaidOrders          int
 }
 
 type Subscription struct {
-	Id        int
-	Name      string
-	TotalUrls int
+	Id             int
+	Name           string
+	TotalUrls      int
+	MaxLinkTTLDays int
+	// RefundDeletedLinks gives the quota spent on a link back when it's deleted
+	RefundDeletedLinks bool
+	// Price is in the smallest currency unit, 0 means the subscription is not for sale
+	Price int
+	// PeriodDays is how long a bought subscription lasts, 0 stands for the service default.
+	// The quota is refilled with TotalUrls every month of it
+	PeriodDays int
+	// LinkTTLDays is the lifetime of new links, 0 stands for the service default
+	LinkTTLDays int
+	// MinAliasLength is the shortest custom alias allowed, 0 stands for the service default
+	MinAliasLength int
+}
+
+// User subscription statuses, a user has at most one active subscription
+const (
+	UserSubscriptionActive = "active"
+	// UserSubscriptionReplaced is a subscription the user changed before it ended
+	UserSubscriptionReplaced = "replaced"
+	UserSubscriptionExpired  = "expired"
+)
+
+// UserSubscription is a period the user is on a plan
+type UserSubscription struct {
+	Id             int
+	UserEmail      string
+	SubscriptionId int
+	Status         string
+	StartsAt       time.Time
+	EndsAt         time.Time
+	// NextResetAt is when the quota is refilled next
+	NextResetAt time.Time
+}
+
+// Order statuses, an order leaves OrderPending once and for all
+const (
+	OrderPending = "pending"
+	OrderPaid    = "paid"
+	OrderFailed  = "failed"
+)
+
+// Order is a purchase of a subscription, the user is credited when it's paid
+type Order struct {
+	Id             string
+	UserEmail      string
+	SubscriptionId int
+	Amount         int
+	// Days is how long the subscription lasts once paid, unused days of the previous one included.
+	// An order of the plan the user is on renews it, the days are added to its end, see RenewalEnd
+	Days int
+	// ReplacesSubscriptionId is the user subscription the order was priced against, nil on the free tier.
+	// The order fails instead of being paid if the user is on another one by then, see OrderOutdated
+	ReplacesSubscriptionId *int
+	Status                 string
+	// PaymentId is the provider's id of the payment, known once the provider reports back
+	PaymentId string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LinkVersion is one change of a short link destination
+type LinkVersion struct {
+	Id         int
+	ShortUrl   string
+	OldLongUrl string
+	NewLongUrl string
+	ChangedBy  string
+	ChangedAt  time.Time
+}
+
+type Click struct {
+	ShortUrl     string
+	ClickedAt    time.Time
+	Referrer     string
+	ReferrerHost string
+	UserAgent    string
+	IPHash       string
+	Country      string
+	Browser      string
+	OS           string
+}
+
+// Click dimensions that can be aggregated with GetTopClickValues
+const (
+	ClickDimensionReferrer = "referrer_host"
+	ClickDimensionCountry  = "country"
+	ClickDimensionBrowser  = "browser"
+	ClickDimensionOS       = "os"
+)
+
+type ClickBucket struct {
+	Start  time.Time
+	Clicks int
+	// UniqueClicks counts visitor-days: visitors are told apart within a UTC day only,
+	// so a week bucket counts a visitor once for every day they came back
+	UniqueClicks int
+}
+
+type ClickCount struct {
+	Value  string
+	Clicks int
+}
+
+type LinkStats struct {
+	ShortUrl    string
+	From        time.Time
+	To          time.Time
+	Interval    string
+	TotalClicks int
+	// UniqueClicks counts visitor-days, a visitor is counted once for every UTC day of the range they clicked on
+	UniqueClicks int
+	Series       []ClickBucket
+	TopReferrers []ClickCount
+	TopCountries []ClickCount
+	TopBrowsers  []ClickCount
+	TopOSes      []ClickCount
 }
@@ -0,0 +1,28 @@
+package postgresDB
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+)
+
+// ErrAlreadyExists is returned when an insert hits a unique key, e.g. a taken short link.
+// Missing rows are reported as pgx.ErrNoRows by every storage.
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrQuotaExceeded is returned when the user has no short links left.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+const uniqueViolationCode = "23505"
+
+// uniqueViolation marks errors of inserts that lost to an existing row with ErrAlreadyExists.
+func uniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return fmt.Errorf("%w: %s", ErrAlreadyExists, pgErr.ConstraintName)
+	}
+
+	return err
+}
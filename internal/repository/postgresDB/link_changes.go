@@ -0,0 +1,50 @@
+package postgresDB
+
+import (
+	"context"
+	"fmt"
+)
+
+// linkChangesChannel carries the short links changed by any instance, see NotifyLinkChanged
+const linkChangesChannel = "link_changes"
+
+// NotifyLinkChanged tells every instance listening with ListenLinkChanges that the short link has changed.
+func (s *Storage) NotifyLinkChanged(ctx context.Context, shortLink string) error {
+	if _, err := s.pgxPool.Exec(ctx, "SELECT pg_notify($1, $2)", linkChangesChannel, shortLink); err != nil {
+		return fmt.Errorf("NotifyLinkChanged query error | %w", err)
+	}
+
+	return nil
+}
+
+// ListenLinkChanges calls changed with the short links NotifyLinkChanged is called with until ctx is done
+// or the connection breaks. Notifications sent before listening calls back are not delivered.
+func (s *Storage) ListenLinkChanges(ctx context.Context, listening func(), changed func(shortLink string)) error {
+	conn, err := s.pgxPool.Acquire(ctx)
+
+	if err != nil {
+		return fmt.Errorf("ListenLinkChanges acquire error | %w", err)
+	}
+
+	// the connection is left in the LISTEN state, so it's closed instead of going back to the pool
+	defer func() {
+		conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+linkChangesChannel); err != nil {
+		return fmt.Errorf("ListenLinkChanges query error | %w", err)
+	}
+
+	listening()
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+
+		if err != nil {
+			return fmt.Errorf("ListenLinkChanges wait error | %w", err)
+		}
+
+		changed(notification.Payload)
+	}
+}
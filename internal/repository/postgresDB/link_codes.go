@@ -0,0 +1,139 @@
+package postgresDB
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"time"
+)
+
+// NextLinkNumber hands out the next number of link_code_seq, every number once, starting with 1.
+func (s *Storage) NextLinkNumber(ctx context.Context) (int64, error) {
+	var n int64
+
+	err := s.pgxPool.QueryRow(ctx, "SELECT nextval('link_code_seq')").Scan(&n)
+
+	if err != nil {
+		return 0, fmt.Errorf("NextLinkNumber query error | %w", err)
+	}
+
+	return n, nil
+}
+
+// AddPoolCodes puts the codes into the pool, skipping the ones pooled or used by a link already.
+// It returns how many were added.
+func (s *Storage) AddPoolCodes(ctx context.Context, codes []string, now time.Time) (int, error) {
+	taken, err := s.takenCodes(ctx, codes)
+
+	if err != nil {
+		return 0, fmt.Errorf("AddPoolCodes query error | %w", err)
+	}
+
+	insert := s.queryBuilder.
+		Insert("link_code_pool").
+		Columns("code", "created_at").
+		Suffix("ON CONFLICT DO NOTHING")
+
+	free := 0
+
+	for _, code := range codes {
+		if !taken[code] {
+			insert = insert.Values(code, now.UTC())
+			free++
+		}
+	}
+
+	if free == 0 {
+		return 0, nil
+	}
+
+	query, args, err := insert.ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("AddPoolCodes query error | %w", err)
+	}
+
+	tag, err := s.pgxPool.Exec(ctx, query, args...)
+
+	if err != nil {
+		return 0, fmt.Errorf("AddPoolCodes query error | %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *Storage) takenCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	query, args, err := s.queryBuilder.
+		Select("short_url").
+		From("urls").
+		Where(squirrel.Eq{"short_url": codes}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pgxPool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	taken := make(map[string]bool)
+
+	for rows.Next() {
+		var code string
+
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+
+		taken[code] = true
+	}
+
+	return taken, rows.Err()
+}
+
+// ClaimPoolCode takes a code out of the pool, pgx.ErrNoRows if it's empty.
+// Concurrent claims skip the rows locked by each other instead of waiting for them.
+func (s *Storage) ClaimPoolCode(ctx context.Context) (string, error) {
+	query, args, err := s.queryBuilder.
+		Delete("link_code_pool").
+		Where("code = (SELECT code FROM link_code_pool LIMIT 1 FOR UPDATE SKIP LOCKED)").
+		Suffix("RETURNING code").
+		ToSql()
+
+	if err != nil {
+		return "", fmt.Errorf("ClaimPoolCode query error | %w", err)
+	}
+
+	var code string
+
+	if err := s.pgxPool.QueryRow(ctx, query, args...).Scan(&code); err != nil {
+		return "", fmt.Errorf("ClaimPoolCode query error | %w", err)
+	}
+
+	return code, nil
+}
+
+// CountPoolCodes is how many codes are left in the pool.
+func (s *Storage) CountPoolCodes(ctx context.Context) (int, error) {
+	query, args, err := s.queryBuilder.
+		Select("count(*)").
+		From("link_code_pool").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("CountPoolCodes query error | %w", err)
+	}
+
+	var count int
+
+	if err := s.pgxPool.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("CountPoolCodes query error | %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,110 @@
+package postgresDB
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashLinkPassword makes the password_hash of a link, nil when it has no password.
+func HashLinkPassword(password string) (*string, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash := string(hash)
+
+	return &passwordHash, nil
+}
+
+// SetLinkPassword protects the link with the password, stored as a bcrypt hash. An empty password removes the protection.
+func (s *Storage) SetLinkPassword(ctx context.Context, shortLink string, password string) (*Link, error) {
+	var passwordHash *string
+
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+		if err != nil {
+			return nil, fmt.Errorf("SetLinkPassword hash error | %w", err)
+		}
+
+		passwordHash = new(string)
+		*passwordHash = string(hash)
+	}
+
+	query, args, err := s.queryBuilder.
+		Update("urls").
+		Set("password_hash", passwordHash).
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("SetLinkPassword query error | %w", err)
+	}
+
+	var link Link
+
+	err = s.pgxPool.QueryRow(ctx, query, args...).Scan(
+		&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+
+	if err != nil {
+		return nil, fmt.Errorf("SetLinkPassword query error | %w", err)
+	}
+
+	return &link, nil
+}
+
+// GetLinkPasswordHash returns the bcrypt hash of the link password, empty for the links without one.
+func (s *Storage) GetLinkPasswordHash(ctx context.Context, shortLink string) (string, error) {
+	query, args, err := s.queryBuilder.
+		Select("password_hash").
+		From("urls").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		ToSql()
+
+	if err != nil {
+		return "", fmt.Errorf("GetLinkPasswordHash query error | %w", err)
+	}
+
+	var passwordHash *string
+
+	if err := s.pgxPool.QueryRow(ctx, query, args...).Scan(&passwordHash); err != nil {
+		return "", fmt.Errorf("GetLinkPasswordHash query error | %w", err)
+	}
+
+	if passwordHash == nil {
+		return "", nil
+	}
+
+	return *passwordHash, nil
+}
+
+// VerifyLinkPassword checks the password of a protected link, the links without one take any password.
+// It returns the hash the password was checked against, empty for the links without one.
+func (s *Storage) VerifyLinkPassword(ctx context.Context, shortLink string, password string) (string, error) {
+	passwordHash, err := s.GetLinkPasswordHash(ctx, shortLink)
+
+	if err != nil || passwordHash == "" {
+		return "", err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		return "", fmt.Errorf("VerifyLinkPassword query error | %w", err)
+	}
+
+	return passwordHash, nil
+}
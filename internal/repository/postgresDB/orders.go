@@ -0,0 +1,235 @@
+package postgresDB
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
+)
+
+var orderColumns = []string{
+	"id",
+	"user_email",
+	"subscription_id",
+	"amount",
+	"days",
+	"replaces_subscription_id",
+	"status",
+	"payment_id",
+	"created_at",
+	"updated_at",
+}
+
+func scanOrder(row pgx.Row) (*Order, error) {
+	var order Order
+
+	err := row.Scan(
+		&order.Id,
+		&order.UserEmail,
+		&order.SubscriptionId,
+		&order.Amount,
+		&order.Days,
+		&order.ReplacesSubscriptionId,
+		&order.Status,
+		&order.PaymentId,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// OrderOutdated tells whether the user moved to another subscription since the order was priced,
+// current is the id of their active subscription, nil on the free tier.
+func OrderOutdated(order *Order, current *int) bool {
+	if order.ReplacesSubscriptionId == nil || current == nil {
+		return order.ReplacesSubscriptionId != nil || current != nil
+	}
+
+	return *order.ReplacesSubscriptionId != *current
+}
+
+// CreateOrder fails the user's pending order, if any, in the same transaction: a user has one pending order
+// at a time, so that the unused part of their subscription isn't credited to several orders.
+func (s *Storage) CreateOrder(ctx context.Context, order Order) (*Order, error) {
+	tx, err := s.pgxPool.Begin(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrder begin error | %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	query, args, err := s.queryBuilder.
+		Update("orders").
+		Set("status", OrderFailed).
+		Set("updated_at", order.CreatedAt.UTC().Format(time.RFC3339)).
+		Where(squirrel.Eq{"user_email": order.UserEmail, "status": OrderPending}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrder query error | %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("CreateOrder query error | %w", err)
+	}
+
+	query, args, err = s.queryBuilder.
+		Insert("orders").
+		Columns(orderColumns...).
+		Values(
+			order.Id,
+			order.UserEmail,
+			order.SubscriptionId,
+			order.Amount,
+			order.Days,
+			order.ReplacesSubscriptionId,
+			order.Status,
+			order.PaymentId,
+			order.CreatedAt.UTC().Format(time.RFC3339),
+			order.UpdatedAt.UTC().Format(time.RFC3339),
+		).
+		Suffix("RETURNING " + strings.Join(orderColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrder query error | %w", err)
+	}
+
+	created, err := scanOrder(tx.QueryRow(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrder query error | %w", uniqueViolation(err))
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("CreateOrder commit error | %w", err)
+	}
+
+	return created, nil
+}
+
+func (s *Storage) GetOrder(ctx context.Context, orderId string) (*Order, error) {
+	query, args, err := s.queryBuilder.
+		Select(orderColumns...).
+		From("orders").
+		Where(squirrel.Eq{"id": orderId}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetOrder query error | %w", err)
+	}
+
+	order, err := scanOrder(s.pgxPool.QueryRow(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("GetOrder query error | %w", err)
+	}
+
+	return order, nil
+}
+
+// PayOrder marks a pending order paid and, in the same transaction, starts the subscription it's for,
+// see startUserSubscription. Orders that are not pending are returned as they are,
+// so a payment reported twice is credited once. An outdated order, see OrderOutdated, is failed instead.
+func (s *Storage) PayOrder(ctx context.Context, orderId string, paymentId string) (*Order, error) {
+	tx, err := s.pgxPool.Begin(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("PayOrder begin error | %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	order, closed, err := s.closeOrder(ctx, tx, orderId, paymentId, OrderPaid)
+
+	if err != nil || !closed {
+		return order, err
+	}
+
+	current, err := lockActiveUserSubscription(ctx, tx, s.queryBuilder, order.UserEmail)
+
+	if err != nil {
+		return nil, fmt.Errorf("PayOrder query error | %w", err)
+	}
+
+	if OrderOutdated(order, current) {
+		query, args, err := s.queryBuilder.
+			Update("orders").
+			Set("status", OrderFailed).
+			Where(squirrel.Eq{"id": order.Id}).
+			Suffix("RETURNING " + strings.Join(orderColumns, ", ")).
+			ToSql()
+
+		if err != nil {
+			return nil, fmt.Errorf("PayOrder query error | %w", err)
+		}
+
+		if order, err = scanOrder(tx.QueryRow(ctx, query, args...)); err != nil {
+			return nil, fmt.Errorf("PayOrder query error | %w", err)
+		}
+	} else {
+		renewed, err := renewUserSubscription(ctx, tx, s.queryBuilder, order.UserEmail, order.SubscriptionId, order.UpdatedAt, order.Days)
+
+		if err == nil && !renewed {
+			err = startUserSubscription(ctx, tx, s.queryBuilder, order.UserEmail, order.SubscriptionId, order.UpdatedAt, order.Days)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("PayOrder query error | %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("PayOrder commit error | %w", err)
+	}
+
+	return order, nil
+}
+
+// FailOrder marks a pending order failed, other orders are returned as they are.
+func (s *Storage) FailOrder(ctx context.Context, orderId string, paymentId string) (*Order, error) {
+	order, _, err := s.closeOrder(ctx, s.pgxPool, orderId, paymentId, OrderFailed)
+
+	return order, err
+}
+
+// closeOrder moves a pending order to the status and reports whether it did,
+// an order that is not pending is returned unchanged.
+func (s *Storage) closeOrder(ctx context.Context, q querier, orderId string, paymentId string, status string) (*Order, bool, error) {
+	query, args, err := s.queryBuilder.
+		Update("orders").
+		Set("status", status).
+		Set("payment_id", paymentId).
+		Set("updated_at", time.Now().UTC().Format(time.RFC3339)).
+		Where(squirrel.Eq{"id": orderId, "status": OrderPending}).
+		Suffix("RETURNING " + strings.Join(orderColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return nil, false, fmt.Errorf("closeOrder query error | %w", err)
+	}
+
+	order, err := scanOrder(q.QueryRow(ctx, query, args...))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// closed before or doesn't exist at all
+		order, err = s.GetOrder(ctx, orderId)
+
+		return order, false, err
+
+	case err != nil:
+		return nil, false, fmt.Errorf("closeOrder query error | %w", err)
+	}
+
+	return order, true, nil
+}
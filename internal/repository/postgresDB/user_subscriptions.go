@@ -0,0 +1,375 @@
+package postgresDB
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+var userSubscriptionColumns = []string{
+	"id",
+	"user_email",
+	"subscription_id",
+	"status",
+	"starts_at",
+	"ends_at",
+	"next_reset_at",
+}
+
+func scanUserSubscription(row pgx.Row) (*UserSubscription, error) {
+	var sub UserSubscription
+
+	err := row.Scan(
+		&sub.Id,
+		&sub.UserEmail,
+		&sub.SubscriptionId,
+		&sub.Status,
+		&sub.StartsAt,
+		&sub.EndsAt,
+		&sub.NextResetAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// RenewalEnd is when a subscription ending at endsAt ends once it's renewed at paidAt for the given number of days,
+// the days are added to the end of the subscription, or to paidAt if it has ended by then.
+func RenewalEnd(endsAt time.Time, paidAt time.Time, days int) time.Time {
+	if endsAt.Before(paidAt) {
+		endsAt = paidAt
+	}
+
+	return endsAt.AddDate(0, 0, days).UTC()
+}
+
+// NextQuotaReset is the first monthly anniversary of startsAt after now.
+func NextQuotaReset(startsAt time.Time, now time.Time) time.Time {
+	for months := 1; ; months++ {
+		if next := startsAt.AddDate(0, months, 0); next.After(now) {
+			return next
+		}
+	}
+}
+
+// GetActiveUserSubscription returns the user's current subscription period, pgx.ErrNoRows if they are on the free tier.
+func (s *Storage) GetActiveUserSubscription(ctx context.Context, email string) (*UserSubscription, error) {
+	query, args, err := s.queryBuilder.
+		Select(userSubscriptionColumns...).
+		From("user_subscriptions").
+		Where(squirrel.Eq{"user_email": email, "status": UserSubscriptionActive}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveUserSubscription query error | %w", err)
+	}
+
+	sub, err := scanUserSubscription(s.pgxPool.QueryRow(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveUserSubscription query error | %w", err)
+	}
+
+	return sub, nil
+}
+
+// lockActiveUserSubscription returns the id of the user's active subscription, nil on the free tier,
+// and keeps it from changing until the transaction ends.
+func lockActiveUserSubscription(ctx context.Context, tx pgx.Tx, queryBuilder squirrel.StatementBuilderType, email string) (*int, error) {
+	query, args, err := queryBuilder.
+		Select("id").
+		From("user_subscriptions").
+		Where(squirrel.Eq{"user_email": email, "status": UserSubscriptionActive}).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&id)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil
+
+	case err != nil:
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+// renewUserSubscription adds the days to the user's active subscription if it's of the plan,
+// and reports whether it did. The quota is left as it is, it's refilled monthly anyway.
+func renewUserSubscription(ctx context.Context, tx pgx.Tx, queryBuilder squirrel.StatementBuilderType, email string, subscriptionId int, paidAt time.Time, days int) (bool, error) {
+	query, args, err := queryBuilder.
+		Select("ends_at").
+		From("user_subscriptions").
+		Where(squirrel.Eq{"user_email": email, "subscription_id": subscriptionId, "status": UserSubscriptionActive}).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var endsAt time.Time
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&endsAt)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return false, nil
+
+	case err != nil:
+		return false, err
+	}
+
+	query, args, err = queryBuilder.
+		Update("user_subscriptions").
+		Set("ends_at", RenewalEnd(endsAt, paidAt, days).Format(time.RFC3339)).
+		Where(squirrel.Eq{"user_email": email, "status": UserSubscriptionActive}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// startUserSubscription replaces the user's current subscription, if any, with a new one
+// lasting the given number of days, and refills their quota with the links of the new plan.
+func startUserSubscription(ctx context.Context, tx pgx.Tx, queryBuilder squirrel.StatementBuilderType, email string, subscriptionId int, startsAt time.Time, days int) error {
+	query, args, err := queryBuilder.
+		Update("user_subscriptions").
+		Set("status", UserSubscriptionReplaced).
+		Set("ends_at", startsAt.UTC().Format(time.RFC3339)).
+		Where(squirrel.Eq{"user_email": email, "status": UserSubscriptionActive}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query, args, err = queryBuilder.
+		Insert("user_subscriptions").
+		Columns("user_email", "subscription_id", "status", "starts_at", "ends_at", "next_reset_at").
+		Values(
+			email,
+			subscriptionId,
+			UserSubscriptionActive,
+			startsAt.UTC().Format(time.RFC3339),
+			startsAt.AddDate(0, 0, days).UTC().Format(time.RFC3339),
+			NextQuotaReset(startsAt, startsAt).UTC().Format(time.RFC3339),
+		).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query, args, err = queryBuilder.
+		Update("users").
+		Set("urls_left", squirrel.Expr("(SELECT total_urls FROM subscriptions WHERE id = ?)", subscriptionId)).
+		Set("subscription_id", subscriptionId).
+		Where(squirrel.Eq{"email": email}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+
+	return err
+}
+
+// ResetQuotas refills the quota of every active subscription whose reset is due and returns how many were reset.
+// Quotas that were due several times, e.g. while the job wasn't running, are reset once.
+func (s *Storage) ResetQuotas(ctx context.Context, now time.Time) (int, error) {
+	tx, err := s.pgxPool.Begin(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("ResetQuotas begin error | %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	query, args, err := s.queryBuilder.
+		Select("us.id", "us.user_email", "us.starts_at", "s.total_urls").
+		From("user_subscriptions us").
+		Join("subscriptions s ON s.id = us.subscription_id").
+		Where(squirrel.Eq{"us.status": UserSubscriptionActive}).
+		Where(squirrel.LtOrEq{"us.next_reset_at": now.UTC().Format(time.RFC3339)}).
+		Where(squirrel.Gt{"us.ends_at": now.UTC().Format(time.RFC3339)}).
+		Suffix("FOR UPDATE OF us").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+	}
+
+	type dueReset struct {
+		id        int
+		email     string
+		startsAt  time.Time
+		totalUrls int
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+
+	if err != nil {
+		return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+	}
+
+	var due []dueReset
+
+	for rows.Next() {
+		var r dueReset
+
+		if err = rows.Scan(&r.id, &r.email, &r.startsAt, &r.totalUrls); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ResetQuotas scan error | %w", err)
+		}
+
+		due = append(due, r)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+	}
+
+	for _, r := range due {
+		query, args, err = s.queryBuilder.
+			Update("user_subscriptions").
+			Set("next_reset_at", NextQuotaReset(r.startsAt, now).UTC().Format(time.RFC3339)).
+			Where(squirrel.Eq{"id": r.id}).
+			ToSql()
+
+		if err != nil {
+			return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+		}
+
+		query, args, err = s.queryBuilder.
+			Update("users").
+			Set("urls_left", r.totalUrls).
+			Where(squirrel.Eq{"email": r.email}).
+			ToSql()
+
+		if err != nil {
+			return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("ResetQuotas commit error | %w", err)
+	}
+
+	return len(due), nil
+}
+
+// ExpireUserSubscriptions ends the subscriptions that ran out by now and moves their users to the free tier:
+// they are left with no plan and at most freeQuota links. Returns how many subscriptions expired.
+func (s *Storage) ExpireUserSubscriptions(ctx context.Context, now time.Time, freeQuota int) (int, error) {
+	tx, err := s.pgxPool.Begin(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions begin error | %w", err)
+	}
+
+	defer tx.Rollback(ctx)
+
+	query, args, err := s.queryBuilder.
+		Update("user_subscriptions").
+		Set("status", UserSubscriptionExpired).
+		Where(squirrel.Eq{"status": UserSubscriptionActive}).
+		Where(squirrel.LtOrEq{"ends_at": now.UTC().Format(time.RFC3339)}).
+		Suffix("RETURNING user_email").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+
+	if err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	var emails []string
+
+	for rows.Next() {
+		var email string
+
+		if err = rows.Scan(&email); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ExpireUserSubscriptions scan error | %w", err)
+		}
+
+		emails = append(emails, email)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	if len(emails) == 0 {
+		return 0, nil
+	}
+
+	query, args, err = s.queryBuilder.
+		Update("users").
+		Set("subscription_id", nil).
+		Set("urls_left", squirrel.Expr("LEAST(urls_left, ?)", freeQuota)).
+		Where(squirrel.Eq{"email": emails}).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions commit error | %w", err)
+	}
+
+	return len(emails), nil
+}
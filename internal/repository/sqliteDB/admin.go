@@ -0,0 +1,219 @@
+package sqliteDB
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"strings"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+// containsPattern matches values containing query with LIKE, the wildcards in query are taken literally.
+// SQLite's LIKE ignores the case of ASCII letters like ILIKE does.
+func containsPattern(query string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+}
+
+// SearchUsers returns the users whose email contains query, the newest first.
+func (s *Storage) SearchUsers(ctx context.Context, query string, offset int, limit int) ([]postgresDB.UserSummary, error) {
+	var users []postgresDB.UserSummary
+
+	builder := s.queryBuilder.
+		Select(
+			"u.email",
+			"u.urls_left",
+			"u.role",
+			"u.disabled",
+			"u.created_at",
+			"(SELECT count(*) FROM urls l WHERE l.user_email = u.email)",
+		).
+		From("users u").
+		OrderBy("u.created_at DESC", "u.email").
+		Offset(uint64(offset)).
+		Limit(uint64(limit))
+
+	if query != "" {
+		builder = builder.Where("u.email LIKE ? ESCAPE '\\'", containsPattern(query))
+	}
+
+	sql, args, err := builder.ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("SearchUsers query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, sql, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("SearchUsers query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var user postgresDB.UserSummary
+
+		err = rows.Scan(&user.Email, &user.UrlsLeft, &user.Role, &user.Disabled, &user.CreatedAt, &user.Links)
+
+		if err != nil {
+			return nil, fmt.Errorf("SearchUsers scan error | %w", err)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// SetUserDisabled disables or enables the user, pgx.ErrNoRows if there is no such user.
+func (s *Storage) SetUserDisabled(ctx context.Context, email string, disabled bool) error {
+	query, args, err := s.queryBuilder.
+		Update("users").
+		Set("disabled", disabled).
+		Where(squirrel.Eq{"email": email}).
+		Suffix("RETURNING email").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("SetUserDisabled query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&email)
+
+	if err != nil {
+		return fmt.Errorf("SetUserDisabled query error | %w", noRows(err))
+	}
+
+	return nil
+}
+
+// SearchLinks returns the links whose alias, destination or owner contains query, the newest first.
+func (s *Storage) SearchLinks(ctx context.Context, query string, offset int, limit int) ([]postgresDB.Link, error) {
+	var links []postgresDB.Link
+
+	builder := s.queryBuilder.
+		Select(
+			"short_url",
+			"long_url",
+			"user_email",
+			"expires_at",
+			"disabled",
+			"password_hash IS NOT NULL",
+			"clicks_left",
+		).
+		From("urls").
+		OrderBy("created_at DESC", "short_url").
+		Offset(uint64(offset)).
+		Limit(uint64(limit))
+
+	if query != "" {
+		pattern := containsPattern(query)
+
+		builder = builder.Where(squirrel.Or{
+			squirrel.Expr("short_url LIKE ? ESCAPE '\\'", pattern),
+			squirrel.Expr("long_url LIKE ? ESCAPE '\\'", pattern),
+			squirrel.Expr("user_email LIKE ? ESCAPE '\\'", pattern),
+		})
+	}
+
+	sql, args, err := builder.ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("SearchLinks query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, sql, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("SearchLinks query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var link postgresDB.Link
+
+		err = rows.Scan(&link.ShortUrl, &link.LongUrl, &link.UserEmail, &link.ExpiresAt, &link.Disabled, &link.Protected, &link.ClicksLeft)
+
+		if err != nil {
+			return nil, fmt.Errorf("SearchLinks scan error | %w", err)
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// SetLinkDisabled disables or enables the short link, pgx.ErrNoRows if there is no such link.
+func (s *Storage) SetLinkDisabled(ctx context.Context, shortLink string, disabled bool) (*postgresDB.Link, error) {
+	var link postgresDB.Link
+
+	query, args, err := s.queryBuilder.
+		Update("urls").
+		Set("disabled", disabled).
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("SetLinkDisabled query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+
+	if err != nil {
+		return nil, fmt.Errorf("SetLinkDisabled query error | %w", noRows(err))
+	}
+
+	return &link, nil
+}
+
+// GetSystemStats counts the rows of the whole service, links expire as of now.
+func (s *Storage) GetSystemStats(ctx context.Context, now time.Time) (*postgresDB.SystemStats, error) {
+	var stats postgresDB.SystemStats
+
+	nowArg := now.UTC()
+
+	query, args, err := s.queryBuilder.
+		Select().
+		Column("(SELECT count(*) FROM users)").
+		Column("(SELECT count(*) FROM users WHERE disabled)").
+		Column("(SELECT count(*) FROM urls)").
+		Column("(SELECT count(*) FROM urls WHERE NOT disabled AND expires_at > ?)", nowArg).
+		Column("(SELECT count(*) FROM urls WHERE expires_at <= ?)", nowArg).
+		Column("(SELECT count(*) FROM urls WHERE disabled)").
+		Column("(SELECT count(*) FROM clicks)").
+		Column("(SELECT count(*) FROM user_subscriptions WHERE status = ?)", postgresDB.UserSubscriptionActive).
+		Column("(SELECT count(*) FROM orders WHERE status = ?)", postgresDB.OrderPaid).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetSystemStats query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(
+		&stats.Users,
+		&stats.DisabledUsers,
+		&stats.Links,
+		&stats.ActiveLinks,
+		&stats.ExpiredLinks,
+		&stats.DisabledLinks,
+		&stats.Clicks,
+		&stats.ActiveSubscriptions,
+		&stats.PaidOrders,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetSystemStats query error | %w", err)
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,189 @@
+package sqliteDB
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"strings"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+var apiTokenColumns = []string{
+	"id",
+	"user_email",
+	"name",
+	"prefix",
+	"scopes",
+	"created_at",
+	"expires_at",
+	"last_used_at",
+}
+
+func scanApiToken(row scanner) (*postgresDB.ApiToken, error) {
+	var (
+		token  postgresDB.ApiToken
+		scopes string
+	)
+
+	err := row.Scan(
+		&token.Id,
+		&token.UserEmail,
+		&token.Name,
+		&token.Prefix,
+		&scopes,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+	)
+
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	token.Scopes = strings.Fields(scopes)
+
+	return &token, nil
+}
+
+// nullableTime is written like the other timestamps, nil stays NULL.
+func nullableTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return t.UTC().Truncate(time.Second)
+}
+
+// CreateApiToken saves the token under its hash, the id is assigned by the database.
+func (s *Storage) CreateApiToken(ctx context.Context, token postgresDB.ApiToken, tokenHash string) (*postgresDB.ApiToken, error) {
+	query, args, err := s.queryBuilder.
+		Insert("api_tokens").
+		Columns("user_email", "name", "prefix", "token_hash", "scopes", "created_at", "expires_at").
+		Values(
+			token.UserEmail,
+			token.Name,
+			token.Prefix,
+			tokenHash,
+			strings.Join(token.Scopes, " "),
+			token.CreatedAt.UTC().Truncate(time.Second),
+			nullableTime(token.ExpiresAt),
+		).
+		Suffix("RETURNING " + strings.Join(apiTokenColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateApiToken query error | %w", err)
+	}
+
+	created, err := scanApiToken(s.db.QueryRowContext(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateApiToken query error | %w", uniqueViolation(err))
+	}
+
+	return created, nil
+}
+
+// GetApiTokens lists the tokens of the user, the newest first.
+func (s *Storage) GetApiTokens(ctx context.Context, email string) ([]postgresDB.ApiToken, error) {
+	query, args, err := s.queryBuilder.
+		Select(apiTokenColumns...).
+		From("api_tokens").
+		Where(squirrel.Eq{"user_email": email}).
+		OrderBy("id DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetApiTokens query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetApiTokens query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	tokens := []postgresDB.ApiToken{}
+
+	for rows.Next() {
+		token, err := scanApiToken(rows)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetApiTokens scan error | %w", err)
+		}
+
+		tokens = append(tokens, *token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetApiTokens query error | %w", err)
+	}
+
+	return tokens, nil
+}
+
+// GetApiTokenByHash finds the token a request was made with, pgx.ErrNoRows if there is none.
+func (s *Storage) GetApiTokenByHash(ctx context.Context, tokenHash string) (*postgresDB.ApiToken, error) {
+	query, args, err := s.queryBuilder.
+		Select(apiTokenColumns...).
+		From("api_tokens").
+		Where(squirrel.Eq{"token_hash": tokenHash}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetApiTokenByHash query error | %w", err)
+	}
+
+	token, err := scanApiToken(s.db.QueryRowContext(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("GetApiTokenByHash query error | %w", err)
+	}
+
+	return token, nil
+}
+
+// DeleteApiToken revokes the token of the user, pgx.ErrNoRows if the user has no such token.
+func (s *Storage) DeleteApiToken(ctx context.Context, id int, email string) error {
+	query, args, err := s.queryBuilder.
+		Delete("api_tokens").
+		Where(squirrel.Eq{"id": id, "user_email": email}).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("DeleteApiToken query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&id)
+
+	if err != nil {
+		return fmt.Errorf("DeleteApiToken query error | %w", noRows(err))
+	}
+
+	return nil
+}
+
+// TouchApiToken records when the token was last used.
+func (s *Storage) TouchApiToken(ctx context.Context, id int, usedAt time.Time) error {
+	query, args, err := s.queryBuilder.
+		Update("api_tokens").
+		Set("last_used_at", usedAt.UTC().Truncate(time.Second)).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("TouchApiToken query error | %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, query, args...)
+
+	if err != nil {
+		return fmt.Errorf("TouchApiToken query error | %w", err)
+	}
+
+	return nil
+}
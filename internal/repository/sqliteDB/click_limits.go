@@ -0,0 +1,73 @@
+package sqliteDB
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"urleater/internal/repository/postgresDB"
+)
+
+// SetLinkClicks lets the link redirect that many more times, nil removes the limit.
+func (s *Storage) SetLinkClicks(ctx context.Context, shortLink string, clicks *int) (*postgresDB.Link, error) {
+	query, args, err := s.queryBuilder.
+		Update("urls").
+		Set("clicks_left", clicks).
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("SetLinkClicks query error | %w", err)
+	}
+
+	var link postgresDB.Link
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(
+		&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+
+	if err != nil {
+		return nil, fmt.Errorf("SetLinkClicks query error | %w", noRows(err))
+	}
+
+	return &link, nil
+}
+
+// ConsumeLinkClick takes one click off a click-limited link. SQLite runs one write at a time,
+// so concurrent redirects never spend the same click twice. Links that are used up, have no limit
+// or don't exist give pgx.ErrNoRows.
+func (s *Storage) ConsumeLinkClick(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	query, args, err := s.queryBuilder.
+		Update("urls").
+		Set("clicks_left", squirrel.Expr("clicks_left - 1")).
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Where(squirrel.Gt{"clicks_left": 0}).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("ConsumeLinkClick query error | %w", err)
+	}
+
+	var link postgresDB.Link
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(
+		&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+
+	if err != nil {
+		return nil, fmt.Errorf("ConsumeLinkClick query error | %w", noRows(err))
+	}
+
+	return &link, nil
+}
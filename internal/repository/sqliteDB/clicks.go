@@ -0,0 +1,204 @@
+package sqliteDB
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+// bucketExpressions are the SQLite equivalents of Postgres date_trunc; weeks start on Monday.
+var bucketExpressions = map[string]string{
+	"hour": "strftime('%Y-%m-%d %H:00:00', clicked_at)",
+	"day":  "strftime('%Y-%m-%d 00:00:00', clicked_at)",
+	"week": "strftime('%Y-%m-%d 00:00:00', clicked_at, 'weekday 0', '-6 days')",
+}
+
+const bucketFormat = "2006-01-02 15:04:05"
+
+func (s *Storage) CreateClick(ctx context.Context, click postgresDB.Click) error {
+	_, err := s.CreateClicks(ctx, []postgresDB.Click{click})
+
+	return err
+}
+
+func (s *Storage) CreateClicks(ctx context.Context, clicks []postgresDB.Click) (int64, error) {
+	if len(clicks) == 0 {
+		return 0, nil
+	}
+
+	insert := s.queryBuilder.
+		Insert("clicks").
+		Columns("short_url", "clicked_at", "referrer", "referrer_host", "user_agent", "ip_hash", "country", "browser", "os")
+
+	for _, click := range clicks {
+		insert = insert.Values(
+			click.ShortUrl,
+			click.ClickedAt.UTC(),
+			click.Referrer,
+			click.ReferrerHost,
+			click.UserAgent,
+			click.IPHash,
+			click.Country,
+			click.Browser,
+			click.OS,
+		)
+	}
+
+	query, args, err := insert.ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("CreateClicks query error | %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+
+	if err != nil {
+		return 0, fmt.Errorf("CreateClicks query error | %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *Storage) GetClickTotals(ctx context.Context, shortLink string, from time.Time, to time.Time) (int, int, error) {
+	var total, unique int
+
+	query, args, err := s.queryBuilder.
+		Select(
+			"count(*)",
+			"count(DISTINCT ip_hash)",
+		).
+		From("clicks").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Where(squirrel.GtOrEq{"clicked_at": from.UTC()}).
+		Where(squirrel.Lt{"clicked_at": to.UTC()}).
+		ToSql()
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("GetClickTotals query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&total, &unique)
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("GetClickTotals query error | %w", err)
+	}
+
+	return total, unique, nil
+}
+
+func (s *Storage) GetClickSeries(ctx context.Context, shortLink string, from time.Time, to time.Time, interval string) ([]postgresDB.ClickBucket, error) {
+	var buckets []postgresDB.ClickBucket
+
+	bucketExpression, ok := bucketExpressions[interval]
+	if !ok {
+		return nil, fmt.Errorf("GetClickSeries: unsupported interval %s", interval)
+	}
+
+	query, args, err := s.queryBuilder.
+		Select(
+			bucketExpression+" AS bucket",
+			"count(*)",
+			"count(DISTINCT ip_hash)",
+		).
+		From("clicks").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Where(squirrel.GtOrEq{"clicked_at": from.UTC()}).
+		Where(squirrel.Lt{"clicked_at": to.UTC()}).
+		GroupBy("bucket").
+		OrderBy("bucket").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetClickSeries query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetClickSeries query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			bucket postgresDB.ClickBucket
+			start  string
+		)
+
+		err = rows.Scan(
+			&start,
+			&bucket.Clicks,
+			&bucket.UniqueClicks,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetClickSeries scan error | %w", err)
+		}
+
+		bucket.Start, err = time.Parse(bucketFormat, start)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetClickSeries scan error | %w", err)
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, rows.Err()
+}
+
+func (s *Storage) GetTopClickValues(ctx context.Context, shortLink string, dimension string, from time.Time, to time.Time, limit int) ([]postgresDB.ClickCount, error) {
+	var counts []postgresDB.ClickCount
+
+	switch dimension {
+	case postgresDB.ClickDimensionReferrer, postgresDB.ClickDimensionCountry, postgresDB.ClickDimensionBrowser, postgresDB.ClickDimensionOS:
+	default:
+		return nil, fmt.Errorf("GetTopClickValues: unknown dimension %s", dimension)
+	}
+
+	query, args, err := s.queryBuilder.
+		Select(
+			dimension,
+			"count(*) AS clicks",
+		).
+		From("clicks").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Where(squirrel.GtOrEq{"clicked_at": from.UTC()}).
+		Where(squirrel.Lt{"clicked_at": to.UTC()}).
+		GroupBy(dimension).
+		OrderBy("clicks DESC", dimension).
+		Limit(uint64(limit)).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetTopClickValues query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetTopClickValues query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var count postgresDB.ClickCount
+
+		err = rows.Scan(
+			&count.Value,
+			&count.Clicks,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetTopClickValues scan error | %w", err)
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,568 @@
+package sqliteDB
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"golang.org/x/crypto/bcrypt"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Storage is the SQLite counterpart of postgresDB.Storage for single-node deployments.
+// Missing rows are reported as pgx.ErrNoRows, which is what the service checks for.
+type Storage struct {
+	db           *sql.DB
+	queryBuilder squirrel.StatementBuilderType
+}
+
+func NewStorage(db *sql.DB) *Storage {
+	return &Storage{
+		db:           db,
+		queryBuilder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Question),
+	}
+}
+
+// noRows translates database/sql's own "not found" into the error the service expects.
+func noRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+
+	return err
+}
+
+// uniqueViolation marks errors of inserts that lost to an existing row with postgresDB.ErrAlreadyExists.
+func uniqueViolation(err error) error {
+	var sqliteErr *sqlite.Error
+
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+			return fmt.Errorf("%w: %s", postgresDB.ErrAlreadyExists, sqliteErr.Error())
+		}
+	}
+
+	return err
+}
+
+func (s *Storage) CreateUser(ctx context.Context, email string, password string, urlsLeft int) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := s.queryBuilder.Insert("users").
+		Columns("email", "password_hash", "created_at", "urls_left").
+		Values(email, string(passwordHash), time.Now().UTC(), urlsLeft).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("CreateUser query error | %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("CreateUser query error | %w", uniqueViolation(err))
+	}
+
+	return nil
+}
+
+func (s *Storage) ChangePassword(ctx context.Context, email string, password string) error {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := s.queryBuilder.
+		Update("users").
+		Set("password_hash", string(passwordHash)).
+		Where(squirrel.Eq{"email": email}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("ChangePassword query error | %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("ChangePassword query error | %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetUser(ctx context.Context, email string) (*postgresDB.User, error) {
+	var user postgresDB.User
+
+	query, args, err := s.queryBuilder.
+		Select(
+			"email",
+			"password_hash",
+			"urls_left",
+			"disabled",
+		).
+		From("users").
+		Where(squirrel.Eq{"email": email}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUser query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&user.Email, &user.PasswordHash, &user.UrlsLeft, &user.Disabled)
+	if err != nil {
+		return nil, fmt.Errorf("GetUser query error | %w", noRows(err))
+	}
+
+	return &user, nil
+}
+
+func (s *Storage) VerifyUserPassword(ctx context.Context, email string, password string) error {
+	user, err := s.GetUser(ctx, email)
+
+	if err != nil {
+		return fmt.Errorf("VerifyUserPassword query error | %w", err)
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return fmt.Errorf("VerifyUserPassword query error | %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateUserLinks(ctx context.Context, email string, urlsDelta int) (*postgresDB.User, error) {
+	var user postgresDB.User
+
+	query, args, err := s.queryBuilder.
+		Update("users").
+		Set("urls_left", squirrel.Expr("max(urls_left + ?, 0)", urlsDelta)).
+		Where(squirrel.Eq{"email": email}).
+		Suffix("RETURNING email, password_hash, urls_left, disabled").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateUserLinks query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(
+		&user.Email,
+		&user.PasswordHash,
+		&user.UrlsLeft,
+		&user.Disabled,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateUserLinks query error | %w", noRows(err))
+	}
+
+	return &user, nil
+}
+
+func (s *Storage) CreateShortLink(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time) (*postgresDB.Link, error) {
+	return s.createShortLink(ctx, s.db, shortLink, longLink, userEmail, expiresAt, postgresDB.LinkOptions{})
+}
+
+// querier is implemented by both the database and its transactions.
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (s *Storage) createShortLink(ctx context.Context, q querier, shortLink string, longLink string, userEmail string, expiresAt time.Time, options postgresDB.LinkOptions) (*postgresDB.Link, error) {
+	var link postgresDB.Link
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	passwordHash, err := postgresDB.HashLinkPassword(options.Password)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLink hash error | %w", err)
+	}
+
+	query, args, err := s.queryBuilder.Insert("urls").
+		Columns("short_url", "long_url", "created_at", "user_email", "expires_at", "password_hash", "clicks_left").
+		Values(shortLink, longLink, now, userEmail, expiresAt.UTC().Truncate(time.Second), passwordHash, options.ClicksLeft).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLink query error | %w", err)
+	}
+
+	err = q.QueryRowContext(ctx, query, args...).Scan(
+		&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLink query error | %w", uniqueViolation(err))
+	}
+
+	return &link, nil
+}
+
+// CreateShortLinkWithQuota spends one of the user's links on a new short link with the options in one transaction,
+// the single connection serializes concurrent creations. Returns postgresDB.ErrQuotaExceeded when no links are left.
+func (s *Storage) CreateShortLinkWithQuota(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time, options postgresDB.LinkOptions) (*postgresDB.Link, error) {
+	var urlsLeft int
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota begin error | %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query, args, err := s.queryBuilder.
+		Select("urls_left").
+		From("users").
+		Where(squirrel.Eq{"email": userEmail}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota query error | %w", err)
+	}
+
+	if err = tx.QueryRowContext(ctx, query, args...).Scan(&urlsLeft); err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota query error | %w", noRows(err))
+	}
+
+	if urlsLeft <= 0 {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota: user %s: %w", userEmail, postgresDB.ErrQuotaExceeded)
+	}
+
+	query, args, err = s.queryBuilder.
+		Update("users").
+		Set("urls_left", squirrel.Expr("urls_left - 1")).
+		Where(squirrel.Eq{"email": userEmail}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota query error | %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota query error | %w", err)
+	}
+
+	link, err := s.createShortLink(ctx, tx, shortLink, longLink, userEmail, expiresAt, options)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("CreateShortLinkWithQuota commit error | %w", err)
+	}
+
+	return link, nil
+}
+
+func (s *Storage) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	var link postgresDB.Link
+
+	query, args, err := s.queryBuilder.
+		Select(
+			"short_url",
+			"long_url",
+			"user_email",
+			"expires_at",
+			"disabled",
+			"password_hash IS NOT NULL",
+			"clicks_left",
+		).
+		From("urls").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetShortLink query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetShortLink query error | %w", noRows(err))
+	}
+
+	return &link, nil
+}
+
+func (s *Storage) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email string, offset int, limit int) ([]postgresDB.Link, error) {
+	var links []postgresDB.Link
+
+	query, args, err := s.queryBuilder.
+		Select(
+			"short_url",
+			"long_url",
+			"user_email",
+			"expires_at",
+			"disabled",
+			"password_hash IS NOT NULL",
+			"clicks_left",
+		).
+		From("urls").
+		Where(squirrel.Eq{"user_email": email}).
+		OrderBy("created_at", "short_url").
+		Offset(uint64(offset)).
+		Limit(uint64(limit)).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetAllUserShortLinks query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetAllUserShortLinks query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var link postgresDB.Link
+
+		err = rows.Scan(
+			&link.ShortUrl,
+			&link.LongUrl,
+			&link.UserEmail,
+			&link.ExpiresAt,
+			&link.Disabled,
+			&link.Protected,
+			&link.ClicksLeft,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetAllUserShortLinks query error | %w", err)
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (s *Storage) DeleteShortLink(ctx context.Context, shortLink string, email string, quotaRefund int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("DeleteShortLink begin error | %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query, args, err := s.queryBuilder.
+		Delete("urls").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Where(squirrel.Eq{"user_email": email}).
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("DeleteShortLink query error | %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+
+	if err != nil {
+		return fmt.Errorf("DeleteShortLink query error | %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+
+	if err != nil {
+		return fmt.Errorf("DeleteShortLink query error | %w", err)
+	}
+
+	if deleted > 0 && quotaRefund > 0 {
+		query, args, err = s.queryBuilder.
+			Update("users").
+			Set("urls_left", squirrel.Expr("urls_left + ?", quotaRefund)).
+			Where(squirrel.Eq{"email": email}).
+			ToSql()
+
+		if err != nil {
+			return fmt.Errorf("DeleteShortLink query error | %w", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("DeleteShortLink query error | %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("DeleteShortLink commit error | %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ExtendShortLink(ctx context.Context, shortLink string, expiresAt time.Time) (*postgresDB.Link, error) {
+	var link postgresDB.Link
+
+	query, args, err := s.queryBuilder.
+		Update("urls").
+		Set("expires_at", expiresAt.UTC().Truncate(time.Second)).
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("ExtendShortLink query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+
+	if err != nil {
+		return nil, fmt.Errorf("ExtendShortLink query error | %w", noRows(err))
+	}
+
+	return &link, nil
+}
+
+// subscriptionColumns are the columns scanSubscription expects, prefixed with the table alias.
+func subscriptionColumns(prefix string) []string {
+	columns := []string{
+		"id",
+		"name",
+		"total_urls",
+		"max_link_ttl_days",
+		"refund_deleted_links",
+		"price",
+		"period_days",
+		"link_ttl_days",
+		"min_alias_length",
+	}
+
+	for i := range columns {
+		columns[i] = prefix + columns[i]
+	}
+
+	return columns
+}
+
+func scanSubscription(row scanner) (*postgresDB.Subscription, error) {
+	var sub postgresDB.Subscription
+
+	err := row.Scan(
+		&sub.Id,
+		&sub.Name,
+		&sub.TotalUrls,
+		&sub.MaxLinkTTLDays,
+		&sub.RefundDeletedLinks,
+		&sub.Price,
+		&sub.PeriodDays,
+		&sub.LinkTTLDays,
+		&sub.MinAliasLength,
+	)
+
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	return &sub, nil
+}
+
+func (s *Storage) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscription, error) {
+	var subscriptions []postgresDB.Subscription
+
+	query, args, err := s.queryBuilder.
+		Select(subscriptionColumns("")...).
+		From("subscriptions").
+		OrderBy("id").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetSubscriptions query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetSubscriptions query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetSubscriptions scan error | %w", err)
+		}
+
+		subscriptions = append(subscriptions, *sub)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// GetUserSubscription returns the plan the user is on, pgx.ErrNoRows if there is no such user or they have none.
+func (s *Storage) GetUserSubscription(ctx context.Context, email string) (*postgresDB.Subscription, error) {
+	query, args, err := s.queryBuilder.
+		Select(subscriptionColumns("s.")...).
+		From("users u").
+		Join("subscriptions s ON s.id = u.subscription_id").
+		Where(squirrel.Eq{"u.email": email}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUserSubscription query error | %w", err)
+	}
+
+	sub, err := scanSubscription(s.db.QueryRowContext(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUserSubscription query error | %w", err)
+	}
+
+	return sub, nil
+}
+
+// SetUserSubscription puts the user on a plan, pgx.ErrNoRows if either of them doesn't exist.
+func (s *Storage) SetUserSubscription(ctx context.Context, email string, subscriptionId int) error {
+	query, args, err := s.queryBuilder.
+		Update("users").
+		Set("subscription_id", subscriptionId).
+		Where(squirrel.Eq{"email": email}).
+		Where(squirrel.Expr("EXISTS (SELECT 1 FROM subscriptions WHERE id = ?)", subscriptionId)).
+		Suffix("RETURNING email").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("SetUserSubscription query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&email)
+
+	if err != nil {
+		return fmt.Errorf("SetUserSubscription query error | %w", noRows(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package sqliteDB
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Open opens (creating if needed) the database file and brings its schema up to date.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	// _time_format=sqlite stores time.Time in a format the SQLite date functions understand
+	dsn := fmt.Sprintf("file:%s?_time_format=sqlite&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("could not open sqlite database %s: %w", path, err)
+	}
+
+	// SQLite has a single writer anyway, one connection keeps transactions from hitting SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err = migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// migrate applies the embedded *.up.sql files that are not recorded in schema_migrations yet, in version order.
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		applied_at timestamp NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("could not create schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.up.sql")
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+		var version int64
+
+		name := strings.TrimPrefix(file, "migrations/")
+
+		if _, err = fmt.Sscanf(name, "%d_", &version); err != nil {
+			return fmt.Errorf("invalid migration name %s: %w", name, err)
+		}
+
+		var applied int
+
+		err = db.QueryRowContext(ctx, "SELECT count(*) FROM schema_migrations WHERE version = ?", version).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("could not check migration %d: %w", version, err)
+		}
+
+		if applied > 0 {
+			continue
+		}
+
+		body, err := migrations.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if err = applyMigration(ctx, db, version, string(body)); err != nil {
+			return fmt.Errorf("could not apply migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, version int64, body string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)", version, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
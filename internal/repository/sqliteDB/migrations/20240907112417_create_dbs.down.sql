@@ -0,0 +1,3 @@
+DROP TABLE urls;
+DROP TABLE subscriptions;
+DROP TABLE users;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE clicks DROP COLUMN referrer_host;
+ALTER TABLE clicks DROP COLUMN country;
+ALTER TABLE clicks DROP COLUMN browser;
+ALTER TABLE clicks DROP COLUMN os;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE clicks ADD COLUMN referrer_host varchar NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN country varchar NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN browser varchar NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN os varchar NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN subscription_id;
+ALTER TABLE subscriptions DROP COLUMN max_link_ttl_days;
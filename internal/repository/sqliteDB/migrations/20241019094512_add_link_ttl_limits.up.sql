@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN max_link_ttl_days int NOT NULL DEFAULT 90;
+ALTER TABLE users ADD COLUMN subscription_id int REFERENCES subscriptions(id);
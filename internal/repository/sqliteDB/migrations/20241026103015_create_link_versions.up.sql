@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS link_versions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    short_url varchar NOT NULL REFERENCES urls(short_url) ON DELETE CASCADE,
+    old_long_url varchar NOT NULL,
+    new_long_url varchar NOT NULL,
+    changed_by varchar NOT NULL,
+    changed_at timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS link_versions_short_url_idx ON link_versions (short_url);
@@ -0,0 +1 @@
+ALTER TABLE subscriptions DROP COLUMN refund_deleted_links;
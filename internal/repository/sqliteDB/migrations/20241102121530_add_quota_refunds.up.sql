@@ -0,0 +1 @@
+ALTER TABLE subscriptions ADD COLUMN refund_deleted_links boolean NOT NULL DEFAULT false;
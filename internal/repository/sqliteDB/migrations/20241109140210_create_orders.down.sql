@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS orders;
+ALTER TABLE subscriptions DROP COLUMN price;
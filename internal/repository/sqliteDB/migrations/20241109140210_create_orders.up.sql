@@ -0,0 +1,14 @@
+ALTER TABLE subscriptions ADD COLUMN price int NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS orders (
+    id varchar PRIMARY KEY,
+    user_email varchar NOT NULL REFERENCES users(email) ON DELETE CASCADE,
+    subscription_id int NOT NULL REFERENCES subscriptions(id),
+    amount int NOT NULL,
+    status varchar NOT NULL,
+    payment_id varchar NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL,
+    updated_at timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS orders_user_email_idx ON orders (user_email);
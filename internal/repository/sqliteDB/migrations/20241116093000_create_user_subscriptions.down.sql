@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS user_subscriptions;
+
+ALTER TABLE orders DROP COLUMN days;
+
+ALTER TABLE subscriptions DROP COLUMN period_days;
+ALTER TABLE subscriptions DROP COLUMN link_ttl_days;
+ALTER TABLE subscriptions DROP COLUMN min_alias_length;
@@ -0,0 +1,34 @@
+ALTER TABLE subscriptions ADD COLUMN period_days int NOT NULL DEFAULT 30;
+ALTER TABLE subscriptions ADD COLUMN link_ttl_days int NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN min_alias_length int NOT NULL DEFAULT 0;
+
+ALTER TABLE orders ADD COLUMN days int NOT NULL DEFAULT 0;
+
+UPDATE orders
+SET days = (SELECT period_days FROM subscriptions WHERE id = orders.subscription_id);
+
+CREATE TABLE IF NOT EXISTS user_subscriptions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_email varchar NOT NULL REFERENCES users(email) ON DELETE CASCADE,
+    subscription_id int NOT NULL REFERENCES subscriptions(id),
+    status varchar NOT NULL,
+    starts_at timestamp NOT NULL,
+    ends_at timestamp NOT NULL,
+    next_reset_at timestamp NOT NULL
+);
+
+-- a user is on one plan at a time
+CREATE UNIQUE INDEX IF NOT EXISTS user_subscriptions_active_idx ON user_subscriptions (user_email) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS user_subscriptions_ends_at_idx ON user_subscriptions (ends_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS user_subscriptions_next_reset_at_idx ON user_subscriptions (next_reset_at) WHERE status = 'active';
+
+-- plans bought before are treated as bought now
+INSERT INTO user_subscriptions (user_email, subscription_id, status, starts_at, ends_at, next_reset_at)
+SELECT u.email,
+       s.id,
+       'active',
+       datetime('now'),
+       datetime('now', '+' || s.period_days || ' days'),
+       datetime('now', '+1 month')
+FROM users u
+         JOIN subscriptions s ON s.id = u.subscription_id;
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN role;
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name varchar PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role varchar NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission varchar NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT OR IGNORE INTO roles (name)
+VALUES ('user'),
+       ('support'),
+       ('admin');
+
+INSERT OR IGNORE INTO role_permissions (role, permission)
+VALUES ('support', 'users.view'),
+       ('admin', 'users.view'),
+       ('admin', 'users.manage_quota'),
+       ('admin', 'users.manage_roles');
+
+-- sqlite can't add a foreign key column with a default, the storage checks the role instead
+ALTER TABLE users ADD COLUMN role varchar NOT NULL DEFAULT 'user';
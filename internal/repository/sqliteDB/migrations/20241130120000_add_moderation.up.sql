@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN disabled boolean NOT NULL DEFAULT false;
+
+ALTER TABLE urls ADD COLUMN disabled boolean NOT NULL DEFAULT false;
+
+INSERT OR IGNORE INTO role_permissions (role, permission)
+VALUES ('support', 'links.view'),
+       ('admin', 'links.view'),
+       ('admin', 'links.moderate'),
+       ('admin', 'users.disable');
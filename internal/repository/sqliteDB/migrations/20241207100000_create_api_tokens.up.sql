@@ -0,0 +1,14 @@
+-- scopes are kept space separated, SQLite has no arrays
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_email varchar NOT NULL REFERENCES users(email) ON DELETE CASCADE,
+    name varchar NOT NULL,
+    prefix varchar NOT NULL,
+    token_hash varchar NOT NULL UNIQUE,
+    scopes varchar NOT NULL,
+    created_at timestamp NOT NULL,
+    expires_at timestamp,
+    last_used_at timestamp
+);
+
+CREATE INDEX IF NOT EXISTS api_tokens_user_email_idx ON api_tokens (user_email);
@@ -0,0 +1,6 @@
+-- SQLite has no sequences, the single row counts the numbers handed out instead
+CREATE TABLE IF NOT EXISTS link_code_seq (
+    value integer NOT NULL
+);
+
+INSERT INTO link_code_seq (value) SELECT 0 WHERE NOT EXISTS (SELECT 1 FROM link_code_seq);
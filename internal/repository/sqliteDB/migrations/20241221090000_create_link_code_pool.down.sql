@@ -0,0 +1 @@
+DROP TABLE IF EXISTS link_code_pool;
@@ -0,0 +1,2 @@
+-- links with a password_hash ask visitors for the password before redirecting them
+ALTER TABLE urls ADD COLUMN password_hash varchar;
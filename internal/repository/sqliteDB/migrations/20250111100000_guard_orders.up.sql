@@ -0,0 +1,15 @@
+-- the user subscription an order was priced against, an order is failed if the user is on another one once paid
+ALTER TABLE orders ADD COLUMN replaces_subscription_id integer;
+
+-- a user has one pending order at a time, the newest one is kept
+UPDATE orders
+SET status = 'failed',
+    updated_at = datetime('now')
+WHERE status = 'pending'
+  AND EXISTS (SELECT 1
+              FROM orders n
+              WHERE n.user_email = orders.user_email
+                AND n.status = 'pending'
+                AND (n.created_at, n.id) > (orders.created_at, orders.id));
+
+CREATE UNIQUE INDEX IF NOT EXISTS orders_pending_idx ON orders (user_email) WHERE status = 'pending';
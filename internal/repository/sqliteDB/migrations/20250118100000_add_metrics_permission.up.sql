@@ -0,0 +1,2 @@
+INSERT OR IGNORE INTO role_permissions (role, permission)
+VALUES ('admin', 'system.metrics');
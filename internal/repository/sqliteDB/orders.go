@@ -0,0 +1,235 @@
+package sqliteDB
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+var orderColumns = []string{
+	"id",
+	"user_email",
+	"subscription_id",
+	"amount",
+	"days",
+	"replaces_subscription_id",
+	"status",
+	"payment_id",
+	"created_at",
+	"updated_at",
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row scanner) (*postgresDB.Order, error) {
+	var order postgresDB.Order
+
+	err := row.Scan(
+		&order.Id,
+		&order.UserEmail,
+		&order.SubscriptionId,
+		&order.Amount,
+		&order.Days,
+		&order.ReplacesSubscriptionId,
+		&order.Status,
+		&order.PaymentId,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	return &order, nil
+}
+
+// CreateOrder fails the user's pending order, if any, in the same transaction: a user has one pending order
+// at a time, so that the unused part of their subscription isn't credited to several orders.
+func (s *Storage) CreateOrder(ctx context.Context, order postgresDB.Order) (*postgresDB.Order, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrder begin error | %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query, args, err := s.queryBuilder.
+		Update("orders").
+		Set("status", postgresDB.OrderFailed).
+		Set("updated_at", order.CreatedAt.UTC().Truncate(time.Second)).
+		Where(squirrel.Eq{"user_email": order.UserEmail, "status": postgresDB.OrderPending}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrder query error | %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("CreateOrder query error | %w", err)
+	}
+
+	query, args, err = s.queryBuilder.
+		Insert("orders").
+		Columns(orderColumns...).
+		Values(
+			order.Id,
+			order.UserEmail,
+			order.SubscriptionId,
+			order.Amount,
+			order.Days,
+			order.ReplacesSubscriptionId,
+			order.Status,
+			order.PaymentId,
+			order.CreatedAt.UTC().Truncate(time.Second),
+			order.UpdatedAt.UTC().Truncate(time.Second),
+		).
+		Suffix("RETURNING " + strings.Join(orderColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrder query error | %w", err)
+	}
+
+	created, err := scanOrder(tx.QueryRowContext(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("CreateOrder query error | %w", uniqueViolation(err))
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("CreateOrder commit error | %w", err)
+	}
+
+	return created, nil
+}
+
+func (s *Storage) GetOrder(ctx context.Context, orderId string) (*postgresDB.Order, error) {
+	return s.getOrder(ctx, s.db, orderId)
+}
+
+func (s *Storage) getOrder(ctx context.Context, q querier, orderId string) (*postgresDB.Order, error) {
+	query, args, err := s.queryBuilder.
+		Select(orderColumns...).
+		From("orders").
+		Where(squirrel.Eq{"id": orderId}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetOrder query error | %w", err)
+	}
+
+	order, err := scanOrder(q.QueryRowContext(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("GetOrder query error | %w", err)
+	}
+
+	return order, nil
+}
+
+// PayOrder marks a pending order paid and, in the same transaction, starts the subscription it's for,
+// see startUserSubscription. Orders that are not pending are returned as they are.
+// An outdated order, see postgresDB.OrderOutdated, is failed instead.
+func (s *Storage) PayOrder(ctx context.Context, orderId string, paymentId string) (*postgresDB.Order, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("PayOrder begin error | %w", err)
+	}
+
+	defer tx.Rollback()
+
+	order, closed, err := s.closeOrder(ctx, tx, orderId, paymentId, postgresDB.OrderPaid)
+
+	if err != nil || !closed {
+		return order, err
+	}
+
+	// the transaction holds the write lock since closeOrder, so the subscription can't change under it
+	current, err := s.activeUserSubscriptionId(ctx, tx, order.UserEmail)
+
+	if err != nil {
+		return nil, fmt.Errorf("PayOrder query error | %w", err)
+	}
+
+	if postgresDB.OrderOutdated(order, current) {
+		query, args, err := s.queryBuilder.
+			Update("orders").
+			Set("status", postgresDB.OrderFailed).
+			Where(squirrel.Eq{"id": order.Id}).
+			Suffix("RETURNING " + strings.Join(orderColumns, ", ")).
+			ToSql()
+
+		if err != nil {
+			return nil, fmt.Errorf("PayOrder query error | %w", err)
+		}
+
+		if order, err = scanOrder(tx.QueryRowContext(ctx, query, args...)); err != nil {
+			return nil, fmt.Errorf("PayOrder query error | %w", err)
+		}
+	} else {
+		renewed, err := s.renewUserSubscription(ctx, tx, order.UserEmail, order.SubscriptionId, order.UpdatedAt, order.Days)
+
+		if err == nil && !renewed {
+			err = s.startUserSubscription(ctx, tx, order.UserEmail, order.SubscriptionId, order.UpdatedAt, order.Days)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("PayOrder query error | %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("PayOrder commit error | %w", err)
+	}
+
+	return order, nil
+}
+
+// FailOrder marks a pending order failed, other orders are returned as they are.
+func (s *Storage) FailOrder(ctx context.Context, orderId string, paymentId string) (*postgresDB.Order, error) {
+	order, _, err := s.closeOrder(ctx, s.db, orderId, paymentId, postgresDB.OrderFailed)
+
+	return order, err
+}
+
+// closeOrder moves a pending order to the status and reports whether it did,
+// an order that is not pending is returned unchanged.
+func (s *Storage) closeOrder(ctx context.Context, q querier, orderId string, paymentId string, status string) (*postgresDB.Order, bool, error) {
+	query, args, err := s.queryBuilder.
+		Update("orders").
+		Set("status", status).
+		Set("payment_id", paymentId).
+		Set("updated_at", time.Now().UTC().Truncate(time.Second)).
+		Where(squirrel.Eq{"id": orderId, "status": postgresDB.OrderPending}).
+		Suffix("RETURNING " + strings.Join(orderColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		return nil, false, fmt.Errorf("closeOrder query error | %w", err)
+	}
+
+	order, err := scanOrder(q.QueryRowContext(ctx, query, args...))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// closed before or doesn't exist at all, the single connection is taken by the transaction
+		order, err = s.getOrder(ctx, q, orderId)
+
+		return order, false, err
+
+	case err != nil:
+		return nil, false, fmt.Errorf("closeOrder query error | %w", err)
+	}
+
+	return order, true, nil
+}
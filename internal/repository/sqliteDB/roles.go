@@ -0,0 +1,132 @@
+package sqliteDB
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"urleater/internal/repository/postgresDB"
+)
+
+// GetRoles returns every role with its permissions, ordered by name.
+func (s *Storage) GetRoles(ctx context.Context) ([]postgresDB.Role, error) {
+	query, args, err := s.queryBuilder.
+		Select("r.name", "rp.permission").
+		From("roles r").
+		LeftJoin("role_permissions rp ON rp.role = r.name").
+		OrderBy("r.name", "rp.permission").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetRoles query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetRoles query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	var roles []postgresDB.Role
+
+	for rows.Next() {
+		var (
+			name       string
+			permission *string
+		)
+
+		if err := rows.Scan(&name, &permission); err != nil {
+			return nil, fmt.Errorf("GetRoles scan error | %w", err)
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].Name != name {
+			roles = append(roles, postgresDB.Role{Name: name, Permissions: []string{}})
+		}
+
+		if permission != nil {
+			roles[len(roles)-1].Permissions = append(roles[len(roles)-1].Permissions, *permission)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetRoles query error | %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetUserRole returns the role of the user with its permissions, pgx.ErrNoRows if there is no such user.
+func (s *Storage) GetUserRole(ctx context.Context, email string) (*postgresDB.Role, error) {
+	query, args, err := s.queryBuilder.
+		Select("u.role", "rp.permission").
+		From("users u").
+		LeftJoin("role_permissions rp ON rp.role = u.role").
+		Where(squirrel.Eq{"u.email": email}).
+		OrderBy("rp.permission").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUserRole query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUserRole query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	var role *postgresDB.Role
+
+	for rows.Next() {
+		var permission *string
+
+		if role == nil {
+			role = &postgresDB.Role{Permissions: []string{}}
+		}
+
+		if err := rows.Scan(&role.Name, &permission); err != nil {
+			return nil, fmt.Errorf("GetUserRole scan error | %w", err)
+		}
+
+		if permission != nil {
+			role.Permissions = append(role.Permissions, *permission)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetUserRole query error | %w", err)
+	}
+
+	if role == nil {
+		return nil, fmt.Errorf("GetUserRole query error | %w", pgx.ErrNoRows)
+	}
+
+	return role, nil
+}
+
+// SetUserRole gives the user the role, pgx.ErrNoRows if either of them doesn't exist.
+func (s *Storage) SetUserRole(ctx context.Context, email string, role string) error {
+	query, args, err := s.queryBuilder.
+		Update("users").
+		Set("role", role).
+		Where(squirrel.Eq{"email": email}).
+		Where(squirrel.Expr("EXISTS (SELECT 1 FROM roles WHERE name = ?)", role)).
+		Suffix("RETURNING email").
+		ToSql()
+
+	if err != nil {
+		return fmt.Errorf("SetUserRole query error | %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&email)
+
+	if err != nil {
+		return fmt.Errorf("SetUserRole query error | %w", noRows(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,355 @@
+package sqliteDB
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+var userSubscriptionColumns = []string{
+	"id",
+	"user_email",
+	"subscription_id",
+	"status",
+	"starts_at",
+	"ends_at",
+	"next_reset_at",
+}
+
+func scanUserSubscription(row scanner) (*postgresDB.UserSubscription, error) {
+	var sub postgresDB.UserSubscription
+
+	err := row.Scan(
+		&sub.Id,
+		&sub.UserEmail,
+		&sub.SubscriptionId,
+		&sub.Status,
+		&sub.StartsAt,
+		&sub.EndsAt,
+		&sub.NextResetAt,
+	)
+
+	if err != nil {
+		return nil, noRows(err)
+	}
+
+	return &sub, nil
+}
+
+// GetActiveUserSubscription returns the user's current subscription period, pgx.ErrNoRows if they are on the free tier.
+func (s *Storage) GetActiveUserSubscription(ctx context.Context, email string) (*postgresDB.UserSubscription, error) {
+	query, args, err := s.queryBuilder.
+		Select(userSubscriptionColumns...).
+		From("user_subscriptions").
+		Where(squirrel.Eq{"user_email": email, "status": postgresDB.UserSubscriptionActive}).
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveUserSubscription query error | %w", err)
+	}
+
+	sub, err := scanUserSubscription(s.db.QueryRowContext(ctx, query, args...))
+
+	if err != nil {
+		return nil, fmt.Errorf("GetActiveUserSubscription query error | %w", err)
+	}
+
+	return sub, nil
+}
+
+// activeUserSubscriptionId returns the id of the user's active subscription, nil on the free tier.
+func (s *Storage) activeUserSubscriptionId(ctx context.Context, tx *sql.Tx, email string) (*int, error) {
+	query, args, err := s.queryBuilder.
+		Select("id").
+		From("user_subscriptions").
+		Where(squirrel.Eq{"user_email": email, "status": postgresDB.UserSubscriptionActive}).
+		ToSql()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&id)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+
+	case err != nil:
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+// renewUserSubscription adds the days to the user's active subscription if it's of the plan,
+// and reports whether it did. The quota is left as it is, it's refilled monthly anyway.
+func (s *Storage) renewUserSubscription(ctx context.Context, tx *sql.Tx, email string, subscriptionId int, paidAt time.Time, days int) (bool, error) {
+	query, args, err := s.queryBuilder.
+		Select("ends_at").
+		From("user_subscriptions").
+		Where(squirrel.Eq{"user_email": email, "subscription_id": subscriptionId, "status": postgresDB.UserSubscriptionActive}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	var endsAt time.Time
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&endsAt)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+
+	case err != nil:
+		return false, err
+	}
+
+	query, args, err = s.queryBuilder.
+		Update("user_subscriptions").
+		Set("ends_at", postgresDB.RenewalEnd(endsAt, paidAt.UTC().Truncate(time.Second), days)).
+		Where(squirrel.Eq{"user_email": email, "status": postgresDB.UserSubscriptionActive}).
+		ToSql()
+
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// startUserSubscription replaces the user's current subscription, if any, with a new one
+// lasting the given number of days, and refills their quota with the links of the new plan.
+func (s *Storage) startUserSubscription(ctx context.Context, tx *sql.Tx, email string, subscriptionId int, startsAt time.Time, days int) error {
+	startsAt = startsAt.UTC().Truncate(time.Second)
+
+	query, args, err := s.queryBuilder.
+		Update("user_subscriptions").
+		Set("status", postgresDB.UserSubscriptionReplaced).
+		Set("ends_at", startsAt).
+		Where(squirrel.Eq{"user_email": email, "status": postgresDB.UserSubscriptionActive}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query, args, err = s.queryBuilder.
+		Insert("user_subscriptions").
+		Columns("user_email", "subscription_id", "status", "starts_at", "ends_at", "next_reset_at").
+		Values(
+			email,
+			subscriptionId,
+			postgresDB.UserSubscriptionActive,
+			startsAt,
+			startsAt.AddDate(0, 0, days),
+			postgresDB.NextQuotaReset(startsAt, startsAt),
+		).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query, args, err = s.queryBuilder.
+		Update("users").
+		Set("urls_left", squirrel.Expr("(SELECT total_urls FROM subscriptions WHERE id = ?)", subscriptionId)).
+		Set("subscription_id", subscriptionId).
+		Where(squirrel.Eq{"email": email}).
+		ToSql()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// ResetQuotas refills the quota of every active subscription whose reset is due and returns how many were reset.
+// Quotas that were due several times, e.g. while the job wasn't running, are reset once.
+func (s *Storage) ResetQuotas(ctx context.Context, now time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, fmt.Errorf("ResetQuotas begin error | %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query, args, err := s.queryBuilder.
+		Select("us.id", "us.user_email", "us.starts_at", "s.total_urls").
+		From("user_subscriptions us").
+		Join("subscriptions s ON s.id = us.subscription_id").
+		Where(squirrel.Eq{"us.status": postgresDB.UserSubscriptionActive}).
+		Where(squirrel.LtOrEq{"us.next_reset_at": now.UTC()}).
+		Where(squirrel.Gt{"us.ends_at": now.UTC()}).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+	}
+
+	type dueReset struct {
+		id        int
+		email     string
+		startsAt  time.Time
+		totalUrls int
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+	}
+
+	var due []dueReset
+
+	for rows.Next() {
+		var r dueReset
+
+		if err = rows.Scan(&r.id, &r.email, &r.startsAt, &r.totalUrls); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ResetQuotas scan error | %w", err)
+		}
+
+		due = append(due, r)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+	}
+
+	for _, r := range due {
+		query, args, err = s.queryBuilder.
+			Update("user_subscriptions").
+			Set("next_reset_at", postgresDB.NextQuotaReset(r.startsAt, now).UTC()).
+			Where(squirrel.Eq{"id": r.id}).
+			ToSql()
+
+		if err != nil {
+			return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+		}
+
+		query, args, err = s.queryBuilder.
+			Update("users").
+			Set("urls_left", r.totalUrls).
+			Where(squirrel.Eq{"email": r.email}).
+			ToSql()
+
+		if err != nil {
+			return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("ResetQuotas query error | %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ResetQuotas commit error | %w", err)
+	}
+
+	return len(due), nil
+}
+
+// ExpireUserSubscriptions ends the subscriptions that ran out by now and moves their users to the free tier:
+// they are left with no plan and at most freeQuota links. Returns how many subscriptions expired.
+func (s *Storage) ExpireUserSubscriptions(ctx context.Context, now time.Time, freeQuota int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions begin error | %w", err)
+	}
+
+	defer tx.Rollback()
+
+	query, args, err := s.queryBuilder.
+		Update("user_subscriptions").
+		Set("status", postgresDB.UserSubscriptionExpired).
+		Where(squirrel.Eq{"status": postgresDB.UserSubscriptionActive}).
+		Where(squirrel.LtOrEq{"ends_at": now.UTC()}).
+		Suffix("RETURNING user_email").
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	var emails []string
+
+	for rows.Next() {
+		var email string
+
+		if err = rows.Scan(&email); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ExpireUserSubscriptions scan error | %w", err)
+		}
+
+		emails = append(emails, email)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	if len(emails) == 0 {
+		return 0, nil
+	}
+
+	query, args, err = s.queryBuilder.
+		Update("users").
+		Set("subscription_id", nil).
+		Set("urls_left", squirrel.Expr("MIN(urls_left, ?)", freeQuota)).
+		Where(squirrel.Eq{"email": emails}).
+		ToSql()
+
+	if err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions query error | %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ExpireUserSubscriptions commit error | %w", err)
+	}
+
+	return len(emails), nil
+}
@@ -0,0 +1,165 @@
+package sqliteDB
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+func (s *Storage) UpdateShortLink(ctx context.Context, shortLink string, longLink string, changedBy string) (*postgresDB.Link, error) {
+	return s.UpdateLink(ctx, shortLink, postgresDB.LinkChanges{LongUrl: &longLink}, changedBy)
+}
+
+// UpdateLink applies all the changes to the link in one transaction, a new destination is recorded
+// as a link version. At least one change is expected.
+func (s *Storage) UpdateLink(ctx context.Context, shortLink string, changes postgresDB.LinkChanges, changedBy string) (*postgresDB.Link, error) {
+	var link postgresDB.Link
+
+	update := s.queryBuilder.
+		Update("urls").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		Suffix("RETURNING short_url, long_url, user_email, expires_at, disabled, password_hash IS NOT NULL, clicks_left")
+
+	if changes.LongUrl != nil {
+		update = update.Set("long_url", *changes.LongUrl)
+	}
+
+	if changes.ExpiresAt != nil {
+		update = update.Set("expires_at", changes.ExpiresAt.UTC().Truncate(time.Second))
+	}
+
+	if changes.Password != nil {
+		passwordHash, err := postgresDB.HashLinkPassword(*changes.Password)
+
+		if err != nil {
+			return nil, fmt.Errorf("UpdateLink hash error | %w", err)
+		}
+
+		update = update.Set("password_hash", passwordHash)
+	}
+
+	if changes.ClicksLeft != nil {
+		var clicksLeft *int
+
+		if *changes.ClicksLeft > 0 {
+			clicksLeft = changes.ClicksLeft
+		}
+
+		update = update.Set("clicks_left", clicksLeft)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateLink begin error | %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if changes.LongUrl != nil {
+		// the insert takes the write lock before the old destination is read
+		query, args, err := s.queryBuilder.
+			Insert("link_versions").
+			Columns("short_url", "old_long_url", "new_long_url", "changed_by", "changed_at").
+			Select(squirrel.
+				Select("short_url", "long_url").
+				Column("?", *changes.LongUrl).
+				Column("?", changedBy).
+				Column("?", time.Now().UTC().Truncate(time.Second)).
+				From("urls").
+				Where(squirrel.Eq{"short_url": shortLink})).
+			ToSql()
+
+		if err != nil {
+			return nil, fmt.Errorf("UpdateLink query error | %w", err)
+		}
+
+		res, err := tx.ExecContext(ctx, query, args...)
+
+		if err != nil {
+			return nil, fmt.Errorf("UpdateLink query error | %w", err)
+		}
+
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			return nil, fmt.Errorf("UpdateLink query error | %w", pgx.ErrNoRows)
+		}
+	}
+
+	query, args, err := update.ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateLink query error | %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&link.ShortUrl,
+		&link.LongUrl,
+		&link.UserEmail,
+		&link.ExpiresAt,
+		&link.Disabled,
+		&link.Protected,
+		&link.ClicksLeft)
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateLink query error | %w", noRows(err))
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("UpdateLink commit error | %w", err)
+	}
+
+	return &link, nil
+}
+
+func (s *Storage) GetLinkVersions(ctx context.Context, shortLink string) ([]postgresDB.LinkVersion, error) {
+	var versions []postgresDB.LinkVersion
+
+	query, args, err := s.queryBuilder.
+		Select(
+			"id",
+			"short_url",
+			"old_long_url",
+			"new_long_url",
+			"changed_by",
+			"changed_at",
+		).
+		From("link_versions").
+		Where(squirrel.Eq{"short_url": shortLink}).
+		OrderBy("id DESC").
+		ToSql()
+
+	if err != nil {
+		return nil, fmt.Errorf("GetLinkVersions query error | %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetLinkVersions query error | %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var version postgresDB.LinkVersion
+
+		err = rows.Scan(
+			&version.Id,
+			&version.ShortUrl,
+			&version.OldLongUrl,
+			&version.NewLongUrl,
+			&version.ChangedBy,
+			&version.ChangedAt,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetLinkVersions scan error | %w", err)
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
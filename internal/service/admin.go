@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+const (
+	// DefaultAdminPageSize is how many rows the admin console lists when no limit is given
+	DefaultAdminPageSize = 20
+	maxAdminPageSize     = 100
+)
+
+func adminPage(offset int, limit int) (int, error) {
+	if offset < 0 || limit < 0 {
+		return 0, newError(ErrInvalidInput, "offset and limit can't be negative")
+	}
+
+	if limit > maxAdminPageSize {
+		return 0, newError(ErrInvalidInput, "limit can't be over %d", maxAdminPageSize)
+	}
+
+	if limit == 0 {
+		limit = DefaultAdminPageSize
+	}
+
+	return limit, nil
+}
+
+// SearchUsers lists the users whose email contains query, all of them for an empty one.
+func (s *Service) SearchUsers(ctx context.Context, query string, offset int, limit int) ([]postgresDB.UserSummary, error) {
+	limit, err := adminPage(offset, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.storage.SearchUsers(ctx, strings.TrimSpace(query), offset, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("SearchUsers: could not search users by %q: %w", query, err)
+	}
+
+	return users, nil
+}
+
+// SetUserDisabled disables the account of the user or enables it back. Disabled users can't log in
+// and lose their permissions, their links keep working.
+func (s *Service) SetUserDisabled(ctx context.Context, email string, disabled bool, changedBy string) error {
+	if disabled && email == changedBy {
+		return newError(ErrInvalidInput, "you can't disable your own account")
+	}
+
+	err := s.storage.SetUserDisabled(ctx, email, disabled)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return newError(ErrNotFound, "user %s not found", email)
+
+	case err != nil:
+		return fmt.Errorf("SetUserDisabled: could not change user %s: %w", email, err)
+	}
+
+	return nil
+}
+
+// SetUserRole gives the user another role on behalf of an admin, who may not change their own one.
+func (s *Service) SetUserRole(ctx context.Context, email string, role string, changedBy string) error {
+	if strings.TrimSpace(email) == changedBy {
+		return newError(ErrInvalidInput, "you can't change your own role")
+	}
+
+	return s.GrantRole(ctx, email, role)
+}
+
+// SearchLinks lists the links whose alias, destination or owner contains query, all of them for an empty one.
+func (s *Service) SearchLinks(ctx context.Context, query string, offset int, limit int) ([]postgresDB.Link, error) {
+	limit, err := adminPage(offset, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.storage.SearchLinks(ctx, strings.TrimSpace(query), offset, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("SearchLinks: could not search links by %q: %w", query, err)
+	}
+
+	return links, nil
+}
+
+// SetLinkDisabled stops the short link from redirecting without deleting it, or lets it redirect again.
+func (s *Service) SetLinkDisabled(ctx context.Context, shortLink string, disabled bool) (*postgresDB.Link, error) {
+	link, err := s.storage.SetLinkDisabled(ctx, shortLink, disabled)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, fmt.Errorf("SetLinkDisabled: could not change short link %s: %w", shortLink, err)
+	}
+
+	return link, nil
+}
+
+func (s *Service) GetSystemStats(ctx context.Context) (*postgresDB.SystemStats, error) {
+	stats, err := s.storage.GetSystemStats(ctx, time.Now())
+
+	if err != nil {
+		return nil, fmt.Errorf("GetSystemStats: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"slices"
+	"strings"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+// API token scopes, handlers.RequireScope lets tokens call the endpoints of the scopes they were given.
+const (
+	ScopeReadLinks = "links.read"
+	// ScopeCreateLinks covers changing the existing links too
+	ScopeCreateLinks = "links.create"
+	ScopeDeleteLinks = "links.delete"
+	ScopeStats       = "stats.read"
+)
+
+var apiTokenScopes = []string{ScopeCreateLinks, ScopeDeleteLinks, ScopeReadLinks, ScopeStats}
+
+const (
+	// apiTokenPrefix marks the tokens of this service, so they are easy to find in leaked configs
+	apiTokenPrefix = "ue_"
+	// apiTokenShownLength is how much of a token is kept to show it in the list
+	apiTokenShownLength  = len(apiTokenPrefix) + 6
+	maxApiTokenNameLen   = 64
+	maxApiTokenTTLDays   = 365
+	maxApiTokensPerUser  = 20
+	apiTokenLastUsedStep = time.Minute
+)
+
+// CreateApiToken makes a token for the user's scripts, it's returned once and only its hash is stored.
+// ttlDays of 0 makes a token that never expires.
+func (s *Service) CreateApiToken(ctx context.Context, email string, name string, scopes []string, ttlDays int) (string, *postgresDB.ApiToken, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" || len(name) > maxApiTokenNameLen {
+		return "", nil, newError(ErrInvalidInput, "token name must be 1 to %d characters long", maxApiTokenNameLen)
+	}
+
+	if ttlDays < 0 || ttlDays > maxApiTokenTTLDays {
+		return "", nil, newError(ErrInvalidInput, "token can live from 1 to %d days, 0 for no expiry", maxApiTokenTTLDays)
+	}
+
+	scopes, err := normalizeScopes(scopes)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	tokens, err := s.storage.GetApiTokens(ctx, email)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("CreateApiToken: could not get tokens of user %s: %w", email, err)
+	}
+
+	if len(tokens) >= maxApiTokensPerUser {
+		return "", nil, newError(ErrInvalidInput, "you can't have more than %d tokens, revoke the ones you don't use", maxApiTokensPerUser)
+	}
+
+	secret := newApiToken()
+	now := time.Now().UTC()
+
+	token := postgresDB.ApiToken{
+		UserEmail: email,
+		Name:      name,
+		Prefix:    secret[:apiTokenShownLength],
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+
+	if ttlDays > 0 {
+		expiresAt := now.AddDate(0, 0, ttlDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	created, err := s.storage.CreateApiToken(ctx, token, hashApiToken(secret))
+
+	if err != nil {
+		return "", nil, fmt.Errorf("CreateApiToken: could not create token %q of user %s: %w", name, email, err)
+	}
+
+	return secret, created, nil
+}
+
+// GetApiTokens lists the tokens of the user, the newest first.
+func (s *Service) GetApiTokens(ctx context.Context, email string) ([]postgresDB.ApiToken, error) {
+	tokens, err := s.storage.GetApiTokens(ctx, email)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetApiTokens: could not get tokens of user %s: %w", email, err)
+	}
+
+	return tokens, nil
+}
+
+// RevokeApiToken deletes the token of the user, requests made with it are refused from then on.
+func (s *Service) RevokeApiToken(ctx context.Context, email string, id int) error {
+	err := s.storage.DeleteApiToken(ctx, id, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return newError(ErrNotFound, "token %d not found", id)
+
+	case err != nil:
+		return fmt.Errorf("RevokeApiToken: could not delete token %d of user %s: %w", id, email, err)
+	}
+
+	return nil
+}
+
+// AuthenticateApiToken returns the principal a request made with the token acts for. It has
+// the scopes of the token and none of the role permissions, so tokens can't reach the admin console.
+func (s *Service) AuthenticateApiToken(ctx context.Context, secret string) (*Principal, error) {
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		return nil, newError(ErrUnauthorized, "invalid API token")
+	}
+
+	token, err := s.storage.GetApiTokenByHash(ctx, hashApiToken(secret))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrUnauthorized, "invalid API token")
+
+	case err != nil:
+		return nil, fmt.Errorf("AuthenticateApiToken: could not get token: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(now) {
+		return nil, newError(ErrUnauthorized, "API token %s expired", token.Prefix)
+	}
+
+	user, err := s.storage.GetUser(ctx, token.UserEmail)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrUnauthorized, "invalid API token")
+
+	case err != nil:
+		return nil, fmt.Errorf("AuthenticateApiToken: could not get user %s: %w", token.UserEmail, err)
+	}
+
+	// scripts may call the API many times a second, the time of the last use doesn't have to be exact
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenLastUsedStep {
+		if err := s.storage.TouchApiToken(ctx, token.Id, now); err != nil {
+			return nil, fmt.Errorf("AuthenticateApiToken: could not touch token %d: %w", token.Id, err)
+		}
+	}
+
+	return &Principal{Email: user.Email, Scopes: token.Scopes, Disabled: user.Disabled}, nil
+}
+
+// normalizeScopes checks the scopes and sorts them, dropping the repeated ones.
+func normalizeScopes(scopes []string) ([]string, error) {
+	res := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+
+		if !slices.Contains(apiTokenScopes, scope) {
+			return nil, newError(ErrInvalidInput, "unknown scope %q, expected any of %s", scope, strings.Join(apiTokenScopes, ", "))
+		}
+
+		if !slices.Contains(res, scope) {
+			res = append(res, scope)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, newError(ErrInvalidInput, "token needs at least one scope")
+	}
+
+	slices.Sort(res)
+
+	return res, nil
+}
+
+func newApiToken() string {
+	b := make([]byte, 32)
+
+	// crypto/rand never fails on supported platforms
+	_, _ = rand.Read(b)
+
+	return apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashApiToken needs no salt or slow hashing like passwords do, the tokens are random and long.
+func hashApiToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
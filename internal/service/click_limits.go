@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"urleater/internal/repository/postgresDB"
+)
+
+// maxLinkClicks caps the click limit of a link, links meant for more visitors don't need one
+const maxLinkClicks = 1_000_000
+
+func validateMaxClicks(clicks int) error {
+	if clicks < 0 || clicks > maxLinkClicks {
+		return newError(ErrInvalidInput, "number of clicks must be 0 to %d, 0 means no limit", maxLinkClicks)
+	}
+
+	return nil
+}
+
+// usedUp tells whether a click-limited link has spent all of its clicks.
+func usedUp(link *postgresDB.Link) bool {
+	return link.ClicksLeft != nil && *link.ClicksLeft <= 0
+}
+
+// SetLinkClicks lets the user's short link redirect that many more times, 0 removes the limit.
+func (s *Service) SetLinkClicks(ctx context.Context, shortLink string, email string, clicks int) (*postgresDB.Link, error) {
+	if err := validateMaxClicks(clicks); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userLink(ctx, shortLink, email); err != nil {
+		return nil, fmt.Errorf("SetLinkClicks: %w", err)
+	}
+
+	var clicksLeft *int
+
+	if clicks > 0 {
+		clicksLeft = &clicks
+	}
+
+	link, err := s.storage.SetLinkClicks(ctx, shortLink, clicksLeft)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, fmt.Errorf("SetLinkClicks: could not set clicks of short link %s: %w", shortLink, err)
+	}
+
+	return link, nil
+}
+
+// ConsumeLinkClick spends a click of the link a visitor is about to be redirected by, the links
+// without a limit are returned as they are. The storage takes the click off atomically, so
+// the last click goes to one visitor only and everybody else gets ErrExpired along with the link.
+func (s *Service) ConsumeLinkClick(ctx context.Context, link *postgresDB.Link) (*postgresDB.Link, error) {
+	if link.ClicksLeft == nil {
+		return link, nil
+	}
+
+	consumed, err := s.storage.ConsumeLinkClick(ctx, link.ShortUrl)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		spent := *link
+		spent.ClicksLeft = new(int)
+
+		return &spent, newError(ErrExpired, "short link %s has no clicks left", link.ShortUrl)
+
+	case err != nil:
+		return nil, fmt.Errorf("ConsumeLinkClick: could not spend a click of short link %s: %w", link.ShortUrl, err)
+	}
+
+	return consumed, nil
+}
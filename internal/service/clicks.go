@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+// clickWriteTimeout bounds a single click insert, which runs detached from the redirect request.
+const clickWriteTimeout = 5 * time.Second
+
+// RecordClick stores a click on the short link in the background, so the redirect
+// does not wait for the database. Errors are only logged: losing a click must never break a redirect.
+// With a ClickRecorder the click is batched with others, otherwise it's inserted on its own.
+func (s *Service) RecordClick(ctx context.Context, shortLink string, referrer string, userAgent string, ip string, country string) {
+	browser, os := parseUserAgent(userAgent)
+	now := time.Now().UTC()
+
+	click := postgresDB.Click{
+		ShortUrl:     shortLink,
+		ClickedAt:    now,
+		Referrer:     referrer,
+		ReferrerHost: referrerHost(referrer),
+		UserAgent:    userAgent,
+		IPHash:       s.hashIP(ip, now),
+		Country:      strings.ToUpper(strings.TrimSpace(country)),
+		Browser:      browser,
+		OS:           os,
+	}
+
+	if s.clicks != nil {
+		s.clicks.Enqueue(click)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), clickWriteTimeout)
+		defer cancel()
+
+		if err := s.storage.CreateClick(ctx, click); err != nil {
+			log.Printf("RecordClick: could not save click on %s: %v\n", shortLink, err)
+		}
+	}()
+}
+
+// hashIP keeps raw client addresses out of the database while still allowing unique visitors to be counted.
+// The hash is keyed with the visitor secret, so that the few billion addresses can't simply be tried,
+// and the key changes every UTC day, so that a visitor can't be followed from one day to the next:
+// the visitors are unique within a day, a longer period counts a returning visitor once per day.
+func (s *Service) hashIP(ip string, at time.Time) string {
+	day := hmac.New(sha256.New, s.visitorSecret)
+	day.Write([]byte(at.UTC().Format(time.DateOnly)))
+
+	mac := hmac.New(sha256.New, day.Sum(nil))
+	mac.Write([]byte(ip))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func referrerHost(referrer string) string {
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// parseUserAgent recognizes the browser families and operating systems we report on.
+// Order matters: most browsers also mention the engines of the browsers they are based on.
+func parseUserAgent(userAgent string) (browser string, os string) {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case ua == "":
+		browser = ""
+	case strings.Contains(ua, "bot") || strings.Contains(ua, "spider") || strings.Contains(ua, "crawl"):
+		browser = "Bot"
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "yabrowser/"):
+		browser = "Yandex Browser"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.HasPrefix(ua, "curl/"):
+		browser = "curl"
+	default:
+		browser = "Other"
+	}
+
+	switch {
+	case ua == "":
+		os = ""
+	case strings.Contains(ua, "windows"):
+		os = "Windows"
+	case strings.Contains(ua, "android"):
+		os = "Android"
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") || strings.Contains(ua, "ipod"):
+		os = "iOS"
+	case strings.Contains(ua, "mac os x") || strings.Contains(ua, "macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "cros"):
+		os = "ChromeOS"
+	case strings.Contains(ua, "linux"):
+		os = "Linux"
+	default:
+		os = "Other"
+	}
+
+	return browser, os
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of failures the caller can act on, check them with errors.Is.
+// Anything else returned by the service is an internal error.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrForbidden     = errors.New("forbidden")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrExpired       = errors.New("expired")
+	ErrDisabled      = errors.New("disabled")
+	ErrRateLimited   = errors.New("rate limited")
+)
+
+// Error is a failure of one of the kinds above. Its message is meant for the user
+// and must not contain internal details, so it's safe to put into a response.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"urleater/internal/repository/postgresDB"
+)
+
+// LinkOptions are the extras of a short link, the zero value makes a plain one.
+type LinkOptions struct {
+	// Password protects the link, visitors have to enter it before they are redirected
+	Password string
+	// MaxClicks is how many times the link redirects before it's used up, 0 is for no limit
+	MaxClicks int
+}
+
+// LinkUpdate lists the changes to a short link, the nil fields are left as they are.
+type LinkUpdate struct {
+	// LongUrl is the new destination of the link
+	LongUrl *string
+	// Days extends the link for that many days counted from now, 0 stands for the link TTL
+	Days *int
+	// Password protects the link, an empty one removes the protection
+	Password *string
+	// MaxClicks is how many more times the link redirects, 0 removes the limit
+	MaxClicks *int
+}
+
+// CreateShortLinkWithOptions is CreateShortLink for the links with a password or a click limit.
+func (s *Service) CreateShortLinkWithOptions(ctx context.Context, alias string, longLink string, userEmail string, options LinkOptions) (*postgresDB.Link, error) {
+	if options == (LinkOptions{}) {
+		return s.CreateShortLink(ctx, alias, longLink, userEmail)
+	}
+
+	if options.Password != "" {
+		if err := validateLinkPassword(options.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateMaxClicks(options.MaxClicks); err != nil {
+		return nil, err
+	}
+
+	storageOptions := postgresDB.LinkOptions{Password: options.Password}
+
+	if options.MaxClicks > 0 {
+		storageOptions.ClicksLeft = &options.MaxClicks
+	}
+
+	return s.createShortLink(ctx, alias, longLink, userEmail, storageOptions)
+}
+
+// UpdateLink applies all the changes to the user's short link or none of them, every change is checked before
+// the link is touched. A new destination is kept in the link history, a link that already lives longer is not shortened.
+func (s *Service) UpdateLink(ctx context.Context, shortLink string, email string, update LinkUpdate) (*postgresDB.Link, error) {
+	if update.LongUrl != nil {
+		if len(*update.LongUrl) == 0 {
+			return nil, newError(ErrInvalidInput, "long link is empty")
+		}
+
+		if !IsValidUrl(*update.LongUrl) {
+			return nil, newError(ErrInvalidInput, "invalid long link format")
+		}
+	}
+
+	if update.Days != nil && *update.Days < 0 {
+		return nil, newError(ErrInvalidInput, "number of days must not be negative")
+	}
+
+	if update.Password != nil && *update.Password != "" {
+		if err := validateLinkPassword(*update.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.MaxClicks != nil {
+		if err := validateMaxClicks(*update.MaxClicks); err != nil {
+			return nil, err
+		}
+	}
+
+	link, err := s.userLink(ctx, shortLink, email)
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateLink: %w", err)
+	}
+
+	var changes postgresDB.LinkChanges
+
+	if update.LongUrl != nil && *update.LongUrl != link.LongUrl {
+		changes.LongUrl = update.LongUrl
+	}
+
+	if update.Days != nil {
+		expiresAt, err := s.extendedExpiry(ctx, email, *update.Days)
+
+		if err != nil {
+			return nil, fmt.Errorf("UpdateLink: %w", err)
+		}
+
+		if expiresAt.After(link.ExpiresAt) {
+			changes.ExpiresAt = &expiresAt
+		}
+	}
+
+	changes.Password = update.Password
+	changes.ClicksLeft = update.MaxClicks
+
+	if changes == (postgresDB.LinkChanges{}) {
+		return link, nil
+	}
+
+	link, err = s.storage.UpdateLink(ctx, shortLink, changes, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// deleted in the meantime
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, fmt.Errorf("UpdateLink: error while updating short link %s: %w", shortLink, err)
+	}
+
+	return link, nil
+}
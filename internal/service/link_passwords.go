@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"sync"
+	"time"
+	"urleater/internal/repository/postgresDB"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minLinkPasswordLength = 4
+	// bcrypt ignores what's past 72 bytes
+	maxLinkPasswordLength = 72
+	// maxUnlockFailures is how many wrong passwords a visitor may enter for a link per unlockWindow
+	maxUnlockFailures = 5
+	// maxLinkUnlockFailures is how many wrong passwords all visitors together may enter for a link per unlockWindow
+	// before each of them gets a single try
+	maxLinkUnlockFailures = 50
+	unlockWindow          = 15 * time.Minute
+	// limiterSweepSize is how many keys attemptLimiter keeps before it drops the stale ones
+	limiterSweepSize = 1024
+)
+
+func validateLinkPassword(password string) error {
+	if len(password) < minLinkPasswordLength || len(password) > maxLinkPasswordLength {
+		return newError(ErrInvalidInput, "link password must be %d to %d characters", minLinkPasswordLength, maxLinkPasswordLength)
+	}
+
+	return nil
+}
+
+// SetLinkPassword protects the user's short link with the password, an empty one removes the protection.
+// The visitors who entered the old password are asked for the new one.
+func (s *Service) SetLinkPassword(ctx context.Context, shortLink string, email string, password string) (*postgresDB.Link, error) {
+	if password != "" {
+		if err := validateLinkPassword(password); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.userLink(ctx, shortLink, email); err != nil {
+		return nil, fmt.Errorf("SetLinkPassword: %w", err)
+	}
+
+	link, err := s.storage.SetLinkPassword(ctx, shortLink, password)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, fmt.Errorf("SetLinkPassword: could not set password of short link %s: %w", shortLink, err)
+	}
+
+	return link, nil
+}
+
+// passwordVersion tells the passwords of links apart without giving the hash away. bcrypt salts every hash,
+// so setting a password again, or protecting a recreated link with the same one, makes a new version.
+func passwordVersion(passwordHash string) string {
+	if passwordHash == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(passwordHash))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// LinkPasswordVersion returns the version of the link password the visitors were let through with,
+// see UnlockShortLink. It's empty for the links without a password.
+func (s *Service) LinkPasswordVersion(ctx context.Context, shortLink string) (string, error) {
+	passwordHash, err := s.storage.GetLinkPasswordHash(ctx, shortLink)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return "", newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return "", fmt.Errorf("LinkPasswordVersion: could not get password of short link %s: %w", shortLink, err)
+	}
+
+	return passwordVersion(passwordHash), nil
+}
+
+// UnlockShortLink checks the password a visitor entered for a protected link, client tells the visitors apart.
+// Wrong passwords are limited per visitor, and tighter so while the link is being guessed from many clients.
+// The link is never closed as a whole, a visitor who knows the password always gets a try.
+// It returns the version of the password that was entered, the visitor is let through while it's the current one.
+func (s *Service) UnlockShortLink(ctx context.Context, shortLink string, password string, client string) (*postgresDB.Link, string, error) {
+	link, err := s.GetShortLink(ctx, shortLink)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !link.Protected {
+		return link, "", nil
+	}
+
+	now := time.Now()
+	visitorKey := shortLink + " " + client
+
+	visitorLimit := maxUnlockFailures
+
+	if !s.unlockFailures.allowed(now, shortLink, maxLinkUnlockFailures) {
+		visitorLimit = 1
+	}
+
+	if !s.unlockFailures.allowed(now, visitorKey, visitorLimit) {
+		return nil, "", newError(ErrRateLimited, "too many wrong passwords for short link %s, try again later", shortLink)
+	}
+
+	passwordHash, err := s.storage.VerifyLinkPassword(ctx, shortLink, password)
+
+	switch {
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		s.unlockFailures.fail(now, visitorKey)
+		s.unlockFailures.fail(now, shortLink)
+
+		return nil, "", newError(ErrUnauthorized, "wrong password for short link %s", shortLink)
+
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, "", newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, "", fmt.Errorf("UnlockShortLink: could not verify password of short link %s: %w", shortLink, err)
+	}
+
+	s.unlockFailures.reset(visitorKey)
+
+	return link, passwordVersion(passwordHash), nil
+}
+
+// attemptLimiter counts the failed attempts per key in fixed windows. It's kept in memory,
+// so every instance limits on its own.
+type attemptLimiter struct {
+	mu       sync.Mutex
+	window   time.Duration
+	failures map[string]*failures
+}
+
+type failures struct {
+	count int
+	since time.Time
+}
+
+func newAttemptLimiter(window time.Duration) *attemptLimiter {
+	return &attemptLimiter{window: window, failures: make(map[string]*failures)}
+}
+
+// allowed tells whether the key failed less than limit times in the current window.
+func (l *attemptLimiter) allowed(now time.Time, key string, limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, ok := l.failures[key]
+
+	return !ok || now.Sub(f.since) >= l.window || f.count < limit
+}
+
+func (l *attemptLimiter) fail(now time.Time, key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.failures) >= limiterSweepSize {
+		for k, f := range l.failures {
+			if now.Sub(f.since) >= l.window {
+				delete(l.failures, k)
+			}
+		}
+	}
+
+	f, ok := l.failures[key]
+
+	if !ok || now.Sub(f.since) >= l.window {
+		l.failures[key] = &failures{count: 1, since: now}
+		return
+	}
+
+	f.count++
+}
+
+func (l *attemptLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.failures, key)
+}
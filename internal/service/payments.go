@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"log"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+// Outcomes of a payment reported by a PaymentProvider
+const (
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+// PaymentProvider takes the payments for orders, see payments.Fake.
+type PaymentProvider interface {
+	// CreatePayment registers the order with the provider and returns the URL of its checkout page.
+	CreatePayment(ctx context.Context, order postgresDB.Order) (string, error)
+	// VerifyCallback checks the signature of a notification sent by the provider and parses it.
+	VerifyCallback(payload []byte, signature string) (*PaymentEvent, error)
+}
+
+// PaymentEvent is the provider telling how the payment for an order went.
+type PaymentEvent struct {
+	OrderId   string `json:"order_id"`
+	PaymentId string `json:"payment_id"`
+	// Status is PaymentSucceeded or PaymentFailed
+	Status string `json:"status"`
+	Amount int    `json:"amount"`
+}
+
+// WithPaymentProvider enables buying subscriptions.
+func WithPaymentProvider(provider PaymentProvider) Option {
+	return func(s *Service) {
+		s.payments = provider
+	}
+}
+
+// CreateOrder starts the purchase of a subscription, the user pays for it at the returned URL.
+// Nothing is credited until the provider confirms the payment, see HandlePaymentCallback.
+// Switching plans is prorated: when the unused part of the current subscription covers the price,
+// the order is paid right away and there is no URL. Buying the plan the user is on renews it at the full price,
+// the days are added to the end of the subscription. A new order fails the user's pending one, so that the same credit
+// isn't spent twice.
+func (s *Service) CreateOrder(ctx context.Context, email string, subscriptionId int) (*postgresDB.Order, string, error) {
+	sub, err := s.plan(ctx, subscriptionId)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, "", newError(ErrNotFound, "subscription %d not found", subscriptionId)
+
+	case err != nil:
+		return nil, "", fmt.Errorf("CreateOrder: %w", err)
+
+	case sub.Price <= 0:
+		return nil, "", newError(ErrInvalidInput, "subscription %s is not for sale", sub.Name)
+	}
+
+	now := time.Now().UTC()
+
+	current, amount, days, err := s.quote(ctx, email, *sub, now)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("CreateOrder: %w", err)
+	}
+
+	var replaces *int
+
+	if current != nil {
+		if current.SubscriptionId == sub.Id {
+			// nothing is credited, the unused days are kept
+			amount, days = sub.Price, periodDays(*sub)
+		}
+
+		replaces = &current.Id
+	}
+
+	if amount > 0 && s.payments == nil {
+		return nil, "", newError(ErrForbidden, "payments are not available")
+	}
+
+	order, err := s.storage.CreateOrder(ctx, postgresDB.Order{
+		Id:             newOrderId(),
+		UserEmail:      email,
+		SubscriptionId: sub.Id,
+		Amount:         amount,
+		Days:           days,
+		// the storage fails the order instead of paying it if the user is on another subscription by then
+		ReplacesSubscriptionId: replaces,
+		Status:                 postgresDB.OrderPending,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+	})
+
+	if err != nil {
+		return nil, "", fmt.Errorf("CreateOrder: could not create order: %w", err)
+	}
+
+	if amount == 0 {
+		order, err = s.storage.PayOrder(ctx, order.Id, "")
+
+		switch {
+		case err != nil:
+			return nil, "", fmt.Errorf("CreateOrder: could not pay order: %w", err)
+
+		case order.Status != postgresDB.OrderPaid:
+			return nil, "", newError(ErrInvalidInput, "your subscription changed while ordering, try again")
+		}
+
+		return order, "", nil
+	}
+
+	paymentURL, err := s.payments.CreatePayment(ctx, *order)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("CreateOrder: could not create payment for order %s: %w", order.Id, err)
+	}
+
+	return order, paymentURL, nil
+}
+
+// quote prices the plan for the user, see prorate. It returns the user's subscription the price
+// is based on as well, nil on the free tier.
+func (s *Service) quote(ctx context.Context, email string, plan postgresDB.Subscription, now time.Time) (*postgresDB.UserSubscription, int, int, error) {
+	current, err := s.storage.GetActiveUserSubscription(ctx, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		amount, days := prorate(nil, postgresDB.Subscription{}, plan, now)
+
+		return nil, amount, days, nil
+
+	case err != nil:
+		return nil, 0, 0, fmt.Errorf("could not get subscription of %s: %w", email, err)
+	}
+
+	currentPlan, err := s.plan(ctx, current.SubscriptionId)
+
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	amount, days := prorate(current, *currentPlan, plan, now)
+
+	return current, amount, days, nil
+}
+
+// GetOrder returns the user's order.
+func (s *Service) GetOrder(ctx context.Context, orderId string, email string) (*postgresDB.Order, error) {
+	order, err := s.storage.GetOrder(ctx, orderId)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "order %s not found", orderId)
+
+	case err != nil:
+		return nil, fmt.Errorf("GetOrder: error while getting order %s: %w", orderId, err)
+
+	case order.UserEmail != email:
+		return nil, newError(ErrForbidden, "order %s belongs to another user", orderId)
+	}
+
+	return order, nil
+}
+
+// HandlePaymentCallback applies a notification of the payment provider. Providers retry notifications,
+// so the same one may come any number of times: an order is credited once, and only while it's pending.
+func (s *Service) HandlePaymentCallback(ctx context.Context, payload []byte, signature string) error {
+	if s.payments == nil {
+		return newError(ErrForbidden, "payments are not available")
+	}
+
+	event, err := s.payments.VerifyCallback(payload, signature)
+
+	if err != nil {
+		return newError(ErrUnauthorized, "invalid payment notification: %v", err)
+	}
+
+	order, err := s.storage.GetOrder(ctx, event.OrderId)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return newError(ErrNotFound, "order %s not found", event.OrderId)
+
+	case err != nil:
+		return fmt.Errorf("HandlePaymentCallback: error while getting order %s: %w", event.OrderId, err)
+	}
+
+	switch event.Status {
+	case PaymentSucceeded:
+		if event.Amount != order.Amount {
+			return newError(ErrInvalidInput, "order %s costs %d, got %d", order.Id, order.Amount, event.Amount)
+		}
+
+		order, err = s.storage.PayOrder(ctx, order.Id, event.PaymentId)
+
+	case PaymentFailed:
+		order, err = s.storage.FailOrder(ctx, order.Id, event.PaymentId)
+
+	default:
+		return newError(ErrInvalidInput, "unknown payment status %s", event.Status)
+	}
+
+	if err != nil {
+		return fmt.Errorf("HandlePaymentCallback: could not close order %s: %w", event.OrderId, err)
+	}
+
+	switch {
+	case event.Status == PaymentSucceeded && order.Status == postgresDB.OrderFailed && order.PaymentId == event.PaymentId:
+		// the order was superseded or the user's subscription changed since it was priced
+		log.Printf("order %s is outdated, payment %s has to be refunded", order.Id, event.PaymentId)
+
+	case order.PaymentId != event.PaymentId:
+		// a second payment for a closed order, it has to be refunded by hand
+		log.Printf("order %s is %s by payment %s, ignoring payment %s that %s", order.Id, order.Status, order.PaymentId, event.PaymentId, event.Status)
+	}
+
+	return nil
+}
+
+func newOrderId() string {
+	b := make([]byte, 16)
+
+	// crypto/rand never fails on supported platforms
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"slices"
+	"strings"
+)
+
+// Permissions are granted to roles in the roles table, routes require them with handlers.RequirePermission.
+const (
+	PermissionViewUsers     = "users.view"
+	PermissionManageQuota   = "users.manage_quota"
+	PermissionManageRoles   = "users.manage_roles"
+	PermissionDisableUsers  = "users.disable"
+	PermissionViewLinks     = "links.view"
+	PermissionModerateLinks = "links.moderate"
+	PermissionViewMetrics   = "system.metrics"
+)
+
+// Principal is who a request is made by. Its zero value is an anonymous visitor with no permissions.
+type Principal struct {
+	Email       string
+	Role        string
+	Permissions []string
+	// Disabled principals keep their role but may not use it
+	Disabled bool
+	// Scopes limit what a principal authenticated with an API token may do, they are nil for sessions
+	Scopes []string
+}
+
+func (p *Principal) Anonymous() bool {
+	return p.Email == ""
+}
+
+func (p *Principal) Can(permission string) bool {
+	return !p.Disabled && slices.Contains(p.Permissions, permission)
+}
+
+// ViaApiToken tells the principals authenticated with an API token from the logged-in ones.
+func (p *Principal) ViaApiToken() bool {
+	return p.Scopes != nil
+}
+
+// Allows tells whether the principal may use an endpoint of the scope, sessions may use them all.
+func (p *Principal) Allows(scope string) bool {
+	return !p.ViaApiToken() || slices.Contains(p.Scopes, scope)
+}
+
+// GetPrincipal loads the role of the user, an empty email stands for an anonymous visitor.
+func (s *Service) GetPrincipal(ctx context.Context, email string) (*Principal, error) {
+	if email == "" {
+		return &Principal{}, nil
+	}
+
+	user, err := s.storage.GetUser(ctx, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// the session outlived the user
+		return &Principal{}, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("GetPrincipal: could not get user %s: %w", email, err)
+	}
+
+	role, err := s.storage.GetUserRole(ctx, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return &Principal{}, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("GetPrincipal: could not get role of user %s: %w", email, err)
+	}
+
+	return &Principal{Email: email, Role: role.Name, Permissions: role.Permissions, Disabled: user.Disabled}, nil
+}
+
+// GrantRole replaces the role of the user.
+func (s *Service) GrantRole(ctx context.Context, email string, role string) error {
+	email = strings.TrimSpace(email)
+	role = strings.TrimSpace(role)
+
+	roles, err := s.storage.GetRoles(ctx)
+
+	if err != nil {
+		return fmt.Errorf("GrantRole: could not get roles: %w", err)
+	}
+
+	names := make([]string, 0, len(roles))
+
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+
+	if !slices.Contains(names, role) {
+		return newError(ErrInvalidInput, "unknown role %q, expected one of %s", role, strings.Join(names, ", "))
+	}
+
+	err = s.storage.SetUserRole(ctx, email, role)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return newError(ErrNotFound, "user %s not found", email)
+
+	case err != nil:
+		return fmt.Errorf("GrantRole: could not grant role %s to user %s: %w", role, email, err)
+	}
+
+	return nil
+}
@@ -2,52 +2,198 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v4"
-	"math/rand"
 	"net/mail"
 	"net/url"
 	"strings"
-	"sync"
 	"time"
 	"unicode"
+	"urleater/internal/codegen"
 	"urleater/internal/repository/postgresDB"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Storage interface {
-	CreateUser(ctx context.Context, email string, password string) error
+	CreateUser(ctx context.Context, email string, password string, urlsLeft int) error
 	ChangePassword(ctx context.Context, email string, password string) error
 	GetUser(ctx context.Context, email string) (*postgresDB.User, error)
-	CreateShortLink(ctx context.Context, shortLink string, longLink string, userID string) (*postgresDB.Link, error)
+	CreateShortLink(ctx context.Context, shortLink string, longLink string, userID string, expiresAt time.Time) (*postgresDB.Link, error)
 	GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error)
-	DeleteShortLink(ctx context.Context, shortLink string, email string) error
+	CreateShortLinkWithQuota(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time, options postgresDB.LinkOptions) (*postgresDB.Link, error)
+	DeleteShortLink(ctx context.Context, shortLink string, email string, quotaRefund int) error
 	ExtendShortLink(ctx context.Context, shortLink string, expiresAt time.Time) (*postgresDB.Link, error)
+	UpdateShortLink(ctx context.Context, shortLink string, longLink string, changedBy string) (*postgresDB.Link, error)
+	GetLinkVersions(ctx context.Context, shortLink string) ([]postgresDB.LinkVersion, error)
 	GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email string, offset int, limit int) ([]postgresDB.Link, error)
 	UpdateUserLinks(ctx context.Context, email string, urlsDelta int) (*postgresDB.User, error)
 	GetSubscriptions(ctx context.Context) ([]postgresDB.Subscription, error)
+	GetUserSubscription(ctx context.Context, email string) (*postgresDB.Subscription, error)
+	SetUserSubscription(ctx context.Context, email string, subscriptionId int) error
 	VerifyUserPassword(ctx context.Context, email string, password string) error
+	CreateClick(ctx context.Context, click postgresDB.Click) error
+	GetClickTotals(ctx context.Context, shortLink string, from time.Time, to time.Time) (int, int, error)
+	GetClickSeries(ctx context.Context, shortLink string, from time.Time, to time.Time, interval string) ([]postgresDB.ClickBucket, error)
+	GetTopClickValues(ctx context.Context, shortLink string, dimension string, from time.Time, to time.Time, limit int) ([]postgresDB.ClickCount, error)
+	CreateOrder(ctx context.Context, order postgresDB.Order) (*postgresDB.Order, error)
+	GetOrder(ctx context.Context, orderId string) (*postgresDB.Order, error)
+	PayOrder(ctx context.Context, orderId string, paymentId string) (*postgresDB.Order, error)
+	FailOrder(ctx context.Context, orderId string, paymentId string) (*postgresDB.Order, error)
+	GetActiveUserSubscription(ctx context.Context, email string) (*postgresDB.UserSubscription, error)
+	ResetQuotas(ctx context.Context, now time.Time) (int, error)
+	ExpireUserSubscriptions(ctx context.Context, now time.Time, freeQuota int) (int, error)
+	GetRoles(ctx context.Context) ([]postgresDB.Role, error)
+	GetUserRole(ctx context.Context, email string) (*postgresDB.Role, error)
+	SetUserRole(ctx context.Context, email string, role string) error
+	SearchUsers(ctx context.Context, query string, offset int, limit int) ([]postgresDB.UserSummary, error)
+	SetUserDisabled(ctx context.Context, email string, disabled bool) error
+	SearchLinks(ctx context.Context, query string, offset int, limit int) ([]postgresDB.Link, error)
+	SetLinkDisabled(ctx context.Context, shortLink string, disabled bool) (*postgresDB.Link, error)
+	GetSystemStats(ctx context.Context, now time.Time) (*postgresDB.SystemStats, error)
+	CreateApiToken(ctx context.Context, token postgresDB.ApiToken, tokenHash string) (*postgresDB.ApiToken, error)
+	GetApiTokens(ctx context.Context, email string) ([]postgresDB.ApiToken, error)
+	GetApiTokenByHash(ctx context.Context, tokenHash string) (*postgresDB.ApiToken, error)
+	DeleteApiToken(ctx context.Context, id int, email string) error
+	TouchApiToken(ctx context.Context, id int, usedAt time.Time) error
+	SetLinkPassword(ctx context.Context, shortLink string, password string) (*postgresDB.Link, error)
+	VerifyLinkPassword(ctx context.Context, shortLink string, password string) (string, error)
+	GetLinkPasswordHash(ctx context.Context, shortLink string) (string, error)
+	SetLinkClicks(ctx context.Context, shortLink string, clicks *int) (*postgresDB.Link, error)
+	UpdateLink(ctx context.Context, shortLink string, changes postgresDB.LinkChanges, changedBy string) (*postgresDB.Link, error)
+	ConsumeLinkClick(ctx context.Context, shortLink string) (*postgresDB.Link, error)
 }
 
-var mutex = &sync.Mutex{}
+const (
+	DefaultLinkTTL = 90 * 24 * time.Hour
+	DefaultQuota   = 10
+)
+
+// maxCodeAttempts is how many generated short links CreateShortLink tries before giving up
+const maxCodeAttempts = 10
+
+// ClickRecorder buffers clicks for asynchronous persistence, see clicks.Writer.
+type ClickRecorder interface {
+	Enqueue(click postgresDB.Click) bool
+}
+
+// CodeGenerator makes the short links of the links created without an alias, see codegen.Random.
+// A code may be taken already, CreateShortLink tries another one then.
+type CodeGenerator interface {
+	Generate(ctx context.Context) (string, error)
+}
 
 type Service struct {
-	storage Storage
+	storage      Storage
+	clicks       ClickRecorder
+	linkTTL      time.Duration
+	defaultQuota int
+	payments     PaymentProvider
+	codes        CodeGenerator
+	// visitorSecret keys the hashes of the visitor addresses, see hashIP
+	visitorSecret []byte
+	// unlockFailures are the wrong passwords entered for protected links
+	unlockFailures *attemptLimiter
+}
+
+type Option func(*Service)
+
+// WithClickRecorder makes RecordClick hand clicks to the recorder instead of writing them one by one.
+func WithClickRecorder(recorder ClickRecorder) Option {
+	return func(s *Service) {
+		s.clicks = recorder
+	}
 }
 
+// WithLinkTTL sets how long a new short link lives.
+func WithLinkTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.linkTTL = ttl
+	}
+}
+
+// WithVisitorSecret sets the key the visitor addresses of the clicks are hashed with.
+// Without one a random key is made, so that the visitors are told apart until a restart only.
+func WithVisitorSecret(secret []byte) Option {
+	return func(s *Service) {
+		s.visitorSecret = secret
+	}
+}
+
+// WithDefaultQuota sets how many short links a new user may create.
+func WithDefaultQuota(quota int) Option {
+	return func(s *Service) {
+		s.defaultQuota = quota
+	}
+}
+
+// WithCodeGenerator sets how short links are made for the links created without an alias.
+func WithCodeGenerator(codes CodeGenerator) Option {
+	return func(s *Service) {
+		s.codes = codes
+	}
+}
+
+// reservedNames are the top-level path segments of the routes and the static files,
+// a short link under one of them would never be reached.
 var reservedNames = []string{
 	"register",
 	"login",
 	"logout",
+	"user",
+	"links",
 	"create_link",
+	"get_links",
+	"get_link_versions",
+	"extend_link",
+	"update_link",
+	"rollback_link",
+	"delete_link",
+	"get_link_stats",
 	"buy",
+	"get_order",
 	"subscriptions",
+	"get_subscriptions",
+	"get_current_subscription",
+	"payments",
+	"tokens",
+	"get_tokens",
+	"create_token",
+	"revoke_token",
+	"admin",
+	"api",
+	"metrics",
+	"swagger",
+	"static",
+	"css",
+	"js",
 }
 
-func New(storage Storage) *Service {
-	return &Service{
-		storage: storage,
+func New(storage Storage, opts ...Option) *Service {
+	s := &Service{
+		storage:        storage,
+		linkTTL:        DefaultLinkTTL,
+		defaultQuota:   DefaultQuota,
+		unlockFailures: newAttemptLimiter(unlockWindow),
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	if s.codes == nil {
+		s.codes, _ = codegen.NewRandom(codegen.DefaultLength)
+	}
+
+	if len(s.visitorSecret) == 0 {
+		s.visitorSecret = make([]byte, 32)
+		// crypto/rand never fails on supported platforms
+		_, _ = rand.Read(s.visitorSecret)
+	}
+
+	return s
 }
 
 func (s *Service) LoginUser(ctx context.Context, email string, password string) error {
@@ -55,11 +201,11 @@ func (s *Service) LoginUser(ctx context.Context, email string, password string)
 	password = strings.TrimSpace(password)
 
 	if len(email) == 0 || len(password) == 0 {
-		return fmt.Errorf("LoginUser: email or password is empty")
+		return newError(ErrInvalidInput, "email or password is empty")
 	}
 
 	if !validateEmail(email) {
-		return fmt.Errorf("LoginUser: invalid email format")
+		return newError(ErrInvalidInput, "invalid email format")
 	}
 
 	err := s.storage.VerifyUserPassword(ctx, email, password)
@@ -67,14 +213,24 @@ func (s *Service) LoginUser(ctx context.Context, email string, password string)
 	switch {
 	case err == nil:
 
-	case errors.Is(err, pgx.ErrNoRows):
-		return fmt.Errorf("LoginUser: invalid password")
+	case errors.Is(err, pgx.ErrNoRows), errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return newError(ErrUnauthorized, "invalid email or password")
 
 	default:
 		return fmt.Errorf("LoginUser: could not verify password %w", err)
 
 	}
 
+	user, err := s.storage.GetUser(ctx, email)
+
+	if err != nil {
+		return fmt.Errorf("LoginUser: could not get user %s: %w", email, err)
+	}
+
+	if user.Disabled {
+		return newError(ErrForbidden, "account %s is disabled", email)
+	}
+
 	return nil
 }
 
@@ -122,15 +278,15 @@ func (s *Service) RegisterUser(ctx context.Context, email string, password strin
 	password = strings.TrimSpace(password)
 
 	if len(email) == 0 || len(password) == 0 {
-		return fmt.Errorf("RegisterUser: email or password is empty")
+		return newError(ErrInvalidInput, "email or password is empty")
 	}
 
 	if !validatePassword(password) {
-		return fmt.Errorf("RegisterUser: invalid password format")
+		return newError(ErrInvalidInput, "password must be at least 8 characters of latin letters, digits and special characters")
 	}
 
 	if !validateEmail(email) {
-		return fmt.Errorf("RegisterUser: invalid email format")
+		return newError(ErrInvalidInput, "invalid email format")
 	}
 
 	_, err := s.storage.GetUser(ctx, email)
@@ -141,20 +297,24 @@ func (s *Service) RegisterUser(ctx context.Context, email string, password strin
 	case err != nil:
 		return fmt.Errorf("RegisterUser: could not get user %w", err)
 	default:
-		return fmt.Errorf("RegisterUser: user already exists")
+		return newError(ErrAlreadyExists, "user %s already exists", email)
 	}
 
-	err = s.storage.CreateUser(ctx, email, password)
+	err = s.storage.CreateUser(ctx, email, password, s.defaultQuota)
 
-	if err != nil {
+	switch {
+	case errors.Is(err, postgresDB.ErrAlreadyExists):
+		return newError(ErrAlreadyExists, "user %s already exists", email)
+
+	case err != nil:
 		return fmt.Errorf("RegisterUser: could not create user %w", err)
 	}
 
 	return nil
 }
 
-func validateLinkAlias(alias string) bool {
-	if len(alias) < 8 || len(alias) > 20 {
+func validateLinkAlias(alias string, minLength int) bool {
+	if len(alias) < minLength || len(alias) > maxAliasLength {
 		return false
 	}
 	for _, char := range alias {
@@ -172,67 +332,107 @@ func IsValidUrl(str string) bool {
 }
 
 func (s *Service) CreateShortLink(ctx context.Context, alias string, longLink string, userEmail string) (*postgresDB.Link, error) {
+	return s.createShortLink(ctx, alias, longLink, userEmail, postgresDB.LinkOptions{})
+}
+
+// createShortLink creates the link with the options in one insert, they are validated already.
+func (s *Service) createShortLink(ctx context.Context, alias string, longLink string, userEmail string, options postgresDB.LinkOptions) (*postgresDB.Link, error) {
 	if len(longLink) == 0 {
-		return nil, fmt.Errorf("CreateShortLink: longLink is empty")
+		return nil, newError(ErrInvalidInput, "long link is empty")
 	}
 
 	if !IsValidUrl(longLink) {
-		return nil, fmt.Errorf("CreateShortLink: invalid longLink format")
+		return nil, newError(ErrInvalidInput, "invalid long link format")
 	}
 
-	var shortLink string
+	rules, err := s.linkRules(ctx, userEmail)
 
-	if alias != "" {
-		if !validateLinkAlias(alias) {
-			return nil, fmt.Errorf("CreateShortLink: invalid alias: %s", alias)
-		}
-		shortLink = alias
-	} else {
-		var err error
+	if err != nil {
+		return nil, fmt.Errorf("CreateShortLink: %w", err)
+	}
 
-	forLoop:
-		for i := 0; i < 10; i++ { // генерируем ссылки, пока такие существуют
-			shortLink = GenerateShortLink()
+	if alias == "" {
+		return s.createGeneratedShortLink(ctx, longLink, userEmail, rules.ttl, options)
+	}
 
-			_, err = s.storage.GetShortLink(ctx, shortLink)
+	if !validateLinkAlias(alias, rules.minAliasLength) {
+		return nil, newError(ErrInvalidInput, "alias must be %d to %d latin letters or digits, got %s", rules.minAliasLength, maxAliasLength, alias)
+	}
 
-			switch {
-			case errors.Is(err, pgx.ErrNoRows):
-				break forLoop
+	if IsReservedName(alias) {
+		return nil, newError(ErrAlreadyExists, "short link %s is not available", alias)
+	}
 
-			case err != nil:
-				return nil, fmt.Errorf("failed to check if short link exists: %w", err)
+	_, err = s.storage.GetShortLink(ctx, alias)
 
-			case i == 9:
-				return nil, fmt.Errorf("could not generate short link in 10 tries")
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
 
-			}
-		}
+	case err != nil:
+		return nil, fmt.Errorf("CreateShortLink: error while getting shortlink: %w", err)
+	default:
+		return nil, newError(ErrAlreadyExists, "short link %s already exists", alias)
 	}
 
-	for _, val := range reservedNames {
-		if val == shortLink {
-			return nil, fmt.Errorf("short link %s is not available", shortLink)
+	return s.insertShortLink(ctx, alias, longLink, userEmail, rules.ttl, options)
+}
+
+// createGeneratedShortLink creates the link under a code of the CodeGenerator, trying another one when it's taken.
+// The insert itself tells whether the code is free, so concurrent requests can't take the same one.
+func (s *Service) createGeneratedShortLink(ctx context.Context, longLink string, userEmail string, ttl time.Duration, options postgresDB.LinkOptions) (*postgresDB.Link, error) {
+	for i := 0; i < maxCodeAttempts; i++ {
+		shortLink, err := s.codes.Generate(ctx)
+
+		if err != nil {
+			return nil, fmt.Errorf("CreateShortLink: could not generate short link: %w", err)
+		}
+
+		if IsReservedName(shortLink) {
+			continue
+		}
+
+		link, err := s.insertShortLink(ctx, shortLink, longLink, userEmail, ttl, options)
+
+		if errors.Is(err, ErrAlreadyExists) {
+			continue
 		}
+
+		return link, err
 	}
 
-	_, err := s.storage.GetShortLink(ctx, shortLink)
+	return nil, fmt.Errorf("CreateShortLink: could not generate short link in %d tries", maxCodeAttempts)
+}
+
+func (s *Service) insertShortLink(ctx context.Context, shortLink string, longLink string, userEmail string, ttl time.Duration, options postgresDB.LinkOptions) (*postgresDB.Link, error) {
+	// spending the quota and creating the link is atomic, concurrent requests can't overspend it,
+	// and the link is never live without its options
+	link, err := s.storage.CreateShortLinkWithQuota(ctx, shortLink, longLink, userEmail, time.Now().UTC().Add(ttl), options)
 
 	switch {
+	case errors.Is(err, postgresDB.ErrAlreadyExists):
+		return nil, newError(ErrAlreadyExists, "short link %s already exists", shortLink)
+
+	case errors.Is(err, postgresDB.ErrQuotaExceeded):
+		return nil, newError(ErrQuotaExceeded, "you have no short links left")
+
 	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "user %s not found", userEmail)
 
 	case err != nil:
-		return nil, fmt.Errorf("CreateShortLink: error while getting shortlink: %#v", err)
-	default:
-		return nil, fmt.Errorf("CreateShortLink: shortlink already exists")
+		return nil, fmt.Errorf("CreateShortLink: error while creating a short link %s: %w", shortLink, err)
 	}
+	return link, nil
+}
 
-	link, err := s.storage.CreateShortLink(ctx, shortLink, longLink, userEmail)
-
-	if err != nil {
-		return nil, fmt.Errorf("CreateShortLink: error while creating a short link %s", shortLink)
+// IsReservedName tells whether the short link would be shadowed by a route of the site.
+func IsReservedName(shortLink string) bool {
+	for _, val := range reservedNames {
+		if val == shortLink {
+			return true
+		}
 	}
-	return link, nil
+
+	return false
 }
 
 func (s *Service) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscription, error) {
@@ -248,7 +448,11 @@ func (s *Service) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscripti
 func (s *Service) GetUser(ctx context.Context, email string) (*postgresDB.User, error) {
 	user, err := s.storage.GetUser(ctx, email)
 
-	if err != nil {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "user %s not found", email)
+
+	case err != nil:
 		return nil, fmt.Errorf("GetUser: could not get user %w", err)
 	}
 
@@ -256,16 +460,24 @@ func (s *Service) GetUser(ctx context.Context, email string) (*postgresDB.User,
 }
 
 func (s *Service) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email string, offset int, limit int) ([]postgresDB.Link, *postgresDB.User, error) {
+	if offset < 0 || limit < 0 {
+		return nil, nil, newError(ErrInvalidInput, "offset and limit can't be negative")
+	}
+
 	user, err := s.storage.GetUser(ctx, email)
 
-	if err != nil {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil, newError(ErrNotFound, "user %s not found", email)
+
+	case err != nil:
 		return nil, nil, fmt.Errorf("GetAllUserShortLinks: error while getting user %s: %w", email, err)
 	}
 
 	links, err := s.storage.GetUserShortLinksWithOffsetAndLimit(ctx, email, offset, limit)
 
 	switch {
-	case errors.Is(err, pgx.ErrNoRows):
+	case err == nil, errors.Is(err, pgx.ErrNoRows):
 
 	default:
 		return nil, nil, fmt.Errorf("GetAllUsersShortLinks: error while getting all user's %s shortlinks: %w", email, err)
@@ -278,7 +490,11 @@ func (s *Service) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email
 func (s *Service) UpdateUserShortLinks(ctx context.Context, email string, deltaLinks int) (*postgresDB.User, error) {
 	user, err := s.storage.UpdateUserLinks(ctx, email, deltaLinks)
 
-	if err != nil {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "user %s not found", email)
+
+	case err != nil:
 		return nil, fmt.Errorf("UpdateUserShortLinks: error while updating user's %s shortlinks: %w by %d", email, err, deltaLinks)
 	}
 
@@ -287,16 +503,128 @@ func (s *Service) UpdateUserShortLinks(ctx context.Context, email string, deltaL
 
 func (s *Service) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
 	link, err := s.storage.GetShortLink(ctx, shortLink)
-	if err != nil {
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
 		return nil, fmt.Errorf("GetShortLink: error while getting short link %s: %w", shortLink, err)
+
+	case link.Disabled:
+		return link, newError(ErrDisabled, "short link %s has been disabled", shortLink)
+
+	case !link.ExpiresAt.After(time.Now()):
+		// the link comes along with the error, so that its owner can be offered to renew it
+		return link, newError(ErrExpired, "short link %s expired on %s", shortLink, link.ExpiresAt.Format(time.DateOnly))
+
+	case usedUp(link):
+		return link, newError(ErrExpired, "short link %s has no clicks left", shortLink)
 	}
 
 	return link, nil
 
 }
 
+// ExtendShortLink renews the user's short link for the given number of days counted from now,
+// 0 days stands for the link TTL or the subscription maximum, whichever is shorter. It works on expired links as well.
+// A link that already lives longer is left as is. The user's subscription caps how far a link can be extended,
+// users without one are capped at the link TTL.
+func (s *Service) ExtendShortLink(ctx context.Context, shortLink string, email string, days int) (*postgresDB.Link, error) {
+	if days < 0 {
+		return nil, newError(ErrInvalidInput, "number of days must not be negative")
+	}
+
+	link, err := s.storage.GetShortLink(ctx, shortLink)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, fmt.Errorf("ExtendShortLink: error while getting short link %s: %w", shortLink, err)
+
+	case link.UserEmail != email:
+		return nil, newError(ErrForbidden, "short link %s belongs to another user", shortLink)
+	}
+
+	expiresAt, err := s.extendedExpiry(ctx, email, days)
+
+	if err != nil {
+		return nil, fmt.Errorf("ExtendShortLink: %w", err)
+	}
+
+	if !expiresAt.After(link.ExpiresAt) {
+		return link, nil
+	}
+
+	link, err = s.storage.ExtendShortLink(ctx, shortLink, expiresAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("ExtendShortLink: error while extending short link %s: %w", shortLink, err)
+	}
+
+	return link, nil
+}
+
+// extendedExpiry is when a link of the user extended for the given number of days expires. 0 days stands for
+// the link TTL, cut to what the user's subscription allows; only asking for more days than that is refused.
+func (s *Service) extendedExpiry(ctx context.Context, email string, days int) (time.Time, error) {
+	maxTTL, err := s.maxLinkTTL(ctx, email)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	ttl := min(s.linkTTL, maxTTL)
+
+	if days > 0 {
+		ttl = time.Duration(days) * 24 * time.Hour
+	}
+
+	if ttl > maxTTL {
+		return time.Time{}, newError(ErrForbidden, "your subscription allows extending links by at most %d days", int(maxTTL/(24*time.Hour)))
+	}
+
+	return time.Now().UTC().Add(ttl), nil
+}
+
+// maxLinkTTL is how far ahead the user may extend their links.
+func (s *Service) maxLinkTTL(ctx context.Context, email string) (time.Duration, error) {
+	sub, err := s.storage.GetUserSubscription(ctx, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return s.linkTTL, nil
+
+	case err != nil:
+		return 0, fmt.Errorf("could not get subscription of %s: %w", email, err)
+	}
+
+	return time.Duration(sub.MaxLinkTTLDays) * 24 * time.Hour, nil
+}
+
 func (s *Service) DeleteShortLink(ctx context.Context, shortLink string, email string) error {
-	err := s.storage.DeleteShortLink(ctx, shortLink, email)
+	link, err := s.storage.GetShortLink(ctx, shortLink)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return fmt.Errorf("DeleteShortLink: error while getting short link %s: %w", shortLink, err)
+
+	case link.UserEmail != email:
+		return newError(ErrForbidden, "short link %s belongs to another user", shortLink)
+	}
+
+	refund, err := s.quotaRefund(ctx, email)
+
+	if err != nil {
+		return fmt.Errorf("DeleteShortLink: %w", err)
+	}
+
+	err = s.storage.DeleteShortLink(ctx, shortLink, email, refund)
 
 	if err != nil {
 		return fmt.Errorf("DeleteShortLink: error while deleting short link %s with email %s: %w", shortLink, email, err)
@@ -305,19 +633,21 @@ func (s *Service) DeleteShortLink(ctx context.Context, shortLink string, email s
 	return nil
 }
 
-const letterBytes = "1234567890abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+// quotaRefund is how many links the user gets back for deleting one, it depends on their subscription.
+// Users without one don't get anything back.
+func (s *Service) quotaRefund(ctx context.Context, email string) (int, error) {
+	sub, err := s.storage.GetUserSubscription(ctx, email)
 
-func GenerateShortLink() string {
-	mutex.Lock()
-	defer mutex.Unlock()
-	source := rand.NewSource(time.Now().UnixNano())
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, nil
 
-	res := make([]byte, 8)
+	case err != nil:
+		return 0, fmt.Errorf("could not get subscription of %s: %w", email, err)
 
-	for i := range res {
-		res[i] = letterBytes[source.Int63()%int64(len(letterBytes))]
+	case sub.RefundDeletedLinks:
+		return 1, nil
 	}
 
-	return string(res)
-
+	return 0, nil
 }
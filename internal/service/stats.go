@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+const (
+	topClickValuesLimit = 10
+	maxStatsBuckets     = 2000
+)
+
+var statsIntervals = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+// GetLinkStats aggregates clicks on one of the user's short links in [from, to).
+// The series contains every bucket of the range, including the ones without clicks.
+// Unique clicks are visitor-days, visitor hashes change every UTC day, see hashIP.
+func (s *Service) GetLinkStats(ctx context.Context, shortLink string, email string, from time.Time, to time.Time, interval string) (*postgresDB.LinkStats, error) {
+	step, ok := statsIntervals[interval]
+	if !ok {
+		return nil, newError(ErrInvalidInput, "unknown interval %s, expected hour, day or week", interval)
+	}
+
+	from, to = from.UTC(), to.UTC()
+
+	if !from.Before(to) {
+		return nil, newError(ErrInvalidInput, "range start must be before its end")
+	}
+
+	if to.Sub(from)/step > maxStatsBuckets {
+		return nil, newError(ErrInvalidInput, "range is too long for interval %s", interval)
+	}
+
+	link, err := s.storage.GetShortLink(ctx, shortLink)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, fmt.Errorf("GetLinkStats: error while getting short link %s: %w", shortLink, err)
+
+	case link.UserEmail != email:
+		return nil, newError(ErrForbidden, "short link %s belongs to another user", shortLink)
+	}
+
+	stats := postgresDB.LinkStats{
+		ShortUrl: link.ShortUrl,
+		From:     from,
+		To:       to,
+		Interval: interval,
+	}
+
+	stats.TotalClicks, stats.UniqueClicks, err = s.storage.GetClickTotals(ctx, shortLink, from, to)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetLinkStats: could not count clicks on %s: %w", shortLink, err)
+	}
+
+	buckets, err := s.storage.GetClickSeries(ctx, shortLink, from, to, interval)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetLinkStats: could not get click series of %s: %w", shortLink, err)
+	}
+
+	stats.Series = fillClickSeries(buckets, from, to, interval)
+
+	for dimension, dst := range map[string]*[]postgresDB.ClickCount{
+		postgresDB.ClickDimensionReferrer: &stats.TopReferrers,
+		postgresDB.ClickDimensionCountry:  &stats.TopCountries,
+		postgresDB.ClickDimensionBrowser:  &stats.TopBrowsers,
+		postgresDB.ClickDimensionOS:       &stats.TopOSes,
+	} {
+		counts, err := s.storage.GetTopClickValues(ctx, shortLink, dimension, from, to, topClickValuesLimit)
+
+		if err != nil {
+			return nil, fmt.Errorf("GetLinkStats: could not get top %s of %s: %w", dimension, shortLink, err)
+		}
+
+		for i := range counts {
+			if counts[i].Value == "" {
+				counts[i].Value = "unknown"
+			}
+		}
+
+		*dst = counts
+	}
+
+	return &stats, nil
+}
+
+// truncateTime mirrors Postgres date_trunc for the supported intervals; weeks start on Monday.
+func truncateTime(t time.Time, interval string) time.Time {
+	switch interval {
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	default:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		offset := (int(day.Weekday()) + 6) % 7
+
+		return day.AddDate(0, 0, -offset)
+	}
+}
+
+func fillClickSeries(buckets []postgresDB.ClickBucket, from time.Time, to time.Time, interval string) []postgresDB.ClickBucket {
+	byStart := make(map[time.Time]postgresDB.ClickBucket, len(buckets))
+
+	for _, bucket := range buckets {
+		byStart[bucket.Start.UTC()] = bucket
+	}
+
+	var series []postgresDB.ClickBucket
+
+	for start := truncateTime(from, interval); start.Before(to); start = start.Add(statsIntervals[interval]) {
+		bucket, ok := byStart[start]
+		if !ok {
+			bucket = postgresDB.ClickBucket{Start: start}
+		}
+
+		bucket.Start = start
+		series = append(series, bucket)
+	}
+
+	return series
+}
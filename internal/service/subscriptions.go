@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"log"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+const (
+	// DefaultPeriodDays is how long a subscription lasts when its plan doesn't say
+	DefaultPeriodDays = 30
+	// DefaultMinAliasLength is the shortest custom alias on the free tier
+	DefaultMinAliasLength = 8
+	maxAliasLength        = 20
+)
+
+// linkRules are what the user's plan allows for new links, the free tier gets the service defaults.
+type linkRules struct {
+	ttl            time.Duration
+	minAliasLength int
+}
+
+func (s *Service) linkRules(ctx context.Context, email string) (linkRules, error) {
+	rules := linkRules{
+		ttl:            s.linkTTL,
+		minAliasLength: DefaultMinAliasLength,
+	}
+
+	sub, err := s.storage.GetUserSubscription(ctx, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return rules, nil
+
+	case err != nil:
+		return rules, fmt.Errorf("could not get subscription of %s: %w", email, err)
+	}
+
+	if sub.LinkTTLDays > 0 {
+		rules.ttl = time.Duration(sub.LinkTTLDays) * 24 * time.Hour
+	}
+
+	if sub.MinAliasLength > 0 {
+		rules.minAliasLength = sub.MinAliasLength
+	}
+
+	return rules, nil
+}
+
+func periodDays(sub postgresDB.Subscription) int {
+	if sub.PeriodDays > 0 {
+		return sub.PeriodDays
+	}
+
+	return DefaultPeriodDays
+}
+
+// prorate prices switching from the current subscription to the plan at now: the unused part of
+// the current subscription is credited against the price, and credit left over buys extra days of the plan.
+// It returns the amount to pay and how many days the new subscription lasts.
+func prorate(current *postgresDB.UserSubscription, currentPlan postgresDB.Subscription, plan postgresDB.Subscription, now time.Time) (int, int) {
+	days := periodDays(plan)
+
+	if current == nil || !current.EndsAt.After(now) {
+		return plan.Price, days
+	}
+
+	// in seconds, nanoseconds times the price overflow. The unused time is valued at the price of a period
+	// of the current plan, renewals and extra days make subscriptions longer than one period.
+	period := int64(periodDays(currentPlan)) * 24 * 60 * 60
+	unused := min(int64(current.EndsAt.Sub(now)/time.Second), int64(current.EndsAt.Sub(current.StartsAt)/time.Second))
+
+	credit := int(int64(currentPlan.Price) * unused / period)
+
+	if credit <= plan.Price {
+		return plan.Price - credit, days
+	}
+
+	return 0, days + (credit-plan.Price)*days/plan.Price
+}
+
+// GetCurrentSubscription returns the user's subscription and its plan, both nil on the free tier.
+func (s *Service) GetCurrentSubscription(ctx context.Context, email string) (*postgresDB.UserSubscription, *postgresDB.Subscription, error) {
+	current, err := s.storage.GetActiveUserSubscription(ctx, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil, nil
+
+	case err != nil:
+		return nil, nil, fmt.Errorf("GetCurrentSubscription: could not get subscription of %s: %w", email, err)
+	}
+
+	plan, err := s.plan(ctx, current.SubscriptionId)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetCurrentSubscription: %w", err)
+	}
+
+	return current, plan, nil
+}
+
+// plan returns the subscription plan, pgx.ErrNoRows if there is none.
+func (s *Service) plan(ctx context.Context, subscriptionId int) (*postgresDB.Subscription, error) {
+	subs, err := s.storage.GetSubscriptions(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not get subscriptions: %w", err)
+	}
+
+	for i := range subs {
+		if subs[i].Id == subscriptionId {
+			return &subs[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("subscription %d: %w", subscriptionId, pgx.ErrNoRows)
+}
+
+// MaintainSubscriptions moves the users whose subscriptions lapsed by now back to the free tier
+// and refills the monthly quotas that are due. It's meant to be run periodically.
+func (s *Service) MaintainSubscriptions(ctx context.Context, now time.Time) error {
+	// expiring goes first, so that lapsed subscriptions don't get one more refill
+	expired, err := s.storage.ExpireUserSubscriptions(ctx, now, s.defaultQuota)
+
+	if err != nil {
+		return fmt.Errorf("MaintainSubscriptions: could not expire subscriptions: %w", err)
+	}
+
+	reset, err := s.storage.ResetQuotas(ctx, now)
+
+	if err != nil {
+		return fmt.Errorf("MaintainSubscriptions: could not reset quotas: %w", err)
+	}
+
+	if expired > 0 || reset > 0 {
+		log.Printf("subscriptions: %d expired, %d quotas reset", expired, reset)
+	}
+
+	return nil
+}
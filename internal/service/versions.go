@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"urleater/internal/repository/postgresDB"
+)
+
+// UpdateShortLink points the user's short link at another long link, the change is kept in the link history.
+// Setting the current destination again is a no-op.
+func (s *Service) UpdateShortLink(ctx context.Context, shortLink string, longLink string, email string) (*postgresDB.Link, error) {
+	if len(longLink) == 0 {
+		return nil, newError(ErrInvalidInput, "long link is empty")
+	}
+
+	if !IsValidUrl(longLink) {
+		return nil, newError(ErrInvalidInput, "invalid long link format")
+	}
+
+	link, err := s.userLink(ctx, shortLink, email)
+
+	if err != nil {
+		return nil, fmt.Errorf("UpdateShortLink: %w", err)
+	}
+
+	if link.LongUrl == longLink {
+		return link, nil
+	}
+
+	link, err = s.storage.UpdateShortLink(ctx, shortLink, longLink, email)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// deleted in the meantime
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, fmt.Errorf("UpdateShortLink: error while updating short link %s: %w", shortLink, err)
+	}
+
+	return link, nil
+}
+
+// GetLinkVersions returns the destination changes of the user's short link, the latest first.
+func (s *Service) GetLinkVersions(ctx context.Context, shortLink string, email string) ([]postgresDB.LinkVersion, error) {
+	if _, err := s.userLink(ctx, shortLink, email); err != nil {
+		return nil, fmt.Errorf("GetLinkVersions: %w", err)
+	}
+
+	versions, err := s.storage.GetLinkVersions(ctx, shortLink)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetLinkVersions: could not get versions of %s: %w", shortLink, err)
+	}
+
+	return versions, nil
+}
+
+// RollbackShortLink undoes the given change of the user's short link and the ones made after it,
+// the link goes back to the destination it had before the change. The rollback is recorded as a change itself.
+func (s *Service) RollbackShortLink(ctx context.Context, shortLink string, versionId int, email string) (*postgresDB.Link, error) {
+	versions, err := s.GetLinkVersions(ctx, shortLink, email)
+
+	if err != nil {
+		return nil, fmt.Errorf("RollbackShortLink: %w", err)
+	}
+
+	for _, version := range versions {
+		if version.Id == versionId {
+			return s.UpdateShortLink(ctx, shortLink, version.OldLongUrl, email)
+		}
+	}
+
+	return nil, newError(ErrNotFound, "short link %s has no version %d", shortLink, versionId)
+}
+
+// GetUserShortLink returns the user's short link, the expired and disabled ones included.
+func (s *Service) GetUserShortLink(ctx context.Context, shortLink string, email string) (*postgresDB.Link, error) {
+	link, err := s.userLink(ctx, shortLink, email)
+
+	if err != nil {
+		return nil, fmt.Errorf("GetUserShortLink: %w", err)
+	}
+
+	return link, nil
+}
+
+// userLink gets the short link, making sure it belongs to the user.
+func (s *Service) userLink(ctx context.Context, shortLink string, email string) (*postgresDB.Link, error) {
+	link, err := s.storage.GetShortLink(ctx, shortLink)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, newError(ErrNotFound, "short link %s not found", shortLink)
+
+	case err != nil:
+		return nil, fmt.Errorf("error while getting short link %s: %w", shortLink, err)
+
+	case link.UserEmail != email:
+		return nil, newError(ErrForbidden, "short link %s belongs to another user", shortLink)
+	}
+
+	return link, nil
+}
@@ -0,0 +1,389 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/url"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+)
+
+func (s *adminSuite) searchUsers(email string, query url.Values) ([]postgresDB.UserSummary, int) {
+	s.loggedInAs(email)
+
+	body, status := s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewUsers, s.Handlers.SearchUsers), query)
+
+	var resp handlers.SearchUsersResponse
+
+	if status == http.StatusOK {
+		s.Require().NoError(json.Unmarshal(body, &resp))
+	}
+
+	return resp.Users, status
+}
+
+func (s *adminSuite) searchLinks(email string, query url.Values) ([]postgresDB.Link, int) {
+	s.loggedInAs(email)
+
+	body, status := s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewLinks, s.Handlers.SearchLinks), query)
+
+	var resp handlers.SearchLinksResponse
+
+	if status == http.StatusOK {
+		s.Require().NoError(json.Unmarshal(body, &resp))
+	}
+
+	return resp.Links, status
+}
+
+func (s *adminSuite) setUserDisabled(email string, data *handlers.SetUserDisabledRequest) int {
+	s.loggedInAs(email)
+
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	_, status := s.MakeRequestWithBody(http.MethodPost, s.guarded(service.PermissionDisableUsers, s.Handlers.SetUserDisabled), string(res))
+
+	return status
+}
+
+func (s *adminSuite) setUserRole(email string, data *handlers.SetUserRoleRequest) int {
+	s.loggedInAs(email)
+
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	_, status := s.MakeRequestWithBody(http.MethodPost, s.guarded(service.PermissionManageRoles, s.Handlers.SetUserRole), string(res))
+
+	return status
+}
+
+func (s *adminSuite) setLinkDisabled(email string, data *handlers.SetLinkDisabledRequest) (*postgresDB.Link, int) {
+	s.loggedInAs(email)
+
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	body, status := s.MakeRequestWithBody(http.MethodPost, s.guarded(service.PermissionModerateLinks, s.Handlers.SetLinkDisabled), string(res))
+
+	var resp handlers.SetLinkDisabledResponse
+
+	if status == http.StatusOK {
+		s.Require().NoError(json.Unmarshal(body, &resp))
+	}
+
+	return &resp.Link, status
+}
+
+func (s *adminSuite) TestSearchUsers() {
+	// 1
+	_, status := s.searchUsers("user@mail.ru", url.Values{})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 2 support may look at users
+	users, status := s.searchUsers("support@mail.ru", url.Values{"query": {"USER@"}})
+
+	s.Equal(http.StatusOK, status)
+	s.Require().Len(users, 1)
+	s.Equal("user@mail.ru", users[0].Email)
+	s.Equal("user", users[0].Role)
+	s.Equal(2, users[0].Links)
+	s.Equal(testQuota, users[0].UrlsLeft)
+
+	// 3
+	users, status = s.searchUsers("admin@mail.ru", url.Values{"limit": {"2"}})
+
+	s.Equal(http.StatusOK, status)
+	s.Len(users, 2)
+
+	users, status = s.searchUsers("admin@mail.ru", url.Values{"limit": {"2"}, "offset": {"2"}})
+
+	s.Equal(http.StatusOK, status)
+	s.Len(users, 1)
+
+	// 4
+	for _, query := range []url.Values{{"limit": {"-1"}}, {"limit": {"101"}}, {"offset": {"x"}}} {
+		_, status = s.searchUsers("admin@mail.ru", query)
+
+		s.Equal(http.StatusBadRequest, status, query)
+	}
+}
+
+func (s *adminSuite) TestChangeQuota() {
+	change := func(email string, delta int) int {
+		s.loggedInAs(email)
+
+		res, err := json.Marshal(&handlers.UpdateUserShortLinksRequest{Email: "user@mail.ru", DeltaLinks: delta})
+		s.NoError(err)
+
+		_, status := s.MakeRequestWithBody(http.MethodPost, s.guarded(service.PermissionManageQuota, s.Handlers.UpdateUserShortLinks), string(res))
+
+		return status
+	}
+
+	// 1 support can't
+	s.Equal(http.StatusForbidden, change("support@mail.ru", 10))
+
+	// 2
+	s.Equal(http.StatusOK, change("admin@mail.ru", 10))
+
+	users, _ := s.searchUsers("admin@mail.ru", url.Values{"query": {"user@"}})
+
+	s.Require().Len(users, 1)
+	s.Equal(testQuota+10, users[0].UrlsLeft)
+
+	// 3 never below zero
+	s.Equal(http.StatusOK, change("admin@mail.ru", -100))
+
+	users, _ = s.searchUsers("admin@mail.ru", url.Values{"query": {"user@"}})
+
+	s.Require().Len(users, 1)
+	s.Equal(0, users[0].UrlsLeft)
+}
+
+func (s *adminSuite) TestDisableUser() {
+	// 1
+	status := s.setUserDisabled("support@mail.ru", &handlers.SetUserDisabledRequest{Email: "user@mail.ru", Disabled: true})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 2
+	status = s.setUserDisabled("admin@mail.ru", &handlers.SetUserDisabledRequest{Email: "admin@mail.ru", Disabled: true})
+
+	s.Equal(http.StatusBadRequest, status)
+
+	// 3
+	status = s.setUserDisabled("admin@mail.ru", &handlers.SetUserDisabledRequest{Email: "nobody@mail.ru", Disabled: true})
+
+	s.Equal(http.StatusNotFound, status)
+
+	// 4
+	status = s.setUserDisabled("admin@mail.ru", &handlers.SetUserDisabledRequest{Email: "support@mail.ru", Disabled: true})
+
+	s.Equal(http.StatusNoContent, status)
+
+	users, _ := s.searchUsers("admin@mail.ru", url.Values{"query": {"support@"}})
+
+	s.Require().Len(users, 1)
+	s.True(users[0].Disabled)
+
+	// 5 can't log in
+	s.loggedInAs("")
+
+	_, status = s.LoginUser(&handlers.LoginRequest{Email: "support@mail.ru", Password: "qwertyui"})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 6 an open session loses its permissions
+	_, status = s.searchUsers("support@mail.ru", url.Values{})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 7 the links still work
+	rec := s.GetShortLink("userLink1", nil)
+
+	s.Equal(http.StatusFound, rec.Code)
+
+	// 8
+	status = s.setUserDisabled("admin@mail.ru", &handlers.SetUserDisabledRequest{Email: "support@mail.ru", Disabled: false})
+
+	s.Equal(http.StatusNoContent, status)
+
+	_, status = s.searchUsers("support@mail.ru", url.Values{})
+
+	s.Equal(http.StatusOK, status)
+}
+
+func (s *adminSuite) TestSetUserRole() {
+	// 1
+	status := s.setUserRole("support@mail.ru", &handlers.SetUserRoleRequest{Email: "user@mail.ru", Role: "support"})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 2
+	status = s.setUserRole("admin@mail.ru", &handlers.SetUserRoleRequest{Email: "admin@mail.ru", Role: "user"})
+
+	s.Equal(http.StatusBadRequest, status)
+
+	// 3
+	status = s.setUserRole("admin@mail.ru", &handlers.SetUserRoleRequest{Email: "user@mail.ru", Role: "superuser"})
+
+	s.Equal(http.StatusBadRequest, status)
+
+	// 4
+	status = s.setUserRole("admin@mail.ru", &handlers.SetUserRoleRequest{Email: "nobody@mail.ru", Role: "support"})
+
+	s.Equal(http.StatusNotFound, status)
+
+	// 5
+	status = s.setUserRole("admin@mail.ru", &handlers.SetUserRoleRequest{Email: "user@mail.ru", Role: "support"})
+
+	s.Equal(http.StatusNoContent, status)
+
+	users, _ := s.searchUsers("admin@mail.ru", url.Values{"query": {"user@"}})
+
+	s.Require().Len(users, 1)
+	s.Equal("support", users[0].Role)
+
+	// 6 the new role applies to an open session
+	_, status = s.searchUsers("user@mail.ru", url.Values{})
+
+	s.Equal(http.StatusOK, status)
+}
+
+func (s *adminSuite) TestSearchLinks() {
+	// 1
+	_, status := s.searchLinks("user@mail.ru", url.Values{})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 2
+	links, status := s.searchLinks("support@mail.ru", url.Values{"query": {"link2"}})
+
+	s.Equal(http.StatusOK, status)
+	s.Require().Len(links, 1)
+	s.Equal("userLink2", links[0].ShortUrl)
+
+	// 3 by owner
+	links, status = s.searchLinks("admin@mail.ru", url.Values{"query": {"user@mail.ru"}})
+
+	s.Equal(http.StatusOK, status)
+	s.Len(links, 2)
+}
+
+func (s *adminSuite) TestDisableLink() {
+	// 1 support may only look
+	_, status := s.setLinkDisabled("support@mail.ru", &handlers.SetLinkDisabledRequest{ShortLink: "userLink1", Disabled: true})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 2
+	_, status = s.setLinkDisabled("admin@mail.ru", &handlers.SetLinkDisabledRequest{ShortLink: "unknown", Disabled: true})
+
+	s.Equal(http.StatusNotFound, status)
+
+	// 3
+	link, status := s.setLinkDisabled("admin@mail.ru", &handlers.SetLinkDisabledRequest{ShortLink: "userLink1", Disabled: true})
+
+	s.Equal(http.StatusOK, status)
+	s.True(link.Disabled)
+
+	// 4 doesn't redirect
+	rec := s.GetShortLink("userLink1", map[string]string{"Accept": "application/json"})
+
+	s.Equal(http.StatusGone, rec.Code)
+
+	var errResp handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &errResp))
+	s.Equal("disabled", errResp.Error.Code)
+
+	rec = s.GetShortLink("userLink1", map[string]string{"Accept": "text/html"})
+
+	s.Equal(http.StatusGone, rec.Code)
+	s.Contains(rec.Body.String(), "заблокирована")
+
+	// 5 but is kept, its owner still sees it
+	links, _, err := s.Handlers.Service.GetUserShortLinksWithOffsetAndLimit(context.Background(), "user@mail.ru", 0, 10)
+
+	s.NoError(err)
+	s.Len(links, 2)
+
+	// 6
+	link, status = s.setLinkDisabled("admin@mail.ru", &handlers.SetLinkDisabledRequest{ShortLink: "userLink1", Disabled: false})
+
+	s.Equal(http.StatusOK, status)
+	s.False(link.Disabled)
+
+	rec = s.GetShortLink("userLink1", nil)
+
+	s.Equal(http.StatusFound, rec.Code)
+}
+
+func (s *adminSuite) TestStats() {
+	_, status := s.setLinkDisabled("admin@mail.ru", &handlers.SetLinkDisabledRequest{ShortLink: "userLink1", Disabled: true})
+	s.Require().Equal(http.StatusOK, status)
+
+	// 1
+	s.loggedInAs("user@mail.ru")
+
+	_, status = s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewUsers, s.Handlers.GetSystemStats), url.Values{})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 2
+	s.loggedInAs("support@mail.ru")
+
+	body, status := s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewUsers, s.Handlers.GetSystemStats), url.Values{})
+
+	s.Equal(http.StatusOK, status)
+
+	var resp handlers.GetSystemStatsResponse
+
+	s.NoError(json.Unmarshal(body, &resp))
+	s.Equal(postgresDB.SystemStats{Users: 3, Links: 2, ActiveLinks: 1, DisabledLinks: 1}, resp.Stats)
+}
+
+func (s *adminSuite) TestAdminPage() {
+	// 1
+	s.loggedInAs("")
+
+	_, status := s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewUsers, s.Handlers.GetAdminPage), url.Values{})
+
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 2 support doesn't get the buttons it can't use
+	s.loggedInAs("support@mail.ru")
+
+	body, status := s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewUsers, s.Handlers.GetAdminPage), url.Values{})
+
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), "support@mail.ru (support)")
+	s.Contains(string(body), `id="links"`)
+	s.NotContains(string(body), `class="btn btn-sm toggle-link"`)
+	s.NotContains(string(body), `class="btn btn-primary btn-sm change-quota"`)
+
+	// 3
+	s.loggedInAs("admin@mail.ru")
+
+	body, status = s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewUsers, s.Handlers.GetAdminPage), url.Values{})
+
+	s.Equal(http.StatusOK, status)
+	s.Contains(string(body), `class="btn btn-sm toggle-link"`)
+	s.Contains(string(body), `class="btn btn-primary btn-sm change-quota"`)
+}
+
+func (s *adminSuite) TestMetrics() {
+	expvar.NewInt("admin_test_counter").Set(7)
+
+	// 1
+	s.loggedInAs("")
+
+	_, status := s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewMetrics, handlers.GetMetrics), url.Values{})
+
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 2 support can't see the internals
+	s.loggedInAs("support@mail.ru")
+
+	_, status = s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewMetrics, handlers.GetMetrics), url.Values{})
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 3
+	s.loggedInAs("admin@mail.ru")
+
+	body, status := s.MakeRequestWithQuery(http.MethodGet, s.guarded(service.PermissionViewMetrics, handlers.GetMetrics), url.Values{})
+
+	s.Equal(http.StatusOK, status)
+
+	var metrics map[string]json.RawMessage
+
+	s.NoError(json.Unmarshal(body, &metrics))
+	s.Equal("7", string(metrics["admin_test_counter"]))
+	s.NotContains(metrics, "cmdline")
+}
@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"time"
+	"urleater/internal/repository/memoryDB"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const testQuota = 3
+
+type adminSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+}
+
+func (s *adminSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	ctx := context.Background()
+
+	s.storage = memoryDB.NewStorage()
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	for _, email := range []string{"user@mail.ru", "support@mail.ru", "admin@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(ctx, email, "qwertyui", testQuota))
+	}
+
+	s.Require().NoError(s.storage.SetUserRole(ctx, "support@mail.ru", "support"))
+	s.Require().NoError(s.storage.SetUserRole(ctx, "admin@mail.ru", "admin"))
+
+	for _, shortLink := range []string{"userLink1", "userLink2"} {
+		_, err := s.storage.CreateShortLink(ctx, shortLink, "https://example.com/"+shortLink, "user@mail.ru", time.Now().Add(time.Hour))
+		s.Require().NoError(err)
+	}
+
+	s.FinishSetupTest(s.storage, s.sessionStore)
+}
+
+func (s *adminSuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
+
+// guarded wraps the handler into the middlewares its admin route has.
+func (s *adminSuite) guarded(permission string, handler base.Handler) base.Handler {
+	return s.Handlers.RequireAuth(s.Handlers.RequirePermission(permission)(handler))
+}
@@ -0,0 +1,205 @@
+package api_tokens
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/jackc/pgx/v4"
+	"net/http"
+	"strings"
+	"time"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+)
+
+func (s *apiTokensSuite) TestCreateApiToken() {
+	// 1
+	s.loggedInAs("user@mail.ru")
+
+	resp, status := s.createToken(&handlers.CreateApiTokenRequest{
+		Name:   " CI ",
+		Scopes: []string{service.ScopeReadLinks, service.ScopeCreateLinks, service.ScopeReadLinks},
+		Days:   30,
+	})
+
+	s.Equal(http.StatusOK, status)
+	s.True(strings.HasPrefix(resp.Token, resp.ApiToken.Prefix))
+	s.Equal("CI", resp.ApiToken.Name)
+	s.Equal([]string{service.ScopeCreateLinks, service.ScopeReadLinks}, resp.ApiToken.Scopes)
+	s.WithinDuration(time.Now().AddDate(0, 0, 30), *resp.ApiToken.ExpiresAt, time.Minute)
+
+	// 2 only the hash of the token is stored
+	_, err := s.storage.GetApiTokenByHash(context.Background(), resp.Token)
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 3 no expiry
+	s.loggedInAs("user@mail.ru")
+
+	resp, status = s.createToken(&handlers.CreateApiTokenRequest{Name: "cron", Scopes: []string{service.ScopeStats}})
+
+	s.Equal(http.StatusOK, status)
+	s.Nil(resp.ApiToken.ExpiresAt)
+
+	// 4
+	for _, request := range []*handlers.CreateApiTokenRequest{
+		{Name: "  ", Scopes: []string{service.ScopeStats}},
+		{Name: "CI", Scopes: []string{"links.everything"}},
+		{Name: "CI", Scopes: []string{}},
+		{Name: "CI", Scopes: []string{service.ScopeStats}, Days: 366},
+		{Name: strings.Repeat("a", 65), Scopes: []string{service.ScopeStats}},
+	} {
+		s.loggedInAs("user@mail.ru")
+
+		_, status = s.createToken(request)
+
+		s.Equal(http.StatusBadRequest, status)
+	}
+
+	// 5 tokens can't make tokens
+	s.loggedInAs("user@mail.ru")
+
+	resp, _ = s.createToken(&handlers.CreateApiTokenRequest{Name: "CI", Scopes: []string{service.ScopeCreateLinks}})
+
+	_, status = s.withToken(resp.Token, http.MethodPost, s.Handlers.RequireAuth(s.Handlers.CreateApiToken), `{"name": "more", "scopes": ["links.create"]}`)
+
+	s.Equal(http.StatusForbidden, status)
+}
+
+func (s *apiTokensSuite) TestScopes() {
+	s.loggedInAs("user@mail.ru")
+
+	resp, _ := s.createToken(&handlers.CreateApiTokenRequest{Name: "CI", Scopes: []string{service.ScopeCreateLinks, service.ScopeReadLinks}})
+
+	getLinks := s.Handlers.RequireScope(service.ScopeReadLinks)(s.Handlers.GetUserShortLinks)
+
+	// 1 links are created on behalf of the owner of the token
+	body, status := s.withToken(resp.Token, http.MethodPost, s.Handlers.RequireScope(service.ScopeCreateLinks)(s.Handlers.CreateShortLink),
+		`{"short_url": "myAlias1", "long_url": "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"}`)
+
+	var resp1 handlers.CreateShortLinkResponse
+
+	s.NoError(json.Unmarshal(body, &resp1))
+	s.Equal(http.StatusOK, status)
+	s.Equal("user@mail.ru", resp1.Link.UserEmail)
+
+	// 2
+	body, status = s.withToken(resp.Token, http.MethodGet, getLinks, "")
+
+	var resp2 handlers.GetUserShortLinksResponse
+
+	s.NoError(json.Unmarshal(body, &resp2))
+	s.Equal(http.StatusOK, status)
+	s.Len(resp2.Links, 1)
+
+	// 3 the token has no scope for it
+	_, status = s.withToken(resp.Token, http.MethodDelete, s.Handlers.RequireScope(service.ScopeDeleteLinks)(s.Handlers.DeleteShortLink), `{"short_link": "myAlias1"}`)
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 4 endpoints without a scope are for sessions only
+	_, status = s.withToken(resp.Token, http.MethodGet, s.Handlers.RequireAuth(s.Handlers.GetUser), "")
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 5 sessions may call them all
+	s.loggedInAs("user@mail.ru")
+
+	_, status = s.MakeRequestWithBody(http.MethodDelete, s.Handlers.RequireScope(service.ScopeDeleteLinks)(s.Handlers.DeleteShortLink), `{"short_link": "myAlias1"}`)
+
+	s.Equal(http.StatusOK, status)
+
+	// 6 not a token of ours
+	_, status = s.withToken("ghp_0123456789", http.MethodGet, getLinks, "")
+
+	s.Equal(http.StatusUnauthorized, status)
+
+	_, status = s.withToken(resp.Token+"x", http.MethodGet, getLinks, "")
+
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 7 the owner got disabled
+	s.Require().NoError(s.storage.SetUserDisabled(context.Background(), "user@mail.ru", true))
+
+	_, status = s.withToken(resp.Token, http.MethodGet, getLinks, "")
+
+	s.Equal(http.StatusForbidden, status)
+}
+
+func (s *apiTokensSuite) TestRevokeApiToken() {
+	s.loggedInAs("user@mail.ru")
+
+	resp, _ := s.createToken(&handlers.CreateApiTokenRequest{Name: "CI", Scopes: []string{service.ScopeReadLinks}})
+
+	getLinks := s.Handlers.RequireScope(service.ScopeReadLinks)(s.Handlers.GetUserShortLinks)
+	revoke := s.Handlers.RequireAuth(s.Handlers.RevokeApiToken)
+
+	// 1 someone else's token
+	s.loggedInAs("other@mail.ru")
+
+	_, status := s.MakeRequestWithBody(http.MethodDelete, revoke, `{"id": 1}`)
+
+	s.Equal(http.StatusNotFound, status)
+
+	// 2
+	s.loggedInAs("user@mail.ru")
+
+	_, status = s.MakeRequestWithBody(http.MethodDelete, revoke, `{"id": 1}`)
+
+	s.Equal(http.StatusOK, status)
+
+	_, status = s.withToken(resp.Token, http.MethodGet, getLinks, "")
+
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 3 revoked already
+	s.loggedInAs("user@mail.ru")
+
+	_, status = s.MakeRequestWithBody(http.MethodDelete, revoke, `{"id": 1}`)
+
+	s.Equal(http.StatusNotFound, status)
+}
+
+func (s *apiTokensSuite) TestExpiryAndLastUse() {
+	s.loggedInAs("user@mail.ru")
+
+	resp, _ := s.createToken(&handlers.CreateApiTokenRequest{Name: "CI", Scopes: []string{service.ScopeReadLinks}, Days: 1})
+
+	getLinks := s.Handlers.RequireScope(service.ScopeReadLinks)(s.Handlers.GetUserShortLinks)
+
+	// 1
+	_, status := s.withToken(resp.Token, http.MethodGet, getLinks, "")
+
+	s.Equal(http.StatusOK, status)
+
+	s.loggedInAs("user@mail.ru")
+
+	body, status := s.MakeRequestWithBody(http.MethodGet, s.Handlers.RequireAuth(s.Handlers.GetApiTokens), "")
+
+	var resp1 handlers.GetApiTokensResponse
+
+	s.NoError(json.Unmarshal(body, &resp1))
+	s.Equal(http.StatusOK, status)
+	s.Require().Len(resp1.Tokens, 1)
+	s.WithinDuration(time.Now(), *resp1.Tokens[0].LastUsedAt, time.Minute)
+
+	// 2 expired
+	expiresAt := time.Now().Add(-time.Hour)
+	sum := sha256.Sum256([]byte("ue_expired"))
+
+	_, err := s.storage.CreateApiToken(context.Background(), postgresDB.ApiToken{
+		UserEmail: "user@mail.ru",
+		Name:      "old",
+		Prefix:    "ue_expired",
+		Scopes:    []string{service.ScopeReadLinks},
+		CreatedAt: expiresAt.AddDate(0, 0, -1),
+		ExpiresAt: &expiresAt,
+	}, hex.EncodeToString(sum[:]))
+	s.Require().NoError(err)
+
+	_, status = s.withToken("ue_expired", http.MethodGet, getLinks, "")
+
+	s.Equal(http.StatusUnauthorized, status)
+}
@@ -0,0 +1,77 @@
+package api_tokens
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/memoryDB"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const testQuota = 3
+
+type apiTokensSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+}
+
+func (s *apiTokensSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	s.storage = memoryDB.NewStorage()
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	for _, email := range []string{"user@mail.ru", "other@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(context.Background(), email, "qwertyui", testQuota))
+	}
+
+	s.FinishSetupTest(s.storage, s.sessionStore)
+}
+
+func (s *apiTokensSuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
+
+// createToken makes a token of the logged-in user through the API.
+func (s *apiTokensSuite) createToken(request *handlers.CreateApiTokenRequest) (*handlers.CreateApiTokenResponse, int) {
+	res, err := json.Marshal(request)
+	s.NoError(err)
+
+	body, status := s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequireAuth(s.Handlers.CreateApiToken), string(res))
+
+	var resp handlers.CreateApiTokenResponse
+
+	if status == http.StatusOK {
+		s.NoError(json.Unmarshal(body, &resp))
+	}
+
+	return &resp, status
+}
+
+// withToken calls the handler the way a script does, with no session but the token.
+func (s *apiTokensSuite) withToken(token string, method string, h base.Handler, jsonString string) ([]byte, int) {
+	e := echo.New()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+
+	req := httptest.NewRequest(method, "http://localhost/?limit=10&offset=0", strings.NewReader(jsonString))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	if err := h(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	return rec.Body.Bytes(), rec.Code
+}
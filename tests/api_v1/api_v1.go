@@ -0,0 +1,306 @@
+package api_v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"urleater/internal/handlers"
+	"urleater/internal/service"
+)
+
+func (s *apiV1Suite) TestCreateLink() {
+	// 1
+	s.loggedInAs("user@mail.ru")
+
+	rec := s.request(http.MethodPost, "/api/v1/links", service.ScopeCreateLinks, s.Handlers.CreateLinkV1, "",
+		`{"code": "myAlias1", "long_url": "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"}`)
+
+	var resp1 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp1))
+	s.Equal(http.StatusCreated, rec.Code)
+	s.Equal("/api/v1/links/myAlias1", rec.Header().Get("Location"))
+	s.Equal(handlers.LinkResource{
+		Code:      "myAlias1",
+		ShortURL:  testBaseURL + "/myAlias1",
+		LongURL:   "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
+		ExpiresAt: resp1.Link.ExpiresAt,
+	}, resp1.Link)
+
+	// 2 a random code
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(http.MethodPost, "/api/v1/links", service.ScopeCreateLinks, s.Handlers.CreateLinkV1, "",
+		`{"long_url": "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"}`)
+
+	var resp2 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp2))
+	s.Equal(http.StatusCreated, rec.Code)
+	s.NotEmpty(resp2.Link.Code)
+	s.Equal("/api/v1/links/"+resp2.Link.Code, rec.Header().Get("Location"))
+
+	// 3 taken
+	s.loggedInAs("other@mail.ru")
+
+	rec = s.request(http.MethodPost, "/api/v1/links", service.ScopeCreateLinks, s.Handlers.CreateLinkV1, "",
+		`{"code": "myAlias1", "long_url": "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"}`)
+
+	s.Equal(http.StatusConflict, rec.Code)
+
+	// 4
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(http.MethodPost, "/api/v1/links", service.ScopeCreateLinks, s.Handlers.CreateLinkV1, "", `{"long_url": "gismeteo"}`)
+
+	s.Equal(http.StatusBadRequest, rec.Code)
+
+	// 5
+	s.loggedInAs("")
+
+	rec = s.request(http.MethodPost, "/api/v1/links", service.ScopeCreateLinks, s.Handlers.CreateLinkV1, "",
+		`{"long_url": "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"}`)
+
+	s.Equal(http.StatusUnauthorized, rec.Code)
+}
+
+func (s *apiV1Suite) TestListLinks() {
+	for _, code := range []string{"myAlias1", "myAlias2", "myAlias3"} {
+		s.createLink(code)
+	}
+
+	// 1 the default page
+	s.loggedInAs("user@mail.ru")
+
+	rec := s.request(http.MethodGet, "/api/v1/links", service.ScopeReadLinks, s.Handlers.ListLinksV1, "", "")
+
+	var resp1 handlers.ListLinksResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp1))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Len(resp1.Links, 3)
+	s.Equal(0, resp1.Offset)
+	s.Equal(20, resp1.Limit)
+
+	// 2
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(http.MethodGet, "/api/v1/links?offset=2&limit=2", service.ScopeReadLinks, s.Handlers.ListLinksV1, "", "")
+
+	var resp2 handlers.ListLinksResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp2))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Len(resp2.Links, 1)
+	s.Equal(2, resp2.Offset)
+	s.Equal(2, resp2.Limit)
+
+	// 3 someone else's links aren't listed
+	s.loggedInAs("other@mail.ru")
+
+	rec = s.request(http.MethodGet, "/api/v1/links", service.ScopeReadLinks, s.Handlers.ListLinksV1, "", "")
+
+	var resp3 handlers.ListLinksResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp3))
+	s.Equal(http.StatusOK, rec.Code)
+	s.NotNil(resp3.Links)
+	s.Empty(resp3.Links)
+
+	// 4
+	for _, query := range []string{"?limit=101", "?offset=-1", "?limit=many"} {
+		s.loggedInAs("user@mail.ru")
+
+		rec = s.request(http.MethodGet, "/api/v1/links"+query, service.ScopeReadLinks, s.Handlers.ListLinksV1, "", "")
+
+		s.Equal(http.StatusBadRequest, rec.Code)
+	}
+}
+
+func (s *apiV1Suite) TestGetLink() {
+	s.createLink("myAlias1")
+
+	// 1
+	s.loggedInAs("user@mail.ru")
+
+	rec := s.request(http.MethodGet, "/api/v1/links/myAlias1", service.ScopeReadLinks, s.Handlers.GetLinkV1, "myAlias1", "")
+
+	var resp1 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp1))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("myAlias1", resp1.Link.Code)
+
+	// 2
+	s.loggedInAs("other@mail.ru")
+
+	rec = s.request(http.MethodGet, "/api/v1/links/myAlias1", service.ScopeReadLinks, s.Handlers.GetLinkV1, "myAlias1", "")
+
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	// 3
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(http.MethodGet, "/api/v1/links/myAlias2", service.ScopeReadLinks, s.Handlers.GetLinkV1, "myAlias2", "")
+
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (s *apiV1Suite) TestUpdateLink() {
+	s.createLink("myAlias1")
+
+	// 1
+	s.loggedInAs("user@mail.ru")
+
+	rec := s.request(http.MethodPatch, "/api/v1/links/myAlias1", service.ScopeCreateLinks, s.Handlers.UpdateLinkV1, "myAlias1",
+		`{"long_url": "https://example.com"}`)
+
+	var resp1 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp1))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("https://example.com", resp1.Link.LongURL)
+
+	// 2 both at once, the link already lives longer than a day
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(http.MethodPatch, "/api/v1/links/myAlias1", service.ScopeCreateLinks, s.Handlers.UpdateLinkV1, "myAlias1",
+		`{"long_url": "https://example.org", "days": 1}`)
+
+	var resp2 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp2))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("https://example.org", resp2.Link.LongURL)
+	s.True(resp2.Link.ExpiresAt.Equal(resp1.Link.ExpiresAt))
+
+	// 3 nothing to change
+	for _, body := range []string{`{}`, `{"days": -1}`} {
+		s.loggedInAs("user@mail.ru")
+
+		rec = s.request(http.MethodPatch, "/api/v1/links/myAlias1", service.ScopeCreateLinks, s.Handlers.UpdateLinkV1, "myAlias1", body)
+
+		s.Equal(http.StatusBadRequest, rec.Code)
+	}
+
+	// 4
+	s.loggedInAs("other@mail.ru")
+
+	rec = s.request(http.MethodPatch, "/api/v1/links/myAlias1", service.ScopeCreateLinks, s.Handlers.UpdateLinkV1, "myAlias1",
+		`{"long_url": "https://example.net"}`)
+
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	// 5 nothing is changed when one of the fields is refused
+	for _, body := range []string{
+		`{"long_url": "https://example.net", "days": 1000}`,
+		`{"long_url": "https://example.net", "password": "1"}`,
+		`{"long_url": "https://example.net", "max_clicks": -1}`,
+	} {
+		s.loggedInAs("user@mail.ru")
+
+		rec = s.request(http.MethodPatch, "/api/v1/links/myAlias1", service.ScopeCreateLinks, s.Handlers.UpdateLinkV1, "myAlias1", body)
+
+		s.NotEqual(http.StatusOK, rec.Code)
+
+		link, err := s.storage.GetShortLink(context.Background(), "myAlias1")
+
+		s.Require().NoError(err)
+		s.Equal("https://example.org", link.LongUrl)
+	}
+
+	// 6 everything at once
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(http.MethodPatch, "/api/v1/links/myAlias1", service.ScopeCreateLinks, s.Handlers.UpdateLinkV1, "myAlias1",
+		`{"long_url": "https://example.net", "password": "secret12", "max_clicks": 5}`)
+
+	var resp6 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp6))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("https://example.net", resp6.Link.LongURL)
+	s.True(resp6.Link.Protected)
+	s.Require().NotNil(resp6.Link.ClicksLeft)
+	s.Equal(5, *resp6.Link.ClicksLeft)
+
+	versions, err := s.storage.GetLinkVersions(context.Background(), "myAlias1")
+
+	s.Require().NoError(err)
+	s.Len(versions, 3)
+}
+
+func (s *apiV1Suite) TestDeleteLink() {
+	s.createLink("myAlias1")
+
+	// 1
+	s.loggedInAs("user@mail.ru")
+
+	rec := s.request(http.MethodDelete, "/api/v1/links/myAlias1", service.ScopeDeleteLinks, s.Handlers.DeleteLinkV1, "myAlias1", "")
+
+	s.Equal(http.StatusNoContent, rec.Code)
+	s.Empty(rec.Body.Bytes())
+
+	// 2
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(http.MethodDelete, "/api/v1/links/myAlias1", service.ScopeDeleteLinks, s.Handlers.DeleteLinkV1, "myAlias1", "")
+
+	var resp2 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp2))
+	s.Equal(http.StatusNotFound, rec.Code)
+	s.Equal("not_found", resp2.Error.Code)
+}
+
+func (s *apiV1Suite) TestMe() {
+	s.createLink("myAlias1")
+
+	// 1
+	s.loggedInAs("user@mail.ru")
+
+	rec := s.request(http.MethodGet, "/api/v1/me", service.ScopeReadLinks, s.Handlers.GetMeV1, "", "")
+
+	var resp1 handlers.MeResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp1))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal(handlers.UserResource{Email: "user@mail.ru", UrlsLeft: testQuota - 1}, resp1.User)
+	s.NotContains(rec.Body.String(), "PasswordHash")
+}
+
+func (s *apiV1Suite) TestSubscriptions() {
+	// 1 the plans are public
+	rec := s.request(http.MethodGet, "/api/v1/subscriptions", "", s.Handlers.ListSubscriptionsV1, "", "")
+
+	var resp1 handlers.ListSubscriptionsResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp1))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal([]handlers.SubscriptionResource{
+		{Id: 1, Name: "Bronze", TotalUrls: 10, MaxLinkTTLDays: 90, Price: 9900, PeriodDays: 30},
+	}, resp1.Subscriptions)
+	s.True(strings.Contains(rec.Body.String(), `"total_urls":10`))
+}
+
+func (s *apiV1Suite) TestDeprecated() {
+	// 1 the old routes point to their successors
+	s.loggedInAs("user@mail.ru")
+
+	h := handlers.Deprecated("/api/v1/me")(s.Handlers.RequireAuth(s.Handlers.GetUser))
+
+	rec := s.request(http.MethodGet, "/user", "", h, "", "")
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("true", rec.Header().Get("Deprecation"))
+	s.Equal(`</api/v1/me>; rel="successor-version"`, rec.Header().Get("Link"))
+
+	// 2 errors included
+	s.loggedInAs("")
+
+	rec = s.request(http.MethodGet, "/user", "", h, "", "")
+
+	s.Equal(http.StatusUnauthorized, rec.Code)
+	s.Equal("true", rec.Header().Get("Deprecation"))
+}
@@ -0,0 +1,10 @@
+package api_v1
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(apiV1Suite))
+}
@@ -0,0 +1,86 @@
+package api_v1
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"net/http/httptest"
+	"strings"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const (
+	testQuota   = 3
+	testBaseURL = "https://urleater.ru"
+)
+
+var testSubscriptions = []postgresDB.Subscription{
+	{Id: 1, Name: "Bronze", TotalUrls: 10, MaxLinkTTLDays: 90, Price: 9900, PeriodDays: 30},
+}
+
+type apiV1Suite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+}
+
+func (s *apiV1Suite) SetupTest() {
+	s.BaseSetupTest()
+
+	s.storage = memoryDB.NewStorage(testSubscriptions...)
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	for _, email := range []string{"user@mail.ru", "other@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(context.Background(), email, "qwertyui", testQuota))
+	}
+
+	s.FinishSetupTest(s.storage, s.sessionStore)
+	s.Handlers.BaseURL = testBaseURL
+}
+
+func (s *apiV1Suite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
+
+// request calls the handler behind the scope of its route the way the router does, code is the path parameter.
+func (s *apiV1Suite) request(method string, target string, scope string, h base.Handler, code string, jsonString string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+
+	req := httptest.NewRequest(method, "http://localhost"+target, strings.NewReader(jsonString))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	if code != "" {
+		c.SetParamNames("code")
+		c.SetParamValues(code)
+	}
+
+	if scope != "" {
+		h = s.Handlers.RequireScope(scope)(h)
+	}
+
+	if err := h(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	return rec
+}
+
+func (s *apiV1Suite) createLink(code string) {
+	s.loggedInAs("user@mail.ru")
+
+	rec := s.request("POST", "/api/v1/links", service.ScopeCreateLinks, s.Handlers.CreateLinkV1, "",
+		`{"code": "`+code+`", "long_url": "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"}`)
+
+	s.Require().Equal(201, rec.Code)
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"urleater/internal/handlers"
+	base "urleater/tests"
+)
+
+const page = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+func (s *authSuite) TestRequireAuth() {
+	h := s.Handlers.RequireAuth(s.Handlers.GetUser)
+
+	// 1 browsers are sent to the login form
+	s.loggedInAs("")
+
+	rec := s.request(h, http.MethodGet, page)
+
+	s.Equal(http.StatusTemporaryRedirect, rec.Code)
+	s.Equal("/login", rec.Header().Get("Location"))
+
+	// 2 pages calling the API are told where to go
+	s.loggedInAs("")
+
+	rec = s.request(h, http.MethodGet, "")
+
+	var resp2 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp2))
+	s.Equal(http.StatusUnauthorized, rec.Code)
+	s.Equal("unauthorized", resp2.Error.Code)
+	s.Equal("/login", resp2.RedirectTo)
+
+	// 3 a form post isn't a navigation to redirect
+	s.loggedInAs("")
+
+	rec = s.request(h, http.MethodPost, page)
+
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	// 4 the handler gets the user from the context
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(h, http.MethodGet, "")
+
+	var resp4 handlers.GetUserResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp4))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("user@mail.ru", resp4.User.Email)
+
+	// 5 the session outlived the user
+	s.loggedInAs("deleted@mail.ru")
+
+	rec = s.request(h, http.MethodGet, "")
+
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	// 6
+	s.loggedInAs("disabled@mail.ru")
+
+	rec = s.request(h, http.MethodGet, page)
+
+	var resp6 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp6))
+	s.Equal(http.StatusForbidden, rec.Code)
+	s.Contains(resp6.Error.Message, "disabled")
+}
+
+func (s *authSuite) TestRequireAnonymous() {
+	h := s.Handlers.RequireAnonymous(ok)
+
+	// 1
+	s.loggedInAs("")
+
+	rec := s.request(h, http.MethodGet, page)
+
+	s.Equal(http.StatusOK, rec.Code)
+
+	// 2 the session outlived the user, who can log in again
+	s.loggedInAs("deleted@mail.ru")
+
+	rec = s.request(h, http.MethodPost, "")
+
+	s.Equal(http.StatusOK, rec.Code)
+
+	// 3 logged-in users are sent to the main page
+	s.loggedInAs("user@mail.ru")
+
+	rec = s.request(h, http.MethodGet, page)
+
+	s.Equal(http.StatusTemporaryRedirect, rec.Code)
+	s.Equal("/", rec.Header().Get("Location"))
+
+	// 4 login and registration forms get the same answer
+	for _, postForm := range []base.Handler{s.Handlers.PostLogin, s.Handlers.PostRegister} {
+		s.loggedInAs("user@mail.ru")
+
+		rec = s.request(s.Handlers.RequireAnonymous(postForm), http.MethodPost, "")
+
+		var resp4 handlers.ErrorResponse
+
+		s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp4))
+		s.Equal(http.StatusForbidden, rec.Code)
+		s.Equal("/", resp4.RedirectTo)
+	}
+}
+
+func (s *authSuite) TestPrincipalLoadedOnce() {
+	// 1 the session is read once however many middlewares ask
+	s.loggedInAs("user@mail.ru")
+
+	rec := s.request(s.Handlers.RequireAuth(s.Handlers.RequireAuth(s.Handlers.GetUser)), http.MethodGet, "")
+
+	s.Equal(http.StatusOK, rec.Code)
+}
+
+func (s *authSuite) TestBadSessionCookie() {
+	s.Handlers.Store = handlers.NewCookieSessionStore([]byte("0123456789abcdef0123456789abcdef"))
+
+	request := func(h base.Handler, method string, accept string, body string) *httptest.ResponseRecorder {
+		e := echo.New()
+		e.HTTPErrorHandler = handlers.HTTPErrorHandler
+
+		req := httptest.NewRequest(method, "http://localhost", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: "session_key", Value: "signed-with-an-old-secret"})
+
+		if accept != "" {
+			req.Header.Set(echo.HeaderAccept, accept)
+		}
+
+		rec := httptest.NewRecorder()
+
+		if err := h(e.NewContext(req, rec)); err != nil {
+			e.HTTPErrorHandler(err, e.NewContext(req, rec))
+		}
+
+		return rec
+	}
+
+	expired := func(rec *httptest.ResponseRecorder) bool {
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Name == "session_key" && cookie.MaxAge < 0 {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// 1 the visitor is anonymous and the cookie is dropped
+	rec := request(s.Handlers.RequireAnonymous(ok), http.MethodGet, page, "")
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.True(expired(rec))
+
+	// 2
+	rec = request(s.Handlers.RequireAuth(ok), http.MethodGet, page, "")
+
+	s.Equal(http.StatusTemporaryRedirect, rec.Code)
+	s.Equal("/login", rec.Header().Get("Location"))
+
+	rec = request(s.Handlers.RequireAuth(ok), http.MethodGet, "", "")
+
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	// 3 the visitor can log in again
+	rec = request(s.Handlers.RequireAnonymous(s.Handlers.PostLogin), http.MethodPost, "",
+		`{"email": "user@mail.ru", "password": "qwertyui"}`)
+
+	s.Equal(http.StatusOK, rec.Code)
+
+	// 4
+	rec = request(s.Handlers.GetLogout, http.MethodGet, page, "")
+
+	s.Equal(http.StatusTemporaryRedirect, rec.Code)
+	s.True(expired(rec))
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/memoryDB"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const testQuota = 3
+
+type authSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+}
+
+func (s *authSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	s.storage = memoryDB.NewStorage()
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	for _, email := range []string{"user@mail.ru", "disabled@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(context.Background(), email, "qwertyui", testQuota))
+	}
+
+	s.Require().NoError(s.storage.SetUserDisabled(context.Background(), "disabled@mail.ru", true))
+
+	s.FinishSetupTest(s.storage, s.sessionStore)
+}
+
+func (s *authSuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
+
+// request calls the handler the way a browser navigation does when accept is text/html, and the way pages call the API otherwise.
+func (s *authSuite) request(h base.Handler, method string, accept string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+
+	req := httptest.NewRequest(method, "http://localhost", nil)
+
+	if accept != "" {
+		req.Header.Set(echo.HeaderAccept, accept)
+	}
+
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	if err := h(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	return rec
+}
+
+func ok(c echo.Context) error {
+	return c.NoContent(http.StatusOK)
+}
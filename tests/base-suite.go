@@ -1,11 +1,13 @@
 package tests
 
 import (
+	"bytes"
 	"encoding/json"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/suite"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"urleater/internal/handlers"
 	"urleater/internal/service"
@@ -27,8 +29,24 @@ func (s *BaseSuite) TearDownSuite() {
 
 }
 
-func (s *BaseSuite) MakeRequestWithBody(method string, f Handler, jsonString string) ([]byte, int) {
+// newEcho handles errors and renders pages the way the server does, tests run from their suite directory.
+func newEcho() *echo.Echo {
 	e := echo.New()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+	e.Renderer = handlers.NewTemplate("../../templates/*.html")
+
+	return e
+}
+
+// serve runs the handler, writing its error response if it fails.
+func serve(c echo.Context, f Handler) {
+	if err := f(c); err != nil {
+		c.Echo().HTTPErrorHandler(err, c)
+	}
+}
+
+func (s *BaseSuite) MakeRequestWithBody(method string, f Handler, jsonString string) ([]byte, int) {
+	e := newEcho()
 
 	req := httptest.NewRequest(method, "http://localhost", strings.NewReader(jsonString))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -37,9 +55,41 @@ func (s *BaseSuite) MakeRequestWithBody(method string, f Handler, jsonString str
 
 	c := e.NewContext(req, rec)
 
-	err := f(c)
+	serve(c, f)
 
-	s.NoError(err)
+	return rec.Body.Bytes(), rec.Code
+}
+
+func (s *BaseSuite) MakeRequestWithParam(method string, f Handler, name string, value string, headers map[string]string) *httptest.ResponseRecorder {
+	e := newEcho()
+
+	req := httptest.NewRequest(method, "http://localhost", nil)
+
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames(name)
+	c.SetParamValues(value)
+
+	serve(c, f)
+
+	return rec
+}
+
+func (s *BaseSuite) MakeRequestWithQuery(method string, f Handler, query url.Values) ([]byte, int) {
+	e := newEcho()
+
+	req := httptest.NewRequest(method, "http://localhost/?"+query.Encode(), nil)
+
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	serve(c, f)
 
 	return rec.Body.Bytes(), rec.Code
 }
@@ -48,29 +98,96 @@ func (s *BaseSuite) RegisterUser(data *handlers.RegisterRequest) ([]byte, int) {
 	res, err := json.Marshal(data)
 	s.NoError(err)
 
-	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.PostRegister, string(res))
+	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequireAnonymous(s.Handlers.PostRegister), string(res))
 }
 
 func (s *BaseSuite) LoginUser(data *handlers.LoginRequest) ([]byte, int) {
 	res, err := json.Marshal(data)
 	s.NoError(err)
 
-	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.PostLogin, string(res))
+	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequireAnonymous(s.Handlers.PostLogin), string(res))
 }
 
 func (s *BaseSuite) CreateShortLink(data *handlers.CreateShortLinkRequest) ([]byte, int) {
 	res, err := json.Marshal(data)
 	s.NoError(err)
 
-	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.CreateShortLink, string(res))
+	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequireAuth(s.Handlers.CreateShortLink), string(res))
+}
+
+func (s *BaseSuite) GetShortLink(shortLink string, headers map[string]string) *httptest.ResponseRecorder {
+	return s.MakeRequestWithParam(http.MethodGet, s.Handlers.GetShortLink, "short_link", shortLink, headers)
+}
+
+func (s *BaseSuite) DeleteShortLink(data *handlers.DeleteShortLinkRequest) ([]byte, int) {
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	return s.MakeRequestWithBody(http.MethodDelete, s.Handlers.RequireAuth(s.Handlers.DeleteShortLink), string(res))
+}
+
+func (s *BaseSuite) ExtendShortLink(data *handlers.ExtendShortLinkRequest) ([]byte, int) {
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequireAuth(s.Handlers.ExtendShortLink), string(res))
+}
+
+func (s *BaseSuite) UpdateShortLink(data *handlers.UpdateShortLinkRequest) ([]byte, int) {
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequireAuth(s.Handlers.UpdateShortLink), string(res))
+}
+
+func (s *BaseSuite) GetLinkVersions(query url.Values) ([]byte, int) {
+	return s.MakeRequestWithQuery(http.MethodGet, s.Handlers.RequireAuth(s.Handlers.GetLinkVersions), query)
+}
+
+func (s *BaseSuite) RollbackShortLink(data *handlers.RollbackShortLinkRequest) ([]byte, int) {
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequireAuth(s.Handlers.RollbackShortLink), string(res))
+}
+
+func (s *BaseSuite) GetLinkStats(query url.Values) ([]byte, int) {
+	return s.MakeRequestWithQuery(http.MethodGet, s.Handlers.RequireAuth(s.Handlers.GetLinkStats), query)
+}
+
+func (s *BaseSuite) BuySubscription(data *handlers.BuySubscriptionRequest) ([]byte, int) {
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	return s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequireAuth(s.Handlers.BuySubscription), string(res))
+}
+
+func (s *BaseSuite) GetOrder(query url.Values) ([]byte, int) {
+	return s.MakeRequestWithQuery(http.MethodGet, s.Handlers.RequireAuth(s.Handlers.GetOrder), query)
+}
+
+// PaymentCallback delivers a notification of the payment provider.
+func (s *BaseSuite) PaymentCallback(payload []byte, signature string) ([]byte, int) {
+	e := newEcho()
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/payments/callback", bytes.NewReader(payload))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Signature", signature)
+
+	rec := httptest.NewRecorder()
+
+	serve(e.NewContext(req, rec), s.Handlers.PaymentCallback)
+
+	return rec.Body.Bytes(), rec.Code
 }
 
-func (s *BaseSuite) FinishSetupTest(storage service.Storage, mockSessionStore handlers.SessionStore) {
-	httpSegSvc := service.New(storage)
+func (s *BaseSuite) FinishSetupTest(storage service.Storage, mockSessionStore handlers.SessionStore, opts ...service.Option) {
+	httpSegSvc := service.New(storage, opts...)
 
 	hndls := handlers.Handlers{
 		Service: httpSegSvc,
 		Store:   mockSessionStore,
+		Unlocks: handlers.NewLinkUnlocks([]byte("test-secret")),
 	}
 
 	s.Handlers = hndls
@@ -0,0 +1,189 @@
+package click_limits
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"urleater/internal/handlers"
+)
+
+const (
+	acceptHTML = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	acceptJSON = "application/json"
+	longURL    = "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
+)
+
+func (s *clickLimitsSuite) createLink(shortLink string, maxClicks int) ([]byte, int) {
+	s.loggedInAs("test_name1@mail.ru")
+
+	return s.CreateShortLink(&handlers.CreateShortLinkRequest{ShortURL: shortLink, LongURL: longURL, MaxClicks: maxClicks})
+}
+
+func (s *clickLimitsSuite) TestCreate() {
+	// 1
+	_, code := s.createLink("myAlias1", 5)
+
+	s.Equal(http.StatusOK, code)
+
+	link, err := s.storage.GetShortLink(context.Background(), "myAlias1")
+
+	s.NoError(err)
+	s.Require().NotNil(link.ClicksLeft)
+	s.Equal(5, *link.ClicksLeft)
+
+	// 2 a negative limit makes no link
+	_, code = s.createLink("myAlias2", -1)
+
+	s.Equal(http.StatusBadRequest, code)
+
+	_, err = s.storage.GetShortLink(context.Background(), "myAlias2")
+
+	s.Error(err)
+
+	// 3 0 is for no limit
+	_, code = s.createLink("myAlias3", 0)
+
+	s.Equal(http.StatusOK, code)
+
+	link, err = s.storage.GetShortLink(context.Background(), "myAlias3")
+
+	s.NoError(err)
+	s.Nil(link.ClicksLeft)
+}
+
+func (s *clickLimitsSuite) TestOneTimeLink() {
+	_, code := s.createLink("myAlias1", 1)
+	s.Require().Equal(http.StatusOK, code)
+
+	// 1
+	rec := s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusFound, rec.Code)
+	s.Equal(longURL, rec.Header().Get("Location"))
+
+	// 2 the next visitor gets the expired page, even the owner can't renew it
+	s.loggedInAs("test_name1@mail.ru")
+
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusGone, rec.Code)
+	s.Empty(rec.Header().Get("Location"))
+	s.Contains(rec.Body.String(), "больше не работает")
+	s.NotContains(rec.Body.String(), "/extend_link")
+
+	// 3 API clients get the error envelope
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptJSON})
+
+	var resp3 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp3))
+	s.Equal(http.StatusGone, rec.Code)
+	s.Equal("expired", resp3.Error.Code)
+}
+
+func (s *clickLimitsSuite) TestConcurrentClicks() {
+	const (
+		clicks   = 3
+		visitors = 20
+	)
+
+	_, code := s.createLink("myAlias1", clicks)
+	s.Require().Equal(http.StatusOK, code)
+
+	var (
+		wg         sync.WaitGroup
+		redirected atomic.Int32
+		gone       atomic.Int32
+	)
+
+	for i := 0; i < visitors; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			switch s.GetShortLink("myAlias1", map[string]string{"Accept": acceptJSON}).Code {
+			case http.StatusFound:
+				redirected.Add(1)
+			case http.StatusGone:
+				gone.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	s.Equal(int32(clicks), redirected.Load())
+	s.Equal(int32(visitors-clicks), gone.Load())
+}
+
+func (s *clickLimitsSuite) TestChangeLimit() {
+	_, code := s.createLink("myAlias1", 1)
+	s.Require().Equal(http.StatusOK, code)
+
+	rec := s.GetShortLink("myAlias1", map[string]string{"Accept": acceptJSON})
+	s.Require().Equal(http.StatusFound, rec.Code)
+
+	// 1 the owner gives a used up link more clicks
+	s.loggedInAs("test_name1@mail.ru")
+
+	rec = s.updateLink("myAlias1", `{"max_clicks": 2}`)
+
+	var resp1 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp1))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Require().NotNil(resp1.Link.ClicksLeft)
+	s.Equal(2, *resp1.Link.ClicksLeft)
+
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptJSON})
+
+	s.Equal(http.StatusFound, rec.Code)
+
+	// 2 only the owner may change it
+	s.loggedInAs("test_name2@mail.ru")
+
+	rec = s.updateLink("myAlias1", `{"max_clicks": 0}`)
+
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	// 3 0 removes the limit
+	s.loggedInAs("test_name1@mail.ru")
+
+	rec = s.updateLink("myAlias1", `{"max_clicks": 0}`)
+
+	var resp3 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp3))
+	s.Equal(http.StatusOK, rec.Code)
+	s.Nil(resp3.Link.ClicksLeft)
+
+	for i := 0; i < 3; i++ {
+		rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptJSON})
+
+		s.Equal(http.StatusFound, rec.Code)
+	}
+}
+
+func (s *clickLimitsSuite) TestProtectedLink() {
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, code := s.CreateShortLink(&handlers.CreateShortLinkRequest{ShortURL: "myAlias1", LongURL: longURL, Password: "secret1", MaxClicks: 1})
+	s.Require().Equal(http.StatusOK, code)
+
+	// 1 visitors asked for the password don't spend the click
+	for i := 0; i < 3; i++ {
+		rec := s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML})
+
+		s.Equal(http.StatusOK, rec.Code)
+	}
+
+	link, err := s.storage.GetShortLink(context.Background(), "myAlias1")
+
+	s.NoError(err)
+	s.True(link.Protected)
+	s.Require().NotNil(link.ClicksLeft)
+	s.Equal(1, *link.ClicksLeft)
+}
@@ -0,0 +1,60 @@
+package click_limits
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+type clickLimitsSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+}
+
+func (s *clickLimitsSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	s.storage = memoryDB.NewStorage()
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	for _, email := range []string{"test_name1@mail.ru", "test_name2@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(context.Background(), email, "qwertyui", service.DefaultQuota))
+	}
+
+	s.FinishSetupTest(s.storage, s.sessionStore)
+}
+
+func (s *clickLimitsSuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
+
+// updateLink calls the /api/v1 update of the short link as the signed in user.
+func (s *clickLimitsSuite) updateLink(code string, jsonString string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+
+	req := httptest.NewRequest(http.MethodPatch, "http://localhost/api/v1/links/"+code, strings.NewReader(jsonString))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames("code")
+	c.SetParamValues(code)
+
+	if err := s.Handlers.RequireScope(service.ScopeCreateLinks)(s.Handlers.UpdateLinkV1)(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	return rec
+}
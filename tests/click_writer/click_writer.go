@@ -0,0 +1,82 @@
+package click_writer
+
+import (
+	"context"
+	"time"
+	"urleater/internal/clicks"
+	"urleater/internal/repository/postgresDB"
+)
+
+func (s *clickWriterSuite) TestBatchBySize() {
+	writer := clicks.NewWriter(s.sink, clicks.Config{
+		QueueSize:     10,
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+		FlushTimeout:  time.Second,
+	})
+	writer.Start()
+
+	for i := 0; i < 7; i++ {
+		s.True(writer.Enqueue(postgresDB.Click{ShortUrl: "myAlias1"}))
+	}
+
+	s.Eventually(func() bool {
+		return len(s.sink.Batches()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// the remaining click is flushed on close
+	s.NoError(writer.Close(context.Background()))
+
+	batches := s.sink.Batches()
+
+	s.Len(batches, 3)
+	s.Len(batches[0], 3)
+	s.Len(batches[1], 3)
+	s.Len(batches[2], 1)
+
+	s.False(writer.Enqueue(postgresDB.Click{ShortUrl: "myAlias1"}))
+}
+
+func (s *clickWriterSuite) TestBatchByInterval() {
+	writer := clicks.NewWriter(s.sink, clicks.Config{
+		QueueSize:     10,
+		BatchSize:     100,
+		FlushInterval: 20 * time.Millisecond,
+		FlushTimeout:  time.Second,
+	})
+	writer.Start()
+
+	s.True(writer.Enqueue(postgresDB.Click{ShortUrl: "myAlias1"}))
+
+	s.Eventually(func() bool {
+		return len(s.sink.Batches()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s.NoError(writer.Close(context.Background()))
+}
+
+func (s *clickWriterSuite) TestDropWhenFull() {
+	s.sink.entered = make(chan struct{}, 3)
+	s.sink.release = make(chan struct{})
+
+	writer := clicks.NewWriter(s.sink, clicks.Config{
+		QueueSize:     2,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		FlushTimeout:  time.Second,
+	})
+	writer.Start()
+
+	// the first click is taken by the blocked flush, two more fill the queue
+	s.True(writer.Enqueue(postgresDB.Click{ShortUrl: "myAlias1"}))
+	<-s.sink.entered
+	s.True(writer.Enqueue(postgresDB.Click{ShortUrl: "myAlias2"}))
+	s.True(writer.Enqueue(postgresDB.Click{ShortUrl: "myAlias3"}))
+	s.False(writer.Enqueue(postgresDB.Click{ShortUrl: "myAlias4"}))
+
+	close(s.sink.release)
+
+	s.NoError(writer.Close(context.Background()))
+
+	s.Len(s.sink.Batches(), 3)
+}
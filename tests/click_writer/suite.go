@@ -0,0 +1,46 @@
+package click_writer
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"urleater/internal/repository/postgresDB"
+)
+
+type clickWriterSuite struct {
+	suite.Suite
+
+	sink *fakeSink
+}
+
+type fakeSink struct {
+	mu      sync.Mutex
+	batches [][]postgresDB.Click
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (f *fakeSink) CreateClicks(ctx context.Context, clicks []postgresDB.Click) (int64, error) {
+	if f.release != nil {
+		f.entered <- struct{}{}
+		<-f.release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.batches = append(f.batches, append([]postgresDB.Click(nil), clicks...))
+
+	return int64(len(clicks)), nil
+}
+
+func (f *fakeSink) Batches() [][]postgresDB.Click {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.batches
+}
+
+func (s *clickWriterSuite) SetupTest() {
+	s.sink = &fakeSink{}
+}
@@ -0,0 +1,197 @@
+package code_generators
+
+import (
+	"expvar"
+	"regexp"
+	"time"
+	"urleater/internal/codegen"
+	"urleater/internal/service"
+)
+
+var (
+	base62Code = regexp.MustCompile(`^[0-9a-zA-Z]+$`)
+	wordCode   = regexp.MustCompile(`^([bdfghjklmnprstvz][aeiou])+[bdfghjklmnprstvz]?$`)
+)
+
+func (s *codeGeneratorsSuite) TestRandom() {
+	// 1
+	gen, err := codegen.NewRandom(10)
+
+	s.Require().NoError(err)
+
+	seen := make(map[string]bool)
+
+	for i := 0; i < 1000; i++ {
+		code, err := gen.Generate(s.ctx)
+
+		s.Require().NoError(err)
+		s.Len(code, 10)
+		s.Regexp(base62Code, code)
+
+		seen[code] = true
+	}
+
+	s.Len(seen, 1000)
+
+	// 2 lengths an alias can't have are refused
+	_, err = codegen.NewRandom(3)
+
+	s.Error(err)
+
+	_, err = codegen.NewRandom(21)
+
+	s.Error(err)
+}
+
+func (s *codeGeneratorsSuite) TestWords() {
+	// 1
+	gen, err := codegen.NewWords(7)
+
+	s.Require().NoError(err)
+
+	for i := 0; i < 100; i++ {
+		code, err := gen.Generate(s.ctx)
+
+		s.Require().NoError(err)
+		s.Len(code, 7)
+		s.Regexp(wordCode, code)
+	}
+
+	// 2
+	_, err = codegen.NewWords(0)
+
+	s.Error(err)
+}
+
+func (s *codeGeneratorsSuite) TestSequence() {
+	const links = 5000
+
+	// 1 numbers of the counter never give the same code
+	gen, err := codegen.NewSequence(s.storage, 4)
+
+	s.Require().NoError(err)
+
+	seen := make(map[string]bool)
+	var previous string
+
+	for i := 0; i < links; i++ {
+		code, err := gen.Generate(s.ctx)
+
+		s.Require().NoError(err)
+		s.Len(code, 4)
+		s.Regexp(base62Code, code)
+		s.NotEqual(previous, code)
+
+		seen[code] = true
+		previous = code
+	}
+
+	s.Len(seen, links)
+
+	// 2 consecutive codes don't look alike
+	first, err := gen.Generate(s.ctx)
+	s.Require().NoError(err)
+
+	second, err := gen.Generate(s.ctx)
+	s.Require().NoError(err)
+
+	s.NotEqual(first[:2], second[:2])
+
+	// 3 the codes get longer once the keyspace is used up
+	gen, err = codegen.NewSequence(&counterFrom{n: 62*62*62*62 - 2}, 4)
+
+	s.Require().NoError(err)
+
+	code, err := gen.Generate(s.ctx)
+
+	s.NoError(err)
+	s.Len(code, 4)
+
+	code, err = gen.Generate(s.ctx)
+
+	s.NoError(err)
+	s.Len(code, 5)
+}
+
+func (s *codeGeneratorsSuite) TestTakenCodes() {
+	// 1 taken and reserved codes are skipped
+	_, err := s.storage.CreateShortLink(s.ctx, "taken1", "https://ya.ru", testEmail, time.Now().Add(time.Hour))
+
+	s.Require().NoError(err)
+
+	codes := &fixedCodes{codes: []string{"taken1", "login", "free1"}}
+	srv := service.New(s.storage, service.WithCodeGenerator(codes))
+
+	link, err := srv.CreateShortLink(s.ctx, "", "https://ya.ru", testEmail)
+
+	s.NoError(err)
+	s.Equal("free1", link.ShortUrl)
+	s.Empty(codes.codes)
+
+	// 2 it gives up after a while
+	for i := 0; i < 10; i++ {
+		codes.codes = append(codes.codes, "taken1")
+	}
+
+	_, err = srv.CreateShortLink(s.ctx, "", "https://ya.ru", testEmail)
+
+	s.ErrorContains(err, "could not generate short link in 10 tries")
+
+	// 3 aliases are not generated
+	link, err = srv.CreateShortLink(s.ctx, "myAlias1", "https://ya.ru", testEmail)
+
+	s.NoError(err)
+	s.Equal("myAlias1", link.ShortUrl)
+}
+
+func (s *codeGeneratorsSuite) TestPool() {
+	// 1 the pool is filled up to its size, skipping the codes of links
+	_, err := s.storage.CreateShortLink(s.ctx, "taken1", "https://ya.ru", testEmail, time.Now().Add(time.Hour))
+
+	s.Require().NoError(err)
+
+	source := &fixedCodes{codes: []string{"taken1", "pooled1", "pooled2", "pooled3", "pooled1"}}
+	pool := codegen.NewPool(s.storage, source, 3)
+
+	s.NoError(pool.Refill(s.ctx))
+
+	count, err := s.storage.CountPoolCodes(s.ctx)
+
+	s.NoError(err)
+	s.Equal(3, count)
+	s.Equal([]string{"pooled1"}, source.codes)
+
+	// 2 new links claim the pooled codes
+	srv := service.New(s.storage, service.WithCodeGenerator(pool))
+	claimed := make(map[string]bool)
+
+	for i := 0; i < 3; i++ {
+		link, err := srv.CreateShortLink(s.ctx, "", "https://ya.ru", testEmail)
+
+		s.Require().NoError(err)
+
+		claimed[link.ShortUrl] = true
+	}
+
+	s.Equal(map[string]bool{"pooled1": true, "pooled2": true, "pooled3": true}, claimed)
+
+	// 3 an empty pool falls back to its source
+	source.codes = []string{"direct1"}
+
+	link, err := srv.CreateShortLink(s.ctx, "", "https://ya.ru", testEmail)
+
+	s.NoError(err)
+	s.Equal("direct1", link.ShortUrl)
+
+	// 4 refilling gives up when the source only makes taken codes
+	source.codes = []string{"pooled1", "pooled2", "pooled3"}
+
+	s.ErrorContains(pool.Refill(s.ctx), "none of 3 generated codes were free")
+
+	// 5 the pool publishes its metrics
+	metrics := expvar.Get("code_pool").(*expvar.Map)
+
+	s.Equal("3", metrics.Get("claimed").String())
+	s.Equal("1", metrics.Get("fallbacks").String())
+	s.Equal("0", metrics.Get("depth").String())
+}
@@ -0,0 +1,10 @@
+package code_generators
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(codeGeneratorsSuite))
+}
@@ -0,0 +1,46 @@
+package code_generators
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"urleater/internal/repository/memoryDB"
+)
+
+const testEmail = "test_name1@mail.ru"
+
+type codeGeneratorsSuite struct {
+	suite.Suite
+
+	ctx     context.Context
+	storage *memoryDB.Storage
+}
+
+// fixedCodes hands out the codes in order, like a generator that keeps hitting taken ones
+type fixedCodes struct {
+	codes []string
+}
+
+func (f *fixedCodes) Generate(ctx context.Context) (string, error) {
+	code := f.codes[0]
+	f.codes = f.codes[1:]
+
+	return code, nil
+}
+
+// counterFrom is a codegen.Counter starting past n
+type counterFrom struct {
+	n int64
+}
+
+func (c *counterFrom) NextLinkNumber(ctx context.Context) (int64, error) {
+	c.n++
+
+	return c.n, nil
+}
+
+func (s *codeGeneratorsSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.storage = memoryDB.NewStorage()
+
+	s.Require().NoError(s.storage.CreateUser(s.ctx, testEmail, "qwertyui", 100))
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"time"
+	"urleater/internal/config"
+)
+
+func (s *configSuite) TestDefaults() {
+	cfg, args, err := config.Load(nil, s.lookupEnv)
+
+	s.Require().NoError(err)
+	s.Empty(args)
+	s.Equal(config.Default(), *cfg)
+
+	// secrets have no defaults
+	s.ErrorContains(cfg.Validate(), "session.secret is required")
+	s.ErrorContains(cfg.Validate(), "stats.secret is required")
+
+	cfg.DB.Driver = config.DriverMemory
+
+	s.NoError(cfg.Validate())
+}
+
+func (s *configSuite) TestPrecedence() {
+	path := s.writeFile(`
+db:
+  driver: sqlite
+  sqlite_path: file.db
+http:
+  addr: ":9000"
+  public_base_url: https://file.example
+links:
+  ttl: 720h
+`)
+
+	// 1 file over defaults
+	s.env["URLEATER_CONFIG"] = path
+
+	cfg, _, err := config.Load(nil, s.lookupEnv)
+
+	s.Require().NoError(err)
+	s.Equal(config.DriverSQLite, cfg.DB.Driver)
+	s.Equal("file.db", cfg.DB.SQLitePath)
+	s.Equal(":9000", cfg.HTTP.Addr)
+	s.Equal(30*24*time.Hour, cfg.Links.TTL)
+	s.Equal(10, cfg.Links.DefaultQuota)
+
+	// 2 env over file
+	s.env["URLEATER_HTTP_ADDR"] = ":9001"
+	s.env["URLEATER_LINKS_DEFAULT_QUOTA"] = "3"
+
+	cfg, _, err = config.Load(nil, s.lookupEnv)
+
+	s.Require().NoError(err)
+	s.Equal(":9001", cfg.HTTP.Addr)
+	s.Equal(3, cfg.Links.DefaultQuota)
+	s.Equal("https://file.example", cfg.HTTP.PublicBaseURL)
+
+	// 3 flags over env, the rest are returned
+	cfg, args, err := config.Load([]string{"-http-addr=:9002", "-auto-migrate=false", "migrate", "up"}, s.lookupEnv)
+
+	s.Require().NoError(err)
+	s.Equal(":9002", cfg.HTTP.Addr)
+	s.False(cfg.DB.AutoMigrate)
+	s.Equal([]string{"migrate", "up"}, args)
+
+	// 4 -config flag over URLEATER_CONFIG
+	other := s.writeFile("db:\n  driver: memory\n")
+
+	cfg, _, err = config.Load([]string{"-config", other}, s.lookupEnv)
+
+	s.Require().NoError(err)
+	s.Equal(config.DriverMemory, cfg.DB.Driver)
+}
+
+func (s *configSuite) TestBoolFlags() {
+	s.env["URLEATER_DB_AUTO_MIGRATE"] = "false"
+
+	// 1 a bare bool flag is set and leaves the subcommand alone
+	cfg, args, err := config.Load([]string{"-auto-migrate", "config", "print"}, s.lookupEnv)
+
+	s.Require().NoError(err)
+	s.True(cfg.DB.AutoMigrate)
+	s.Equal([]string{"config", "print"}, args)
+
+	// 2 it still takes an explicit value
+	cfg, args, err = config.Load([]string{"-dev-mode", "-auto-migrate=false", "migrate", "up"}, s.lookupEnv)
+
+	s.Require().NoError(err)
+	s.True(cfg.DevMode)
+	s.False(cfg.DB.AutoMigrate)
+	s.Equal([]string{"migrate", "up"}, args)
+
+	// 3
+	_, _, err = config.Load([]string{"-auto-migrate=maybe"}, s.lookupEnv)
+
+	s.ErrorContains(err, "-auto-migrate")
+}
+
+func (s *configSuite) TestInvalid() {
+	// 1 unparsable values are all reported
+	s.env["URLEATER_LINKS_DEFAULT_QUOTA"] = "many"
+	s.env["URLEATER_LINKS_TTL"] = "forever"
+
+	_, _, err := config.Load(nil, s.lookupEnv)
+
+	s.ErrorContains(err, "URLEATER_LINKS_DEFAULT_QUOTA")
+	s.ErrorContains(err, "URLEATER_LINKS_TTL")
+
+	// 2 unknown keys in the file
+	s.env = map[string]string{"URLEATER_CONFIG": s.writeFile("http:\n  port: 8080\n")}
+
+	_, _, err = config.Load(nil, s.lookupEnv)
+
+	s.Error(err)
+
+	// 3 unknown flag
+	_, _, err = config.Load([]string{"-port=8080"}, nil)
+
+	s.Error(err)
+
+	// 4 validation
+	cfg := config.Default()
+	cfg.DB.Driver = "mysql"
+	cfg.HTTP.PublicBaseURL = "localhost"
+	cfg.Links.TTL = time.Minute
+	cfg.Links.DefaultQuota = -1
+	cfg.Links.CodeGenerator = "uuid"
+	cfg.Links.CodeLength = 2
+	cfg.Links.CodePoolSize = -1
+	cfg.Cache.Size = -1
+	cfg.Subscriptions.CheckInterval = 0
+	cfg.Payments.Provider = "paypal"
+
+	err = cfg.Validate()
+
+	s.ErrorContains(err, "db.driver")
+	s.ErrorContains(err, "http.public_base_url")
+	s.ErrorContains(err, "session.secret")
+	s.ErrorContains(err, "stats.secret")
+	s.ErrorContains(err, "links.ttl")
+	s.ErrorContains(err, "links.default_quota")
+	s.ErrorContains(err, "links.code_generator")
+	s.ErrorContains(err, "links.code_length")
+	s.ErrorContains(err, "links.code_pool_size")
+	s.ErrorContains(err, "cache.size")
+	s.ErrorContains(err, "subscriptions.check_interval")
+	s.ErrorContains(err, "payments.provider")
+
+	// 5 the fake payment provider needs dev mode
+	cfg = config.Default()
+	cfg.DB.Driver = config.DriverMemory
+	cfg.Payments.Provider = config.PaymentsFake
+
+	s.ErrorContains(cfg.Validate(), "dev_mode")
+
+	cfg.DevMode = true
+
+	s.NoError(cfg.Validate())
+
+	// 6 trusted proxies are CIDRs
+	cfg = config.Default()
+	cfg.DB.Driver = config.DriverMemory
+	cfg.HTTP.TrustedProxies = "10.0.0.0/8, 192.168.1.1"
+
+	s.ErrorContains(cfg.Validate(), "http.trusted_proxies")
+
+	cfg.HTTP.TrustedProxies = "10.0.0.0/8, 192.168.1.1/32"
+
+	s.NoError(cfg.Validate())
+}
+
+func (s *configSuite) TestPrint() {
+	s.env["URLEATER_SESSION_SECRET"] = "very-secret"
+	s.env["URLEATER_DB_POSTGRES_PASSWORD"] = "also-secret"
+	s.env["URLEATER_STATS_SECRET"] = "one-more-secret"
+
+	cfg, _, err := config.Load(nil, s.lookupEnv)
+
+	s.Require().NoError(err)
+
+	var out bytes.Buffer
+
+	s.Require().NoError(cfg.RunCommand([]string{"print"}, &out))
+	s.NotContains(out.String(), "very-secret")
+	s.NotContains(out.String(), "also-secret")
+	s.NotContains(out.String(), "one-more-secret")
+	s.Contains(out.String(), "postgres_host: localhost")
+	s.Equal("very-secret", cfg.Session.Secret)
+
+	// the output is a valid config file
+	s.env = map[string]string{"URLEATER_CONFIG": s.writeFile(out.String())}
+
+	printed, _, err := config.Load(nil, s.lookupEnv)
+
+	s.Require().NoError(err)
+	s.Equal(cfg.HTTP, printed.HTTP)
+	s.Equal(cfg.Links, printed.Links)
+}
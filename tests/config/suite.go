@@ -0,0 +1,29 @@
+package config
+
+import (
+	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+)
+
+type configSuite struct {
+	suite.Suite
+
+	env map[string]string
+}
+
+func (s *configSuite) SetupTest() {
+	s.env = make(map[string]string)
+}
+
+func (s *configSuite) lookupEnv(key string) (string, bool) {
+	value, ok := s.env[key]
+	return value, ok
+}
+
+func (s *configSuite) writeFile(content string) string {
+	path := filepath.Join(s.T().TempDir(), "urleater.yaml")
+	s.Require().NoError(os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
@@ -3,7 +3,11 @@ package create_short_link
 import (
 	"encoding/json"
 	"net/http"
+	"os"
+	"strings"
 	"urleater/internal/handlers"
+	"urleater/internal/payments"
+	"urleater/internal/service"
 )
 
 func (s *createShortLinkSuite) TestCreateShortLink() {
@@ -30,7 +34,7 @@ func (s *createShortLinkSuite) TestCreateShortLink() {
 		LongURL:  "www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 3
 	body, code = s.CreateShortLink(&handlers.CreateShortLinkRequest{
@@ -38,7 +42,7 @@ func (s *createShortLinkSuite) TestCreateShortLink() {
 		LongURL:  "",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 4
 	longUrl4 := "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
@@ -65,7 +69,7 @@ func (s *createShortLinkSuite) TestCreateShortLink() {
 		LongURL:  "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 7
 	_, code = s.CreateShortLink(&handlers.CreateShortLinkRequest{
@@ -73,7 +77,7 @@ func (s *createShortLinkSuite) TestCreateShortLink() {
 		LongURL:  "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 8
 	longUrl8 := "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
@@ -100,6 +104,55 @@ func (s *createShortLinkSuite) TestCreateShortLink() {
 		LongURL:  "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
+	// 10
+	body, code = s.CreateShortLink(&handlers.CreateShortLinkRequest{
+		ShortURL: alias4,
+		LongURL:  "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
+	})
+
+	var resp10 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(body, &resp10))
+	s.Equal(http.StatusConflict, code)
+	s.Equal("already_exists", resp10.Error.Code)
+	s.Equal("short link myAlias1 already exists", resp10.Error.Message)
+}
+
+func (s *createShortLinkSuite) TestReservedNames() {
+	// the routes load the pages relative to the repository root
+	wd, err := os.Getwd()
+	s.Require().NoError(err)
+	s.Require().NoError(os.Chdir("../.."))
+
+	defer func() {
+		s.Require().NoError(os.Chdir(wd))
+	}()
+
+	e := handlers.GetRoutes(&s.Handlers)
+
+	paths := []string{payments.CheckoutPath}
+
+	for _, route := range e.Routes() {
+		paths = append(paths, route.Path)
+	}
+
+	static, err := os.ReadDir("static")
+	s.Require().NoError(err)
+
+	for _, entry := range static {
+		paths = append(paths, "/"+entry.Name())
+	}
+
+	// 1 no short link can take the first segment of a path the site answers itself
+	for _, path := range paths {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			continue
+		}
+
+		s.True(service.IsReservedName(segment), "%s is not reserved", segment)
+	}
 }
@@ -1,9 +1,10 @@
 package create_short_link
 
 import (
-	"github.com/jackc/pgx/v4"
+	"context"
 	"github.com/stretchr/testify/mock"
-	"urleater/internal/repository/postgresDB"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/service"
 	base "urleater/tests"
 	"urleater/tests/mocks"
 )
@@ -15,43 +16,13 @@ type createShortLinkSuite struct {
 func (s *createShortLinkSuite) SetupTest() {
 	s.BaseSetupTest()
 
-	storage := mocks.NewStorage(s.T())
+	storage := memoryDB.NewStorage()
 	sessionStore := mocks.NewSessionStore(s.T())
 
-	sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return("any_email", nil)
+	sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return("any_email", nil).Maybe()
 
-	storage.On("GetShortLink", mock.Anything, mock.Anything).Return(nil, pgx.ErrNoRows)
-
-	// 1
-	longUrl1 := "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
-	createdNewLink1 := postgresDB.Link{
-		ShortUrl: "new_short_link",
-		LongUrl:  longUrl1,
-	}
-
-	storage.On("CreateShortLink", mock.Anything, mock.Anything, longUrl1, mock.Anything).Return(&createdNewLink1, nil).Once()
-
-	// 4
-	longUrl4 := "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
-	alias4 := "myAlias1"
-
-	createdNewLink4 := postgresDB.Link{
-		ShortUrl: alias4,
-		LongUrl:  longUrl4,
-	}
-
-	storage.On("CreateShortLink", mock.Anything, alias4, longUrl4, mock.Anything).Return(&createdNewLink4, nil).Once()
-
-	// 8
-	longUrl8 := "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
-	alias8 := "myCustomAlias1234567"
-
-	createdNewLink8 := postgresDB.Link{
-		ShortUrl: alias8,
-		LongUrl:  longUrl8,
-	}
-
-	storage.On("CreateShortLink", mock.Anything, alias8, longUrl8, mock.Anything).Return(&createdNewLink8, nil).Once()
+	err := storage.CreateUser(context.Background(), "any_email", "qwertyui", service.DefaultQuota)
+	s.NoError(err)
 
 	s.FinishSetupTest(storage, sessionStore)
 }
@@ -0,0 +1,166 @@
+package extend_short_link
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"urleater/internal/handlers"
+	"urleater/internal/service"
+)
+
+const day = 24 * time.Hour
+
+func (s *extendShortLinkSuite) extend(data *handlers.ExtendShortLinkRequest) (handlers.ExtendShortLinkResponse, int) {
+	body, status := s.ExtendShortLink(data)
+
+	var resp handlers.ExtendShortLinkResponse
+
+	if status == http.StatusOK {
+		s.NoError(json.Unmarshal(body, &resp))
+	}
+
+	return resp, status
+}
+
+func (s *extendShortLinkSuite) errorCode(data *handlers.ExtendShortLinkRequest) (string, int) {
+	body, status := s.ExtendShortLink(data)
+
+	var resp handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(body, &resp))
+
+	return resp.Error.Code, status
+}
+
+func (s *extendShortLinkSuite) TestAccess() {
+	// 1 anonymous
+	s.loggedInAs("")
+
+	body, status := s.ExtendShortLink(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias1"})
+
+	var resp1 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(body, &resp1))
+	s.Equal(http.StatusUnauthorized, status)
+	s.Equal("/login", resp1.RedirectTo)
+
+	// 2 somebody else's link
+	s.loggedInAs("test_name1@mail.ru")
+
+	code, status := s.errorCode(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias2"})
+
+	s.Equal(http.StatusForbidden, status)
+	s.Equal("forbidden", code)
+
+	// 3
+	s.loggedInAs("test_name1@mail.ru")
+
+	code, status = s.errorCode(&handlers.ExtendShortLinkRequest{ShortLink: "unknownAlias"})
+
+	s.Equal(http.StatusNotFound, status)
+	s.Equal("not_found", code)
+
+	// 4
+	s.loggedInAs("test_name1@mail.ru")
+
+	code, status = s.errorCode(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias1", Days: -1})
+
+	s.Equal(http.StatusBadRequest, status)
+	s.Equal("invalid_input", code)
+}
+
+func (s *extendShortLinkSuite) TestDefaultTTL() {
+	// 1
+	s.loggedInAs("test_name1@mail.ru")
+
+	resp, status := s.extend(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias1"})
+
+	s.Equal(http.StatusOK, status)
+	s.Equal("myAlias1", resp.Link.ShortUrl)
+	s.WithinDuration(time.Now().Add(service.DefaultLinkTTL), resp.Link.ExpiresAt, time.Minute)
+
+	// 2 expired links come back to life
+	s.loggedInAs("test_name1@mail.ru")
+
+	resp, status = s.extend(&handlers.ExtendShortLinkRequest{ShortLink: "deadAlias"})
+
+	s.Equal(http.StatusOK, status)
+	s.WithinDuration(time.Now().Add(service.DefaultLinkTTL), resp.Link.ExpiresAt, time.Minute)
+
+	rec := s.GetShortLink("deadAlias", nil)
+
+	s.Equal(http.StatusFound, rec.Code)
+}
+
+func (s *extendShortLinkSuite) TestSubscriptionLimit() {
+	// 1 users without a subscription are limited by the link TTL
+	s.loggedInAs("test_name1@mail.ru")
+
+	code, status := s.errorCode(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias1", Days: 91})
+
+	s.Equal(http.StatusForbidden, status)
+	s.Equal("forbidden", code)
+
+	// 2
+	s.loggedInAs("test_name1@mail.ru")
+
+	resp, status := s.extend(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias1", Days: 30})
+
+	s.Equal(http.StatusOK, status)
+	s.WithinDuration(time.Now().Add(30*day), resp.Link.ExpiresAt, time.Minute)
+
+	// 3 Gold allows a year
+	s.loggedInAs("test_name2@mail.ru")
+
+	resp, status = s.extend(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias2", Days: 365})
+
+	s.Equal(http.StatusOK, status)
+	s.WithinDuration(time.Now().Add(365*day), resp.Link.ExpiresAt, time.Minute)
+
+	// 4 but not more
+	s.loggedInAs("test_name2@mail.ru")
+
+	code, status = s.errorCode(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias2", Days: 366})
+
+	s.Equal(http.StatusForbidden, status)
+	s.Equal("forbidden", code)
+
+	// 5 plans allowing less than the link TTL are extended by their maximum when no days are given
+	s.loggedInAs("test_name3@mail.ru")
+
+	resp, status = s.extend(&handlers.ExtendShortLinkRequest{ShortLink: "trialAlias"})
+
+	s.Equal(http.StatusOK, status)
+	s.WithinDuration(time.Now().Add(7*day), resp.Link.ExpiresAt, time.Minute)
+
+	// 6 but asking for more is refused
+	s.loggedInAs("test_name3@mail.ru")
+
+	code, status = s.errorCode(&handlers.ExtendShortLinkRequest{ShortLink: "trialAlias", Days: 8})
+
+	s.Equal(http.StatusForbidden, status)
+	s.Equal("forbidden", code)
+}
+
+func (s *extendShortLinkSuite) TestNeverShortens() {
+	// 1
+	s.loggedInAs("test_name2@mail.ru")
+
+	resp, status := s.extend(&handlers.ExtendShortLinkRequest{ShortLink: "longAlias", Days: 30})
+
+	s.Equal(http.StatusOK, status)
+	s.WithinDuration(time.Now().Add(400*day), resp.Link.ExpiresAt, time.Minute)
+
+	// 2 a shorter renewal of a freshly extended link is a no-op as well
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.extend(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias1", Days: 60})
+	s.Equal(http.StatusOK, status)
+
+	s.loggedInAs("test_name1@mail.ru")
+
+	resp, status = s.extend(&handlers.ExtendShortLinkRequest{ShortLink: "myAlias1", Days: 10})
+
+	s.Equal(http.StatusOK, status)
+	s.WithinDuration(time.Now().Add(60*day), resp.Link.ExpiresAt, time.Minute)
+}
@@ -0,0 +1,61 @@
+package extend_short_link
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"time"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+type extendShortLinkSuite struct {
+	base.BaseSuite
+
+	sessionStore *mocks.SessionStore
+}
+
+func (s *extendShortLinkSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	ctx := context.Background()
+
+	storage := memoryDB.NewStorage(
+		postgresDB.Subscription{Id: 1, Name: "Bronze", TotalUrls: 10, MaxLinkTTLDays: 90},
+		postgresDB.Subscription{Id: 2, Name: "Gold", TotalUrls: 200, MaxLinkTTLDays: 365},
+		postgresDB.Subscription{Id: 3, Name: "Trial", TotalUrls: 3, MaxLinkTTLDays: 7},
+	)
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	// test_name1 has no subscription, test_name2 is on Gold, test_name3 is on Trial
+	s.Require().NoError(storage.CreateUser(ctx, "test_name1@mail.ru", "qwertyui", service.DefaultQuota))
+	s.Require().NoError(storage.CreateUser(ctx, "test_name2@mail.ru", "qwertyui", service.DefaultQuota))
+	s.Require().NoError(storage.CreateUser(ctx, "test_name3@mail.ru", "qwertyui", service.DefaultQuota))
+	s.Require().NoError(storage.SetUserSubscription(ctx, "test_name2@mail.ru", 2))
+	s.Require().NoError(storage.SetUserSubscription(ctx, "test_name3@mail.ru", 3))
+
+	links := []struct {
+		short     string
+		email     string
+		expiresAt time.Time
+	}{
+		{"myAlias1", "test_name1@mail.ru", time.Now().Add(time.Hour)},
+		{"myAlias2", "test_name2@mail.ru", time.Now().Add(time.Hour)},
+		{"deadAlias", "test_name1@mail.ru", time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{"longAlias", "test_name2@mail.ru", time.Now().Add(400 * 24 * time.Hour)},
+		{"trialAlias", "test_name3@mail.ru", time.Now().Add(time.Hour)},
+	}
+
+	for _, link := range links {
+		_, err := storage.CreateShortLink(ctx, link.short, "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset", link.email, link.expiresAt)
+		s.Require().NoError(err)
+	}
+
+	s.FinishSetupTest(storage, s.sessionStore)
+}
+
+func (s *extendShortLinkSuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
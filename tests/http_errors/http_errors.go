@@ -0,0 +1,70 @@
+package http_errors
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"urleater/internal/service"
+)
+
+func (s *httpErrorsSuite) TestServiceErrors() {
+	for _, tc := range []struct {
+		kind   error
+		status int
+		code   string
+	}{
+		{service.ErrInvalidInput, http.StatusBadRequest, "invalid_input"},
+		{service.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+		{service.ErrForbidden, http.StatusForbidden, "forbidden"},
+		{service.ErrNotFound, http.StatusNotFound, "not_found"},
+		{service.ErrAlreadyExists, http.StatusConflict, "already_exists"},
+		{service.ErrExpired, http.StatusGone, "expired"},
+		{service.ErrDisabled, http.StatusGone, "disabled"},
+		{service.ErrQuotaExceeded, http.StatusTooManyRequests, "quota_exceeded"},
+	} {
+		err := fmt.Errorf("wrapped: %w", &service.Error{Kind: tc.kind, Message: "something happened"})
+
+		resp, status := s.get(err)
+
+		s.Equal(tc.status, status, tc.code)
+		s.Equal(tc.code, resp.Error.Code)
+		s.Equal("something happened", resp.Error.Message)
+		s.Empty(resp.RedirectTo)
+	}
+}
+
+func (s *httpErrorsSuite) TestInternalErrors() {
+	// 1 details stay in the log
+	resp, status := s.get(errors.New("CreateShortLink query error | connection refused"))
+
+	s.Equal(http.StatusInternalServerError, status)
+	s.Equal("internal", resp.Error.Code)
+	s.Equal("internal server error", resp.Error.Message)
+
+	// 2 a bare sentinel carries no message meant for users
+	resp, status = s.get(fmt.Errorf("GetUser: %w", service.ErrNotFound))
+
+	s.Equal(http.StatusInternalServerError, status)
+	s.Equal("internal", resp.Error.Code)
+}
+
+func (s *httpErrorsSuite) TestEchoErrors() {
+	// 1
+	resp, status := s.get(echo.ErrNotFound)
+
+	s.Equal(http.StatusNotFound, status)
+	s.Equal("not_found", resp.Error.Code)
+	s.Equal("Not Found", resp.Error.Message)
+
+	// 2
+	resp, status = s.get(echo.ErrMethodNotAllowed)
+
+	s.Equal(http.StatusMethodNotAllowed, status)
+	s.Equal("method_not_allowed", resp.Error.Code)
+
+	// 3
+	_, status = s.handle(http.MethodHead, echo.ErrNotFound)
+
+	s.Equal(http.StatusNotFound, status)
+}
@@ -0,0 +1,36 @@
+package http_errors
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"urleater/internal/handlers"
+)
+
+type httpErrorsSuite struct {
+	suite.Suite
+}
+
+// handle writes the response the server gives for err.
+func (s *httpErrorsSuite) handle(method string, err error) (handlers.ErrorResponse, int) {
+	e := echo.New()
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(method, "http://localhost/create_link", nil), rec)
+
+	handlers.HTTPErrorHandler(err, c)
+
+	var resp handlers.ErrorResponse
+
+	if rec.Body.Len() > 0 {
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+
+	return resp, rec.Code
+}
+
+func (s *httpErrorsSuite) get(err error) (handlers.ErrorResponse, int) {
+	return s.handle(http.MethodGet, err)
+}
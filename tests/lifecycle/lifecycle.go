@@ -0,0 +1,59 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+func (s *lifecycleSuite) TestOrder() {
+	s.manager.OnShutdown("pool", 0, s.stopper("pool", nil))
+	s.manager.OnShutdown("writer", 0, s.stopper("writer", nil))
+	s.manager.OnShutdown("http", 0, s.stopper("http", nil))
+
+	// 1 reverse order of registration
+	s.NoError(s.manager.Shutdown(context.Background()))
+	s.Equal([]string{"http", "writer", "pool"}, s.stopped)
+	s.Contains(s.logs.String(), "stopping writer")
+	s.Contains(s.logs.String(), "stopped writer in")
+
+	// 2 only once
+	s.NoError(s.manager.Shutdown(context.Background()))
+	s.Len(s.stopped, 3)
+}
+
+func (s *lifecycleSuite) TestErrors() {
+	errWriter := errors.New("queue is stuck")
+
+	s.manager.OnShutdown("pool", 0, s.stopper("pool", nil))
+	s.manager.OnShutdown("writer", 0, s.stopper("writer", errWriter))
+	s.manager.OnShutdown("http", 0, s.stopper("http", nil))
+
+	err := s.manager.Shutdown(context.Background())
+
+	// a failing component doesn't keep the rest running
+	s.ErrorIs(err, errWriter)
+	s.ErrorContains(err, "writer: queue is stuck")
+	s.Equal([]string{"http", "writer", "pool"}, s.stopped)
+	s.Contains(s.logs.String(), "could not stop writer: queue is stuck")
+}
+
+func (s *lifecycleSuite) TestTimeout() {
+	s.manager.OnShutdown("pool", 0, func(ctx context.Context) error {
+		// the deadline of the previous hook does not leak into the next one
+		return ctx.Err()
+	})
+
+	s.manager.OnShutdown("http", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	started := time.Now()
+	err := s.manager.Shutdown(context.Background())
+
+	s.ErrorIs(err, context.DeadlineExceeded)
+	s.ErrorContains(err, "http")
+	s.NotContains(err.Error(), "pool")
+	s.Less(time.Since(started), time.Second)
+}
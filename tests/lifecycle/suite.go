@@ -0,0 +1,31 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/suite"
+	"log"
+	"urleater/internal/lifecycle"
+)
+
+type lifecycleSuite struct {
+	suite.Suite
+
+	logs    bytes.Buffer
+	manager *lifecycle.Manager
+	stopped []string
+}
+
+func (s *lifecycleSuite) SetupTest() {
+	s.logs.Reset()
+	s.stopped = nil
+	s.manager = lifecycle.New(log.New(&s.logs, "", 0))
+}
+
+// stopper records the order components are stopped in.
+func (s *lifecycleSuite) stopper(name string, err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		s.stopped = append(s.stopped, name)
+		return err
+	}
+}
@@ -0,0 +1,245 @@
+package link_cache
+
+import (
+	"expvar"
+	"fmt"
+	"strconv"
+	"time"
+	"urleater/internal/repository/cachedDB"
+	"urleater/internal/repository/postgresDB"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func (s *linkCacheSuite) TestReadThrough() {
+	s.createLink("myAlias1")
+
+	hits := expvar.Get("link_cache").(*expvar.Map).Get("hits").(*expvar.Int)
+	hitsBefore := hits.Value()
+
+	// 1 the first lookup reaches the storage, the next ones don't
+	lookups := s.lookupsOf(func() {
+		for i := 0; i < 3; i++ {
+			link, err := s.cache.GetShortLink(s.ctx, "myAlias1")
+
+			s.Require().NoError(err)
+			s.Equal("https://ya.ru", link.LongUrl)
+		}
+	})
+
+	s.Equal(1, lookups)
+	s.Equal(int64(2), hits.Value()-hitsBefore)
+
+	// 2 callers can't change the cached link
+	link, err := s.cache.GetShortLink(s.ctx, "myAlias1")
+
+	s.Require().NoError(err)
+
+	link.LongUrl = "https://google.com"
+
+	link, err = s.cache.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Equal("https://ya.ru", link.LongUrl)
+
+	// 3 missing links are cached as such
+	lookups = s.lookupsOf(func() {
+		for i := 0; i < 3; i++ {
+			_, err := s.cache.GetShortLink(s.ctx, "unknownAlias")
+
+			s.ErrorIs(err, pgx.ErrNoRows)
+		}
+	})
+
+	s.Equal(1, lookups)
+
+	// 4 until they are created
+	_, err = s.cache.CreateShortLinkWithQuota(s.ctx, "unknownAlias", "https://ya.ru", testEmail, time.Now().Add(time.Hour), postgresDB.LinkOptions{})
+
+	s.Require().NoError(err)
+
+	_, err = s.cache.GetShortLink(s.ctx, "unknownAlias")
+
+	s.NoError(err)
+	s.Equal([]string{"unknownAlias"}, s.notifier.Notified())
+}
+
+func (s *linkCacheSuite) TestExpiry() {
+	cache := cachedDB.NewStorage(s.backend, cachedDB.Config{Size: 64, TTL: 500 * time.Millisecond, MissTTL: 10 * time.Millisecond}, nil)
+
+	s.createLink("myAlias1")
+
+	// 1 links are kept for the TTL
+	lookups := s.lookupsOf(func() {
+		_, err := cache.GetShortLink(s.ctx, "myAlias1")
+		s.NoError(err)
+
+		_, err = cache.GetShortLink(s.ctx, "unknownAlias")
+		s.ErrorIs(err, pgx.ErrNoRows)
+
+		time.Sleep(20 * time.Millisecond)
+
+		_, err = cache.GetShortLink(s.ctx, "myAlias1")
+		s.NoError(err)
+
+		_, err = cache.GetShortLink(s.ctx, "unknownAlias")
+		s.ErrorIs(err, pgx.ErrNoRows)
+	})
+
+	s.Equal(3, lookups)
+
+	// 2 and looked up again after it
+	time.Sleep(500 * time.Millisecond)
+
+	lookups = s.lookupsOf(func() {
+		_, err := cache.GetShortLink(s.ctx, "myAlias1")
+		s.NoError(err)
+	})
+
+	s.Equal(1, lookups)
+}
+
+func (s *linkCacheSuite) TestEviction() {
+	const links = 200
+
+	// 1 the cache keeps no more links than its size
+	for i := 0; i < links; i++ {
+		s.createLink(fmt.Sprintf("myAlias%d", i))
+
+		_, err := s.cache.GetShortLink(s.ctx, fmt.Sprintf("myAlias%d", i))
+
+		s.Require().NoError(err)
+	}
+
+	size, err := strconv.Atoi(expvar.Get("link_cache").(*expvar.Map).Get("size").String())
+
+	s.NoError(err)
+	s.LessOrEqual(size, testConfig.Size)
+
+	// 2 the least recently used ones are evicted
+	lookups := s.lookupsOf(func() {
+		_, err := s.cache.GetShortLink(s.ctx, "myAlias0")
+		s.NoError(err)
+	})
+
+	s.Equal(1, lookups)
+}
+
+func (s *linkCacheSuite) TestInvalidation() {
+	s.createLink("myAlias1")
+
+	cached := func() bool {
+		return s.lookupsOf(func() {
+			_, _ = s.cache.GetShortLink(s.ctx, "myAlias1")
+		}) == 0
+	}
+
+	// 1 every change drops the link and is announced
+	changes := []func() error{
+		func() error {
+			_, err := s.cache.UpdateShortLink(s.ctx, "myAlias1", "https://google.com", testEmail)
+			return err
+		},
+		func() error {
+			_, err := s.cache.ExtendShortLink(s.ctx, "myAlias1", time.Now().Add(48*time.Hour))
+			return err
+		},
+		func() error {
+			_, err := s.cache.SetLinkDisabled(s.ctx, "myAlias1", true)
+			return err
+		},
+		func() error {
+			return s.cache.DeleteShortLink(s.ctx, "myAlias1", testEmail, 0)
+		},
+	}
+
+	for _, change := range changes {
+		cached()
+
+		s.True(cached())
+
+		s.Require().NoError(change())
+
+		s.False(cached())
+	}
+
+	s.Equal([]string{"myAlias1", "myAlias1", "myAlias1", "myAlias1"}, s.notifier.Notified())
+
+	// 2 the changes are visible right away
+	_, err := s.cache.GetShortLink(s.ctx, "myAlias1")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (s *linkCacheSuite) TestClickLimits() {
+	s.createLink("myAlias1")
+
+	clicks := 2
+
+	_, err := s.cache.SetLinkClicks(s.ctx, "myAlias1", &clicks)
+	s.Require().NoError(err)
+
+	// 1 spending a click keeps the link cached
+	_, err = s.cache.GetShortLink(s.ctx, "myAlias1")
+	s.Require().NoError(err)
+
+	_, err = s.cache.ConsumeLinkClick(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Zero(s.lookupsOf(func() {
+		_, _ = s.cache.GetShortLink(s.ctx, "myAlias1")
+	}))
+
+	// 2 spending the last one drops it everywhere
+	_, err = s.cache.ConsumeLinkClick(s.ctx, "myAlias1")
+
+	s.NoError(err)
+
+	link, err := s.cache.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Require().NotNil(link.ClicksLeft)
+	s.Zero(*link.ClicksLeft)
+	s.Equal([]string{"myAlias1", "myAlias1"}, s.notifier.Notified())
+}
+
+func (s *linkCacheSuite) TestOtherInstances() {
+	s.createLink("myAlias1")
+	s.createLink("myAlias2")
+
+	_, err := s.cache.GetShortLink(s.ctx, "myAlias1")
+	s.Require().NoError(err)
+
+	s.cache.Start()
+
+	defer func() {
+		s.NoError(s.cache.Close(s.ctx))
+	}()
+
+	// 1 nothing cached before listening is trusted
+	s.Eventually(func() bool {
+		return s.notifier.listens.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	s.Equal(1, s.lookupsOf(func() {
+		_, err := s.cache.GetShortLink(s.ctx, "myAlias1")
+		s.NoError(err)
+	}))
+
+	_, err = s.cache.GetShortLink(s.ctx, "myAlias2")
+	s.Require().NoError(err)
+
+	// 2 links changed by the other instances are dropped, the second notification waits for the first one
+	s.notifier.remote <- "myAlias1"
+	s.notifier.remote <- "unknownAlias"
+
+	s.Equal(1, s.lookupsOf(func() {
+		_, err := s.cache.GetShortLink(s.ctx, "myAlias1")
+		s.NoError(err)
+	}))
+
+	s.Zero(s.lookupsOf(func() {
+		_, err := s.cache.GetShortLink(s.ctx, "myAlias2")
+		s.NoError(err)
+	}))
+}
@@ -0,0 +1,105 @@
+package link_cache
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"sync"
+	"sync/atomic"
+	"time"
+	"urleater/internal/repository/cachedDB"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/repository/postgresDB"
+)
+
+const testEmail = "test_name1@mail.ru"
+
+var testConfig = cachedDB.Config{
+	Size:    64,
+	TTL:     time.Hour,
+	MissTTL: time.Hour,
+}
+
+type linkCacheSuite struct {
+	suite.Suite
+
+	ctx      context.Context
+	backend  *countingStorage
+	notifier *fakeNotifier
+	cache    *cachedDB.Storage
+}
+
+// countingStorage counts the lookups that get past the cache
+type countingStorage struct {
+	*memoryDB.Storage
+
+	lookups atomic.Int32
+}
+
+func (c *countingStorage) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	c.lookups.Add(1)
+
+	return c.Storage.GetShortLink(ctx, shortLink)
+}
+
+// fakeNotifier stands for NOTIFY: the test sends the notifications of the other instances to remote
+type fakeNotifier struct {
+	mu       sync.Mutex
+	notified []string
+	remote   chan string
+	// listens counts the times it started listening
+	listens atomic.Int32
+}
+
+func (f *fakeNotifier) NotifyLinkChanged(ctx context.Context, shortLink string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.notified = append(f.notified, shortLink)
+
+	return nil
+}
+
+func (f *fakeNotifier) ListenLinkChanges(ctx context.Context, listening func(), changed func(shortLink string)) error {
+	listening()
+	f.listens.Add(1)
+
+	for {
+		select {
+		case shortLink := <-f.remote:
+			changed(shortLink)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (f *fakeNotifier) Notified() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.notified
+}
+
+func (s *linkCacheSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.backend = &countingStorage{Storage: memoryDB.NewStorage()}
+	s.notifier = &fakeNotifier{remote: make(chan string)}
+	s.cache = cachedDB.NewStorage(s.backend, testConfig, s.notifier)
+
+	s.Require().NoError(s.backend.CreateUser(s.ctx, testEmail, "qwertyui", 100))
+}
+
+func (s *linkCacheSuite) createLink(shortLink string) {
+	_, err := s.backend.CreateShortLink(s.ctx, shortLink, "https://ya.ru", testEmail, time.Now().Add(time.Hour))
+
+	s.Require().NoError(err)
+}
+
+// lookupsOf tells how many of the calls to GetShortLink reached the storage
+func (s *linkCacheSuite) lookupsOf(calls func()) int {
+	before := s.backend.lookups.Load()
+
+	calls()
+
+	return int(s.backend.lookups.Load() - before)
+}
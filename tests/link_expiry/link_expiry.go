@@ -0,0 +1,74 @@
+package link_expiry
+
+import (
+	"encoding/json"
+	"net/http"
+	"urleater/internal/handlers"
+)
+
+const (
+	acceptHTML = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	acceptJSON = "application/json"
+)
+
+func (s *linkExpirySuite) TestLiveLink() {
+	rec := s.GetShortLink("liveAlias", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusFound, rec.Code)
+	s.Equal("https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset", rec.Header().Get("Location"))
+}
+
+func (s *linkExpirySuite) TestExpiredLink() {
+	// 1 owner is offered to renew
+	s.loggedInAs("test_name1@mail.ru")
+
+	rec := s.GetShortLink("deadAlias", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusGone, rec.Code)
+	s.Empty(rec.Header().Get("Location"))
+	s.Contains(rec.Body.String(), "01.10.2024")
+	s.Contains(rec.Body.String(), "/extend_link")
+
+	// 2 anybody else is not
+	s.loggedInAs("test_name2@mail.ru")
+
+	rec = s.GetShortLink("deadAlias", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusGone, rec.Code)
+	s.NotContains(rec.Body.String(), "/extend_link")
+
+	// 3 neither are anonymous users
+	s.loggedInAs("")
+
+	rec = s.GetShortLink("deadAlias", nil)
+
+	s.Equal(http.StatusGone, rec.Code)
+	s.NotContains(rec.Body.String(), "/extend_link")
+
+	// 4 API clients get the error envelope
+	rec = s.GetShortLink("deadAlias", map[string]string{"Accept": acceptJSON})
+
+	var resp4 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp4))
+	s.Equal(http.StatusGone, rec.Code)
+	s.Equal("expired", resp4.Error.Code)
+}
+
+func (s *linkExpirySuite) TestUnknownLink() {
+	// 1
+	rec := s.GetShortLink("unknownAlias", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusNotFound, rec.Code)
+	s.Contains(rec.Header().Get("Content-Type"), "text/html")
+	s.Contains(rec.Body.String(), "unknownAlias")
+
+	// 2
+	rec = s.GetShortLink("unknownAlias", map[string]string{"Accept": acceptJSON})
+
+	var resp2 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp2))
+	s.Equal(http.StatusNotFound, rec.Code)
+	s.Equal("not_found", resp2.Error.Code)
+}
@@ -0,0 +1,40 @@
+package link_expiry
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"time"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+type linkExpirySuite struct {
+	base.BaseSuite
+
+	sessionStore *mocks.SessionStore
+}
+
+func (s *linkExpirySuite) SetupTest() {
+	s.BaseSetupTest()
+
+	ctx := context.Background()
+
+	storage := memoryDB.NewStorage()
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	s.Require().NoError(storage.CreateUser(ctx, "test_name1@mail.ru", "qwertyui", service.DefaultQuota))
+
+	_, err := storage.CreateShortLink(ctx, "liveAlias", "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset", "test_name1@mail.ru", time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+
+	_, err = storage.CreateShortLink(ctx, "deadAlias", "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset", "test_name1@mail.ru", time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+
+	s.FinishSetupTest(storage, s.sessionStore)
+}
+
+func (s *linkExpirySuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
@@ -0,0 +1,10 @@
+package link_passwords
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(linkPasswordsSuite))
+}
@@ -0,0 +1,269 @@
+package link_passwords
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"urleater/internal/handlers"
+)
+
+const (
+	acceptHTML = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+	acceptJSON = "application/json"
+	longURL    = "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
+)
+
+func (s *linkPasswordsSuite) createLink(shortLink string, password string) ([]byte, int) {
+	s.loggedInAs("test_name1@mail.ru")
+
+	return s.CreateShortLink(&handlers.CreateShortLinkRequest{ShortURL: shortLink, LongURL: longURL, Password: password})
+}
+
+func (s *linkPasswordsSuite) TestCreate() {
+	// 1
+	_, code := s.createLink("myAlias1", "secret1")
+
+	s.Equal(http.StatusOK, code)
+
+	link, err := s.storage.GetShortLink(context.Background(), "myAlias1")
+
+	s.NoError(err)
+	s.True(link.Protected)
+
+	// 2 a too short password makes no link
+	_, code = s.createLink("myAlias2", "abc")
+
+	s.Equal(http.StatusBadRequest, code)
+
+	_, err = s.storage.GetShortLink(context.Background(), "myAlias2")
+
+	s.Error(err)
+
+	// 3 no password, no protection
+	_, code = s.createLink("myAlias3", "")
+
+	s.Equal(http.StatusOK, code)
+
+	rec := s.GetShortLink("myAlias3", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusFound, rec.Code)
+}
+
+func (s *linkPasswordsSuite) TestLockedLink() {
+	_, code := s.createLink("myAlias1", "secret1")
+	s.Require().Equal(http.StatusOK, code)
+
+	// 1 browsers are asked for the password
+	rec := s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get("Location"))
+	s.Contains(rec.Body.String(), `name="password"`)
+	s.NotContains(rec.Body.String(), "gismeteo")
+
+	// 2 API clients get the error envelope
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptJSON})
+
+	var resp2 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp2))
+	s.Equal(http.StatusUnauthorized, rec.Code)
+	s.Equal("unauthorized", resp2.Error.Code)
+}
+
+func (s *linkPasswordsSuite) TestUnlock() {
+	_, code := s.createLink("myAlias1", "secret1")
+	s.Require().Equal(http.StatusOK, code)
+
+	// 1 a wrong password shows the page again
+	rec := s.unlock("myAlias1", "secret2", "10.0.0.1", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusUnauthorized, rec.Code)
+	s.Contains(rec.Body.String(), "wrong password")
+	s.Empty(rec.Result().Cookies())
+
+	// 2
+	rec = s.unlock("myAlias1", "secret1", "10.0.0.1", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusSeeOther, rec.Code)
+	s.Equal("/myAlias1", rec.Header().Get("Location"))
+	s.Require().Len(rec.Result().Cookies(), 1)
+
+	cookie := rec.Result().Cookies()[0]
+
+	s.Equal("/myAlias1", cookie.Path)
+	s.True(cookie.HttpOnly)
+
+	// 3 the cookie lets the visitor through
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML, "Cookie": cookie.String()})
+
+	s.Equal(http.StatusFound, rec.Code)
+	s.Equal(longURL, rec.Header().Get("Location"))
+
+	// 4 but not to another link
+	_, code = s.createLink("myAlias2", "secret1")
+	s.Require().Equal(http.StatusOK, code)
+
+	rec = s.GetShortLink("myAlias2", map[string]string{"Accept": acceptHTML, "Cookie": "unlocked_myAlias2=" + cookie.Value})
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get("Location"))
+
+	// 5
+	rec = s.unlock("unknownAlias", "secret1", "10.0.0.1", map[string]string{"Accept": acceptJSON})
+
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (s *linkPasswordsSuite) TestRateLimit() {
+	_, code := s.createLink("myAlias1", "secret1")
+	s.Require().Equal(http.StatusOK, code)
+
+	// 1
+	for i := 0; i < 5; i++ {
+		rec := s.unlock("myAlias1", "secret2", "10.0.0.1", map[string]string{"Accept": acceptJSON})
+
+		s.Equal(http.StatusUnauthorized, rec.Code)
+	}
+
+	// 2 even the right password is refused for a while
+	rec := s.unlock("myAlias1", "secret1", "10.0.0.1", map[string]string{"Accept": acceptJSON})
+
+	var resp2 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp2))
+	s.Equal(http.StatusTooManyRequests, rec.Code)
+	s.Equal("rate_limited", resp2.Error.Code)
+
+	// 3 other visitors are not
+	rec = s.unlock("myAlias1", "secret1", "10.0.0.2", map[string]string{"Accept": acceptJSON})
+
+	s.Equal(http.StatusSeeOther, rec.Code)
+}
+
+func (s *linkPasswordsSuite) TestLockout() {
+	_, code := s.createLink("myAlias1", "secret1")
+	s.Require().Equal(http.StatusOK, code)
+
+	// 1 the address is taken from the connection, forged headers don't make new visitors
+	for i := 0; i < 5; i++ {
+		rec := s.unlock("myAlias1", "secret2", "10.0.0.1", map[string]string{"Accept": acceptJSON, echo.HeaderXForwardedFor: fmt.Sprintf("10.1.0.%d", i), echo.HeaderXRealIP: fmt.Sprintf("10.1.0.%d", i)})
+
+		s.Equal(http.StatusUnauthorized, rec.Code)
+	}
+
+	rec := s.unlock("myAlias1", "secret2", "10.0.0.1", map[string]string{"Accept": acceptJSON, echo.HeaderXForwardedFor: "10.1.0.99"})
+
+	s.Equal(http.StatusTooManyRequests, rec.Code)
+
+	// 2 guessing from many addresses doesn't lock out a visitor who knows the password
+	for i := 0; i < 60; i++ {
+		rec = s.unlock("myAlias1", "secret2", fmt.Sprintf("10.2.0.%d", i), map[string]string{"Accept": acceptJSON})
+
+		s.Equal(http.StatusUnauthorized, rec.Code)
+	}
+
+	rec = s.unlock("myAlias1", "secret1", "10.0.0.2", map[string]string{"Accept": acceptJSON})
+
+	s.Equal(http.StatusSeeOther, rec.Code)
+
+	// 3 but every visitor gets a single try meanwhile
+	rec = s.unlock("myAlias1", "secret2", "10.0.0.3", map[string]string{"Accept": acceptJSON})
+
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	rec = s.unlock("myAlias1", "secret1", "10.0.0.3", map[string]string{"Accept": acceptJSON})
+
+	s.Equal(http.StatusTooManyRequests, rec.Code)
+}
+
+func (s *linkPasswordsSuite) TestChangePassword() {
+	_, code := s.createLink("myAlias1", "")
+	s.Require().Equal(http.StatusOK, code)
+
+	// 1
+	s.loggedInAs("test_name1@mail.ru")
+
+	rec := s.updateLink("myAlias1", `{"password": "secret1"}`)
+
+	var resp1 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp1))
+	s.Equal(http.StatusOK, rec.Code)
+	s.True(resp1.Link.Protected)
+
+	// 2 only the owner may change it
+	s.loggedInAs("test_name2@mail.ru")
+
+	rec = s.updateLink("myAlias1", `{"password": ""}`)
+
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	// 3 an empty password removes the protection
+	s.loggedInAs("test_name1@mail.ru")
+
+	rec = s.updateLink("myAlias1", `{"password": ""}`)
+
+	var resp3 handlers.LinkResponse
+
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp3))
+	s.Equal(http.StatusOK, rec.Code)
+	s.False(resp3.Link.Protected)
+
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML})
+
+	s.Equal(http.StatusFound, rec.Code)
+}
+
+func (s *linkPasswordsSuite) TestOutdatedUnlock() {
+	_, code := s.createLink("myAlias1", "secret1")
+	s.Require().Equal(http.StatusOK, code)
+
+	unlocked := func() string {
+		rec := s.unlock("myAlias1", "secret1", "10.0.0.1", map[string]string{"Accept": acceptHTML})
+		s.Require().Equal(http.StatusSeeOther, rec.Code)
+		s.Require().Len(rec.Result().Cookies(), 1)
+
+		return rec.Result().Cookies()[0].String()
+	}
+
+	// 1 a new password asks the visitors again
+	cookie := unlocked()
+
+	s.loggedInAs("test_name1@mail.ru")
+	s.Require().Equal(http.StatusOK, s.updateLink("myAlias1", `{"password": "secret2"}`).Code)
+
+	rec := s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML, "Cookie": cookie})
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get("Location"))
+
+	// 2 setting the old password back doesn't bring the old cookies back either
+	s.loggedInAs("test_name1@mail.ru")
+	s.Require().Equal(http.StatusOK, s.updateLink("myAlias1", `{"password": "secret1"}`).Code)
+
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML, "Cookie": cookie})
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get("Location"))
+
+	// 3 nor does a link created again under the same alias
+	cookie = unlocked()
+
+	s.Require().NoError(s.storage.DeleteShortLink(context.Background(), "myAlias1", "test_name1@mail.ru", 0))
+
+	_, code = s.createLink("myAlias1", "secret1")
+	s.Require().Equal(http.StatusOK, code)
+
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML, "Cookie": cookie})
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Empty(rec.Header().Get("Location"))
+
+	// 4
+	rec = s.GetShortLink("myAlias1", map[string]string{"Accept": acceptHTML, "Cookie": unlocked()})
+
+	s.Equal(http.StatusFound, rec.Code)
+}
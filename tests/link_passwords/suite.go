@@ -0,0 +1,89 @@
+package link_passwords
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+type linkPasswordsSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+}
+
+func (s *linkPasswordsSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	s.storage = memoryDB.NewStorage()
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	for _, email := range []string{"test_name1@mail.ru", "test_name2@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(context.Background(), email, "qwertyui", service.DefaultQuota))
+	}
+
+	s.FinishSetupTest(s.storage, s.sessionStore)
+}
+
+func (s *linkPasswordsSuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
+
+// unlock posts the password form of the short link from the client address, the way the page does.
+func (s *linkPasswordsSuite) unlock(shortLink string, password string, client string, headers map[string]string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+	e.Renderer = handlers.NewTemplate("../../templates/*.html")
+	e.IPExtractor = handlers.IPExtractor(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/"+shortLink, strings.NewReader(url.Values{"password": {password}}.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.RemoteAddr = client + ":41000"
+
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames("short_link")
+	c.SetParamValues(shortLink)
+
+	if err := s.Handlers.UnlockShortLink(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	return rec
+}
+
+// updateLink calls the /api/v1 update of the short link as the signed in user.
+func (s *linkPasswordsSuite) updateLink(code string, jsonString string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+
+	req := httptest.NewRequest(http.MethodPatch, "http://localhost/api/v1/links/"+code, strings.NewReader(jsonString))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetParamNames("code")
+	c.SetParamValues(code)
+
+	if err := s.Handlers.RequireScope(service.ScopeCreateLinks)(s.Handlers.UpdateLinkV1)(c); err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	return rec
+}
@@ -0,0 +1,149 @@
+package link_quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"urleater/internal/handlers"
+)
+
+const longLink = "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
+
+func (s *linkQuotaSuite) create(alias string) int {
+	_, status := s.CreateShortLink(&handlers.CreateShortLinkRequest{ShortURL: alias, LongURL: longLink})
+
+	return status
+}
+
+func (s *linkQuotaSuite) delete(alias string) int {
+	_, status := s.DeleteShortLink(&handlers.DeleteShortLinkRequest{ShortLink: alias})
+
+	return status
+}
+
+func (s *linkQuotaSuite) TestQuotaExceeded() {
+	s.loggedInAs("test_name1@mail.ru", testQuota+2)
+
+	// 1
+	for i := 1; i <= testQuota; i++ {
+		s.Equal(http.StatusOK, s.create(fmt.Sprintf("myAlias%d", i)))
+	}
+
+	s.Equal(0, s.urlsLeft("test_name1@mail.ru"))
+
+	// 2
+	body, status := s.CreateShortLink(&handlers.CreateShortLinkRequest{ShortURL: "myAlias9", LongURL: longLink})
+
+	var resp2 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(body, &resp2))
+	s.Equal(http.StatusTooManyRequests, status)
+	s.Equal("quota_exceeded", resp2.Error.Code)
+
+	// 3 a taken alias fails before the quota is looked at
+	s.Equal(http.StatusConflict, s.create("myAlias1"))
+}
+
+func (s *linkQuotaSuite) TestTakenAliasIsFree() {
+	s.loggedInAs("test_name1@mail.ru", 2)
+
+	s.Equal(http.StatusOK, s.create("myAlias1"))
+	s.Equal(http.StatusConflict, s.create("myAlias1"))
+
+	s.Equal(testQuota-1, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *linkQuotaSuite) TestRefunds() {
+	// 1 no subscription, no refund
+	s.loggedInAs("test_name1@mail.ru", 2)
+
+	s.Equal(http.StatusOK, s.create("myAlias1"))
+	s.Equal(http.StatusOK, s.delete("myAlias1"))
+
+	s.Equal(testQuota-1, s.urlsLeft("test_name1@mail.ru"))
+
+	// 2 Bronze doesn't refund either
+	s.loggedInAs("test_name2@mail.ru", 2)
+
+	s.Equal(http.StatusOK, s.create("myAlias2"))
+	s.Equal(http.StatusOK, s.delete("myAlias2"))
+
+	s.Equal(testQuota-1, s.urlsLeft("test_name2@mail.ru"))
+
+	// 3 Gold does
+	s.loggedInAs("test_name3@mail.ru", 2)
+
+	s.Equal(http.StatusOK, s.create("myAlias3"))
+	s.Equal(http.StatusOK, s.delete("myAlias3"))
+
+	s.Equal(testQuota, s.urlsLeft("test_name3@mail.ru"))
+
+	// 4 but only for links that are still there
+	s.loggedInAs("test_name3@mail.ru", 1)
+
+	s.Equal(http.StatusNotFound, s.delete("myAlias3"))
+
+	s.Equal(testQuota, s.urlsLeft("test_name3@mail.ru"))
+}
+
+func (s *linkQuotaSuite) TestConcurrentCreation() {
+	const attempts = 50
+
+	s.loggedInAs("test_name1@mail.ru", attempts)
+
+	statuses := make(chan int, attempts)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// generated short links, so that requests only compete for the quota
+			statuses <- s.create("")
+		}()
+	}
+
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+
+	for status := range statuses {
+		counts[status]++
+	}
+
+	s.Equal(map[int]int{
+		http.StatusOK:              testQuota,
+		http.StatusTooManyRequests: attempts - testQuota,
+	}, counts)
+	s.Equal(0, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *linkQuotaSuite) TestConcurrentDeletion() {
+	const attempts = 20
+
+	s.loggedInAs("test_name3@mail.ru", attempts+1)
+
+	s.Require().Equal(http.StatusOK, s.create("myAlias1"))
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			s.delete("myAlias1")
+		}()
+	}
+
+	wg.Wait()
+
+	// refunded once
+	s.Equal(testQuota, s.urlsLeft("test_name3@mail.ru"))
+}
@@ -0,0 +1,52 @@
+package link_quota
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/repository/postgresDB"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const testQuota = 3
+
+type linkQuotaSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+}
+
+func (s *linkQuotaSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	ctx := context.Background()
+
+	s.storage = memoryDB.NewStorage(
+		postgresDB.Subscription{Id: 1, Name: "Bronze", TotalUrls: 10, MaxLinkTTLDays: 90},
+		postgresDB.Subscription{Id: 2, Name: "Gold", TotalUrls: 200, MaxLinkTTLDays: 365, RefundDeletedLinks: true},
+	)
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	// test_name1 has no subscription, test_name2 is on Bronze, test_name3 is on Gold
+	for _, email := range []string{"test_name1@mail.ru", "test_name2@mail.ru", "test_name3@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(ctx, email, "qwertyui", testQuota))
+	}
+
+	s.Require().NoError(s.storage.SetUserSubscription(ctx, "test_name2@mail.ru", 1))
+	s.Require().NoError(s.storage.SetUserSubscription(ctx, "test_name3@mail.ru", 2))
+
+	s.FinishSetupTest(s.storage, s.sessionStore)
+}
+
+func (s *linkQuotaSuite) loggedInAs(email string, requests int) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Times(requests)
+}
+
+func (s *linkQuotaSuite) urlsLeft(email string) int {
+	user, err := s.storage.GetUser(context.Background(), email)
+	s.Require().NoError(err)
+
+	return user.UrlsLeft
+}
@@ -0,0 +1,73 @@
+package link_stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"urleater/internal/handlers"
+)
+
+func (s *linkStatsSuite) TestGetLinkStats() {
+	// 1
+	body, code := s.GetLinkStats(url.Values{
+		"short_link": {"myAlias1"},
+		"from":       {"2024-10-01"},
+		"to":         {"2024-10-04"},
+	})
+
+	s.Equal(http.StatusOK, code)
+
+	var resp1 handlers.GetLinkStatsResponse
+
+	err := json.Unmarshal(body, &resp1)
+
+	s.NoError(err)
+
+	s.Equal(5, resp1.Stats.TotalClicks)
+	s.Equal(3, resp1.Stats.UniqueClicks)
+	s.Len(resp1.Stats.Series, 3)
+	s.Equal(0, resp1.Stats.Series[0].Clicks)
+	s.Equal(5, resp1.Stats.Series[1].Clicks)
+	s.Equal(0, resp1.Stats.Series[2].Clicks)
+	s.Equal("t.me", resp1.Stats.TopReferrers[0].Value)
+	s.Equal("unknown", resp1.Stats.TopReferrers[1].Value)
+
+	// 2
+	_, code = s.GetLinkStats(url.Values{
+		"short_link": {"myAlias2"},
+	})
+
+	s.Equal(http.StatusForbidden, code)
+
+	// 3
+	_, code = s.GetLinkStats(url.Values{
+		"short_link": {"myAlias1"},
+		"interval":   {"month"},
+	})
+
+	s.Equal(http.StatusBadRequest, code)
+
+	// 4
+	_, code = s.GetLinkStats(url.Values{
+		"short_link": {"myAlias1"},
+		"from":       {"yesterday"},
+	})
+
+	s.Equal(http.StatusBadRequest, code)
+
+	// 5
+	_, code = s.GetLinkStats(url.Values{})
+
+	s.Equal(http.StatusBadRequest, code)
+
+	// 6
+	body, code = s.GetLinkStats(url.Values{
+		"short_link": {"myAlias3"},
+	})
+
+	var resp6 handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(body, &resp6))
+	s.Equal(http.StatusNotFound, code)
+	s.Equal("not_found", resp6.Error.Code)
+}
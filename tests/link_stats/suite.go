@@ -0,0 +1,57 @@
+package link_stats
+
+import (
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/mock"
+	"time"
+	"urleater/internal/repository/postgresDB"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+type linkStatsSuite struct {
+	base.BaseSuite
+}
+
+func (s *linkStatsSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	storage := mocks.NewStorage(s.T())
+	sessionStore := mocks.NewSessionStore(s.T())
+
+	sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return("test_name1@mail.ru", nil)
+	storage.On("GetUser", mock.Anything, "test_name1@mail.ru").Return(&postgresDB.User{Email: "test_name1@mail.ru"}, nil)
+	storage.On("GetUserRole", mock.Anything, "test_name1@mail.ru").Return(&postgresDB.Role{Name: "user", Permissions: []string{}}, nil)
+
+	// 1
+	link1 := postgresDB.Link{
+		ShortUrl:  "myAlias1",
+		LongUrl:   "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
+		UserEmail: "test_name1@mail.ru",
+	}
+
+	storage.On("GetShortLink", mock.Anything, "myAlias1").Return(&link1, nil).Once()
+	storage.On("GetClickTotals", mock.Anything, "myAlias1", mock.Anything, mock.Anything).Return(5, 3, nil).Once()
+	storage.On("GetClickSeries", mock.Anything, "myAlias1", mock.Anything, mock.Anything, "day").
+		Return([]postgresDB.ClickBucket{
+			{Start: time.Date(2024, 10, 2, 0, 0, 0, 0, time.UTC), Clicks: 5, UniqueClicks: 3},
+		}, nil).Once()
+	storage.On("GetTopClickValues", mock.Anything, "myAlias1", postgresDB.ClickDimensionReferrer, mock.Anything, mock.Anything, mock.Anything).
+		Return([]postgresDB.ClickCount{{Value: "t.me", Clicks: 4}, {Value: "", Clicks: 1}}, nil).Once()
+	storage.On("GetTopClickValues", mock.Anything, "myAlias1", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]postgresDB.ClickCount{}, nil).Times(3)
+
+	// 2
+	link2 := postgresDB.Link{
+		ShortUrl:  "myAlias2",
+		LongUrl:   "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
+		UserEmail: "test_name2@mail.ru",
+	}
+
+	storage.On("GetShortLink", mock.Anything, "myAlias2").Return(&link2, nil).Once()
+
+	// 6
+	storage.On("GetShortLink", mock.Anything, "myAlias3").Return(nil, pgx.ErrNoRows).Once()
+
+	s.FinishSetupTest(storage, sessionStore)
+}
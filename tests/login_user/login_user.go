@@ -21,7 +21,7 @@ func (s *loginUserSuite) TestLoginUser() {
 		Password: "",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 3
 	_, code = s.LoginUser(&handlers.LoginRequest{
@@ -29,7 +29,7 @@ func (s *loginUserSuite) TestLoginUser() {
 		Password: "        ",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 4
 	_, code = s.LoginUser(&handlers.LoginRequest{
@@ -37,7 +37,7 @@ func (s *loginUserSuite) TestLoginUser() {
 		Password: "qwertyui",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 5
 	_, code = s.LoginUser(&handlers.LoginRequest{
@@ -45,7 +45,7 @@ func (s *loginUserSuite) TestLoginUser() {
 		Password: "12345678",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusUnauthorized, code)
 
 	// 6
 	_, code = s.LoginUser(&handlers.LoginRequest{
@@ -53,6 +53,6 @@ func (s *loginUserSuite) TestLoginUser() {
 		Password: "qwertyui5",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusUnauthorized, code)
 
 }
@@ -1,9 +1,10 @@
 package login_user
 
 import (
-	"errors"
-	"github.com/jackc/pgx/v4"
+	"context"
 	"github.com/stretchr/testify/mock"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/service"
 	base "urleater/tests"
 	"urleater/tests/mocks"
 )
@@ -15,24 +16,16 @@ type loginUserSuite struct {
 func (s *loginUserSuite) SetupTest() {
 	s.BaseSetupTest()
 
-	storage := mocks.NewStorage(s.T())
+	storage := memoryDB.NewStorage()
 	sessionStore := mocks.NewSessionStore(s.T())
 
 	sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return("", nil)
 	sessionStore.On("Get", mock.Anything, mock.Anything).Return(nil, nil)
 	sessionStore.On("Save", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	// 1
-	storage.On("VerifyUserPassword", mock.Anything, "test_name1@mail.ru", mock.Anything).
-		Return(nil).Once()
-
-	// 5
-	storage.On("VerifyUserPassword", mock.Anything, "test_name10@mail.com", mock.Anything).
-		Return(pgx.ErrNoRows).Once()
-
-	// 6
-	storage.On("VerifyUserPassword", mock.Anything, "test_name1@mail.ru", mock.Anything).
-		Return(errors.New("invalid password")).Once()
+	// 1, 6
+	err := storage.CreateUser(context.Background(), "test_name1@mail.ru", "qwertyui", service.DefaultQuota)
+	s.NoError(err)
 
 	s.FinishSetupTest(storage, sessionStore)
 }
@@ -0,0 +1,322 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"sync"
+	"testing/fstest"
+	"urleater/build/migrations"
+	"urleater/internal/migrator"
+)
+
+func versions(list []migrator.Migration) []int64 {
+	result := make([]int64, 0, len(list))
+
+	for _, migration := range list {
+		result = append(result, migration.Version)
+	}
+
+	return result
+}
+
+func (s *filesSuite) TestParse() {
+	// 1 versions are ordered as numbers, a down file is optional
+	m, err := migrator.New(nil, fstest.MapFS{
+		"10_third.up.sql":      {Data: []byte("CREATE TABLE third (id int);")},
+		"2_second.up.sql":      {Data: []byte("CREATE TABLE second (id int);")},
+		"2_second.down.sql":    {Data: []byte("DROP TABLE second;")},
+		"1_first_table.up.sql": {Data: []byte("CREATE TABLE first (id int);")},
+		"README.md":            {Data: []byte("not a migration")},
+	})
+
+	s.Require().NoError(err)
+
+	list := m.Migrations()
+
+	s.Equal([]int64{1, 2, 10}, versions(list))
+	s.Equal("first_table", list[0].Name)
+	s.Equal("CREATE TABLE second (id int);", list[1].Up)
+	s.Equal("DROP TABLE second;", list[1].Down)
+	s.Empty(list[2].Down)
+}
+
+func (s *filesSuite) TestInvalid() {
+	cases := []struct {
+		files fstest.MapFS
+		err   string
+	}{
+		// 1
+		{files: fstest.MapFS{"first.up.sql": {}}, err: "invalid migration name first.up.sql"},
+		// 2
+		{files: fstest.MapFS{"v1_first.up.sql": {}}, err: "invalid migration version in v1_first.up.sql"},
+		// 3
+		{files: fstest.MapFS{"1_first.sql": {}}, err: "migration 1_first.sql is neither up nor down"},
+		// 4
+		{files: fstest.MapFS{"1_first.down.sql": {Data: []byte("DROP TABLE first;")}}, err: "migration 1 has no up file"},
+	}
+
+	for _, c := range cases {
+		_, err := migrator.New(nil, c.files)
+
+		s.ErrorContains(err, c.err)
+	}
+}
+
+func (s *filesSuite) TestShippedMigrations() {
+	m, err := migrator.New(nil, migrations.FS)
+
+	s.Require().NoError(err)
+	s.NotEmpty(m.Migrations())
+
+	// 1 every migration can be reverted
+	for _, migration := range m.Migrations() {
+		s.NotEmpty(migration.Down, "%d_%s has no down file", migration.Version, migration.Name)
+	}
+
+	// 2 the sqlite storage mirrors them
+	postgres, err := migrations.FS.ReadDir(".")
+	s.Require().NoError(err)
+
+	sqlite, err := os.ReadDir("../../internal/repository/sqliteDB/migrations")
+	s.Require().NoError(err)
+
+	var postgresNames, sqliteNames []string
+
+	for _, entry := range postgres {
+		if entry.Name() != "migrations.go" {
+			postgresNames = append(postgresNames, entry.Name())
+		}
+	}
+
+	for _, entry := range sqlite {
+		sqliteNames = append(sqliteNames, entry.Name())
+	}
+
+	s.Equal(postgresNames, sqliteNames)
+}
+
+func (s *filesSuite) TestCommandArgs() {
+	m, err := migrator.New(nil, fstest.MapFS{})
+
+	s.Require().NoError(err)
+
+	// the arguments are checked before the database is touched
+	cases := []struct {
+		args []string
+		err  string
+	}{
+		// 1
+		{args: nil, err: "usage"},
+		// 2
+		{args: []string{"sideways"}, err: "usage"},
+		// 3
+		{args: []string{"down", "two"}, err: `invalid number of steps "two"`},
+		// 4
+		{args: []string{"down", "0"}, err: `invalid number of steps "0"`},
+		// 5
+		{args: []string{"force"}, err: "usage"},
+		// 6
+		{args: []string{"force", "latest"}, err: `invalid version "latest"`},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+
+		err := m.RunCommand(context.Background(), c.args, &out)
+
+		s.ErrorContains(err, c.err, "%v", c.args)
+		s.Empty(out.String())
+	}
+}
+
+func (s *postgresSuite) TestUpDownStatus() {
+	m, err := migrator.New(s.pool, testMigrations)
+	s.Require().NoError(err)
+
+	// 1 a new database has everything pending
+	status, err := m.Status(s.ctx)
+
+	s.Require().NoError(err)
+	s.Zero(status.Version)
+	s.False(status.Dirty)
+	s.Empty(status.Applied)
+	s.Equal([]int64{1, 2}, versions(status.Pending))
+
+	// 2
+	applied, err := m.Up(s.ctx)
+
+	s.Require().NoError(err)
+	s.Equal([]int64{1, 2}, versions(applied))
+	s.True(s.tableExists("first"))
+	s.True(s.tableExists("second"))
+
+	// 3 nothing is applied twice
+	applied, err = m.Up(s.ctx)
+
+	s.NoError(err)
+	s.Empty(applied)
+
+	status, err = m.Status(s.ctx)
+
+	s.Require().NoError(err)
+	s.Equal(int64(2), status.Version)
+	s.Equal([]int64{1, 2}, versions(status.Applied))
+	s.Empty(status.Pending)
+
+	// 4 down reverts the newest first
+	reverted, err := m.Down(s.ctx, 1)
+
+	s.Require().NoError(err)
+	s.Equal([]int64{2}, versions(reverted))
+	s.True(s.tableExists("first"))
+	s.False(s.tableExists("second"))
+
+	var out bytes.Buffer
+
+	s.Require().NoError(m.RunCommand(s.ctx, []string{"status"}, &out))
+	s.Equal("applied  1_first\npending  2_second\n", out.String())
+
+	// 5 and stops at the first one
+	reverted, err = m.Down(s.ctx, 5)
+
+	s.Require().NoError(err)
+	s.Equal([]int64{1}, versions(reverted))
+	s.False(s.tableExists("first"))
+
+	status, err = m.Status(s.ctx)
+
+	s.Require().NoError(err)
+	s.Zero(status.Version)
+}
+
+func (s *postgresSuite) TestDirty() {
+	files := fstest.MapFS{
+		"3_broken.up.sql":   {Data: []byte("CREATE TABLE broken (id int); SELECT * FROM missing;")},
+		"3_broken.down.sql": {Data: []byte("DROP TABLE broken;")},
+	}
+
+	for name, file := range testMigrations {
+		files[name] = file
+	}
+
+	m, err := migrator.New(s.pool, files)
+	s.Require().NoError(err)
+
+	// 1 a failed migration is rolled back and leaves the schema dirty at its version
+	applied, err := m.Up(s.ctx)
+
+	s.ErrorContains(err, "could not apply migration 3_broken")
+	s.Equal([]int64{1, 2}, versions(applied))
+	s.False(s.tableExists("broken"))
+
+	status, err := m.Status(s.ctx)
+
+	s.Require().NoError(err)
+	s.Equal(int64(3), status.Version)
+	s.True(status.Dirty)
+
+	var out bytes.Buffer
+
+	s.Require().NoError(m.RunCommand(s.ctx, []string{"status"}, &out))
+	s.Contains(out.String(), "schema is DIRTY at version 3")
+
+	// 2 nothing runs on a dirty schema
+	_, err = m.Up(s.ctx)
+
+	s.True(errors.Is(err, migrator.ErrDirty))
+
+	_, err = m.Down(s.ctx, 1)
+
+	s.True(errors.Is(err, migrator.ErrDirty))
+	s.True(s.tableExists("second"))
+
+	// 3 until it's forced clean
+	s.Require().NoError(m.RunCommand(s.ctx, []string{"force", "2"}, &out))
+
+	status, err = m.Status(s.ctx)
+
+	s.Require().NoError(err)
+	s.Equal(int64(2), status.Version)
+	s.False(status.Dirty)
+
+	reverted, err := m.Down(s.ctx, 1)
+
+	s.Require().NoError(err)
+	s.Equal([]int64{2}, versions(reverted))
+}
+
+func (s *postgresSuite) TestCheck() {
+	m, err := migrator.New(s.pool, testMigrations)
+	s.Require().NoError(err)
+
+	// 1 a new database isn't ready
+	err = m.Check(s.ctx)
+
+	s.True(errors.Is(err, migrator.ErrPending))
+	s.ErrorContains(err, "it is at version 0 and the latest one is 2")
+
+	// 2
+	_, err = m.Up(s.ctx)
+	s.Require().NoError(err)
+
+	s.NoError(m.Check(s.ctx))
+
+	// 3 a schema migrated by a newer release is fine
+	older, err := migrator.New(s.pool, fstest.MapFS{
+		"1_first.up.sql":   testMigrations["1_first.up.sql"],
+		"1_first.down.sql": testMigrations["1_first.down.sql"],
+	})
+	s.Require().NoError(err)
+
+	s.NoError(older.Check(s.ctx))
+
+	// 4 a dirty one isn't
+	s.Require().NoError(m.Force(s.ctx, 2))
+
+	_, err = s.pool.Exec(s.ctx, "UPDATE schema_migrations SET dirty = true")
+	s.Require().NoError(err)
+
+	s.True(errors.Is(m.Check(s.ctx), migrator.ErrDirty))
+}
+
+func (s *postgresSuite) TestLock() {
+	// 1 replicas migrating at once apply every migration exactly once
+	pools := []*pgxpool.Pool{s.pool, s.connect()}
+
+	defer pools[1].Close()
+
+	var (
+		wg      sync.WaitGroup
+		applied = make([][]migrator.Migration, len(pools))
+		errs    = make([]error, len(pools))
+	)
+
+	for i, pool := range pools {
+		m, err := migrator.New(pool, testMigrations)
+		s.Require().NoError(err)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			applied[i], errs[i] = m.Up(s.ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	s.NoError(errors.Join(errs...))
+	s.Equal([]int64{1, 2}, versions(append(applied[0], applied[1]...)))
+
+	// 2 the lock is given back, so the connections can migrate again
+	m, err := migrator.New(pools[1], testMigrations)
+	s.Require().NoError(err)
+
+	_, err = m.Down(s.ctx, 2)
+
+	s.NoError(err)
+}
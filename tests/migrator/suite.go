@@ -0,0 +1,67 @@
+package migrator
+
+import (
+	"context"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/suite"
+	"testing/fstest"
+)
+
+// testMigrations create a table each, the second one is applied slowly,
+// so that concurrent runs overlap.
+var testMigrations = fstest.MapFS{
+	"1_first.up.sql":    {Data: []byte("CREATE TABLE first (id int);")},
+	"1_first.down.sql":  {Data: []byte("DROP TABLE first;")},
+	"2_second.up.sql":   {Data: []byte("SELECT pg_sleep(0.5); CREATE TABLE second (id int);")},
+	"2_second.down.sql": {Data: []byte("DROP TABLE second;")},
+}
+
+type filesSuite struct {
+	suite.Suite
+}
+
+// postgresSuite runs the migrator in a schema of its own, which is emptied before every test.
+type postgresSuite struct {
+	suite.Suite
+
+	url    string
+	schema string
+
+	ctx  context.Context
+	pool *pgxpool.Pool
+}
+
+func (s *postgresSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.pool = s.connect()
+
+	_, err := s.pool.Exec(s.ctx, "DROP TABLE IF EXISTS schema_migrations, first, second, broken")
+	s.Require().NoError(err)
+}
+
+func (s *postgresSuite) TearDownTest() {
+	s.pool.Close()
+}
+
+// connect opens a pool that works in the test schema.
+func (s *postgresSuite) connect() *pgxpool.Pool {
+	cfg, err := pgxpool.ParseConfig(s.url)
+	s.Require().NoError(err)
+
+	cfg.ConnConfig.RuntimeParams["search_path"] = s.schema
+
+	pool, err := pgxpool.ConnectConfig(s.ctx, cfg)
+	s.Require().NoError(err)
+
+	return pool
+}
+
+// tableExists tells whether the test schema has the table.
+func (s *postgresSuite) tableExists(name string) bool {
+	var exists bool
+
+	err := s.pool.QueryRow(s.ctx, "SELECT to_regclass($1) IS NOT NULL", s.schema+"."+name).Scan(&exists)
+	s.Require().NoError(err)
+
+	return exists
+}
@@ -13,6 +13,60 @@ type ServerInterface struct {
 	mock.Mock
 }
 
+// BuySubscription provides a mock function with given fields: c
+func (_m *ServerInterface) BuySubscription(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BuySubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateApiToken provides a mock function with given fields: c
+func (_m *ServerInterface) CreateApiToken(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateApiToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateLinkV1 provides a mock function with given fields: c
+func (_m *ServerInterface) CreateLinkV1(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLinkV1")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateShortLink provides a mock function with given fields: c
 func (_m *ServerInterface) CreateShortLink(c echo.Context) error {
 	ret := _m.Called(c)
@@ -31,6 +85,24 @@ func (_m *ServerInterface) CreateShortLink(c echo.Context) error {
 	return r0
 }
 
+// DeleteLinkV1 provides a mock function with given fields: c
+func (_m *ServerInterface) DeleteLinkV1(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteLinkV1")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteShortLink provides a mock function with given fields: c
 func (_m *ServerInterface) DeleteShortLink(c echo.Context) error {
 	ret := _m.Called(c)
@@ -49,6 +121,78 @@ func (_m *ServerInterface) DeleteShortLink(c echo.Context) error {
 	return r0
 }
 
+// ExtendShortLink provides a mock function with given fields: c
+func (_m *ServerInterface) ExtendShortLink(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExtendShortLink")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAdminPage provides a mock function with given fields: c
+func (_m *ServerInterface) GetAdminPage(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAdminPage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetApiTokens provides a mock function with given fields: c
+func (_m *ServerInterface) GetApiTokens(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetApiTokens")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetApiTokensPage provides a mock function with given fields: c
+func (_m *ServerInterface) GetApiTokensPage(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetApiTokensPage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetCreateShortLink provides a mock function with given fields: c
 func (_m *ServerInterface) GetCreateShortLink(c echo.Context) error {
 	ret := _m.Called(c)
@@ -67,6 +211,96 @@ func (_m *ServerInterface) GetCreateShortLink(c echo.Context) error {
 	return r0
 }
 
+// GetCurrentSubscription provides a mock function with given fields: c
+func (_m *ServerInterface) GetCurrentSubscription(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCurrentSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetLinkStats provides a mock function with given fields: c
+func (_m *ServerInterface) GetLinkStats(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinkStats")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetLinkV1 provides a mock function with given fields: c
+func (_m *ServerInterface) GetLinkV1(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinkV1")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetLinkVersions provides a mock function with given fields: c
+func (_m *ServerInterface) GetLinkVersions(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinkVersions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetLinksPage provides a mock function with given fields: c
+func (_m *ServerInterface) GetLinksPage(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinksPage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetLoginPage provides a mock function with given fields: c
 func (_m *ServerInterface) GetLoginPage(c echo.Context) error {
 	ret := _m.Called(c)
@@ -121,6 +355,42 @@ func (_m *ServerInterface) GetMainPage(_a0 echo.Context) error {
 	return r0
 }
 
+// GetMeV1 provides a mock function with given fields: c
+func (_m *ServerInterface) GetMeV1(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMeV1")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetOrder provides a mock function with given fields: c
+func (_m *ServerInterface) GetOrder(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrder")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetRegisterPage provides a mock function with given fields: c
 func (_m *ServerInterface) GetRegisterPage(c echo.Context) error {
 	ret := _m.Called(c)
@@ -193,6 +463,24 @@ func (_m *ServerInterface) GetSubscriptionsPage(c echo.Context) error {
 	return r0
 }
 
+// GetSystemStats provides a mock function with given fields: c
+func (_m *ServerInterface) GetSystemStats(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSystemStats")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetUser provides a mock function with given fields: c
 func (_m *ServerInterface) GetUser(c echo.Context) error {
 	ret := _m.Called(c)
@@ -229,6 +517,60 @@ func (_m *ServerInterface) GetUserShortLinks(c echo.Context) error {
 	return r0
 }
 
+// ListLinksV1 provides a mock function with given fields: c
+func (_m *ServerInterface) ListLinksV1(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLinksV1")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListSubscriptionsV1 provides a mock function with given fields: c
+func (_m *ServerInterface) ListSubscriptionsV1(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSubscriptionsV1")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PaymentCallback provides a mock function with given fields: c
+func (_m *ServerInterface) PaymentCallback(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PaymentCallback")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // PostLogin provides a mock function with given fields: c
 func (_m *ServerInterface) PostLogin(c echo.Context) error {
 	ret := _m.Called(c)
@@ -265,6 +607,266 @@ func (_m *ServerInterface) PostRegister(c echo.Context) error {
 	return r0
 }
 
+// RequireAnonymous provides a mock function with given fields: next
+func (_m *ServerInterface) RequireAnonymous(next echo.HandlerFunc) echo.HandlerFunc {
+	ret := _m.Called(next)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequireAnonymous")
+	}
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func(echo.HandlerFunc) echo.HandlerFunc); ok {
+		r0 = rf(next)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// RequireAuth provides a mock function with given fields: next
+func (_m *ServerInterface) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	ret := _m.Called(next)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequireAuth")
+	}
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func(echo.HandlerFunc) echo.HandlerFunc); ok {
+		r0 = rf(next)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// RequirePermission provides a mock function with given fields: permission
+func (_m *ServerInterface) RequirePermission(permission string) echo.MiddlewareFunc {
+	ret := _m.Called(permission)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequirePermission")
+	}
+
+	var r0 echo.MiddlewareFunc
+	if rf, ok := ret.Get(0).(func(string) echo.MiddlewareFunc); ok {
+		r0 = rf(permission)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.MiddlewareFunc)
+		}
+	}
+
+	return r0
+}
+
+// RequireScope provides a mock function with given fields: scope
+func (_m *ServerInterface) RequireScope(scope string) echo.MiddlewareFunc {
+	ret := _m.Called(scope)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequireScope")
+	}
+
+	var r0 echo.MiddlewareFunc
+	if rf, ok := ret.Get(0).(func(string) echo.MiddlewareFunc); ok {
+		r0 = rf(scope)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.MiddlewareFunc)
+		}
+	}
+
+	return r0
+}
+
+// RevokeApiToken provides a mock function with given fields: c
+func (_m *ServerInterface) RevokeApiToken(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeApiToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RollbackShortLink provides a mock function with given fields: c
+func (_m *ServerInterface) RollbackShortLink(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RollbackShortLink")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchLinks provides a mock function with given fields: c
+func (_m *ServerInterface) SearchLinks(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchLinks")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchUsers provides a mock function with given fields: c
+func (_m *ServerInterface) SearchUsers(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchUsers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetLinkDisabled provides a mock function with given fields: c
+func (_m *ServerInterface) SetLinkDisabled(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserDisabled provides a mock function with given fields: c
+func (_m *ServerInterface) SetUserDisabled(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserRole provides a mock function with given fields: c
+func (_m *ServerInterface) SetUserRole(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UnlockShortLink provides a mock function with given fields: c
+func (_m *ServerInterface) UnlockShortLink(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnlockShortLink")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateLinkV1 provides a mock function with given fields: c
+func (_m *ServerInterface) UpdateLinkV1(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLinkV1")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateShortLink provides a mock function with given fields: c
+func (_m *ServerInterface) UpdateShortLink(c echo.Context) error {
+	ret := _m.Called(c)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateShortLink")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateUserShortLinks provides a mock function with given fields: c
 func (_m *ServerInterface) UpdateUserShortLinks(c echo.Context) error {
 	ret := _m.Called(c)
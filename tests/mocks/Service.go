@@ -8,6 +8,10 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	postgresDB "urleater/internal/repository/postgresDB"
+
+	service "urleater/internal/service"
+
+	time "time"
 )
 
 // Service is an autogenerated mock type for the Service type
@@ -15,6 +19,140 @@ type Service struct {
 	mock.Mock
 }
 
+// AuthenticateApiToken provides a mock function with given fields: ctx, secret
+func (_m *Service) AuthenticateApiToken(ctx context.Context, secret string) (*service.Principal, error) {
+	ret := _m.Called(ctx, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthenticateApiToken")
+	}
+
+	var r0 *service.Principal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*service.Principal, error)); ok {
+		return rf(ctx, secret)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *service.Principal); ok {
+		r0 = rf(ctx, secret)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*service.Principal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, secret)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConsumeLinkClick provides a mock function with given fields: ctx, link
+func (_m *Service) ConsumeLinkClick(ctx context.Context, link *postgresDB.Link) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, link)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeLinkClick")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *postgresDB.Link) (*postgresDB.Link, error)); ok {
+		return rf(ctx, link)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *postgresDB.Link) *postgresDB.Link); ok {
+		r0 = rf(ctx, link)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *postgresDB.Link) error); ok {
+		r1 = rf(ctx, link)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateApiToken provides a mock function with given fields: ctx, email, name, scopes, ttlDays
+func (_m *Service) CreateApiToken(ctx context.Context, email string, name string, scopes []string, ttlDays int) (string, *postgresDB.ApiToken, error) {
+	ret := _m.Called(ctx, email, name, scopes, ttlDays)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateApiToken")
+	}
+
+	var r0 string
+	var r1 *postgresDB.ApiToken
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, int) (string, *postgresDB.ApiToken, error)); ok {
+		return rf(ctx, email, name, scopes, ttlDays)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, int) string); ok {
+		r0 = rf(ctx, email, name, scopes, ttlDays)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, int) *postgresDB.ApiToken); ok {
+		r1 = rf(ctx, email, name, scopes, ttlDays)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*postgresDB.ApiToken)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, []string, int) error); ok {
+		r2 = rf(ctx, email, name, scopes, ttlDays)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CreateOrder provides a mock function with given fields: ctx, email, subscriptionId
+func (_m *Service) CreateOrder(ctx context.Context, email string, subscriptionId int) (*postgresDB.Order, string, error) {
+	ret := _m.Called(ctx, email, subscriptionId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrder")
+	}
+
+	var r0 *postgresDB.Order
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (*postgresDB.Order, string, error)); ok {
+		return rf(ctx, email, subscriptionId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) *postgresDB.Order); ok {
+		r0 = rf(ctx, email, subscriptionId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) string); ok {
+		r1 = rf(ctx, email, subscriptionId)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, int) error); ok {
+		r2 = rf(ctx, email, subscriptionId)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // CreateShortLink provides a mock function with given fields: ctx, shortLink, longLink, userEmail
 func (_m *Service) CreateShortLink(ctx context.Context, shortLink string, longLink string, userEmail string) (*postgresDB.Link, error) {
 	ret := _m.Called(ctx, shortLink, longLink, userEmail)
@@ -45,6 +183,36 @@ func (_m *Service) CreateShortLink(ctx context.Context, shortLink string, longLi
 	return r0, r1
 }
 
+// CreateShortLinkWithOptions provides a mock function with given fields: ctx, alias, longLink, userEmail, options
+func (_m *Service) CreateShortLinkWithOptions(ctx context.Context, alias string, longLink string, userEmail string, options service.LinkOptions) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, alias, longLink, userEmail, options)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateShortLinkWithOptions")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, service.LinkOptions) (*postgresDB.Link, error)); ok {
+		return rf(ctx, alias, longLink, userEmail, options)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, service.LinkOptions) *postgresDB.Link); ok {
+		r0 = rf(ctx, alias, longLink, userEmail, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, service.LinkOptions) error); ok {
+		r1 = rf(ctx, alias, longLink, userEmail, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteShortLink provides a mock function with given fields: ctx, shortLink, email
 func (_m *Service) DeleteShortLink(ctx context.Context, shortLink string, email string) error {
 	ret := _m.Called(ctx, shortLink, email)
@@ -63,29 +231,29 @@ func (_m *Service) DeleteShortLink(ctx context.Context, shortLink string, email
 	return r0
 }
 
-// GetShortLink provides a mock function with given fields: ctx, shortLink
-func (_m *Service) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
-	ret := _m.Called(ctx, shortLink)
+// ExtendShortLink provides a mock function with given fields: ctx, shortLink, email, days
+func (_m *Service) ExtendShortLink(ctx context.Context, shortLink string, email string, days int) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, email, days)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetShortLink")
+		panic("no return value specified for ExtendShortLink")
 	}
 
 	var r0 *postgresDB.Link
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.Link, error)); ok {
-		return rf(ctx, shortLink)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, email, days)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.Link); ok {
-		r0 = rf(ctx, shortLink)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, email, days)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*postgresDB.Link)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, shortLink)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, shortLink, email, days)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -93,29 +261,29 @@ func (_m *Service) GetShortLink(ctx context.Context, shortLink string) (*postgre
 	return r0, r1
 }
 
-// GetSubscriptions provides a mock function with given fields: ctx
-func (_m *Service) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscription, error) {
-	ret := _m.Called(ctx)
+// GetApiTokens provides a mock function with given fields: ctx, email
+func (_m *Service) GetApiTokens(ctx context.Context, email string) ([]postgresDB.ApiToken, error) {
+	ret := _m.Called(ctx, email)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetSubscriptions")
+		panic("no return value specified for GetApiTokens")
 	}
 
-	var r0 []postgresDB.Subscription
+	var r0 []postgresDB.ApiToken
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]postgresDB.Subscription, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]postgresDB.ApiToken, error)); ok {
+		return rf(ctx, email)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []postgresDB.Subscription); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []postgresDB.ApiToken); ok {
+		r0 = rf(ctx, email)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]postgresDB.Subscription)
+			r0 = ret.Get(0).([]postgresDB.ApiToken)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -123,29 +291,68 @@ func (_m *Service) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscript
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: ctx, email
-func (_m *Service) GetUser(ctx context.Context, email string) (*postgresDB.User, error) {
+// GetCurrentSubscription provides a mock function with given fields: ctx, email
+func (_m *Service) GetCurrentSubscription(ctx context.Context, email string) (*postgresDB.UserSubscription, *postgresDB.Subscription, error) {
 	ret := _m.Called(ctx, email)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUser")
+		panic("no return value specified for GetCurrentSubscription")
 	}
 
-	var r0 *postgresDB.User
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.User, error)); ok {
+	var r0 *postgresDB.UserSubscription
+	var r1 *postgresDB.Subscription
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.UserSubscription, *postgresDB.Subscription, error)); ok {
 		return rf(ctx, email)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.User); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.UserSubscription); ok {
 		r0 = rf(ctx, email)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*postgresDB.User)
+			r0 = ret.Get(0).(*postgresDB.UserSubscription)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, string) *postgresDB.Subscription); ok {
 		r1 = rf(ctx, email)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*postgresDB.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, email)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetLinkStats provides a mock function with given fields: ctx, shortLink, email, from, to, interval
+func (_m *Service) GetLinkStats(ctx context.Context, shortLink string, email string, from time.Time, to time.Time, interval string) (*postgresDB.LinkStats, error) {
+	ret := _m.Called(ctx, shortLink, email, from, to, interval)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinkStats")
+	}
+
+	var r0 *postgresDB.LinkStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time, string) (*postgresDB.LinkStats, error)); ok {
+		return rf(ctx, shortLink, email, from, to, interval)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time, string) *postgresDB.LinkStats); ok {
+		r0 = rf(ctx, shortLink, email, from, to, interval)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.LinkStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, time.Time, string) error); ok {
+		r1 = rf(ctx, shortLink, email, from, to, interval)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -153,79 +360,701 @@ func (_m *Service) GetUser(ctx context.Context, email string) (*postgresDB.User,
 	return r0, r1
 }
 
-// GetUserShortLinksWithOffsetAndLimit provides a mock function with given fields: ctx, email, offset, limit
-func (_m *Service) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email string, offset int, limit int) ([]postgresDB.Link, *postgresDB.User, error) {
-	ret := _m.Called(ctx, email, offset, limit)
+// GetLinkVersions provides a mock function with given fields: ctx, shortLink, email
+func (_m *Service) GetLinkVersions(ctx context.Context, shortLink string, email string) ([]postgresDB.LinkVersion, error) {
+	ret := _m.Called(ctx, shortLink, email)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserShortLinksWithOffsetAndLimit")
+		panic("no return value specified for GetLinkVersions")
 	}
 
-	var r0 []postgresDB.Link
-	var r1 *postgresDB.User
-	var r2 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]postgresDB.Link, *postgresDB.User, error)); ok {
-		return rf(ctx, email, offset, limit)
+	var r0 []postgresDB.LinkVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]postgresDB.LinkVersion, error)); ok {
+		return rf(ctx, shortLink, email)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []postgresDB.Link); ok {
-		r0 = rf(ctx, email, offset, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []postgresDB.LinkVersion); ok {
+		r0 = rf(ctx, shortLink, email)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]postgresDB.Link)
+			r0 = ret.Get(0).([]postgresDB.LinkVersion)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) *postgresDB.User); ok {
-		r1 = rf(ctx, email, offset, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, shortLink, email)
 	} else {
-		if ret.Get(1) != nil {
-			r1 = ret.Get(1).(*postgresDB.User)
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrder provides a mock function with given fields: ctx, orderId, email
+func (_m *Service) GetOrder(ctx context.Context, orderId string, email string) (*postgresDB.Order, error) {
+	ret := _m.Called(ctx, orderId, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrder")
+	}
+
+	var r0 *postgresDB.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*postgresDB.Order, error)); ok {
+		return rf(ctx, orderId, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *postgresDB.Order); ok {
+		r0 = rf(ctx, orderId, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Order)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
-		r2 = rf(ctx, email, offset, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orderId, email)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// LoginUser provides a mock function with given fields: ctx, email, password
-func (_m *Service) LoginUser(ctx context.Context, email string, password string) error {
-	ret := _m.Called(ctx, email, password)
+// GetPrincipal provides a mock function with given fields: ctx, email
+func (_m *Service) GetPrincipal(ctx context.Context, email string) (*service.Principal, error) {
+	ret := _m.Called(ctx, email)
 
 	if len(ret) == 0 {
-		panic("no return value specified for LoginUser")
+		panic("no return value specified for GetPrincipal")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, email, password)
+	var r0 *service.Principal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*service.Principal, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *service.Principal); ok {
+		r0 = rf(ctx, email)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*service.Principal)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// RegisterUser provides a mock function with given fields: ctx, email, password
-func (_m *Service) RegisterUser(ctx context.Context, email string, password string) error {
-	ret := _m.Called(ctx, email, password)
+// GetShortLink provides a mock function with given fields: ctx, shortLink
+func (_m *Service) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RegisterUser")
+		panic("no return value specified for GetShortLink")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, email, password)
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, shortLink)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscriptions provides a mock function with given fields: ctx
+func (_m *Service) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscription, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubscriptions")
+	}
+
+	var r0 []postgresDB.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]postgresDB.Subscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []postgresDB.Subscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSystemStats provides a mock function with given fields: ctx
+func (_m *Service) GetSystemStats(ctx context.Context) (*postgresDB.SystemStats, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSystemStats")
+	}
+
+	var r0 *postgresDB.SystemStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*postgresDB.SystemStats, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *postgresDB.SystemStats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.SystemStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, email
+func (_m *Service) GetUser(ctx context.Context, email string) (*postgresDB.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUser")
+	}
+
+	var r0 *postgresDB.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserShortLink provides a mock function with given fields: ctx, shortLink, email
+func (_m *Service) GetUserShortLink(ctx context.Context, shortLink string, email string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserShortLink")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, shortLink, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserShortLinksWithOffsetAndLimit provides a mock function with given fields: ctx, email, offset, limit
+func (_m *Service) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email string, offset int, limit int) ([]postgresDB.Link, *postgresDB.User, error) {
+	ret := _m.Called(ctx, email, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserShortLinksWithOffsetAndLimit")
+	}
+
+	var r0 []postgresDB.Link
+	var r1 *postgresDB.User
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]postgresDB.Link, *postgresDB.User, error)); ok {
+		return rf(ctx, email, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []postgresDB.Link); ok {
+		r0 = rf(ctx, email, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) *postgresDB.User); ok {
+		r1 = rf(ctx, email, offset, limit)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*postgresDB.User)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, int, int) error); ok {
+		r2 = rf(ctx, email, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// HandlePaymentCallback provides a mock function with given fields: ctx, payload, signature
+func (_m *Service) HandlePaymentCallback(ctx context.Context, payload []byte, signature string) error {
+	ret := _m.Called(ctx, payload, signature)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HandlePaymentCallback")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) error); ok {
+		r0 = rf(ctx, payload, signature)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LinkPasswordVersion provides a mock function with given fields: ctx, shortLink
+func (_m *Service) LinkPasswordVersion(ctx context.Context, shortLink string) (string, error) {
+	ret := _m.Called(ctx, shortLink)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkPasswordVersion")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, shortLink)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, shortLink)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, shortLink)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginUser provides a mock function with given fields: ctx, email, password
+func (_m *Service) LoginUser(ctx context.Context, email string, password string) error {
+	ret := _m.Called(ctx, email, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoginUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, email, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordClick provides a mock function with given fields: ctx, shortLink, referrer, userAgent, ip, country
+func (_m *Service) RecordClick(ctx context.Context, shortLink string, referrer string, userAgent string, ip string, country string) {
+	_m.Called(ctx, shortLink, referrer, userAgent, ip, country)
+}
+
+// RegisterUser provides a mock function with given fields: ctx, email, password
+func (_m *Service) RegisterUser(ctx context.Context, email string, password string) error {
+	ret := _m.Called(ctx, email, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, email, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeApiToken provides a mock function with given fields: ctx, email, id
+func (_m *Service) RevokeApiToken(ctx context.Context, email string, id int) error {
+	ret := _m.Called(ctx, email, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeApiToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, email, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RollbackShortLink provides a mock function with given fields: ctx, shortLink, versionId, email
+func (_m *Service) RollbackShortLink(ctx context.Context, shortLink string, versionId int, email string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, versionId, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RollbackShortLink")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, versionId, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, versionId, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, string) error); ok {
+		r1 = rf(ctx, shortLink, versionId, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchLinks provides a mock function with given fields: ctx, query, offset, limit
+func (_m *Service) SearchLinks(ctx context.Context, query string, offset int, limit int) ([]postgresDB.Link, error) {
+	ret := _m.Called(ctx, query, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchLinks")
+	}
+
+	var r0 []postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]postgresDB.Link, error)); ok {
+		return rf(ctx, query, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []postgresDB.Link); ok {
+		r0 = rf(ctx, query, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, query, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchUsers provides a mock function with given fields: ctx, query, offset, limit
+func (_m *Service) SearchUsers(ctx context.Context, query string, offset int, limit int) ([]postgresDB.UserSummary, error) {
+	ret := _m.Called(ctx, query, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchUsers")
+	}
+
+	var r0 []postgresDB.UserSummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]postgresDB.UserSummary, error)); ok {
+		return rf(ctx, query, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []postgresDB.UserSummary); ok {
+		r0 = rf(ctx, query, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.UserSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, query, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLinkClicks provides a mock function with given fields: ctx, shortLink, email, clicks
+func (_m *Service) SetLinkClicks(ctx context.Context, shortLink string, email string, clicks int) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, email, clicks)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkClicks")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, email, clicks)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, email, clicks)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, shortLink, email, clicks)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLinkDisabled provides a mock function with given fields: ctx, shortLink, disabled
+func (_m *Service) SetLinkDisabled(ctx context.Context, shortLink string, disabled bool) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, disabled)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkDisabled")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, disabled)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, disabled)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, shortLink, disabled)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLinkPassword provides a mock function with given fields: ctx, shortLink, email, password
+func (_m *Service) SetLinkPassword(ctx context.Context, shortLink string, email string, password string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, email, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkPassword")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, email, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, email, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, shortLink, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserDisabled provides a mock function with given fields: ctx, email, disabled, changedBy
+func (_m *Service) SetUserDisabled(ctx context.Context, email string, disabled bool, changedBy string) error {
+	ret := _m.Called(ctx, email, disabled, changedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, string) error); ok {
+		r0 = rf(ctx, email, disabled, changedBy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserRole provides a mock function with given fields: ctx, email, role, changedBy
+func (_m *Service) SetUserRole(ctx context.Context, email string, role string, changedBy string) error {
+	ret := _m.Called(ctx, email, role, changedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, email, role, changedBy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UnlockShortLink provides a mock function with given fields: ctx, shortLink, password, client
+func (_m *Service) UnlockShortLink(ctx context.Context, shortLink string, password string, client string) (*postgresDB.Link, string, error) {
+	ret := _m.Called(ctx, shortLink, password, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnlockShortLink")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*postgresDB.Link, string, error)); ok {
+		return rf(ctx, shortLink, password, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, password, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) string); ok {
+		r1 = rf(ctx, shortLink, password, client)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = rf(ctx, shortLink, password, client)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UpdateLink provides a mock function with given fields: ctx, shortLink, email, update
+func (_m *Service) UpdateLink(ctx context.Context, shortLink string, email string, update service.LinkUpdate) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, email, update)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLink")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, service.LinkUpdate) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, email, update)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, service.LinkUpdate) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, email, update)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, service.LinkUpdate) error); ok {
+		r1 = rf(ctx, shortLink, email, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateShortLink provides a mock function with given fields: ctx, shortLink, longLink, email
+func (_m *Service) UpdateShortLink(ctx context.Context, shortLink string, longLink string, email string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, longLink, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateShortLink")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, longLink, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, longLink, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, shortLink, longLink, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // UpdateUserShortLinks provides a mock function with given fields: ctx, email, deltaLinks
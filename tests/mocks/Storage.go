@@ -34,9 +34,117 @@ func (_m *Storage) ChangePassword(ctx context.Context, email string, password st
 	return r0
 }
 
-// CreateShortLink provides a mock function with given fields: ctx, shortLink, longLink, userID
-func (_m *Storage) CreateShortLink(ctx context.Context, shortLink string, longLink string, userID string) (*postgresDB.Link, error) {
-	ret := _m.Called(ctx, shortLink, longLink, userID)
+// ConsumeLinkClick provides a mock function with given fields: ctx, shortLink
+func (_m *Storage) ConsumeLinkClick(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeLinkClick")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, shortLink)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateApiToken provides a mock function with given fields: ctx, token, tokenHash
+func (_m *Storage) CreateApiToken(ctx context.Context, token postgresDB.ApiToken, tokenHash string) (*postgresDB.ApiToken, error) {
+	ret := _m.Called(ctx, token, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateApiToken")
+	}
+
+	var r0 *postgresDB.ApiToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, postgresDB.ApiToken, string) (*postgresDB.ApiToken, error)); ok {
+		return rf(ctx, token, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, postgresDB.ApiToken, string) *postgresDB.ApiToken); ok {
+		r0 = rf(ctx, token, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.ApiToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, postgresDB.ApiToken, string) error); ok {
+		r1 = rf(ctx, token, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateClick provides a mock function with given fields: ctx, click
+func (_m *Storage) CreateClick(ctx context.Context, click postgresDB.Click) error {
+	ret := _m.Called(ctx, click)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateClick")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, postgresDB.Click) error); ok {
+		r0 = rf(ctx, click)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOrder provides a mock function with given fields: ctx, order
+func (_m *Storage) CreateOrder(ctx context.Context, order postgresDB.Order) (*postgresDB.Order, error) {
+	ret := _m.Called(ctx, order)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrder")
+	}
+
+	var r0 *postgresDB.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, postgresDB.Order) (*postgresDB.Order, error)); ok {
+		return rf(ctx, order)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, postgresDB.Order) *postgresDB.Order); ok {
+		r0 = rf(ctx, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, postgresDB.Order) error); ok {
+		r1 = rf(ctx, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateShortLink provides a mock function with given fields: ctx, shortLink, longLink, userID, expiresAt
+func (_m *Storage) CreateShortLink(ctx context.Context, shortLink string, longLink string, userID string, expiresAt time.Time) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, longLink, userID, expiresAt)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateShortLink")
@@ -44,19 +152,19 @@ func (_m *Storage) CreateShortLink(ctx context.Context, shortLink string, longLi
 
 	var r0 *postgresDB.Link
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*postgresDB.Link, error)); ok {
-		return rf(ctx, shortLink, longLink, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, longLink, userID, expiresAt)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *postgresDB.Link); ok {
-		r0 = rf(ctx, shortLink, longLink, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, longLink, userID, expiresAt)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*postgresDB.Link)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
-		r1 = rf(ctx, shortLink, longLink, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, time.Time) error); ok {
+		r1 = rf(ctx, shortLink, longLink, userID, expiresAt)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -64,17 +172,65 @@ func (_m *Storage) CreateShortLink(ctx context.Context, shortLink string, longLi
 	return r0, r1
 }
 
-// CreateUser provides a mock function with given fields: ctx, email, password
-func (_m *Storage) CreateUser(ctx context.Context, email string, password string) error {
-	ret := _m.Called(ctx, email, password)
+// CreateShortLinkWithQuota provides a mock function with given fields: ctx, shortLink, longLink, userEmail, expiresAt, options
+func (_m *Storage) CreateShortLinkWithQuota(ctx context.Context, shortLink string, longLink string, userEmail string, expiresAt time.Time, options postgresDB.LinkOptions) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, longLink, userEmail, expiresAt, options)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateShortLinkWithQuota")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time, postgresDB.LinkOptions) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, longLink, userEmail, expiresAt, options)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time, postgresDB.LinkOptions) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, longLink, userEmail, expiresAt, options)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, time.Time, postgresDB.LinkOptions) error); ok {
+		r1 = rf(ctx, shortLink, longLink, userEmail, expiresAt, options)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUser provides a mock function with given fields: ctx, email, password, urlsLeft
+func (_m *Storage) CreateUser(ctx context.Context, email string, password string, urlsLeft int) error {
+	ret := _m.Called(ctx, email, password, urlsLeft)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, email, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) error); ok {
+		r0 = rf(ctx, email, password, urlsLeft)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteApiToken provides a mock function with given fields: ctx, id, email
+func (_m *Storage) DeleteApiToken(ctx context.Context, id int, email string) error {
+	ret := _m.Called(ctx, id, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteApiToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(ctx, id, email)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -82,17 +238,17 @@ func (_m *Storage) CreateUser(ctx context.Context, email string, password string
 	return r0
 }
 
-// DeleteShortLink provides a mock function with given fields: ctx, shortLink, email
-func (_m *Storage) DeleteShortLink(ctx context.Context, shortLink string, email string) error {
-	ret := _m.Called(ctx, shortLink, email)
+// DeleteShortLink provides a mock function with given fields: ctx, shortLink, email, quotaRefund
+func (_m *Storage) DeleteShortLink(ctx context.Context, shortLink string, email string, quotaRefund int) error {
+	ret := _m.Called(ctx, shortLink, email, quotaRefund)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteShortLink")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = rf(ctx, shortLink, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) error); ok {
+		r0 = rf(ctx, shortLink, email, quotaRefund)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -100,6 +256,34 @@ func (_m *Storage) DeleteShortLink(ctx context.Context, shortLink string, email
 	return r0
 }
 
+// ExpireUserSubscriptions provides a mock function with given fields: ctx, now, freeQuota
+func (_m *Storage) ExpireUserSubscriptions(ctx context.Context, now time.Time, freeQuota int) (int, error) {
+	ret := _m.Called(ctx, now, freeQuota)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpireUserSubscriptions")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) (int, error)); ok {
+		return rf(ctx, now, freeQuota)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int); ok {
+		r0 = rf(ctx, now, freeQuota)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, now, freeQuota)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ExtendShortLink provides a mock function with given fields: ctx, shortLink, expiresAt
 func (_m *Storage) ExtendShortLink(ctx context.Context, shortLink string, expiresAt time.Time) (*postgresDB.Link, error) {
 	ret := _m.Called(ctx, shortLink, expiresAt)
@@ -130,29 +314,59 @@ func (_m *Storage) ExtendShortLink(ctx context.Context, shortLink string, expire
 	return r0, r1
 }
 
-// GetShortLink provides a mock function with given fields: ctx, shortLink
-func (_m *Storage) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
-	ret := _m.Called(ctx, shortLink)
+// FailOrder provides a mock function with given fields: ctx, orderId, paymentId
+func (_m *Storage) FailOrder(ctx context.Context, orderId string, paymentId string) (*postgresDB.Order, error) {
+	ret := _m.Called(ctx, orderId, paymentId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetShortLink")
+		panic("no return value specified for FailOrder")
 	}
 
-	var r0 *postgresDB.Link
+	var r0 *postgresDB.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.Link, error)); ok {
-		return rf(ctx, shortLink)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*postgresDB.Order, error)); ok {
+		return rf(ctx, orderId, paymentId)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.Link); ok {
-		r0 = rf(ctx, shortLink)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *postgresDB.Order); ok {
+		r0 = rf(ctx, orderId, paymentId)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*postgresDB.Link)
+			r0 = ret.Get(0).(*postgresDB.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orderId, paymentId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetActiveUserSubscription provides a mock function with given fields: ctx, email
+func (_m *Storage) GetActiveUserSubscription(ctx context.Context, email string) (*postgresDB.UserSubscription, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActiveUserSubscription")
+	}
+
+	var r0 *postgresDB.UserSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.UserSubscription, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.UserSubscription); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.UserSubscription)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, shortLink)
+		r1 = rf(ctx, email)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -160,29 +374,29 @@ func (_m *Storage) GetShortLink(ctx context.Context, shortLink string) (*postgre
 	return r0, r1
 }
 
-// GetSubscriptions provides a mock function with given fields: ctx
-func (_m *Storage) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscription, error) {
-	ret := _m.Called(ctx)
+// GetApiTokenByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *Storage) GetApiTokenByHash(ctx context.Context, tokenHash string) (*postgresDB.ApiToken, error) {
+	ret := _m.Called(ctx, tokenHash)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetSubscriptions")
+		panic("no return value specified for GetApiTokenByHash")
 	}
 
-	var r0 []postgresDB.Subscription
+	var r0 *postgresDB.ApiToken
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]postgresDB.Subscription, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.ApiToken, error)); ok {
+		return rf(ctx, tokenHash)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []postgresDB.Subscription); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.ApiToken); ok {
+		r0 = rf(ctx, tokenHash)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]postgresDB.Subscription)
+			r0 = ret.Get(0).(*postgresDB.ApiToken)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -190,24 +404,24 @@ func (_m *Storage) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscript
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: ctx, email
-func (_m *Storage) GetUser(ctx context.Context, email string) (*postgresDB.User, error) {
+// GetApiTokens provides a mock function with given fields: ctx, email
+func (_m *Storage) GetApiTokens(ctx context.Context, email string) ([]postgresDB.ApiToken, error) {
 	ret := _m.Called(ctx, email)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUser")
+		panic("no return value specified for GetApiTokens")
 	}
 
-	var r0 *postgresDB.User
+	var r0 []postgresDB.ApiToken
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.User, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]postgresDB.ApiToken, error)); ok {
 		return rf(ctx, email)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.User); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) []postgresDB.ApiToken); ok {
 		r0 = rf(ctx, email)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*postgresDB.User)
+			r0 = ret.Get(0).([]postgresDB.ApiToken)
 		}
 	}
 
@@ -220,29 +434,29 @@ func (_m *Storage) GetUser(ctx context.Context, email string) (*postgresDB.User,
 	return r0, r1
 }
 
-// GetUserShortLinksWithOffsetAndLimit provides a mock function with given fields: ctx, email, offset, limit
-func (_m *Storage) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email string, offset int, limit int) ([]postgresDB.Link, error) {
-	ret := _m.Called(ctx, email, offset, limit)
+// GetClickSeries provides a mock function with given fields: ctx, shortLink, from, to, interval
+func (_m *Storage) GetClickSeries(ctx context.Context, shortLink string, from time.Time, to time.Time, interval string) ([]postgresDB.ClickBucket, error) {
+	ret := _m.Called(ctx, shortLink, from, to, interval)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserShortLinksWithOffsetAndLimit")
+		panic("no return value specified for GetClickSeries")
 	}
 
-	var r0 []postgresDB.Link
+	var r0 []postgresDB.ClickBucket
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]postgresDB.Link, error)); ok {
-		return rf(ctx, email, offset, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time, string) ([]postgresDB.ClickBucket, error)); ok {
+		return rf(ctx, shortLink, from, to, interval)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []postgresDB.Link); ok {
-		r0 = rf(ctx, email, offset, limit)
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time, string) []postgresDB.ClickBucket); ok {
+		r0 = rf(ctx, shortLink, from, to, interval)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]postgresDB.Link)
+			r0 = ret.Get(0).([]postgresDB.ClickBucket)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
-		r1 = rf(ctx, email, offset, limit)
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time, string) error); ok {
+		r1 = rf(ctx, shortLink, from, to, interval)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -250,29 +464,790 @@ func (_m *Storage) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, emai
 	return r0, r1
 }
 
-// UpdateUserLinks provides a mock function with given fields: ctx, email, urlsDelta
-func (_m *Storage) UpdateUserLinks(ctx context.Context, email string, urlsDelta int) (*postgresDB.User, error) {
-	ret := _m.Called(ctx, email, urlsDelta)
+// GetClickTotals provides a mock function with given fields: ctx, shortLink, from, to
+func (_m *Storage) GetClickTotals(ctx context.Context, shortLink string, from time.Time, to time.Time) (int, int, error) {
+	ret := _m.Called(ctx, shortLink, from, to)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateUserLinks")
+		panic("no return value specified for GetClickTotals")
 	}
 
-	var r0 *postgresDB.User
+	var r0 int
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) (int, int, error)); ok {
+		return rf(ctx, shortLink, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) int); ok {
+		r0 = rf(ctx, shortLink, from, to)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) int); ok {
+		r1 = rf(ctx, shortLink, from, to)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r2 = rf(ctx, shortLink, from, to)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetLinkPasswordHash provides a mock function with given fields: ctx, shortLink
+func (_m *Storage) GetLinkPasswordHash(ctx context.Context, shortLink string) (string, error) {
+	ret := _m.Called(ctx, shortLink)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinkPasswordHash")
+	}
+
+	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, int) (*postgresDB.User, error)); ok {
-		return rf(ctx, email, urlsDelta)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, shortLink)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, int) *postgresDB.User); ok {
-		r0 = rf(ctx, email, urlsDelta)
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, shortLink)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, shortLink)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLinkVersions provides a mock function with given fields: ctx, shortLink
+func (_m *Storage) GetLinkVersions(ctx context.Context, shortLink string) ([]postgresDB.LinkVersion, error) {
+	ret := _m.Called(ctx, shortLink)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinkVersions")
+	}
+
+	var r0 []postgresDB.LinkVersion
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]postgresDB.LinkVersion, error)); ok {
+		return rf(ctx, shortLink)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []postgresDB.LinkVersion); ok {
+		r0 = rf(ctx, shortLink)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*postgresDB.User)
+			r0 = ret.Get(0).([]postgresDB.LinkVersion)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
-		r1 = rf(ctx, email, urlsDelta)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, shortLink)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrder provides a mock function with given fields: ctx, orderId
+func (_m *Storage) GetOrder(ctx context.Context, orderId string) (*postgresDB.Order, error) {
+	ret := _m.Called(ctx, orderId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrder")
+	}
+
+	var r0 *postgresDB.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.Order, error)); ok {
+		return rf(ctx, orderId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.Order); ok {
+		r0 = rf(ctx, orderId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orderId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRoles provides a mock function with given fields: ctx
+func (_m *Storage) GetRoles(ctx context.Context) ([]postgresDB.Role, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRoles")
+	}
+
+	var r0 []postgresDB.Role
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]postgresDB.Role, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []postgresDB.Role); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.Role)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetShortLink provides a mock function with given fields: ctx, shortLink
+func (_m *Storage) GetShortLink(ctx context.Context, shortLink string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetShortLink")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, shortLink)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscriptions provides a mock function with given fields: ctx
+func (_m *Storage) GetSubscriptions(ctx context.Context) ([]postgresDB.Subscription, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubscriptions")
+	}
+
+	var r0 []postgresDB.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]postgresDB.Subscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []postgresDB.Subscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSystemStats provides a mock function with given fields: ctx, now
+func (_m *Storage) GetSystemStats(ctx context.Context, now time.Time) (*postgresDB.SystemStats, error) {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSystemStats")
+	}
+
+	var r0 *postgresDB.SystemStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (*postgresDB.SystemStats, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) *postgresDB.SystemStats); ok {
+		r0 = rf(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.SystemStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTopClickValues provides a mock function with given fields: ctx, shortLink, dimension, from, to, limit
+func (_m *Storage) GetTopClickValues(ctx context.Context, shortLink string, dimension string, from time.Time, to time.Time, limit int) ([]postgresDB.ClickCount, error) {
+	ret := _m.Called(ctx, shortLink, dimension, from, to, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTopClickValues")
+	}
+
+	var r0 []postgresDB.ClickCount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time, int) ([]postgresDB.ClickCount, error)); ok {
+		return rf(ctx, shortLink, dimension, from, to, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, time.Time, int) []postgresDB.ClickCount); ok {
+		r0 = rf(ctx, shortLink, dimension, from, to, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.ClickCount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, time.Time, int) error); ok {
+		r1 = rf(ctx, shortLink, dimension, from, to, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, email
+func (_m *Storage) GetUser(ctx context.Context, email string) (*postgresDB.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUser")
+	}
+
+	var r0 *postgresDB.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserRole provides a mock function with given fields: ctx, email
+func (_m *Storage) GetUserRole(ctx context.Context, email string) (*postgresDB.Role, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserRole")
+	}
+
+	var r0 *postgresDB.Role
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.Role, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.Role); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Role)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserShortLinksWithOffsetAndLimit provides a mock function with given fields: ctx, email, offset, limit
+func (_m *Storage) GetUserShortLinksWithOffsetAndLimit(ctx context.Context, email string, offset int, limit int) ([]postgresDB.Link, error) {
+	ret := _m.Called(ctx, email, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserShortLinksWithOffsetAndLimit")
+	}
+
+	var r0 []postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]postgresDB.Link, error)); ok {
+		return rf(ctx, email, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []postgresDB.Link); ok {
+		r0 = rf(ctx, email, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, email, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserSubscription provides a mock function with given fields: ctx, email
+func (_m *Storage) GetUserSubscription(ctx context.Context, email string) (*postgresDB.Subscription, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserSubscription")
+	}
+
+	var r0 *postgresDB.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*postgresDB.Subscription, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *postgresDB.Subscription); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PayOrder provides a mock function with given fields: ctx, orderId, paymentId
+func (_m *Storage) PayOrder(ctx context.Context, orderId string, paymentId string) (*postgresDB.Order, error) {
+	ret := _m.Called(ctx, orderId, paymentId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PayOrder")
+	}
+
+	var r0 *postgresDB.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*postgresDB.Order, error)); ok {
+		return rf(ctx, orderId, paymentId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *postgresDB.Order); ok {
+		r0 = rf(ctx, orderId, paymentId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orderId, paymentId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetQuotas provides a mock function with given fields: ctx, now
+func (_m *Storage) ResetQuotas(ctx context.Context, now time.Time) (int, error) {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetQuotas")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchLinks provides a mock function with given fields: ctx, query, offset, limit
+func (_m *Storage) SearchLinks(ctx context.Context, query string, offset int, limit int) ([]postgresDB.Link, error) {
+	ret := _m.Called(ctx, query, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchLinks")
+	}
+
+	var r0 []postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]postgresDB.Link, error)); ok {
+		return rf(ctx, query, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []postgresDB.Link); ok {
+		r0 = rf(ctx, query, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, query, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchUsers provides a mock function with given fields: ctx, query, offset, limit
+func (_m *Storage) SearchUsers(ctx context.Context, query string, offset int, limit int) ([]postgresDB.UserSummary, error) {
+	ret := _m.Called(ctx, query, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchUsers")
+	}
+
+	var r0 []postgresDB.UserSummary
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) ([]postgresDB.UserSummary, error)); ok {
+		return rf(ctx, query, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) []postgresDB.UserSummary); ok {
+		r0 = rf(ctx, query, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]postgresDB.UserSummary)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, query, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLinkClicks provides a mock function with given fields: ctx, shortLink, clicks
+func (_m *Storage) SetLinkClicks(ctx context.Context, shortLink string, clicks *int) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, clicks)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkClicks")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *int) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, clicks)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *int) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, clicks)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *int) error); ok {
+		r1 = rf(ctx, shortLink, clicks)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLinkDisabled provides a mock function with given fields: ctx, shortLink, disabled
+func (_m *Storage) SetLinkDisabled(ctx context.Context, shortLink string, disabled bool) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, disabled)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkDisabled")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, disabled)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, disabled)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, shortLink, disabled)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLinkPassword provides a mock function with given fields: ctx, shortLink, password
+func (_m *Storage) SetLinkPassword(ctx context.Context, shortLink string, password string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkPassword")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, shortLink, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetUserDisabled provides a mock function with given fields: ctx, email, disabled
+func (_m *Storage) SetUserDisabled(ctx context.Context, email string, disabled bool) error {
+	ret := _m.Called(ctx, email, disabled)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserDisabled")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) error); ok {
+		r0 = rf(ctx, email, disabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserRole provides a mock function with given fields: ctx, email, role
+func (_m *Storage) SetUserRole(ctx context.Context, email string, role string) error {
+	ret := _m.Called(ctx, email, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, email, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetUserSubscription provides a mock function with given fields: ctx, email, subscriptionId
+func (_m *Storage) SetUserSubscription(ctx context.Context, email string, subscriptionId int) error {
+	ret := _m.Called(ctx, email, subscriptionId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) error); ok {
+		r0 = rf(ctx, email, subscriptionId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TouchApiToken provides a mock function with given fields: ctx, id, usedAt
+func (_m *Storage) TouchApiToken(ctx context.Context, id int, usedAt time.Time) error {
+	ret := _m.Called(ctx, id, usedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchApiToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) error); ok {
+		r0 = rf(ctx, id, usedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateLink provides a mock function with given fields: ctx, shortLink, changes, changedBy
+func (_m *Storage) UpdateLink(ctx context.Context, shortLink string, changes postgresDB.LinkChanges, changedBy string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, changes, changedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLink")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, postgresDB.LinkChanges, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, changes, changedBy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, postgresDB.LinkChanges, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, changes, changedBy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, postgresDB.LinkChanges, string) error); ok {
+		r1 = rf(ctx, shortLink, changes, changedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateShortLink provides a mock function with given fields: ctx, shortLink, longLink, changedBy
+func (_m *Storage) UpdateShortLink(ctx context.Context, shortLink string, longLink string, changedBy string) (*postgresDB.Link, error) {
+	ret := _m.Called(ctx, shortLink, longLink, changedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateShortLink")
+	}
+
+	var r0 *postgresDB.Link
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*postgresDB.Link, error)); ok {
+		return rf(ctx, shortLink, longLink, changedBy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *postgresDB.Link); ok {
+		r0 = rf(ctx, shortLink, longLink, changedBy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.Link)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, shortLink, longLink, changedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateUserLinks provides a mock function with given fields: ctx, email, urlsDelta
+func (_m *Storage) UpdateUserLinks(ctx context.Context, email string, urlsDelta int) (*postgresDB.User, error) {
+	ret := _m.Called(ctx, email, urlsDelta)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserLinks")
+	}
+
+	var r0 *postgresDB.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (*postgresDB.User, error)); ok {
+		return rf(ctx, email, urlsDelta)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) *postgresDB.User); ok {
+		r0 = rf(ctx, email, urlsDelta)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*postgresDB.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, email, urlsDelta)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyLinkPassword provides a mock function with given fields: ctx, shortLink, password
+func (_m *Storage) VerifyLinkPassword(ctx context.Context, shortLink string, password string) (string, error) {
+	ret := _m.Called(ctx, shortLink, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyLinkPassword")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, shortLink, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, shortLink, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, shortLink, password)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,262 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"urleater/internal/handlers"
+	"urleater/internal/payments"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+)
+
+func (s *paymentsSuite) buy(subscriptionId int) handlers.BuySubscriptionResponse {
+	body, status := s.BuySubscription(&handlers.BuySubscriptionRequest{SubscriptionId: subscriptionId})
+	s.Require().Equal(http.StatusOK, status)
+
+	var resp handlers.BuySubscriptionResponse
+
+	s.Require().NoError(json.Unmarshal(body, &resp))
+
+	return resp
+}
+
+func (s *paymentsSuite) order(orderId string) postgresDB.Order {
+	order, err := s.storage.GetOrder(context.Background(), orderId)
+	s.Require().NoError(err)
+
+	return *order
+}
+
+func (s *paymentsSuite) TestBuy() {
+	// 1 anonymous
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return("", nil).Once()
+
+	_, status := s.BuySubscription(&handlers.BuySubscriptionRequest{SubscriptionId: bronze.Id})
+	s.Equal(http.StatusUnauthorized, status)
+
+	s.loggedInAs("test_name1@mail.ru", 3)
+
+	// 2 unknown subscription
+	_, status = s.BuySubscription(&handlers.BuySubscriptionRequest{SubscriptionId: 42})
+	s.Equal(http.StatusNotFound, status)
+
+	// 3 not for sale
+	_, status = s.BuySubscription(&handlers.BuySubscriptionRequest{SubscriptionId: free.Id})
+	s.Equal(http.StatusBadRequest, status)
+
+	// 4
+	resp := s.buy(gold.Id)
+
+	s.Equal("test_name1@mail.ru", resp.Order.UserEmail)
+	s.Equal(gold.Id, resp.Order.SubscriptionId)
+	s.Equal(gold.Price, resp.Order.Amount)
+	s.Equal(postgresDB.OrderPending, resp.Order.Status)
+	s.Contains(resp.PaymentURL, payments.CheckoutPath)
+	s.Contains(resp.PaymentURL, resp.Order.Id)
+
+	// nothing is credited until the payment is confirmed
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *paymentsSuite) TestPaymentSucceeded() {
+	s.loggedInAs("test_name1@mail.ru", 1)
+
+	order := s.buy(bronze.Id).Order
+
+	// 1
+	_, status := s.PaymentCallback(s.provider.Callback(order.Id, order.Amount, service.PaymentSucceeded))
+
+	s.Equal(http.StatusNoContent, status)
+	s.Equal(postgresDB.OrderPaid, s.order(order.Id).Status)
+	s.Equal(bronze.TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	sub, err := s.storage.GetUserSubscription(context.Background(), "test_name1@mail.ru")
+	s.NoError(err)
+	s.Equal(bronze, *sub)
+
+	// 2 the provider retries the notification
+	payload, signature := s.provider.Callback(order.Id, order.Amount, service.PaymentSucceeded)
+
+	_, status = s.PaymentCallback(payload, signature)
+	s.Equal(http.StatusNoContent, status)
+
+	_, status = s.PaymentCallback(payload, signature)
+	s.Equal(http.StatusNoContent, status)
+
+	s.Equal(bronze.TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	// 3 a late failure doesn't undo the payment
+	_, status = s.PaymentCallback(s.provider.Callback(order.Id, order.Amount, service.PaymentFailed))
+
+	s.Equal(http.StatusNoContent, status)
+	s.Equal(postgresDB.OrderPaid, s.order(order.Id).Status)
+}
+
+func (s *paymentsSuite) TestConcurrentCallbacks() {
+	const attempts = 20
+
+	s.loggedInAs("test_name1@mail.ru", 1)
+
+	order := s.buy(gold.Id).Order
+	payload, signature := s.provider.Callback(order.Id, order.Amount, service.PaymentSucceeded)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, status := s.PaymentCallback(payload, signature)
+			s.Equal(http.StatusNoContent, status)
+		}()
+	}
+
+	wg.Wait()
+
+	s.Equal(gold.TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *paymentsSuite) TestPaymentFailed() {
+	s.loggedInAs("test_name1@mail.ru", 1)
+
+	order := s.buy(gold.Id).Order
+
+	// 1
+	_, status := s.PaymentCallback(s.provider.Callback(order.Id, order.Amount, service.PaymentFailed))
+
+	s.Equal(http.StatusNoContent, status)
+	s.Equal(postgresDB.OrderFailed, s.order(order.Id).Status)
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+
+	// 2 a failed order stays failed, the user has to place a new one
+	_, status = s.PaymentCallback(s.provider.Callback(order.Id, order.Amount, service.PaymentSucceeded))
+
+	s.Equal(http.StatusNoContent, status)
+	s.Equal(postgresDB.OrderFailed, s.order(order.Id).Status)
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *paymentsSuite) TestInvalidCallbacks() {
+	s.loggedInAs("test_name1@mail.ru", 1)
+
+	order := s.buy(bronze.Id).Order
+
+	// 1 signed with another secret
+	other := payments.NewFake([]byte("another-secret"), "http://localhost:8080")
+
+	_, status := s.PaymentCallback(other.Callback(order.Id, order.Amount, service.PaymentSucceeded))
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 2 tampered with
+	payload, signature := s.provider.Callback(order.Id, order.Amount, service.PaymentSucceeded)
+	tampered := strings.Replace(string(payload), strconv.Itoa(order.Amount), "1", 1)
+
+	_, status = s.PaymentCallback([]byte(tampered), signature)
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 3 no signature
+	_, status = s.PaymentCallback(payload, "")
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 4 paid less than the order costs
+	_, status = s.PaymentCallback(s.provider.Callback(order.Id, 1, service.PaymentSucceeded))
+	s.Equal(http.StatusBadRequest, status)
+
+	// 5 unknown order
+	_, status = s.PaymentCallback(s.provider.Callback("unknown", order.Amount, service.PaymentSucceeded))
+	s.Equal(http.StatusNotFound, status)
+
+	// 6 unknown status
+	_, status = s.PaymentCallback(s.provider.Callback(order.Id, order.Amount, "refunded"))
+	s.Equal(http.StatusBadRequest, status)
+
+	s.Equal(postgresDB.OrderPending, s.order(order.Id).Status)
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *paymentsSuite) TestGetOrder() {
+	s.loggedInAs("test_name1@mail.ru", 3)
+
+	order := s.buy(bronze.Id).Order
+
+	// 1
+	body, status := s.GetOrder(url.Values{"order_id": {order.Id}})
+
+	var resp handlers.GetOrderResponse
+
+	s.NoError(json.Unmarshal(body, &resp))
+	s.Equal(http.StatusOK, status)
+	s.Equal(order.Id, resp.Order.Id)
+	s.Equal(postgresDB.OrderPending, resp.Order.Status)
+
+	// 2
+	_, status = s.GetOrder(url.Values{"order_id": {"unknown"}})
+	s.Equal(http.StatusNotFound, status)
+
+	// 3 someone else's order
+	s.loggedInAs("test_name2@mail.ru", 2)
+
+	_, status = s.GetOrder(url.Values{"order_id": {order.Id}})
+	s.Equal(http.StatusForbidden, status)
+
+	// 4
+	_, status = s.GetOrder(url.Values{})
+	s.Equal(http.StatusBadRequest, status)
+}
+
+func (s *paymentsSuite) TestCheckout() {
+	// the fake provider notifies the service over HTTP
+	e := echo.New()
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	provider := payments.NewFake([]byte("test-secret"), server.URL)
+
+	s.FinishSetupTest(s.storage, s.sessionStore, service.WithPaymentProvider(provider))
+
+	e.POST(payments.CallbackPath, s.Handlers.PaymentCallback)
+	e.Any(payments.CheckoutPath, echo.WrapHandler(provider))
+
+	s.loggedInAs("test_name1@mail.ru", 1)
+
+	resp := s.buy(gold.Id)
+
+	// 1
+	page, err := http.Get(resp.PaymentURL)
+	s.Require().NoError(err)
+	defer page.Body.Close()
+
+	s.Equal(http.StatusOK, page.StatusCode)
+
+	// 2 the user pays and is brought back
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	paid, err := client.PostForm(resp.PaymentURL, url.Values{
+		"order_id": {resp.Order.Id},
+		"amount":   {strconv.Itoa(resp.Order.Amount)},
+		"status":   {service.PaymentSucceeded},
+	})
+	s.Require().NoError(err)
+	defer paid.Body.Close()
+
+	s.Equal(http.StatusSeeOther, paid.StatusCode)
+	s.Equal("/subscriptions?order="+resp.Order.Id, paid.Header.Get("Location"))
+	s.Equal(postgresDB.OrderPaid, s.order(resp.Order.Id).Status)
+	s.Equal(gold.TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+}
@@ -0,0 +1,54 @@
+package payments
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"urleater/internal/payments"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const testQuota = 3
+
+var (
+	bronze = postgresDB.Subscription{Id: 1, Name: "Bronze", TotalUrls: 10, MaxLinkTTLDays: 90, Price: 9900}
+	gold   = postgresDB.Subscription{Id: 2, Name: "Gold", TotalUrls: 200, MaxLinkTTLDays: 365, Price: 129900}
+	// free is not for sale
+	free = postgresDB.Subscription{Id: 3, Name: "Free", TotalUrls: 1, MaxLinkTTLDays: 30}
+)
+
+type paymentsSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+	provider     *payments.Fake
+}
+
+func (s *paymentsSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	s.storage = memoryDB.NewStorage(bronze, gold, free)
+	s.sessionStore = mocks.NewSessionStore(s.T())
+	s.provider = payments.NewFake([]byte("test-secret"), "http://localhost:8080")
+
+	for _, email := range []string{"test_name1@mail.ru", "test_name2@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(context.Background(), email, "qwertyui", testQuota))
+	}
+
+	s.FinishSetupTest(s.storage, s.sessionStore, service.WithPaymentProvider(s.provider))
+}
+
+func (s *paymentsSuite) loggedInAs(email string, requests int) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Times(requests)
+}
+
+func (s *paymentsSuite) urlsLeft(email string) int {
+	user, err := s.storage.GetUser(context.Background(), email)
+	s.Require().NoError(err)
+
+	return user.UrlsLeft
+}
@@ -0,0 +1,61 @@
+package record_click
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+func (s *recordClickSuite) TestRecordClick() {
+	// 1
+	rec := s.GetShortLink("myAlias1", map[string]string{
+		"Referer":         "https://t.me/",
+		"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+		"X-Forwarded-For": "10.0.0.1",
+		"CF-IPCountry":    "ru",
+	})
+
+	s.Equal(http.StatusFound, rec.Code)
+	s.Equal("https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset", rec.Header().Get("Location"))
+
+	var ipHash string
+
+	select {
+	case click := <-s.clicks:
+		ipHash = click.IPHash
+
+		s.Equal("myAlias1", click.ShortUrl)
+		s.Equal("https://t.me/", click.Referrer)
+		s.Equal("t.me", click.ReferrerHost)
+		s.Equal("Chrome", click.Browser)
+		s.Equal("Windows", click.OS)
+		s.Equal("RU", click.Country)
+		s.NotEmpty(click.IPHash)
+		s.NotContains(click.IPHash, "10.0.0.1")
+	case <-time.After(time.Second):
+		s.Fail("click was not recorded")
+	}
+
+	// 2 the address is hashed with the secret, so that it can't be found by hashing every address
+	plain := sha256.Sum256([]byte("10.0.0.1"))
+
+	s.NotEqual(hex.EncodeToString(plain[:]), ipHash)
+
+	// 3 the same visitor gets the same hash
+	rec = s.GetShortLink("myAlias1", map[string]string{"X-Forwarded-For": "10.0.0.1"})
+
+	s.Equal(http.StatusFound, rec.Code)
+
+	select {
+	case click := <-s.clicks:
+		s.Equal(ipHash, click.IPHash)
+	case <-time.After(time.Second):
+		s.Fail("click was not recorded")
+	}
+
+	// 4
+	rec = s.GetShortLink("unknownAlias", nil)
+
+	s.Equal(http.StatusNotFound, rec.Code)
+}
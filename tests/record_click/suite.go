@@ -0,0 +1,45 @@
+package record_click
+
+import (
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/mock"
+	"time"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+type recordClickSuite struct {
+	base.BaseSuite
+
+	clicks chan postgresDB.Click
+}
+
+func (s *recordClickSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	storage := mocks.NewStorage(s.T())
+	sessionStore := mocks.NewSessionStore(s.T())
+
+	s.clicks = make(chan postgresDB.Click, 2)
+
+	// 1
+	link1 := postgresDB.Link{
+		ShortUrl:  "myAlias1",
+		LongUrl:   "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	storage.On("GetShortLink", mock.Anything, "myAlias1").Return(&link1, nil).Twice()
+	storage.On("CreateClick", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			s.clicks <- args.Get(1).(postgresDB.Click)
+		}).
+		Return(nil).Twice()
+
+	// 2
+	storage.On("GetShortLink", mock.Anything, "unknownAlias").Return(nil, pgx.ErrNoRows).Once()
+
+	s.FinishSetupTest(storage, sessionStore, service.WithVisitorSecret([]byte("visitor-secret")))
+}
@@ -20,7 +20,7 @@ func (s *registerUserSuite) TestRegisterUser() {
 		Password: "qwertyui",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusConflict, code)
 
 	// 3
 	_, code = s.RegisterUser(&handlers.RegisterRequest{
@@ -28,7 +28,7 @@ func (s *registerUserSuite) TestRegisterUser() {
 		Password: "12345678",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusConflict, code)
 
 	// 4
 	//_, code = s.RegisterUser(&handlers.RegisterRequest{
@@ -44,7 +44,7 @@ func (s *registerUserSuite) TestRegisterUser() {
 		Password: "        ",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 6
 
@@ -53,7 +53,7 @@ func (s *registerUserSuite) TestRegisterUser() {
 		Password: "12345678",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 7
 	_, code = s.RegisterUser(&handlers.RegisterRequest{
@@ -61,7 +61,7 @@ func (s *registerUserSuite) TestRegisterUser() {
 		Password: "1234567",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 8
 	_, code = s.RegisterUser(&handlers.RegisterRequest{
@@ -69,7 +69,7 @@ func (s *registerUserSuite) TestRegisterUser() {
 		Password: "12345678",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 9
 	_, code = s.RegisterUser(&handlers.RegisterRequest{
@@ -77,7 +77,7 @@ func (s *registerUserSuite) TestRegisterUser() {
 		Password: "мойпароль",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 
 	// 10
 	_, code = s.RegisterUser(&handlers.RegisterRequest{
@@ -85,5 +85,5 @@ func (s *registerUserSuite) TestRegisterUser() {
 		Password: "qwertyui",
 	})
 
-	s.Equal(http.StatusInternalServerError, code)
+	s.Equal(http.StatusBadRequest, code)
 }
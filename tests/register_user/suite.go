@@ -1,9 +1,8 @@
 package register_user
 
 import (
-	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/mock"
-	"urleater/internal/repository/postgresDB"
+	"urleater/internal/repository/memoryDB"
 	base "urleater/tests"
 	"urleater/tests/mocks"
 )
@@ -15,35 +14,12 @@ type registerUserSuite struct {
 func (s *registerUserSuite) SetupTest() {
 	s.BaseSetupTest()
 
-	storage := mocks.NewStorage(s.T())
+	storage := memoryDB.NewStorage()
 	sessionStore := mocks.NewSessionStore(s.T())
 
 	sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return("", nil)
 	sessionStore.On("Get", mock.Anything, mock.Anything).Return(nil, nil)
 	sessionStore.On("Save", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	storage.On("GetUser", mock.Anything, "test_name1@mail.ru").
-		Return(nil, pgx.ErrNoRows).Once()
-	storage.On("CreateUser", mock.Anything, "test_name1@mail.ru", "qwertyui").Return(nil).Once()
-
-	// 2
-	user2 := postgresDB.User{
-		Email:        "test_name1@mail.ru",
-		PasswordHash: "some_hash",
-	}
-
-	storage.On("GetUser", mock.Anything, "test_name1@mail.ru").
-		Return(&user2, nil).Once()
-
-	// 3
-	user3 := postgresDB.User{
-		Email:        "test_name1@mail.ru",
-		PasswordHash: "some_hash",
-	}
-
-	storage.On("GetUser", mock.Anything, "test_name1@mail.ru").
-		Return(&user3, nil).Once()
-
 	s.FinishSetupTest(storage, sessionStore)
-
 }
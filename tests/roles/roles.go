@@ -0,0 +1,138 @@
+package roles
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"urleater/internal/handlers"
+	"urleater/internal/service"
+)
+
+func (s *rolesSuite) TestRequirePermission() {
+	request := &handlers.UpdateUserShortLinksRequest{Email: "user@mail.ru", DeltaLinks: 5}
+
+	// 1 anonymous
+	s.loggedInAs("")
+
+	body, status := s.updateUserLinks(request)
+
+	s.Equal(http.StatusUnauthorized, status)
+
+	var errResp handlers.ErrorResponse
+
+	s.NoError(json.Unmarshal(body, &errResp))
+	s.Equal("/login", errResp.RedirectTo)
+
+	// 2
+	s.loggedInAs("user@mail.ru")
+
+	_, status = s.updateUserLinks(request)
+
+	s.Equal(http.StatusForbidden, status)
+
+	// 3 support may only look at users
+	s.loggedInAs("support@mail.ru")
+
+	_, status = s.updateUserLinks(request)
+
+	s.Equal(http.StatusForbidden, status)
+	s.Equal(testQuota, s.urlsLeft("user@mail.ru"))
+
+	// 4
+	s.loggedInAs("admin@mail.ru")
+
+	body, status = s.updateUserLinks(request)
+
+	s.Equal(http.StatusOK, status)
+
+	var resp handlers.UpdateUserShortLinksResponse
+
+	s.NoError(json.Unmarshal(body, &resp))
+	s.Equal(testQuota+5, resp.User.UrlsLeft)
+	s.Equal(testQuota+5, s.urlsLeft("user@mail.ru"))
+
+	// 5 unknown user
+	s.loggedInAs("admin@mail.ru")
+
+	_, status = s.updateUserLinks(&handlers.UpdateUserShortLinksRequest{Email: "nobody@mail.ru", DeltaLinks: 5})
+
+	s.Equal(http.StatusNotFound, status)
+
+	// 6 the session outlived the user
+	s.loggedInAs("deleted@mail.ru")
+
+	_, status = s.updateUserLinks(request)
+
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 7 the principal was never loaded
+	_, status = s.MakeRequestWithBody(http.MethodPost, s.Handlers.RequirePermission(service.PermissionManageQuota)(s.Handlers.UpdateUserShortLinks), "{}")
+
+	s.Equal(http.StatusInternalServerError, status)
+}
+
+func (s *rolesSuite) TestGetPrincipal() {
+	ctx := context.Background()
+
+	// 1
+	principal, err := s.service.GetPrincipal(ctx, "")
+
+	s.NoError(err)
+	s.True(principal.Anonymous())
+	s.False(principal.Can(service.PermissionViewUsers))
+
+	// 2
+	principal, err = s.service.GetPrincipal(ctx, "user@mail.ru")
+
+	s.NoError(err)
+	s.Equal("user", principal.Role)
+	s.False(principal.Can(service.PermissionViewUsers))
+
+	// 3
+	principal, err = s.service.GetPrincipal(ctx, "support@mail.ru")
+
+	s.NoError(err)
+	s.True(principal.Can(service.PermissionViewUsers))
+	s.False(principal.Can(service.PermissionManageQuota))
+
+	// 4
+	principal, err = s.service.GetPrincipal(ctx, "admin@mail.ru")
+
+	s.NoError(err)
+	s.Equal("admin", principal.Role)
+
+	for _, permission := range []string{service.PermissionViewUsers, service.PermissionManageQuota, service.PermissionManageRoles} {
+		s.True(principal.Can(permission), permission)
+	}
+}
+
+func (s *rolesSuite) TestGrantRole() {
+	ctx := context.Background()
+
+	// 1
+	s.NoError(s.service.GrantRole(ctx, " user@mail.ru ", "admin"))
+
+	principal, err := s.service.GetPrincipal(ctx, "user@mail.ru")
+
+	s.NoError(err)
+	s.True(principal.Can(service.PermissionManageRoles))
+
+	// 2 back to a plain user
+	s.NoError(s.service.GrantRole(ctx, "user@mail.ru", "user"))
+
+	principal, err = s.service.GetPrincipal(ctx, "user@mail.ru")
+
+	s.NoError(err)
+	s.Empty(principal.Permissions)
+
+	// 3
+	err = s.service.GrantRole(ctx, "user@mail.ru", "root")
+
+	s.ErrorIs(err, service.ErrInvalidInput)
+	s.Contains(err.Error(), "admin, support, user")
+
+	// 4
+	err = s.service.GrantRole(ctx, "nobody@mail.ru", "admin")
+
+	s.ErrorIs(err, service.ErrNotFound)
+}
@@ -0,0 +1,61 @@
+package roles
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const testQuota = 3
+
+type rolesSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+	service      *service.Service
+}
+
+func (s *rolesSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	s.storage = memoryDB.NewStorage()
+	s.sessionStore = mocks.NewSessionStore(s.T())
+	s.service = service.New(s.storage)
+
+	for _, email := range []string{"user@mail.ru", "support@mail.ru", "admin@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(context.Background(), email, "qwertyui", testQuota))
+	}
+
+	s.Require().NoError(s.service.GrantRole(context.Background(), "support@mail.ru", "support"))
+	s.Require().NoError(s.service.GrantRole(context.Background(), "admin@mail.ru", "admin"))
+
+	s.FinishSetupTest(s.storage, s.sessionStore)
+}
+
+func (s *rolesSuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
+
+// updateUserLinks goes through the middlewares the route has.
+func (s *rolesSuite) updateUserLinks(data *handlers.UpdateUserShortLinksRequest) ([]byte, int) {
+	res, err := json.Marshal(data)
+	s.NoError(err)
+
+	h := s.Handlers.RequireAuth(s.Handlers.RequirePermission(service.PermissionManageQuota)(s.Handlers.UpdateUserShortLinks))
+
+	return s.MakeRequestWithBody(http.MethodPost, h, string(res))
+}
+
+func (s *rolesSuite) urlsLeft(email string) int {
+	user, err := s.storage.GetUser(context.Background(), email)
+	s.Require().NoError(err)
+
+	return user.UrlsLeft
+}
@@ -0,0 +1,1450 @@
+package storage_conformance
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"sync"
+	"sync/atomic"
+	"time"
+	"urleater/internal/repository/postgresDB"
+)
+
+const (
+	testQuota   = 7
+	testLinkTTL = 48 * time.Hour
+)
+
+func (s *storageSuite) createUser(email string) {
+	err := s.storage.CreateUser(s.ctx, email, "qwertyui", testQuota)
+	s.Require().NoError(err)
+}
+
+func (s *storageSuite) createLink(shortLink string, email string) *postgresDB.Link {
+	link, err := s.storage.CreateShortLink(s.ctx, shortLink, "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset", email, time.Now().UTC().Add(testLinkTTL))
+	s.Require().NoError(err)
+
+	return link
+}
+
+// verifyLinkPassword is VerifyLinkPassword for the cases that check the outcome only.
+func (s *storageSuite) verifyLinkPassword(shortLink string, password string) error {
+	_, err := s.storage.VerifyLinkPassword(s.ctx, shortLink, password)
+
+	return err
+}
+
+func (s *storageSuite) TestUsers() {
+	// 1
+	s.createUser("test_name1@mail.ru")
+
+	user, err := s.storage.GetUser(s.ctx, "test_name1@mail.ru")
+
+	s.NoError(err)
+	s.Equal("test_name1@mail.ru", user.Email)
+	s.NotEqual("qwertyui", user.PasswordHash)
+	s.Equal(testQuota, user.UrlsLeft)
+
+	// 2
+	err = s.storage.CreateUser(s.ctx, "test_name1@mail.ru", "12345678", testQuota)
+
+	s.ErrorIs(err, postgresDB.ErrAlreadyExists)
+
+	// 3
+	_, err = s.storage.GetUser(s.ctx, "test_name2@mail.ru")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 4
+	s.NoError(s.storage.VerifyUserPassword(s.ctx, "test_name1@mail.ru", "qwertyui"))
+	s.Error(s.storage.VerifyUserPassword(s.ctx, "test_name1@mail.ru", "qwertyui5"))
+	s.ErrorIs(s.storage.VerifyUserPassword(s.ctx, "test_name2@mail.ru", "qwertyui"), pgx.ErrNoRows)
+
+	// 5
+	err = s.storage.ChangePassword(s.ctx, "test_name1@mail.ru", "12345678")
+
+	s.NoError(err)
+	s.NoError(s.storage.VerifyUserPassword(s.ctx, "test_name1@mail.ru", "12345678"))
+	s.Error(s.storage.VerifyUserPassword(s.ctx, "test_name1@mail.ru", "qwertyui"))
+}
+
+func (s *storageSuite) TestShortLinks() {
+	s.createUser("test_name1@mail.ru")
+	s.createUser("test_name2@mail.ru")
+
+	// 1
+	before := time.Now().UTC()
+	link := s.createLink("myAlias1", "test_name1@mail.ru")
+
+	s.Equal("myAlias1", link.ShortUrl)
+	s.Equal("https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset", link.LongUrl)
+	s.Equal("test_name1@mail.ru", link.UserEmail)
+	s.WithinDuration(before.Add(testLinkTTL), link.ExpiresAt, time.Minute)
+
+	// 2
+	_, err := s.storage.CreateShortLink(s.ctx, "myAlias1", "https://ya.ru", "test_name2@mail.ru", time.Now().Add(testLinkTTL))
+
+	s.ErrorIs(err, postgresDB.ErrAlreadyExists)
+
+	// 3
+	_, err = s.storage.CreateShortLink(s.ctx, "myAlias2", "https://ya.ru", "test_name3@mail.ru", time.Now().Add(testLinkTTL))
+
+	s.Error(err)
+
+	// 4
+	got, err := s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Equal(link.ShortUrl, got.ShortUrl)
+	s.Equal(link.LongUrl, got.LongUrl)
+	s.Equal(link.UserEmail, got.UserEmail)
+	s.WithinDuration(link.ExpiresAt, got.ExpiresAt, time.Second)
+
+	// 5
+	_, err = s.storage.GetShortLink(s.ctx, "myAlias2")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 6
+	extended, err := s.storage.ExtendShortLink(s.ctx, "myAlias1", link.ExpiresAt.Add(testLinkTTL))
+
+	s.NoError(err)
+	s.WithinDuration(link.ExpiresAt.Add(testLinkTTL), extended.ExpiresAt, time.Second)
+
+	got, err = s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.WithinDuration(extended.ExpiresAt, got.ExpiresAt, time.Second)
+
+	// 7
+	_, err = s.storage.ExtendShortLink(s.ctx, "myAlias2", link.ExpiresAt)
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 8
+	err = s.storage.DeleteShortLink(s.ctx, "myAlias1", "test_name2@mail.ru", 0)
+
+	s.NoError(err)
+
+	_, err = s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+
+	// 9
+	err = s.storage.DeleteShortLink(s.ctx, "myAlias1", "test_name1@mail.ru", 0)
+
+	s.NoError(err)
+
+	_, err = s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (s *storageSuite) TestUserShortLinksPagination() {
+	s.createUser("test_name1@mail.ru")
+	s.createUser("test_name2@mail.ru")
+
+	for _, shortLink := range []string{"myAlias1", "myAlias2", "myAlias3"} {
+		s.createLink(shortLink, "test_name1@mail.ru")
+	}
+
+	s.createLink("myAlias4", "test_name2@mail.ru")
+
+	// 1
+	links, err := s.storage.GetUserShortLinksWithOffsetAndLimit(s.ctx, "test_name1@mail.ru", 0, 2)
+
+	s.NoError(err)
+	s.Len(links, 2)
+
+	// 2
+	rest, err := s.storage.GetUserShortLinksWithOffsetAndLimit(s.ctx, "test_name1@mail.ru", 2, 2)
+
+	s.NoError(err)
+	s.Len(rest, 1)
+
+	seen := map[string]bool{}
+
+	for _, link := range append(links, rest...) {
+		s.Equal("test_name1@mail.ru", link.UserEmail)
+		seen[link.ShortUrl] = true
+	}
+
+	s.Len(seen, 3)
+
+	// 3
+	links, err = s.storage.GetUserShortLinksWithOffsetAndLimit(s.ctx, "test_name3@mail.ru", 0, 10)
+
+	s.NoError(err)
+	s.Empty(links)
+}
+
+func (s *storageSuite) TestLinkVersions() {
+	s.createUser("test_name1@mail.ru")
+	s.createLink("myAlias1", "test_name1@mail.ru")
+	s.createLink("myAlias2", "test_name1@mail.ru")
+
+	// 1
+	versions, err := s.storage.GetLinkVersions(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Empty(versions)
+
+	// 2
+	link, err := s.storage.UpdateShortLink(s.ctx, "myAlias1", "https://example.com/first", "test_name1@mail.ru")
+
+	s.NoError(err)
+	s.Equal("https://example.com/first", link.LongUrl)
+	s.Equal("test_name1@mail.ru", link.UserEmail)
+
+	link, err = s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Equal("https://example.com/first", link.LongUrl)
+
+	// 3 the latest change comes first
+	_, err = s.storage.UpdateShortLink(s.ctx, "myAlias1", "https://example.com/second", "admin@mail.ru")
+	s.NoError(err)
+
+	versions, err = s.storage.GetLinkVersions(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Require().Len(versions, 2)
+	s.Greater(versions[0].Id, versions[1].Id)
+
+	s.Equal("myAlias1", versions[0].ShortUrl)
+	s.Equal("https://example.com/first", versions[0].OldLongUrl)
+	s.Equal("https://example.com/second", versions[0].NewLongUrl)
+	s.Equal("admin@mail.ru", versions[0].ChangedBy)
+	s.WithinDuration(time.Now(), versions[0].ChangedAt, time.Minute)
+
+	s.Equal("https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset", versions[1].OldLongUrl)
+	s.Equal("https://example.com/first", versions[1].NewLongUrl)
+	s.Equal("test_name1@mail.ru", versions[1].ChangedBy)
+
+	// 4 other links have their own history
+	versions, err = s.storage.GetLinkVersions(s.ctx, "myAlias2")
+
+	s.NoError(err)
+	s.Empty(versions)
+
+	// 5
+	_, err = s.storage.UpdateShortLink(s.ctx, "unknownAlias", "https://example.com/first", "test_name1@mail.ru")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	versions, err = s.storage.GetLinkVersions(s.ctx, "unknownAlias")
+
+	s.NoError(err)
+	s.Empty(versions)
+
+	// 6 history goes away with the link
+	s.NoError(s.storage.DeleteShortLink(s.ctx, "myAlias1", "test_name1@mail.ru", 0))
+
+	versions, err = s.storage.GetLinkVersions(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Empty(versions)
+}
+
+func (s *storageSuite) TestUpdateLink() {
+	s.createUser("test_name1@mail.ru")
+	s.createLink("myAlias1", "test_name1@mail.ru")
+
+	// 1 everything at once
+	longUrl := "https://example.com/first"
+	expiresAt := time.Now().UTC().Add(10 * testLinkTTL).Truncate(time.Second)
+	password := "secret1"
+	clicks := 3
+
+	link, err := s.storage.UpdateLink(s.ctx, "myAlias1", postgresDB.LinkChanges{
+		LongUrl:    &longUrl,
+		ExpiresAt:  &expiresAt,
+		Password:   &password,
+		ClicksLeft: &clicks,
+	}, "test_name1@mail.ru")
+
+	s.Require().NoError(err)
+	s.Equal(longUrl, link.LongUrl)
+	s.True(expiresAt.Equal(link.ExpiresAt))
+	s.True(link.Protected)
+	s.Require().NotNil(link.ClicksLeft)
+	s.Equal(clicks, *link.ClicksLeft)
+
+	s.NoError(s.verifyLinkPassword("myAlias1", "secret1"))
+
+	versions, err := s.storage.GetLinkVersions(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Require().Len(versions, 1)
+	s.Equal(longUrl, versions[0].NewLongUrl)
+
+	// 2 the nil fields are kept, the destination is not recorded when it's left as is
+	password = ""
+	clicks = 0
+
+	link, err = s.storage.UpdateLink(s.ctx, "myAlias1", postgresDB.LinkChanges{
+		Password:   &password,
+		ClicksLeft: &clicks,
+	}, "test_name1@mail.ru")
+
+	s.Require().NoError(err)
+	s.Equal(longUrl, link.LongUrl)
+	s.True(expiresAt.Equal(link.ExpiresAt))
+	s.False(link.Protected)
+	s.Nil(link.ClicksLeft)
+
+	versions, err = s.storage.GetLinkVersions(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Len(versions, 1)
+
+	// 3
+	_, err = s.storage.UpdateLink(s.ctx, "unknownAlias", postgresDB.LinkChanges{LongUrl: &longUrl}, "test_name1@mail.ru")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	_, err = s.storage.UpdateLink(s.ctx, "unknownAlias", postgresDB.LinkChanges{Password: &password}, "test_name1@mail.ru")
+	s.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (s *storageSuite) TestQuota() {
+	s.createUser("test_name1@mail.ru")
+
+	// 1 the quota never goes below zero
+	user, err := s.storage.UpdateUserLinks(s.ctx, "test_name1@mail.ru", -testQuota-5)
+
+	s.NoError(err)
+	s.Equal("test_name1@mail.ru", user.Email)
+	s.Equal(0, user.UrlsLeft)
+
+	// 2
+	user, err = s.storage.UpdateUserLinks(s.ctx, "test_name1@mail.ru", 2)
+
+	s.NoError(err)
+	s.Equal(2, user.UrlsLeft)
+
+	// 3
+	_, err = s.storage.UpdateUserLinks(s.ctx, "test_name2@mail.ru", 2)
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 4 creating links spends the quota
+	link, err := s.storage.CreateShortLinkWithQuota(s.ctx, "myAlias1", "https://ya.ru", "test_name1@mail.ru", time.Now().Add(testLinkTTL), postgresDB.LinkOptions{})
+
+	s.NoError(err)
+	s.Equal("myAlias1", link.ShortUrl)
+	s.Equal("test_name1@mail.ru", link.UserEmail)
+	s.Equal(1, s.urlsLeft("test_name1@mail.ru"))
+
+	// 5 a taken short link doesn't cost anything
+	_, err = s.storage.CreateShortLinkWithQuota(s.ctx, "myAlias1", "https://ya.ru", "test_name1@mail.ru", time.Now().Add(testLinkTTL), postgresDB.LinkOptions{})
+
+	s.ErrorIs(err, postgresDB.ErrAlreadyExists)
+	s.Equal(1, s.urlsLeft("test_name1@mail.ru"))
+
+	// 6
+	_, err = s.storage.CreateShortLinkWithQuota(s.ctx, "myAlias2", "https://ya.ru", "test_name1@mail.ru", time.Now().Add(testLinkTTL), postgresDB.LinkOptions{})
+
+	s.NoError(err)
+	s.Equal(0, s.urlsLeft("test_name1@mail.ru"))
+
+	// 7
+	_, err = s.storage.CreateShortLinkWithQuota(s.ctx, "myAlias3", "https://ya.ru", "test_name1@mail.ru", time.Now().Add(testLinkTTL), postgresDB.LinkOptions{})
+
+	s.ErrorIs(err, postgresDB.ErrQuotaExceeded)
+
+	_, err = s.storage.GetShortLink(s.ctx, "myAlias3")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 8
+	_, err = s.storage.CreateShortLinkWithQuota(s.ctx, "myAlias3", "https://ya.ru", "test_name2@mail.ru", time.Now().Add(testLinkTTL), postgresDB.LinkOptions{})
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 9 deleting refunds once
+	s.NoError(s.storage.DeleteShortLink(s.ctx, "myAlias1", "test_name1@mail.ru", 1))
+	s.NoError(s.storage.DeleteShortLink(s.ctx, "myAlias1", "test_name1@mail.ru", 1))
+
+	s.Equal(1, s.urlsLeft("test_name1@mail.ru"))
+
+	// 10
+	s.NoError(s.storage.DeleteShortLink(s.ctx, "myAlias2", "test_name1@mail.ru", 0))
+
+	s.Equal(1, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *storageSuite) TestQuotaRace() {
+	const attempts = 30
+
+	s.createUser("test_name1@mail.ru")
+
+	var (
+		wg      sync.WaitGroup
+		created atomic.Int32
+		refused atomic.Int32
+	)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := s.storage.CreateShortLinkWithQuota(s.ctx, fmt.Sprintf("myAlias%d", i), "https://ya.ru", "test_name1@mail.ru", time.Now().Add(testLinkTTL), postgresDB.LinkOptions{})
+
+			switch {
+			case err == nil:
+				created.Add(1)
+			case errors.Is(err, postgresDB.ErrQuotaExceeded):
+				refused.Add(1)
+			default:
+				s.T().Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	s.Equal(int32(testQuota), created.Load())
+	s.Equal(int32(attempts-testQuota), refused.Load())
+	s.Equal(0, s.urlsLeft("test_name1@mail.ru"))
+
+	links, err := s.storage.GetUserShortLinksWithOffsetAndLimit(s.ctx, "test_name1@mail.ru", 0, attempts)
+
+	s.NoError(err)
+	s.Len(links, testQuota)
+}
+
+func (s *storageSuite) urlsLeft(email string) int {
+	user, err := s.storage.GetUser(s.ctx, email)
+	s.Require().NoError(err)
+
+	return user.UrlsLeft
+}
+
+func (s *storageSuite) TestSubscriptions() {
+	subscriptions, err := s.storage.GetSubscriptions(s.ctx)
+
+	s.NoError(err)
+	s.Equal(testSubscriptions, subscriptions)
+}
+
+func (s *storageSuite) TestUserSubscription() {
+	s.createUser("test_name1@mail.ru")
+
+	// 1 user without a subscription
+	_, err := s.storage.GetUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 2
+	err = s.storage.SetUserSubscription(s.ctx, "test_name1@mail.ru", 2)
+	s.NoError(err)
+
+	sub, err := s.storage.GetUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.NoError(err)
+	s.Equal(testSubscriptions[1], *sub)
+
+	// 3 switching plans
+	err = s.storage.SetUserSubscription(s.ctx, "test_name1@mail.ru", 1)
+	s.NoError(err)
+
+	sub, err = s.storage.GetUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.NoError(err)
+	s.Equal(testSubscriptions[0], *sub)
+
+	// 4 unknown subscription leaves the user on their plan
+	err = s.storage.SetUserSubscription(s.ctx, "test_name1@mail.ru", 42)
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	sub, err = s.storage.GetUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.NoError(err)
+	s.Equal(testSubscriptions[0], *sub)
+
+	// 5 unknown user
+	err = s.storage.SetUserSubscription(s.ctx, "test_name2@mail.ru", 1)
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	_, err = s.storage.GetUserSubscription(s.ctx, "test_name2@mail.ru")
+	s.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (s *storageSuite) newOrder(id string, email string, subscriptionId int) postgresDB.Order {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	return postgresDB.Order{
+		Id:             id,
+		UserEmail:      email,
+		SubscriptionId: subscriptionId,
+		Amount:         testSubscriptions[subscriptionId-1].Price,
+		Days:           testSubscriptions[subscriptionId-1].PeriodDays,
+		Status:         postgresDB.OrderPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+func (s *storageSuite) TestOrders() {
+	s.createUser("test_name1@mail.ru")
+
+	// 1
+	expected := s.newOrder("order1", "test_name1@mail.ru", 2)
+
+	order, err := s.storage.CreateOrder(s.ctx, expected)
+	s.Require().NoError(err)
+
+	got, err := s.storage.GetOrder(s.ctx, "order1")
+	s.Require().NoError(err)
+
+	for _, o := range []*postgresDB.Order{order, got} {
+		s.Equal(expected.Id, o.Id)
+		s.Equal(expected.UserEmail, o.UserEmail)
+		s.Equal(expected.SubscriptionId, o.SubscriptionId)
+		s.Equal(expected.Amount, o.Amount)
+		s.Equal(expected.Days, o.Days)
+		s.Equal(postgresDB.OrderPending, o.Status)
+		s.Empty(o.PaymentId)
+		s.WithinDuration(expected.CreatedAt, o.CreatedAt, time.Second)
+		s.WithinDuration(expected.UpdatedAt, o.UpdatedAt, time.Second)
+	}
+
+	// 2 duplicate id
+	_, err = s.storage.CreateOrder(s.ctx, s.newOrder("order1", "test_name1@mail.ru", 1))
+	s.ErrorIs(err, postgresDB.ErrAlreadyExists)
+
+	// 3 nothing is credited for a pending order
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+
+	// 4 paying puts the user on the plan with its quota
+	order, err = s.storage.PayOrder(s.ctx, "order1", "payment1")
+	s.Require().NoError(err)
+	s.Equal(postgresDB.OrderPaid, order.Status)
+	s.Equal("payment1", order.PaymentId)
+	s.Equal(testSubscriptions[1].TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	sub, err := s.storage.GetUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.NoError(err)
+	s.Equal(testSubscriptions[1], *sub)
+
+	// 5 a paid order is credited once
+	order, err = s.storage.PayOrder(s.ctx, "order1", "payment2")
+	s.NoError(err)
+	s.Equal("payment1", order.PaymentId)
+
+	order, err = s.storage.FailOrder(s.ctx, "order1", "payment3")
+	s.NoError(err)
+	s.Equal(postgresDB.OrderPaid, order.Status)
+	s.Equal(testSubscriptions[1].TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	// 6 a failed order can't be paid
+	_, err = s.storage.CreateOrder(s.ctx, s.newOrder("order2", "test_name1@mail.ru", 1))
+	s.Require().NoError(err)
+
+	order, err = s.storage.FailOrder(s.ctx, "order2", "payment4")
+	s.NoError(err)
+	s.Equal(postgresDB.OrderFailed, order.Status)
+
+	order, err = s.storage.PayOrder(s.ctx, "order2", "payment5")
+	s.NoError(err)
+	s.Equal(postgresDB.OrderFailed, order.Status)
+	s.Equal("payment4", order.PaymentId)
+	s.Equal(testSubscriptions[1].TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	// 7 unknown order
+	_, err = s.storage.GetOrder(s.ctx, "order3")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	_, err = s.storage.PayOrder(s.ctx, "order3", "payment6")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	_, err = s.storage.FailOrder(s.ctx, "order3", "payment6")
+	s.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (s *storageSuite) TestPayOrderRace() {
+	s.createUser("test_name1@mail.ru")
+
+	_, err := s.storage.CreateOrder(s.ctx, s.newOrder("order1", "test_name1@mail.ru", 1))
+	s.Require().NoError(err)
+
+	// a provider retrying its notification
+	const attempts = 30
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := s.storage.PayOrder(s.ctx, "order1", fmt.Sprintf("payment%d", i))
+			s.NoError(err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	s.Equal(testSubscriptions[0].TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *storageSuite) TestUserSubscriptions() {
+	s.createUser("test_name1@mail.ru")
+	s.createUser("test_name2@mail.ru")
+
+	// 1 free tier
+	_, err := s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 2 a paid order starts a subscription
+	_, err = s.storage.CreateOrder(s.ctx, s.newOrder("order1", "test_name1@mail.ru", 1))
+	s.Require().NoError(err)
+
+	order, err := s.storage.PayOrder(s.ctx, "order1", "payment1")
+	s.Require().NoError(err)
+
+	bronze, err := s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.Require().NoError(err)
+
+	s.Equal(testSubscriptions[0].Id, bronze.SubscriptionId)
+	s.Equal(postgresDB.UserSubscriptionActive, bronze.Status)
+	s.WithinDuration(order.UpdatedAt, bronze.StartsAt, time.Second)
+	s.WithinDuration(bronze.StartsAt.AddDate(0, 0, 30), bronze.EndsAt, time.Second)
+	s.WithinDuration(bronze.StartsAt.AddDate(0, 1, 0), bronze.NextResetAt, time.Second)
+
+	// 3 changing plans replaces the subscription
+	upgrade := s.newOrder("order2", "test_name1@mail.ru", 2)
+	upgrade.ReplacesSubscriptionId = &bronze.Id
+
+	_, err = s.storage.CreateOrder(s.ctx, upgrade)
+	s.Require().NoError(err)
+
+	_, err = s.storage.PayOrder(s.ctx, "order2", "payment2")
+	s.Require().NoError(err)
+
+	gold, err := s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.Require().NoError(err)
+
+	s.NotEqual(bronze.Id, gold.Id)
+	s.Equal(testSubscriptions[1].Id, gold.SubscriptionId)
+	s.Equal(testSubscriptions[1].TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	// 4 nothing is due yet
+	reset, err := s.storage.ResetQuotas(s.ctx, time.Now())
+	s.NoError(err)
+	s.Zero(reset)
+
+	expired, err := s.storage.ExpireUserSubscriptions(s.ctx, time.Now(), testQuota)
+	s.NoError(err)
+	s.Zero(expired)
+
+	// 5 the quota is refilled every month
+	_, err = s.storage.UpdateUserLinks(s.ctx, "test_name1@mail.ru", -5)
+	s.Require().NoError(err)
+
+	reset, err = s.storage.ResetQuotas(s.ctx, gold.NextResetAt)
+	s.NoError(err)
+	s.Equal(1, reset)
+	s.Equal(testSubscriptions[1].TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	gold, err = s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.Require().NoError(err)
+	s.WithinDuration(gold.StartsAt.AddDate(0, 2, 0), gold.NextResetAt, time.Second)
+
+	reset, err = s.storage.ResetQuotas(s.ctx, gold.NextResetAt.Add(-time.Minute))
+	s.NoError(err)
+	s.Zero(reset)
+
+	// 6 months missed by the job are reset once
+	reset, err = s.storage.ResetQuotas(s.ctx, gold.StartsAt.AddDate(0, 5, 1))
+	s.NoError(err)
+	s.Equal(1, reset)
+
+	gold, err = s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.Require().NoError(err)
+	s.WithinDuration(gold.StartsAt.AddDate(0, 6, 0), gold.NextResetAt, time.Second)
+
+	// 7 a lapsed subscription moves the user to the free tier
+	expired, err = s.storage.ExpireUserSubscriptions(s.ctx, gold.EndsAt, testQuota)
+	s.NoError(err)
+	s.Equal(1, expired)
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+
+	_, err = s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	_, err = s.storage.GetUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	reset, err = s.storage.ResetQuotas(s.ctx, gold.EndsAt.AddDate(0, 1, 0))
+	s.NoError(err)
+	s.Zero(reset)
+
+	expired, err = s.storage.ExpireUserSubscriptions(s.ctx, gold.EndsAt, testQuota)
+	s.NoError(err)
+	s.Zero(expired)
+
+	// 8 the free tier keeps fewer links than its quota as they are
+	_, err = s.storage.CreateOrder(s.ctx, s.newOrder("order3", "test_name2@mail.ru", 1))
+	s.Require().NoError(err)
+
+	_, err = s.storage.PayOrder(s.ctx, "order3", "payment3")
+	s.Require().NoError(err)
+
+	_, err = s.storage.UpdateUserLinks(s.ctx, "test_name2@mail.ru", -(testSubscriptions[0].TotalUrls - 1))
+	s.Require().NoError(err)
+
+	expired, err = s.storage.ExpireUserSubscriptions(s.ctx, time.Now().AddDate(0, 0, 31), testQuota)
+	s.NoError(err)
+	s.Equal(1, expired)
+	s.Equal(1, s.urlsLeft("test_name2@mail.ru"))
+}
+
+func (s *storageSuite) TestRenewUserSubscription() {
+	s.createUser("test_name1@mail.ru")
+
+	_, err := s.storage.CreateOrder(s.ctx, s.newOrder("order1", "test_name1@mail.ru", 1))
+	s.Require().NoError(err)
+
+	_, err = s.storage.PayOrder(s.ctx, "order1", "payment1")
+	s.Require().NoError(err)
+
+	bronze, err := s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.Require().NoError(err)
+
+	_, err = s.storage.UpdateUserLinks(s.ctx, "test_name1@mail.ru", -2)
+	s.Require().NoError(err)
+
+	// 1 paying for the same plan again adds its days to the end of the subscription
+	renewal := s.newOrder("order2", "test_name1@mail.ru", 1)
+	renewal.ReplacesSubscriptionId = &bronze.Id
+
+	_, err = s.storage.CreateOrder(s.ctx, renewal)
+	s.Require().NoError(err)
+
+	order, err := s.storage.PayOrder(s.ctx, "order2", "payment2")
+	s.Require().NoError(err)
+	s.Equal(postgresDB.OrderPaid, order.Status)
+
+	renewed, err := s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.Require().NoError(err)
+
+	s.Equal(bronze.Id, renewed.Id)
+	s.WithinDuration(bronze.StartsAt, renewed.StartsAt, time.Second)
+	s.WithinDuration(bronze.EndsAt.AddDate(0, 0, renewal.Days), renewed.EndsAt, time.Second)
+	s.WithinDuration(bronze.NextResetAt, renewed.NextResetAt, time.Second)
+
+	// 2 the quota is left as it is
+	s.Equal(testSubscriptions[0].TotalUrls-2, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *storageSuite) TestClicks() {
+	s.createUser("test_name1@mail.ru")
+	s.createLink("myAlias1", "test_name1@mail.ru")
+	s.createLink("myAlias2", "test_name1@mail.ru")
+
+	// Monday
+	day := time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC)
+
+	// 1
+	err := s.storage.CreateClick(s.ctx, postgresDB.Click{
+		ShortUrl:     "myAlias1",
+		ClickedAt:    day.Add(10 * time.Hour),
+		ReferrerHost: "t.me",
+		IPHash:       "a",
+		Country:      "RU",
+		Browser:      "Chrome",
+		OS:           "Windows",
+	})
+
+	s.NoError(err)
+
+	// 2
+	written, err := s.storage.CreateClicks(s.ctx, []postgresDB.Click{
+		{ShortUrl: "myAlias1", ClickedAt: day.Add(10*time.Hour + 30*time.Minute), ReferrerHost: "t.me", IPHash: "a", Browser: "Chrome"},
+		{ShortUrl: "myAlias1", ClickedAt: day.Add(26 * time.Hour), ReferrerHost: "vk.com", IPHash: "b", Browser: "Firefox"},
+		{ShortUrl: "myAlias1", ClickedAt: day.Add(8 * 24 * time.Hour), IPHash: "c", Browser: "Chrome"},
+		{ShortUrl: "myAlias2", ClickedAt: day.Add(10 * time.Hour), IPHash: "a"},
+	})
+
+	s.NoError(err)
+	s.EqualValues(4, written)
+
+	// 3
+	total, unique, err := s.storage.GetClickTotals(s.ctx, "myAlias1", day, day.Add(7*24*time.Hour))
+
+	s.NoError(err)
+	s.Equal(3, total)
+	s.Equal(2, unique)
+
+	// 4
+	buckets, err := s.storage.GetClickSeries(s.ctx, "myAlias1", day, day.Add(14*24*time.Hour), "day")
+
+	s.NoError(err)
+	s.Len(buckets, 3)
+	s.True(day.Equal(buckets[0].Start))
+	s.Equal(2, buckets[0].Clicks)
+	s.Equal(1, buckets[0].UniqueClicks)
+	s.True(day.Add(24 * time.Hour).Equal(buckets[1].Start))
+
+	// 5
+	buckets, err = s.storage.GetClickSeries(s.ctx, "myAlias1", day, day.Add(14*24*time.Hour), "week")
+
+	s.NoError(err)
+	s.Len(buckets, 2)
+	s.True(day.Equal(buckets[0].Start))
+	s.Equal(3, buckets[0].Clicks)
+	s.True(day.Add(7 * 24 * time.Hour).Equal(buckets[1].Start))
+
+	// 6
+	buckets, err = s.storage.GetClickSeries(s.ctx, "myAlias1", day, day.Add(24*time.Hour), "hour")
+
+	s.NoError(err)
+	s.Len(buckets, 1)
+	s.True(day.Add(10 * time.Hour).Equal(buckets[0].Start))
+
+	// 7
+	counts, err := s.storage.GetTopClickValues(s.ctx, "myAlias1", postgresDB.ClickDimensionBrowser, day, day.Add(14*24*time.Hour), 10)
+
+	s.NoError(err)
+	s.Equal([]postgresDB.ClickCount{{Value: "Chrome", Clicks: 3}, {Value: "Firefox", Clicks: 1}}, counts)
+
+	// 8
+	counts, err = s.storage.GetTopClickValues(s.ctx, "myAlias1", postgresDB.ClickDimensionReferrer, day, day.Add(14*24*time.Hour), 1)
+
+	s.NoError(err)
+	s.Equal([]postgresDB.ClickCount{{Value: "t.me", Clicks: 2}}, counts)
+
+	// 9
+	_, err = s.storage.GetTopClickValues(s.ctx, "myAlias1", "password_hash", day, day.Add(14*24*time.Hour), 10)
+
+	s.Error(err)
+
+	// 10
+	err = s.storage.DeleteShortLink(s.ctx, "myAlias1", "test_name1@mail.ru", 0)
+
+	s.NoError(err)
+
+	total, _, err = s.storage.GetClickTotals(s.ctx, "myAlias1", day, day.Add(14*24*time.Hour))
+
+	s.NoError(err)
+	s.Equal(0, total)
+}
+
+func (s *storageSuite) TestRoles() {
+	s.createUser("test_name1@mail.ru")
+
+	// 1
+	roles, err := s.storage.GetRoles(s.ctx)
+
+	s.NoError(err)
+	s.Equal([]postgresDB.Role{
+		{Name: "admin", Permissions: []string{"links.moderate", "links.view", "system.metrics", "users.disable", "users.manage_quota", "users.manage_roles", "users.view"}},
+		{Name: "support", Permissions: []string{"links.view", "users.view"}},
+		{Name: "user", Permissions: []string{}},
+	}, roles)
+
+	// 2 new users have the default role
+	role, err := s.storage.GetUserRole(s.ctx, "test_name1@mail.ru")
+
+	s.NoError(err)
+	s.Equal(&postgresDB.Role{Name: "user", Permissions: []string{}}, role)
+
+	// 3
+	err = s.storage.SetUserRole(s.ctx, "test_name1@mail.ru", "support")
+
+	s.NoError(err)
+
+	role, err = s.storage.GetUserRole(s.ctx, "test_name1@mail.ru")
+
+	s.NoError(err)
+	s.Equal(&postgresDB.Role{Name: "support", Permissions: []string{"links.view", "users.view"}}, role)
+
+	// 4 unknown role
+	err = s.storage.SetUserRole(s.ctx, "test_name1@mail.ru", "root")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	role, err = s.storage.GetUserRole(s.ctx, "test_name1@mail.ru")
+
+	s.NoError(err)
+	s.Equal("support", role.Name)
+
+	// 5 unknown user
+	err = s.storage.SetUserRole(s.ctx, "test_name2@mail.ru", "admin")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	_, err = s.storage.GetUserRole(s.ctx, "test_name2@mail.ru")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (s *storageSuite) TestAdmin() {
+	s.createUser("test_name1@mail.ru")
+	s.createUser("Test_Name2@mail.ru")
+	s.createUser("other@yandex.ru")
+
+	s.createLink("myAlias1", "test_name1@mail.ru")
+	s.createLink("myAlias2", "test_name1@mail.ru")
+	s.createLink("100%_off", "other@yandex.ru")
+
+	emails := func(users []postgresDB.UserSummary) []string {
+		var res []string
+
+		for _, user := range users {
+			res = append(res, user.Email)
+		}
+
+		return res
+	}
+
+	shortLinks := func(links []postgresDB.Link) []string {
+		var res []string
+
+		for _, link := range links {
+			res = append(res, link.ShortUrl)
+		}
+
+		return res
+	}
+
+	// 1 case-insensitive
+	users, err := s.storage.SearchUsers(s.ctx, "TEST_name", 0, 10)
+
+	s.NoError(err)
+	s.ElementsMatch([]string{"test_name1@mail.ru", "Test_Name2@mail.ru"}, emails(users))
+
+	// 2
+	users, err = s.storage.SearchUsers(s.ctx, "", 0, 10)
+
+	s.NoError(err)
+	s.Len(users, 3)
+
+	for _, user := range users {
+		s.Equal("user", user.Role)
+		s.False(user.Disabled)
+		s.WithinDuration(time.Now(), user.CreatedAt, time.Minute)
+
+		if user.Email == "test_name1@mail.ru" {
+			s.Equal(2, user.Links)
+			s.Equal(testQuota, user.UrlsLeft)
+		}
+	}
+
+	// 3 pages don't overlap
+	first, err := s.storage.SearchUsers(s.ctx, "", 0, 2)
+
+	s.NoError(err)
+
+	second, err := s.storage.SearchUsers(s.ctx, "", 2, 2)
+
+	s.NoError(err)
+	s.ElementsMatch(emails(users), append(emails(first), emails(second)...))
+
+	// 4 wildcards are taken literally
+	users, err = s.storage.SearchUsers(s.ctx, "test%name", 0, 10)
+
+	s.NoError(err)
+	s.Empty(users)
+
+	links, err := s.storage.SearchLinks(s.ctx, "0%_", 0, 10)
+
+	s.NoError(err)
+	s.Equal([]string{"100%_off"}, shortLinks(links))
+
+	// 5 by alias, destination and owner
+	links, err = s.storage.SearchLinks(s.ctx, "myalias", 0, 10)
+
+	s.NoError(err)
+	s.ElementsMatch([]string{"myAlias1", "myAlias2"}, shortLinks(links))
+
+	links, err = s.storage.SearchLinks(s.ctx, "gismeteo", 0, 10)
+
+	s.NoError(err)
+	s.Len(links, 3)
+
+	links, err = s.storage.SearchLinks(s.ctx, "yandex", 0, 10)
+
+	s.NoError(err)
+	s.Equal([]string{"100%_off"}, shortLinks(links))
+
+	// 6
+	err = s.storage.SetUserDisabled(s.ctx, "other@yandex.ru", true)
+
+	s.NoError(err)
+
+	user, err := s.storage.GetUser(s.ctx, "other@yandex.ru")
+
+	s.NoError(err)
+	s.True(user.Disabled)
+
+	err = s.storage.SetUserDisabled(s.ctx, "nobody@mail.ru", true)
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 7
+	link, err := s.storage.SetLinkDisabled(s.ctx, "myAlias1", true)
+
+	s.NoError(err)
+	s.True(link.Disabled)
+
+	link, err = s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.True(link.Disabled)
+
+	_, err = s.storage.SetLinkDisabled(s.ctx, "myAlias42", true)
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 8
+	stats, err := s.storage.GetSystemStats(s.ctx, time.Now())
+
+	s.NoError(err)
+	s.Equal(postgresDB.SystemStats{Users: 3, DisabledUsers: 1, Links: 3, ActiveLinks: 2, DisabledLinks: 1}, *stats)
+
+	// 9 all of them expired by then
+	stats, err = s.storage.GetSystemStats(s.ctx, time.Now().Add(2*testLinkTTL))
+
+	s.NoError(err)
+	s.Equal(0, stats.ActiveLinks)
+	s.Equal(3, stats.ExpiredLinks)
+
+	// 10
+	link, err = s.storage.SetLinkDisabled(s.ctx, "myAlias1", false)
+
+	s.NoError(err)
+	s.False(link.Disabled)
+}
+
+func (s *storageSuite) TestApiTokens() {
+	s.createUser("test_name1@mail.ru")
+	s.createUser("test_name2@mail.ru")
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	expiresAt := createdAt.Add(testLinkTTL)
+
+	// 1
+	token1, err := s.storage.CreateApiToken(s.ctx, postgresDB.ApiToken{
+		UserEmail: "test_name1@mail.ru",
+		Name:      "CI",
+		Prefix:    "ue_abcdef",
+		Scopes:    []string{"links.create", "links.read"},
+		CreatedAt: createdAt,
+		ExpiresAt: &expiresAt,
+	}, "hash1")
+
+	s.NoError(err)
+	s.NotZero(token1.Id)
+	s.Equal("CI", token1.Name)
+	s.Equal("ue_abcdef", token1.Prefix)
+	s.Equal([]string{"links.create", "links.read"}, token1.Scopes)
+	s.True(createdAt.Equal(token1.CreatedAt))
+	s.True(expiresAt.Equal(*token1.ExpiresAt))
+	s.Nil(token1.LastUsedAt)
+
+	// 2 no expiry
+	token2, err := s.storage.CreateApiToken(s.ctx, postgresDB.ApiToken{
+		UserEmail: "test_name1@mail.ru",
+		Name:      "cron",
+		Prefix:    "ue_ghijkl",
+		Scopes:    []string{"stats.read"},
+		CreatedAt: createdAt,
+	}, "hash2")
+
+	s.NoError(err)
+	s.Nil(token2.ExpiresAt)
+
+	// 3 the hash is unique
+	_, err = s.storage.CreateApiToken(s.ctx, postgresDB.ApiToken{
+		UserEmail: "test_name2@mail.ru",
+		Name:      "CI",
+		Prefix:    "ue_abcdef",
+		Scopes:    []string{"links.read"},
+		CreatedAt: createdAt,
+	}, "hash1")
+
+	s.ErrorIs(err, postgresDB.ErrAlreadyExists)
+
+	// 4 the newest first
+	tokens, err := s.storage.GetApiTokens(s.ctx, "test_name1@mail.ru")
+
+	s.NoError(err)
+	s.Equal([]postgresDB.ApiToken{*token2, *token1}, tokens)
+
+	tokens, err = s.storage.GetApiTokens(s.ctx, "test_name2@mail.ru")
+
+	s.NoError(err)
+	s.Empty(tokens)
+
+	// 5
+	token, err := s.storage.GetApiTokenByHash(s.ctx, "hash1")
+
+	s.NoError(err)
+	s.Equal(token1, token)
+
+	_, err = s.storage.GetApiTokenByHash(s.ctx, "hash3")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 6
+	usedAt := createdAt.Add(time.Hour)
+
+	err = s.storage.TouchApiToken(s.ctx, token1.Id, usedAt)
+
+	s.NoError(err)
+
+	token, err = s.storage.GetApiTokenByHash(s.ctx, "hash1")
+
+	s.NoError(err)
+	s.True(usedAt.Equal(*token.LastUsedAt))
+
+	// 7 only the owner can delete a token
+	err = s.storage.DeleteApiToken(s.ctx, token1.Id, "test_name2@mail.ru")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	err = s.storage.DeleteApiToken(s.ctx, token1.Id, "test_name1@mail.ru")
+
+	s.NoError(err)
+
+	_, err = s.storage.GetApiTokenByHash(s.ctx, "hash1")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	err = s.storage.DeleteApiToken(s.ctx, token1.Id, "test_name1@mail.ru")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+}
+
+func (s *storageSuite) TestLinkNumbers() {
+	const workers = 10
+
+	// 1 numbers start with 1 and go up
+	n, err := s.storage.NextLinkNumber(s.ctx)
+
+	s.NoError(err)
+	s.Equal(int64(1), n)
+
+	n, err = s.storage.NextLinkNumber(s.ctx)
+
+	s.NoError(err)
+	s.Equal(int64(2), n)
+
+	// 2 concurrent callers never get the same number
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		numbers = make(map[int64]bool)
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			n, err := s.storage.NextLinkNumber(s.ctx)
+
+			if err != nil {
+				s.T().Errorf("unexpected error: %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			numbers[n] = true
+		}()
+	}
+
+	wg.Wait()
+
+	s.Len(numbers, workers)
+}
+
+func (s *storageSuite) TestCodePool() {
+	const codes = 20
+
+	s.createUser("test_name1@mail.ru")
+	s.createLink("myAlias1", "test_name1@mail.ru")
+
+	// 1 an empty pool has nothing to claim
+	_, err := s.storage.ClaimPoolCode(s.ctx)
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 2 codes of links and codes pooled already are skipped
+	added, err := s.storage.AddPoolCodes(s.ctx, []string{"code1", "myAlias1", "code2"}, time.Now())
+
+	s.NoError(err)
+	s.Equal(2, added)
+
+	added, err = s.storage.AddPoolCodes(s.ctx, []string{"code2", "myAlias1"}, time.Now())
+
+	s.NoError(err)
+	s.Equal(0, added)
+
+	count, err := s.storage.CountPoolCodes(s.ctx)
+
+	s.NoError(err)
+	s.Equal(2, count)
+
+	// 3 every code is claimed once
+	var pooled []string
+
+	for i := 3; i < codes+3; i++ {
+		pooled = append(pooled, fmt.Sprintf("code%d", i))
+	}
+
+	added, err = s.storage.AddPoolCodes(s.ctx, pooled, time.Now())
+
+	s.NoError(err)
+	s.Equal(codes, added)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claimed = make(map[string]bool)
+	)
+
+	for i := 0; i < codes+2; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			code, err := s.storage.ClaimPoolCode(s.ctx)
+
+			if err != nil {
+				s.T().Errorf("unexpected error: %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			claimed[code] = true
+		}()
+	}
+
+	wg.Wait()
+
+	s.Len(claimed, codes+2)
+
+	_, err = s.storage.ClaimPoolCode(s.ctx)
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	count, err = s.storage.CountPoolCodes(s.ctx)
+
+	s.NoError(err)
+	s.Zero(count)
+}
+
+func (s *storageSuite) TestLinkPasswords() {
+	s.createUser("test_name1@mail.ru")
+	s.createLink("myAlias1", "test_name1@mail.ru")
+
+	// 1 links aren't protected by default, any password goes
+	link, err := s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.False(link.Protected)
+	s.NoError(s.verifyLinkPassword("myAlias1", "anything"))
+
+	// 2
+	link, err = s.storage.SetLinkPassword(s.ctx, "myAlias1", "secret1")
+
+	s.NoError(err)
+	s.True(link.Protected)
+	s.Equal("myAlias1", link.ShortUrl)
+
+	link, err = s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.True(link.Protected)
+
+	s.NoError(s.verifyLinkPassword("myAlias1", "secret1"))
+	s.Error(s.verifyLinkPassword("myAlias1", "secret2"))
+
+	// the hash checked is the one stored, setting the same password again makes a new one
+	passwordHash, err := s.storage.VerifyLinkPassword(s.ctx, "myAlias1", "secret1")
+
+	s.NoError(err)
+	s.NotEmpty(passwordHash)
+
+	storedHash, err := s.storage.GetLinkPasswordHash(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Equal(passwordHash, storedHash)
+
+	_, err = s.storage.SetLinkPassword(s.ctx, "myAlias1", "secret1")
+	s.Require().NoError(err)
+
+	storedHash, err = s.storage.GetLinkPasswordHash(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.NotEqual(passwordHash, storedHash)
+
+	// 3 an empty password removes the protection
+	link, err = s.storage.SetLinkPassword(s.ctx, "myAlias1", "")
+
+	s.NoError(err)
+	s.False(link.Protected)
+	s.NoError(s.verifyLinkPassword("myAlias1", "secret2"))
+
+	storedHash, err = s.storage.GetLinkPasswordHash(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Empty(storedHash)
+
+	// 4
+	_, err = s.storage.SetLinkPassword(s.ctx, "unknownAlias", "secret1")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+	s.ErrorIs(s.verifyLinkPassword("unknownAlias", "secret1"), pgx.ErrNoRows)
+
+	_, err = s.storage.GetLinkPasswordHash(s.ctx, "unknownAlias")
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 5 a link created with a password is protected from the start
+	link, err = s.storage.CreateShortLinkWithQuota(s.ctx, "myAlias2", "https://ya.ru", "test_name1@mail.ru", time.Now().Add(testLinkTTL), postgresDB.LinkOptions{Password: "secret3"})
+
+	s.NoError(err)
+	s.True(link.Protected)
+	s.NoError(s.verifyLinkPassword("myAlias2", "secret3"))
+	s.Error(s.verifyLinkPassword("myAlias2", "secret1"))
+}
+
+func (s *storageSuite) TestClickLimits() {
+	const (
+		clicks  = 3
+		workers = 10
+	)
+
+	s.createUser("test_name1@mail.ru")
+	s.createLink("myAlias1", "test_name1@mail.ru")
+
+	// 1 links have no limit by default, there is nothing to consume
+	link, err := s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Nil(link.ClicksLeft)
+
+	_, err = s.storage.ConsumeLinkClick(s.ctx, "myAlias1")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 2
+	limit := clicks
+
+	link, err = s.storage.SetLinkClicks(s.ctx, "myAlias1", &limit)
+
+	s.NoError(err)
+	s.Require().NotNil(link.ClicksLeft)
+	s.Equal(clicks, *link.ClicksLeft)
+
+	// 3 concurrent redirects spend every click once
+	var consumed atomic.Int32
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := s.storage.ConsumeLinkClick(s.ctx, "myAlias1")
+
+			switch {
+			case err == nil:
+				consumed.Add(1)
+
+			case !errors.Is(err, pgx.ErrNoRows):
+				s.T().Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	s.Equal(int32(clicks), consumed.Load())
+
+	link, err = s.storage.GetShortLink(s.ctx, "myAlias1")
+
+	s.NoError(err)
+	s.Require().NotNil(link.ClicksLeft)
+	s.Zero(*link.ClicksLeft)
+
+	// 4 nil removes the limit
+	link, err = s.storage.SetLinkClicks(s.ctx, "myAlias1", nil)
+
+	s.NoError(err)
+	s.Nil(link.ClicksLeft)
+
+	// 5
+	_, err = s.storage.SetLinkClicks(s.ctx, "unknownAlias", &limit)
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	_, err = s.storage.ConsumeLinkClick(s.ctx, "unknownAlias")
+
+	s.ErrorIs(err, pgx.ErrNoRows)
+
+	// 6 a link created with a limit counts its first click
+	once := 1
+
+	link, err = s.storage.CreateShortLinkWithQuota(s.ctx, "myAlias2", "https://ya.ru", "test_name1@mail.ru", time.Now().Add(testLinkTTL), postgresDB.LinkOptions{ClicksLeft: &once})
+
+	s.NoError(err)
+	s.Require().NotNil(link.ClicksLeft)
+	s.Equal(1, *link.ClicksLeft)
+
+	link, err = s.storage.ConsumeLinkClick(s.ctx, "myAlias2")
+
+	s.NoError(err)
+	s.Zero(*link.ClicksLeft)
+	s.Equal(1, once)
+}
+
+func (s *storageSuite) TestOrderGuards() {
+	s.createUser("test_name1@mail.ru")
+
+	// 1 a new order fails the pending one of the user
+	_, err := s.storage.CreateOrder(s.ctx, s.newOrder("order1", "test_name1@mail.ru", 1))
+	s.Require().NoError(err)
+
+	_, err = s.storage.CreateOrder(s.ctx, s.newOrder("order2", "test_name1@mail.ru", 1))
+	s.Require().NoError(err)
+
+	order, err := s.storage.GetOrder(s.ctx, "order1")
+	s.NoError(err)
+	s.Equal(postgresDB.OrderFailed, order.Status)
+
+	order, err = s.storage.PayOrder(s.ctx, "order1", "payment1")
+	s.NoError(err)
+	s.Equal(postgresDB.OrderFailed, order.Status)
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+
+	// 2
+	order, err = s.storage.PayOrder(s.ctx, "order2", "payment2")
+	s.NoError(err)
+	s.Equal(postgresDB.OrderPaid, order.Status)
+	s.Equal(testSubscriptions[0].TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	// 3 an order priced on the free tier is failed once the user is on a plan
+	_, err = s.storage.UpdateUserLinks(s.ctx, "test_name1@mail.ru", -testSubscriptions[0].TotalUrls)
+	s.Require().NoError(err)
+
+	_, err = s.storage.CreateOrder(s.ctx, s.newOrder("order3", "test_name1@mail.ru", 2))
+	s.Require().NoError(err)
+
+	order, err = s.storage.PayOrder(s.ctx, "order3", "payment3")
+	s.NoError(err)
+	s.Equal(postgresDB.OrderFailed, order.Status)
+	s.Equal("payment3", order.PaymentId)
+	s.Zero(s.urlsLeft("test_name1@mail.ru"))
+
+	sub, err := s.storage.GetActiveUserSubscription(s.ctx, "test_name1@mail.ru")
+	s.NoError(err)
+	s.Equal(testSubscriptions[0].Id, sub.SubscriptionId)
+}
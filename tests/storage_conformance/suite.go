@@ -0,0 +1,40 @@
+package storage_conformance
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"urleater/internal/clicks"
+	"urleater/internal/codegen"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+)
+
+// Storage is what every backend has to implement to be usable by cmd/main.go
+type Storage interface {
+	service.Storage
+	clicks.Sink
+	codegen.Counter
+	codegen.PoolStore
+}
+
+// testSubscriptions are the only rows a new storage starts with.
+var testSubscriptions = []postgresDB.Subscription{
+	{Id: 1, Name: "Bronze", TotalUrls: 10, MaxLinkTTLDays: 90, Price: 9900, PeriodDays: 30},
+	{Id: 2, Name: "Gold", TotalUrls: 200, MaxLinkTTLDays: 365, RefundDeletedLinks: true, Price: 129900, PeriodDays: 365, LinkTTLDays: 180, MinAliasLength: 4},
+}
+
+// storageSuite runs the same scenarios against every Storage implementation,
+// newStorage must return a storage holding nothing but the given subscriptions on every call.
+type storageSuite struct {
+	suite.Suite
+
+	newStorage func(subscriptions []postgresDB.Subscription) Storage
+
+	ctx     context.Context
+	storage Storage
+}
+
+func (s *storageSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.storage = s.newStorage(testSubscriptions)
+}
@@ -0,0 +1,10 @@
+package subscriptions
+
+import (
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(subscriptionsSuite))
+}
@@ -0,0 +1,262 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+	"urleater/internal/handlers"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+)
+
+const longLink = "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
+
+// subscribe buys the plan on behalf of the logged in user and pays for it if there is anything to pay.
+func (s *subscriptionsSuite) subscribe(plan postgresDB.Subscription) handlers.BuySubscriptionResponse {
+	body, status := s.BuySubscription(&handlers.BuySubscriptionRequest{SubscriptionId: plan.Id})
+	s.Require().Equal(http.StatusOK, status)
+
+	var resp handlers.BuySubscriptionResponse
+
+	s.Require().NoError(json.Unmarshal(body, &resp))
+
+	if resp.PaymentURL != "" {
+		_, status = s.PaymentCallback(s.provider.Callback(resp.Order.Id, resp.Order.Amount, service.PaymentSucceeded))
+		s.Require().Equal(http.StatusNoContent, status)
+	}
+
+	return resp
+}
+
+func (s *subscriptionsSuite) TestSubscribe() {
+	s.loggedInAs("test_name1@mail.ru", 1)
+
+	resp := s.subscribe(bronze)
+
+	s.Equal(bronze.Price, resp.Order.Amount)
+	s.Equal(bronze.PeriodDays, resp.Order.Days)
+
+	current := s.current("test_name1@mail.ru")
+
+	s.Equal(bronze.Id, current.SubscriptionId)
+	s.WithinDuration(time.Now().AddDate(0, 0, bronze.PeriodDays), current.EndsAt, time.Minute)
+	s.WithinDuration(time.Now().AddDate(0, 1, 0), current.NextResetAt, time.Minute)
+	s.Equal(bronze.TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *subscriptionsSuite) TestUpgrade() {
+	s.loggedInAs("test_name1@mail.ru", 2)
+
+	s.subscribe(bronze)
+
+	// the unused Bronze subscription is credited
+	resp := s.subscribe(gold)
+
+	s.InDelta(gold.Price-bronze.Price, resp.Order.Amount, 1)
+	s.Equal(gold.PeriodDays, resp.Order.Days)
+	s.Equal(gold.Id, s.current("test_name1@mail.ru").SubscriptionId)
+	s.Equal(gold.TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *subscriptionsSuite) TestDowngrade() {
+	s.loggedInAs("test_name1@mail.ru", 2)
+
+	s.subscribe(gold)
+
+	// the unused Gold subscription pays for Bronze and the rest buys more days of it
+	resp := s.subscribe(bronze)
+
+	extraDays := (gold.Price - bronze.Price) * bronze.PeriodDays / bronze.Price
+
+	s.Empty(resp.PaymentURL)
+	s.Zero(resp.Order.Amount)
+	s.Equal(postgresDB.OrderPaid, resp.Order.Status)
+	s.InDelta(bronze.PeriodDays+extraDays, resp.Order.Days, 1)
+
+	current := s.current("test_name1@mail.ru")
+
+	s.Equal(bronze.Id, current.SubscriptionId)
+	s.WithinDuration(time.Now().AddDate(0, 0, resp.Order.Days), current.EndsAt, time.Minute)
+	s.Equal(bronze.TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *subscriptionsSuite) TestPlanLinkRules() {
+	s.loggedInAs("test_name1@mail.ru", 1)
+	s.subscribe(gold)
+
+	// 1 Gold allows short aliases and keeps links longer
+	s.loggedInAs("test_name1@mail.ru", 1)
+
+	body, status := s.CreateShortLink(&handlers.CreateShortLinkRequest{ShortURL: "gold", LongURL: longLink})
+
+	var resp1 handlers.CreateShortLinkResponse
+
+	s.NoError(json.Unmarshal(body, &resp1))
+	s.Equal(http.StatusOK, status)
+	s.WithinDuration(time.Now().AddDate(0, 0, gold.LinkTTLDays), resp1.Link.ExpiresAt, time.Minute)
+
+	// 2 the free tier doesn't
+	s.loggedInAs("test_name2@mail.ru", 2)
+
+	_, status = s.CreateShortLink(&handlers.CreateShortLinkRequest{ShortURL: "free", LongURL: longLink})
+	s.Equal(http.StatusBadRequest, status)
+
+	body, status = s.CreateShortLink(&handlers.CreateShortLinkRequest{ShortURL: "freeTier", LongURL: longLink})
+
+	var resp2 handlers.CreateShortLinkResponse
+
+	s.NoError(json.Unmarshal(body, &resp2))
+	s.Equal(http.StatusOK, status)
+	s.WithinDuration(time.Now().Add(service.DefaultLinkTTL), resp2.Link.ExpiresAt, time.Minute)
+}
+
+func (s *subscriptionsSuite) TestMaintainSubscriptions() {
+	ctx := context.Background()
+
+	s.loggedInAs("test_name1@mail.ru", 1)
+	s.subscribe(bronze)
+
+	_, err := s.storage.UpdateUserLinks(ctx, "test_name1@mail.ru", -bronze.TotalUrls)
+	s.Require().NoError(err)
+
+	current := s.current("test_name1@mail.ru")
+
+	// 1 nothing to do yet
+	s.NoError(s.service.MaintainSubscriptions(ctx, time.Now()))
+	s.Zero(s.urlsLeft("test_name1@mail.ru"))
+
+	// 2 a month later the quota is refilled
+	s.NoError(s.service.MaintainSubscriptions(ctx, current.NextResetAt))
+	s.Equal(bronze.TotalUrls, s.urlsLeft("test_name1@mail.ru"))
+
+	// 3 once the subscription lapses the user is back on the free tier, a reset due at the same time is skipped
+	s.NoError(s.service.MaintainSubscriptions(ctx, current.EndsAt.AddDate(0, 1, 0)))
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+
+	_, err = s.storage.GetActiveUserSubscription(ctx, "test_name1@mail.ru")
+	s.Error(err)
+
+	_, err = s.storage.GetUserSubscription(ctx, "test_name1@mail.ru")
+	s.Error(err)
+
+	// 4 the user who never subscribed is left alone
+	s.Equal(testQuota, s.urlsLeft("test_name2@mail.ru"))
+}
+
+func (s *subscriptionsSuite) TestGetCurrentSubscription() {
+	s.loggedInAs("test_name1@mail.ru", 3)
+
+	// 1 free tier
+	body, status := s.MakeRequestWithBody(http.MethodGet, s.Handlers.RequireAuth(s.Handlers.GetCurrentSubscription), "")
+
+	var resp1 handlers.GetCurrentSubscriptionResponse
+
+	s.NoError(json.Unmarshal(body, &resp1))
+	s.Equal(http.StatusOK, status)
+	s.Nil(resp1.Subscription)
+	s.Nil(resp1.Plan)
+
+	// 2
+	s.subscribe(gold)
+
+	body, status = s.MakeRequestWithBody(http.MethodGet, s.Handlers.RequireAuth(s.Handlers.GetCurrentSubscription), "")
+
+	var resp2 handlers.GetCurrentSubscriptionResponse
+
+	s.NoError(json.Unmarshal(body, &resp2))
+	s.Equal(http.StatusOK, status)
+	s.Equal(gold, *resp2.Plan)
+	s.Equal(postgresDB.UserSubscriptionActive, resp2.Subscription.Status)
+}
+
+// order starts buying the plan on behalf of the logged in user without paying for it.
+func (s *subscriptionsSuite) order(plan postgresDB.Subscription) handlers.BuySubscriptionResponse {
+	body, status := s.BuySubscription(&handlers.BuySubscriptionRequest{SubscriptionId: plan.Id})
+	s.Require().Equal(http.StatusOK, status)
+
+	var resp handlers.BuySubscriptionResponse
+
+	s.Require().NoError(json.Unmarshal(body, &resp))
+
+	return resp
+}
+
+func (s *subscriptionsSuite) orderStatus(orderId string) string {
+	order, err := s.storage.GetOrder(context.Background(), orderId)
+	s.Require().NoError(err)
+
+	return order.Status
+}
+
+func (s *subscriptionsSuite) TestBuyCurrentPlan() {
+	s.loggedInAs("test_name1@mail.ru", 3)
+
+	s.subscribe(gold)
+
+	_, err := s.storage.UpdateUserLinks(context.Background(), "test_name1@mail.ru", -gold.TotalUrls)
+	s.Require().NoError(err)
+
+	before := s.current("test_name1@mail.ru")
+
+	// 1 the plan the user is on is renewed at the full price, the unused credit is not spent on it
+	resp := s.subscribe(gold)
+
+	s.Equal(gold.Price, resp.Order.Amount)
+	s.Equal(gold.PeriodDays, resp.Order.Days)
+
+	after := s.current("test_name1@mail.ru")
+
+	s.Equal(before.Id, after.Id)
+	s.WithinDuration(before.EndsAt.AddDate(0, 0, gold.PeriodDays), after.EndsAt, time.Second)
+	s.Zero(s.urlsLeft("test_name1@mail.ru"))
+
+	// 2 the renewed days are credited in full when switching plans
+	resp = s.order(bronze)
+
+	s.Zero(resp.Order.Amount)
+	s.InDelta(bronze.PeriodDays+(2*gold.Price-bronze.Price)*bronze.PeriodDays/bronze.Price, resp.Order.Days, 1)
+}
+
+func (s *subscriptionsSuite) TestOnePendingOrder() {
+	s.loggedInAs("test_name1@mail.ru", 2)
+
+	// 1 the newer order replaces the one still waiting for payment
+	first := s.order(bronze)
+	second := s.order(gold)
+
+	s.Equal(postgresDB.OrderFailed, s.orderStatus(first.Order.Id))
+	s.Equal(postgresDB.OrderPending, s.orderStatus(second.Order.Id))
+
+	// 2 paying the replaced order later credits nothing
+	_, status := s.PaymentCallback(s.provider.Callback(first.Order.Id, first.Order.Amount, service.PaymentSucceeded))
+
+	s.Equal(http.StatusNoContent, status)
+	s.Equal(postgresDB.OrderFailed, s.orderStatus(first.Order.Id))
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+}
+
+func (s *subscriptionsSuite) TestOutdatedOrder() {
+	ctx := context.Background()
+
+	s.loggedInAs("test_name1@mail.ru", 2)
+
+	s.subscribe(bronze)
+
+	// 1 an upgrade is priced with the unused Bronze days credited
+	resp := s.order(gold)
+
+	s.Less(resp.Order.Amount, gold.Price)
+
+	// 2 Bronze lapses before the payment comes, so the credit is gone and the order is failed instead
+	s.NoError(s.service.MaintainSubscriptions(ctx, s.current("test_name1@mail.ru").EndsAt))
+
+	_, status := s.PaymentCallback(s.provider.Callback(resp.Order.Id, resp.Order.Amount, service.PaymentSucceeded))
+
+	s.Equal(http.StatusNoContent, status)
+	s.Equal(postgresDB.OrderFailed, s.orderStatus(resp.Order.Id))
+	s.Equal(testQuota, s.urlsLeft("test_name1@mail.ru"))
+
+	_, err := s.storage.GetActiveUserSubscription(ctx, "test_name1@mail.ru")
+	s.Error(err)
+}
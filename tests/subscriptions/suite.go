@@ -0,0 +1,64 @@
+package subscriptions
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"urleater/internal/payments"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/repository/postgresDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const testQuota = 3
+
+var (
+	bronze = postgresDB.Subscription{Id: 1, Name: "Bronze", TotalUrls: 10, MaxLinkTTLDays: 90, Price: 9900, PeriodDays: 90}
+	gold   = postgresDB.Subscription{Id: 2, Name: "Gold", TotalUrls: 200, MaxLinkTTLDays: 365, Price: 129900, PeriodDays: 30, LinkTTLDays: 180, MinAliasLength: 4}
+)
+
+type subscriptionsSuite struct {
+	base.BaseSuite
+
+	storage      *memoryDB.Storage
+	sessionStore *mocks.SessionStore
+	provider     *payments.Fake
+	// service runs the subscription job on the same storage as the handlers
+	service *service.Service
+}
+
+func (s *subscriptionsSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	s.storage = memoryDB.NewStorage(bronze, gold)
+	s.sessionStore = mocks.NewSessionStore(s.T())
+	s.provider = payments.NewFake([]byte("test-secret"), "http://localhost:8080")
+
+	for _, email := range []string{"test_name1@mail.ru", "test_name2@mail.ru"} {
+		s.Require().NoError(s.storage.CreateUser(context.Background(), email, "qwertyui", testQuota))
+	}
+
+	opts := []service.Option{service.WithPaymentProvider(s.provider), service.WithDefaultQuota(testQuota)}
+
+	s.service = service.New(s.storage, opts...)
+	s.FinishSetupTest(s.storage, s.sessionStore, opts...)
+}
+
+func (s *subscriptionsSuite) loggedInAs(email string, requests int) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Times(requests)
+}
+
+func (s *subscriptionsSuite) urlsLeft(email string) int {
+	user, err := s.storage.GetUser(context.Background(), email)
+	s.Require().NoError(err)
+
+	return user.UrlsLeft
+}
+
+func (s *subscriptionsSuite) current(email string) *postgresDB.UserSubscription {
+	sub, err := s.storage.GetActiveUserSubscription(context.Background(), email)
+	s.Require().NoError(err)
+
+	return sub
+}
@@ -0,0 +1,43 @@
+package update_short_link
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"time"
+	"urleater/internal/repository/memoryDB"
+	"urleater/internal/service"
+	base "urleater/tests"
+	"urleater/tests/mocks"
+)
+
+const originalLongLink = "https://www.gismeteo.ru/weather-moscow-4368/weekend/#dataset"
+
+type updateShortLinkSuite struct {
+	base.BaseSuite
+
+	sessionStore *mocks.SessionStore
+}
+
+func (s *updateShortLinkSuite) SetupTest() {
+	s.BaseSetupTest()
+
+	ctx := context.Background()
+
+	storage := memoryDB.NewStorage()
+	s.sessionStore = mocks.NewSessionStore(s.T())
+
+	s.Require().NoError(storage.CreateUser(ctx, "test_name1@mail.ru", "qwertyui", service.DefaultQuota))
+	s.Require().NoError(storage.CreateUser(ctx, "test_name2@mail.ru", "qwertyui", service.DefaultQuota))
+
+	_, err := storage.CreateShortLink(ctx, "myAlias1", originalLongLink, "test_name1@mail.ru", time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+
+	_, err = storage.CreateShortLink(ctx, "myAlias2", originalLongLink, "test_name2@mail.ru", time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+
+	s.FinishSetupTest(storage, s.sessionStore)
+}
+
+func (s *updateShortLinkSuite) loggedInAs(email string) {
+	s.sessionStore.On("RetrieveEmailFromSession", mock.Anything).Return(email, nil).Once()
+}
@@ -0,0 +1,173 @@
+package update_short_link
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"urleater/internal/handlers"
+)
+
+func (s *updateShortLinkSuite) update(shortLink string, longLink string) (handlers.UpdateShortLinkResponse, int) {
+	body, status := s.UpdateShortLink(&handlers.UpdateShortLinkRequest{ShortLink: shortLink, LongLink: longLink})
+
+	var resp handlers.UpdateShortLinkResponse
+
+	if status == http.StatusOK {
+		s.NoError(json.Unmarshal(body, &resp))
+	}
+
+	return resp, status
+}
+
+func (s *updateShortLinkSuite) versions(shortLink string) (handlers.GetLinkVersionsResponse, int) {
+	body, status := s.GetLinkVersions(url.Values{"short_link": {shortLink}})
+
+	var resp handlers.GetLinkVersionsResponse
+
+	if status == http.StatusOK {
+		s.NoError(json.Unmarshal(body, &resp))
+	}
+
+	return resp, status
+}
+
+func (s *updateShortLinkSuite) redirectsTo(shortLink string, longLink string) {
+	rec := s.GetShortLink(shortLink, nil)
+
+	s.Equal(http.StatusFound, rec.Code)
+	s.Equal(longLink, rec.Header().Get("Location"))
+}
+
+func (s *updateShortLinkSuite) TestUpdate() {
+	// 1
+	s.loggedInAs("test_name1@mail.ru")
+
+	resp, status := s.update("myAlias1", "https://example.com/new")
+
+	s.Equal(http.StatusOK, status)
+	s.Equal("https://example.com/new", resp.Link.LongUrl)
+	s.redirectsTo("myAlias1", "https://example.com/new")
+
+	// 2 the same destination is not a change
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.update("myAlias1", "https://example.com/new")
+	s.Equal(http.StatusOK, status)
+
+	s.loggedInAs("test_name1@mail.ru")
+
+	history, status := s.versions("myAlias1")
+
+	s.Equal(http.StatusOK, status)
+	s.Require().Len(history.Versions, 1)
+	s.Equal(originalLongLink, history.Versions[0].OldLongUrl)
+	s.Equal("https://example.com/new", history.Versions[0].NewLongUrl)
+	s.Equal("test_name1@mail.ru", history.Versions[0].ChangedBy)
+
+	// 3
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.update("myAlias1", "not a url")
+	s.Equal(http.StatusBadRequest, status)
+}
+
+func (s *updateShortLinkSuite) TestAccess() {
+	// 1 anonymous
+	s.loggedInAs("")
+
+	_, status := s.update("myAlias1", "https://example.com/new")
+	s.Equal(http.StatusUnauthorized, status)
+
+	// 2 somebody else's link
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.update("myAlias2", "https://example.com/new")
+
+	s.Equal(http.StatusForbidden, status)
+	s.redirectsTo("myAlias2", originalLongLink)
+
+	// 3
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.versions("myAlias2")
+	s.Equal(http.StatusForbidden, status)
+
+	// 4
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.update("unknownAlias", "https://example.com/new")
+	s.Equal(http.StatusNotFound, status)
+
+	// 5
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.versions("unknownAlias")
+	s.Equal(http.StatusNotFound, status)
+
+	// 6
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.versions("")
+	s.Equal(http.StatusBadRequest, status)
+}
+
+func (s *updateShortLinkSuite) TestRollback() {
+	for _, longLink := range []string{"https://example.com/first", "https://example.com/second", "https://example.com/third"} {
+		s.loggedInAs("test_name1@mail.ru")
+
+		_, status := s.update("myAlias1", longLink)
+		s.Require().Equal(http.StatusOK, status)
+	}
+
+	s.loggedInAs("test_name1@mail.ru")
+
+	history, _ := s.versions("myAlias1")
+	s.Require().Len(history.Versions, 3)
+
+	// 1 undoing the second change brings back the first destination
+	s.loggedInAs("test_name1@mail.ru")
+
+	body, status := s.RollbackShortLink(&handlers.RollbackShortLinkRequest{ShortLink: "myAlias1", VersionId: history.Versions[1].Id})
+
+	var resp1 handlers.RollbackShortLinkResponse
+
+	s.NoError(json.Unmarshal(body, &resp1))
+	s.Equal(http.StatusOK, status)
+	s.Equal("https://example.com/first", resp1.Link.LongUrl)
+	s.redirectsTo("myAlias1", "https://example.com/first")
+
+	// 2 the rollback is recorded
+	s.loggedInAs("test_name1@mail.ru")
+
+	history, _ = s.versions("myAlias1")
+
+	s.Require().Len(history.Versions, 4)
+	s.Equal("https://example.com/third", history.Versions[0].OldLongUrl)
+	s.Equal("https://example.com/first", history.Versions[0].NewLongUrl)
+
+	// 3 undoing the very first change restores the original destination
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.RollbackShortLink(&handlers.RollbackShortLinkRequest{ShortLink: "myAlias1", VersionId: history.Versions[3].Id})
+
+	s.Equal(http.StatusOK, status)
+	s.redirectsTo("myAlias1", originalLongLink)
+
+	// 4
+	s.loggedInAs("test_name1@mail.ru")
+
+	_, status = s.RollbackShortLink(&handlers.RollbackShortLinkRequest{ShortLink: "myAlias1", VersionId: 1000})
+	s.Equal(http.StatusNotFound, status)
+
+	// 5 versions of one link can't be applied to another
+	s.loggedInAs("test_name2@mail.ru")
+
+	_, status = s.RollbackShortLink(&handlers.RollbackShortLinkRequest{ShortLink: "myAlias2", VersionId: history.Versions[0].Id})
+	s.Equal(http.StatusNotFound, status)
+
+	// 6
+	s.loggedInAs("test_name2@mail.ru")
+
+	_, status = s.RollbackShortLink(&handlers.RollbackShortLinkRequest{ShortLink: "myAlias1", VersionId: history.Versions[0].Id})
+	s.Equal(http.StatusForbidden, status)
+}